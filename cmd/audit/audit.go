@@ -0,0 +1,163 @@
+// Package audit provides the "audit" command.
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/safe-waters/docker-lock/pkg/audit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const namespace = "audit"
+
+// NewAuditCmd creates the command 'audit' used in 'docker lock audit'.
+func NewAuditCmd() (*cobra.Command, error) {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report vulnerabilities of the images in a Lockfile",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindPFlags(cmd, []string{
+				"lockfile-name",
+				"database",
+				"severity",
+				"output",
+			})
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags, err := parseFlags()
+			if err != nil {
+				return err
+			}
+
+			report, err := AuditLockfile(flags)
+			if err != nil {
+				return err
+			}
+
+			reportWriter, err := SetupReportWriter(flags)
+			if err != nil {
+				return err
+			}
+
+			if err := reportWriter.WriteReport(
+				report, os.Stdout,
+			); err != nil {
+				return err
+			}
+
+			if len(report.Vulnerable) != 0 {
+				return fmt.Errorf(
+					"lockfile '%s' has %d vulnerable image(s)",
+					flags.LockfileName, len(report.Vulnerable),
+				)
+			}
+
+			return nil
+		},
+	}
+	auditCmd.Flags().String(
+		"lockfile-name", "docker-lock.json", "Lockfile to read from",
+	)
+	auditCmd.Flags().StringSlice(
+		"database", []string{},
+		"JSON reports from Trivy or Grype to match digests against - "+
+			"SQLite databases are not supported",
+	)
+	auditCmd.Flags().String(
+		"severity", string(audit.Low),
+		"Minimum severity of vulnerabilities to report, one of 'unknown', "+
+			"'negligible', 'low', 'medium', 'high', or 'critical'",
+	)
+	auditCmd.Flags().String(
+		"output", "text", "Format of the report, one of 'text' or 'json'",
+	)
+
+	return auditCmd, nil
+}
+
+// SetupAuditor creates an Auditor configured for docker-lock's cli.
+func SetupAuditor(flags *Flags) (audit.IAuditor, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	database, err := audit.NewDatabase(flags.Databases)
+	if err != nil {
+		return nil, err
+	}
+
+	minSeverity, err := audit.ParseSeverity(flags.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit.NewAuditor(database, minSeverity)
+}
+
+// SetupReportWriter creates a ReportWriter for the output format in flags.
+func SetupReportWriter(flags *Flags) (audit.IReportWriter, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	switch flags.Output {
+	case "text":
+		return audit.NewTextReportWriter(), nil
+	case "json":
+		return audit.NewJSONReportWriter(), nil
+	}
+
+	return nil, fmt.Errorf(
+		"'%s' output must be one of 'text' or 'json'", flags.Output,
+	)
+}
+
+// AuditLockfile reports the vulnerabilities of the images in the Lockfile.
+func AuditLockfile(flags *Flags) (*audit.Report, error) {
+	auditor, err := SetupAuditor(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := os.Open(flags.LockfileName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return auditor.AuditLockfile(reader)
+}
+
+func bindPFlags(cmd *cobra.Command, flagNames []string) error {
+	for _, name := range flagNames {
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), cmd.Flags().Lookup(name),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFlags() (*Flags, error) {
+	var (
+		lockfileName = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "lockfile-name"),
+		)
+		databases = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "database"),
+		)
+		severity = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "severity"),
+		)
+		output = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "output"),
+		)
+	)
+
+	return NewFlags(lockfileName, databases, severity, output)
+}
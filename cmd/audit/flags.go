@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/audit"
+)
+
+// Flags holds all command line options for auditing a Lockfile.
+type Flags struct {
+	LockfileName string
+	Databases    []string
+	Severity     string
+	Output       string
+}
+
+// NewFlags returns Flags after validating its fields.
+//
+// lockfileName may not contain slashes.
+//
+// databases cannot be empty, as they are the JSON reports of scanners that
+// the images in the Lockfile are matched against.
+//
+// severity must be one of "unknown", "negligible", "low", "medium", "high",
+// or "critical".
+//
+// output must be one of "text" or "json".
+func NewFlags(
+	lockfileName string,
+	databases []string,
+	severity string,
+	output string,
+) (*Flags, error) {
+	if err := validateLockfileName(lockfileName); err != nil {
+		return nil, err
+	}
+
+	if len(databases) == 0 {
+		return nil, errors.New("'databases' cannot be empty")
+	}
+
+	if _, err := audit.ParseSeverity(severity); err != nil {
+		return nil, err
+	}
+
+	if output != "text" && output != "json" {
+		return nil, fmt.Errorf(
+			"'%s' output must be one of 'text' or 'json'", output,
+		)
+	}
+
+	return &Flags{
+		LockfileName: lockfileName,
+		Databases:    databases,
+		Severity:     severity,
+		Output:       output,
+	}, nil
+}
+
+func validateLockfileName(lockfileName string) error {
+	if filepath.IsAbs(lockfileName) {
+		return fmt.Errorf(
+			"'%s' lockfile-name does not support absolute paths", lockfileName,
+		)
+	}
+
+	lockfileName = filepath.Join(".", lockfileName)
+
+	if strings.ContainsAny(lockfileName, `/\`) {
+		return fmt.Errorf(
+			"'%s' lockfile-name cannot contain slashes", lockfileName,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package audit_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/cmd/audit"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Expected   *audit.Flags
+		ShouldFail bool
+	}{
+		{
+			Name: "Lockfile Name With Slashes",
+			Expected: &audit.Flags{
+				LockfileName: filepath.Join("lockfile", "path"),
+				Databases:    []string{"trivy.json"},
+				Severity:     "low",
+				Output:       "text",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Empty Databases",
+			Expected: &audit.Flags{
+				LockfileName: "docker-lock.json",
+				Severity:     "low",
+				Output:       "text",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Severity",
+			Expected: &audit.Flags{
+				LockfileName: "docker-lock.json",
+				Databases:    []string{"trivy.json"},
+				Severity:     "severe",
+				Output:       "text",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Output",
+			Expected: &audit.Flags{
+				LockfileName: "docker-lock.json",
+				Databases:    []string{"trivy.json"},
+				Severity:     "low",
+				Output:       "yaml",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Normal",
+			Expected: &audit.Flags{
+				LockfileName: "docker-lock.json",
+				Databases:    []string{"trivy.json", "grype.json"},
+				Severity:     "HIGH",
+				Output:       "json",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := audit.NewFlags(
+				test.Expected.LockfileName,
+				test.Expected.Databases,
+				test.Expected.Severity,
+				test.Expected.Output,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertFlagsEqual(t, test.Expected, got)
+		})
+	}
+}
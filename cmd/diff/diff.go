@@ -0,0 +1,180 @@
+// Package diff provides the "diff" command.
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+	"github.com/safe-waters/docker-lock/pkg/verify/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const namespace = "diff"
+
+// NewDiffCmd creates the command 'diff' used in 'docker lock diff'.
+func NewDiffCmd() (*cobra.Command, error) {
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show the images that changed between two Lockfiles",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindPFlags(cmd, []string{
+				"lockfile-name",
+				"from",
+				"to",
+				"exclude-tags",
+			})
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags, err := parseFlags()
+			if err != nil {
+				return err
+			}
+
+			report, err := DiffLockfiles(flags)
+			if err != nil {
+				return err
+			}
+
+			return output.NewChangelogReportWriter().WriteReport(
+				report, os.Stdout,
+			)
+		},
+	}
+	diffCmd.Flags().String(
+		"lockfile-name", "docker-lock.json",
+		"Lockfile to read from the working tree and git revisions",
+	)
+	diffCmd.Flags().String(
+		"from", "HEAD",
+		"Lockfile path or git revision, such as 'HEAD~1', to compare from",
+	)
+	diffCmd.Flags().String(
+		"to", "",
+		"Lockfile path or git revision to compare to "+
+			"(default is the Lockfile in the working tree)",
+	)
+	diffCmd.Flags().Bool(
+		"exclude-tags", false, "Exclude image tags from the comparison",
+	)
+
+	return diffCmd, nil
+}
+
+// DiffLockfiles returns a Report of the differences between the Lockfiles
+// of flags.From and flags.To.
+func DiffLockfiles(flags *Flags) (*verify.Report, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	fromLockfile, err := ReadLockfile(flags.From, flags.LockfileName)
+	if err != nil {
+		return nil, err
+	}
+
+	toLockfile, err := ReadLockfile(flags.To, flags.LockfileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return verify.NewReport(
+		fromLockfile, toLockfile,
+		diff.NewDockerfileDifferentiator(flags.ExcludeTags),
+		diff.NewComposefileDifferentiator(flags.ExcludeTags),
+		diff.NewKubernetesfileDifferentiator(flags.ExcludeTags),
+		diff.NewHelmchartDifferentiator(flags.ExcludeTags),
+		diff.NewKustomizationDifferentiator(flags.ExcludeTags),
+		diff.NewBakefileDifferentiator(flags.ExcludeTags),
+	)
+}
+
+// ReadLockfile reads a Lockfile from source. If source is empty, the
+// Lockfile named lockfileName is read from the working tree. If source is
+// a file, it is read. Otherwise, source is treated as a git revision, and
+// lockfileName is read from it. Revisions cannot start with "-", so that
+// they are not passed to git as options.
+func ReadLockfile(
+	source string,
+	lockfileName string,
+) (*lockfile.Lockfile, error) {
+	if source == "" {
+		source = lockfileName
+	}
+
+	if fileInfo, err := os.Stat(source); err == nil && !fileInfo.IsDir() {
+		reader, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return lockfile.Read(reader)
+	}
+
+	// git would read a revision such as "--output=file" as an option.
+	if strings.HasPrefix(source, "-") {
+		return nil, fmt.Errorf(
+			"'%s' is neither a file nor a git revision, as revisions cannot "+
+				"start with '-'",
+			source,
+		)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(
+		"git", "show",
+		fmt.Sprintf("%s:./%s", source, filepath.ToSlash(lockfileName)),
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf(
+			"'%s' is neither a file nor a git revision with '%s': %s",
+			source, lockfileName, bytes.TrimSpace(stderr.Bytes()),
+		)
+	}
+
+	return lockfile.Read(&stdout)
+}
+
+func bindPFlags(cmd *cobra.Command, flagNames []string) error {
+	for _, name := range flagNames {
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), cmd.Flags().Lookup(name),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFlags() (*Flags, error) {
+	var (
+		lockfileName = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "lockfile-name"),
+		)
+		from = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "from"),
+		)
+		to = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "to"),
+		)
+		excludeTags = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "exclude-tags"),
+		)
+	)
+
+	return NewFlags(lockfileName, from, to, excludeTags)
+}
@@ -0,0 +1,43 @@
+package diff_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/cmd/diff"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestReadLockfileRevisionStartingWithDash(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("paths with ':' are not supported on windows")
+	}
+
+	tempDir := testutils.MakeTempDirInCurrentDir(t)
+	defer os.RemoveAll(tempDir)
+
+	// git appends ":./docker-lock.json" to the revision, so "--output=<dir>"
+	// would write to "<dir>:./docker-lock.json".
+	outputDir := filepath.Join(tempDir, "output:.")
+	testutils.MakeDir(t, outputDir)
+
+	if _, err := diff.ReadLockfile(
+		"--output="+filepath.Join(tempDir, "output"), "docker-lock.json",
+	); err == nil {
+		t.Fatal("expected error but did not get one")
+	}
+
+	fileInfos, err := ioutil.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fileInfos) != 0 {
+		t.Fatalf("expected git not to write to '%s'", outputDir)
+	}
+}
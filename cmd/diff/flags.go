@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Flags holds all command line options for comparing Lockfiles.
+type Flags struct {
+	LockfileName string
+	From         string
+	To           string
+	ExcludeTags  bool
+}
+
+// NewFlags returns Flags after validating its fields.
+//
+// lockfileName may not contain slashes.
+//
+// from cannot be empty. If to is empty, the Lockfile in the working tree
+// is compared.
+func NewFlags(
+	lockfileName string,
+	from string,
+	to string,
+	excludeTags bool,
+) (*Flags, error) {
+	if err := validateLockfileName(lockfileName); err != nil {
+		return nil, err
+	}
+
+	if from == "" {
+		return nil, errors.New("'from' cannot be empty")
+	}
+
+	return &Flags{
+		LockfileName: lockfileName,
+		From:         from,
+		To:           to,
+		ExcludeTags:  excludeTags,
+	}, nil
+}
+
+func validateLockfileName(lockfileName string) error {
+	if filepath.IsAbs(lockfileName) {
+		return fmt.Errorf(
+			"'%s' lockfile-name does not support absolute paths", lockfileName,
+		)
+	}
+
+	lockfileName = filepath.Join(".", lockfileName)
+
+	if strings.ContainsAny(lockfileName, `/\`) {
+		return fmt.Errorf(
+			"'%s' lockfile-name cannot contain slashes", lockfileName,
+		)
+	}
+
+	return nil
+}
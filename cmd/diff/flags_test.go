@@ -0,0 +1,72 @@
+package diff_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/cmd/diff"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Expected   *diff.Flags
+		ShouldFail bool
+	}{
+		{
+			Name: "Lockfile Name With Slashes",
+			Expected: &diff.Flags{
+				LockfileName: filepath.Join("lockfile", "path"),
+				From:         "HEAD",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Empty From",
+			Expected: &diff.Flags{
+				LockfileName: "docker-lock.json",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Normal",
+			Expected: &diff.Flags{
+				LockfileName: "docker-lock.json",
+				From:         "HEAD",
+				To:           "old-lock.json",
+				ExcludeTags:  true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := diff.NewFlags(
+				test.Expected.LockfileName,
+				test.Expected.From,
+				test.Expected.To,
+				test.Expected.ExcludeTags,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertFlagsEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -12,10 +12,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/safe-waters/docker-lock/cmd/audit"
+	"github.com/safe-waters/docker-lock/cmd/diff"
 	"github.com/safe-waters/docker-lock/cmd/docker"
+	"github.com/safe-waters/docker-lock/cmd/export"
 	"github.com/safe-waters/docker-lock/cmd/generate"
 	"github.com/safe-waters/docker-lock/cmd/lock"
+	"github.com/safe-waters/docker-lock/cmd/migrate"
+	"github.com/safe-waters/docker-lock/cmd/outdated"
 	"github.com/safe-waters/docker-lock/cmd/rewrite"
+	"github.com/safe-waters/docker-lock/cmd/update"
 	"github.com/safe-waters/docker-lock/cmd/verify"
 	"github.com/safe-waters/docker-lock/cmd/version"
 	"github.com/spf13/cobra"
@@ -72,9 +78,42 @@ func execute() error {
 		return err
 	}
 
+	migrateCmd, err := migrate.NewMigrateCmd()
+	if err != nil {
+		return err
+	}
+
+	diffCmd, err := diff.NewDiffCmd()
+	if err != nil {
+		return err
+	}
+
+	updateCmd, err := update.NewUpdateCmd()
+	if err != nil {
+		return err
+	}
+
+	outdatedCmd, err := outdated.NewOutdatedCmd()
+	if err != nil {
+		return err
+	}
+
+	auditCmd, err := audit.NewAuditCmd()
+	if err != nil {
+		return err
+	}
+
+	exportCmd, err := export.NewExportCmd()
+	if err != nil {
+		return err
+	}
+
 	dockerCmd.AddCommand(lockCmd)
 	lockCmd.AddCommand(
-		[]*cobra.Command{versionCmd, generateCmd, verifyCmd, rewriteCmd}...,
+		[]*cobra.Command{
+			versionCmd, generateCmd, verifyCmd, rewriteCmd, migrateCmd,
+			diffCmd, updateCmd, outdatedCmd, auditCmd, exportCmd,
+		}...,
 	)
 
 	return dockerCmd.Execute()
@@ -0,0 +1,121 @@
+// Package export provides the "export" command.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/safe-waters/docker-lock/cmd/version"
+	"github.com/safe-waters/docker-lock/pkg/export"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const namespace = "export"
+
+// NewExportCmd creates the command 'export' used in 'docker lock export'.
+func NewExportCmd() (*cobra.Command, error) {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the images in a Lockfile as a CycloneDX or SPDX SBOM",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindPFlags(cmd, []string{
+				"lockfile-name",
+				"format",
+				"document-name",
+			})
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags, err := parseFlags()
+			if err != nil {
+				return err
+			}
+
+			exporter, err := SetupExporter(flags)
+			if err != nil {
+				return err
+			}
+
+			reader, err := os.Open(flags.LockfileName)
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+
+			return exporter.ExportLockfile(reader, os.Stdout)
+		},
+	}
+	exportCmd.Flags().String(
+		"lockfile-name", "docker-lock.json", "Lockfile to read from",
+	)
+	exportCmd.Flags().String(
+		"format", "cyclonedx",
+		"Format of the document, one of 'cyclonedx' or 'spdx'",
+	)
+	exportCmd.Flags().String(
+		"document-name", "",
+		"Name of the document (default is the working directory's name)",
+	)
+
+	return exportCmd, nil
+}
+
+// SetupExporter creates an Exporter configured for docker-lock's cli.
+func SetupExporter(flags *Flags) (export.IExporter, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	documentName := flags.DocumentName
+	if documentName == "" {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		documentName = filepath.Base(workingDir)
+	}
+
+	switch flags.Format {
+	case "cyclonedx":
+		return export.NewCycloneDXExporter(
+			documentName, version.Version, nil,
+		)
+	case "spdx":
+		return export.NewSPDXExporter(documentName, version.Version, nil)
+	}
+
+	return nil, fmt.Errorf(
+		"'%s' format must be one of 'cyclonedx' or 'spdx'", flags.Format,
+	)
+}
+
+func bindPFlags(cmd *cobra.Command, flagNames []string) error {
+	for _, name := range flagNames {
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), cmd.Flags().Lookup(name),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFlags() (*Flags, error) {
+	var (
+		lockfileName = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "lockfile-name"),
+		)
+		format = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "format"),
+		)
+		documentName = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "document-name"),
+		)
+	)
+
+	return NewFlags(lockfileName, format, documentName)
+}
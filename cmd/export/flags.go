@@ -0,0 +1,61 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Flags holds all command line options for exporting a Lockfile.
+type Flags struct {
+	LockfileName string
+	Format       string
+	DocumentName string
+}
+
+// NewFlags returns Flags after validating its fields.
+//
+// lockfileName may not contain slashes.
+//
+// format must be one of "cyclonedx" or "spdx".
+//
+// If documentName is empty, the name of the working directory is used.
+func NewFlags(
+	lockfileName string,
+	format string,
+	documentName string,
+) (*Flags, error) {
+	if err := validateLockfileName(lockfileName); err != nil {
+		return nil, err
+	}
+
+	if format != "cyclonedx" && format != "spdx" {
+		return nil, fmt.Errorf(
+			"'%s' format must be one of 'cyclonedx' or 'spdx'", format,
+		)
+	}
+
+	return &Flags{
+		LockfileName: lockfileName,
+		Format:       format,
+		DocumentName: documentName,
+	}, nil
+}
+
+func validateLockfileName(lockfileName string) error {
+	if filepath.IsAbs(lockfileName) {
+		return fmt.Errorf(
+			"'%s' lockfile-name does not support absolute paths", lockfileName,
+		)
+	}
+
+	lockfileName = filepath.Join(".", lockfileName)
+
+	if strings.ContainsAny(lockfileName, `/\`) {
+		return fmt.Errorf(
+			"'%s' lockfile-name cannot contain slashes", lockfileName,
+		)
+	}
+
+	return nil
+}
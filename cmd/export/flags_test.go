@@ -0,0 +1,71 @@
+package export_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/cmd/export"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Expected   *export.Flags
+		ShouldFail bool
+	}{
+		{
+			Name: "Lockfile Name With Slashes",
+			Expected: &export.Flags{
+				LockfileName: filepath.Join("lockfile", "path"),
+				Format:       "cyclonedx",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Format",
+			Expected: &export.Flags{
+				LockfileName: "docker-lock.json",
+				Format:       "swid",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Normal",
+			Expected: &export.Flags{
+				LockfileName: "docker-lock.json",
+				Format:       "spdx",
+				DocumentName: "repo",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := export.NewFlags(
+				test.Expected.LockfileName,
+				test.Expected.Format,
+				test.Expected.DocumentName,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertFlagsEqual(t, test.Expected, got)
+		})
+	}
+}
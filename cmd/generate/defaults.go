@@ -2,27 +2,36 @@ package generate
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/compose-spec/compose-go/cli"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/safe-waters/docker-lock/pkg/generate"
 	"github.com/safe-waters/docker-lock/pkg/generate/collect"
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
 	"github.com/safe-waters/docker-lock/pkg/generate/update"
 	"github.com/safe-waters/docker-lock/pkg/kind"
 )
 
 // DefaultPathCollector creates an IPathCollector that works with Dockerfiles,
-// Composefiles, and Kubernetesfiles.
+// Composefiles, Kubernetesfiles, Helm charts, Kustomizations, and Bakefiles.
 //
-// For all three, respectively, the defaults are
+// For all six, respectively, the defaults are
 // ["Dockerfile"], ["compose.yml", "compose.yaml",
-// "docker-compose.yml", "docker-compose.yaml"], and
+// "docker-compose.yml", "docker-compose.yaml"],
 // ["deployment.yml", "deployment.yaml", "pod.yml", "pod.yaml",
-// "job.yml", "job.yaml"].
+// "job.yml", "job.yaml"], ["values.yaml", "values.yml"],
+// ["kustomization.yaml", "kustomization.yml", "Kustomization"], and
+// ["docker-bake.hcl", "docker-bake.json"].
 //
 // PathCollectors are set according to the flag, "ExcludePaths".
-// If all "ExcludePaths" are true or any of the three's flags,
+// If all "ExcludePaths" are true or any of the six's flags,
 // are nil, an error is returned.
 func DefaultPathCollector(flags *Flags) (generate.IPathCollector, error) {
 	if err := ensureFlagsNotNil(flags); err != nil {
@@ -31,7 +40,10 @@ func DefaultPathCollector(flags *Flags) (generate.IPathCollector, error) {
 
 	if flags.DockerfileFlags.ExcludePaths &&
 		flags.ComposefileFlags.ExcludePaths &&
-		flags.KubernetesfileFlags.ExcludePaths {
+		flags.KubernetesfileFlags.ExcludePaths &&
+		flags.HelmchartFlags.ExcludePaths &&
+		flags.KustomizationFlags.ExcludePaths &&
+		flags.BakefileFlags.ExcludePaths {
 		return nil, errors.New("nothing to do - all paths excluded")
 	}
 
@@ -39,6 +51,9 @@ func DefaultPathCollector(flags *Flags) (generate.IPathCollector, error) {
 		dockerfileCollector     collect.IPathCollector
 		composefileCollector    collect.IPathCollector
 		kubernetesfileCollector collect.IPathCollector
+		helmchartCollector      collect.IPathCollector
+		kustomizationCollector  collect.IPathCollector
+		bakefileCollector       collect.IPathCollector
 		err                     error
 	)
 
@@ -85,16 +100,61 @@ func DefaultPathCollector(flags *Flags) (generate.IPathCollector, error) {
 		}
 	}
 
+	if !flags.HelmchartFlags.ExcludePaths {
+		helmchartCollector, err = collect.NewPathCollector(
+			kind.Helmchart,
+			flags.FlagsWithSharedValues.BaseDir,
+			[]string{"values.yaml", "values.yml"},
+			flags.HelmchartFlags.ManualPaths,
+			flags.HelmchartFlags.Globs,
+			flags.HelmchartFlags.Recursive,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !flags.KustomizationFlags.ExcludePaths {
+		kustomizationCollector, err = collect.NewPathCollector(
+			kind.Kustomization,
+			flags.FlagsWithSharedValues.BaseDir,
+			[]string{
+				"kustomization.yaml", "kustomization.yml", "Kustomization",
+			},
+			flags.KustomizationFlags.ManualPaths,
+			flags.KustomizationFlags.Globs,
+			flags.KustomizationFlags.Recursive,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !flags.BakefileFlags.ExcludePaths {
+		bakefileCollector, err = collect.NewPathCollector(
+			kind.Bakefile,
+			flags.FlagsWithSharedValues.BaseDir,
+			[]string{"docker-bake.hcl", "docker-bake.json"},
+			flags.BakefileFlags.ManualPaths,
+			flags.BakefileFlags.Globs,
+			flags.BakefileFlags.Recursive,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return generate.NewPathCollector(
 		dockerfileCollector, composefileCollector, kubernetesfileCollector,
+		helmchartCollector, kustomizationCollector, bakefileCollector,
 	)
 }
 
 // DefaultImageParser creates an IImageParser that works with Dockerfiles,
-// Composefiles, and Kubernetesfiles.
+// Composefiles, Kubernetesfiles, Helm charts, Kustomizations, and Bakefiles.
 //
 // ImageParsers are set according to the flag, "ExcludePaths".
-// If all "ExcludePaths" are true or any of the three's flags,
+// If all "ExcludePaths" are true or any of the six's flags,
 // are nil, an error is returned.
 func DefaultImageParser(flags *Flags) (generate.IImageParser, error) {
 	if err := ensureFlagsNotNil(flags); err != nil {
@@ -103,7 +163,10 @@ func DefaultImageParser(flags *Flags) (generate.IImageParser, error) {
 
 	if flags.DockerfileFlags.ExcludePaths &&
 		flags.ComposefileFlags.ExcludePaths &&
-		flags.KubernetesfileFlags.ExcludePaths {
+		flags.KubernetesfileFlags.ExcludePaths &&
+		flags.HelmchartFlags.ExcludePaths &&
+		flags.KustomizationFlags.ExcludePaths &&
+		flags.BakefileFlags.ExcludePaths {
 		return nil, errors.New("nothing to do - all paths excluded")
 	}
 
@@ -111,11 +174,15 @@ func DefaultImageParser(flags *Flags) (generate.IImageParser, error) {
 		dockerfileImageParser     parse.IDockerfileImageParser
 		composefileImageParser    parse.IComposefileImageParser
 		kubernetesfileImageParser parse.IKubernetesfileImageParser
+		helmchartImageParser      parse.IHelmchartImageParser
+		kustomizationImageParser  parse.IKustomizationImageParser
+		bakefileImageParser       parse.IBakefileImageParser
 	)
 
 	if !flags.DockerfileFlags.ExcludePaths ||
-		!flags.ComposefileFlags.ExcludePaths {
-		dockerfileImageParser = parse.NewDockerfileImageParser()
+		!flags.ComposefileFlags.ExcludePaths ||
+		!flags.BakefileFlags.ExcludePaths {
+		dockerfileImageParser = parse.NewDockerfileImageParser(flags.BuildArgs)
 	}
 
 	if !flags.ComposefileFlags.ExcludePaths {
@@ -130,21 +197,52 @@ func DefaultImageParser(flags *Flags) (generate.IImageParser, error) {
 		}
 	}
 
-	if !flags.KubernetesfileFlags.ExcludePaths {
+	if !flags.KubernetesfileFlags.ExcludePaths ||
+		!flags.KustomizationFlags.ExcludePaths {
 		kubernetesfileImageParser = parse.NewKubernetesfileImageParser()
 	}
 
+	if !flags.HelmchartFlags.ExcludePaths {
+		helmchartImageParser = parse.NewHelmchartImageParser()
+	}
+
+	if !flags.KustomizationFlags.ExcludePaths {
+		var err error
+
+		kustomizationImageParser, err = parse.NewKustomizationImageParser(
+			kubernetesfileImageParser,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !flags.BakefileFlags.ExcludePaths {
+		var err error
+
+		bakefileImageParser, err = parse.NewBakefileImageParser(
+			dockerfileImageParser,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return generate.NewImageParser(
 		dockerfileImageParser, composefileImageParser,
-		kubernetesfileImageParser,
+		kubernetesfileImageParser, helmchartImageParser,
+		kustomizationImageParser, bakefileImageParser,
 	)
 }
 
 // DefaultImageFormatter creates an IImageFormatter that works with
-// Dockerfiles, Composefiles, and Kubernetesfiles.
+// Dockerfiles, Composefiles, Kubernetesfiles, Helm charts, Kustomizations,
+// and Bakefiles.
 //
 // ImageFormatters are set according to the flag, "ExcludePaths".
-// If all "ExcludePaths" are true or any of the three's flags,
+// If all "ExcludePaths" are true or any of the six's flags,
 // are nil, an error is returned.
 func DefaultImageFormatter(flags *Flags) (generate.IImageFormatter, error) {
 	if err := ensureFlagsNotNil(flags); err != nil {
@@ -153,7 +251,10 @@ func DefaultImageFormatter(flags *Flags) (generate.IImageFormatter, error) {
 
 	if flags.DockerfileFlags.ExcludePaths &&
 		flags.ComposefileFlags.ExcludePaths &&
-		flags.KubernetesfileFlags.ExcludePaths {
+		flags.KubernetesfileFlags.ExcludePaths &&
+		flags.HelmchartFlags.ExcludePaths &&
+		flags.KustomizationFlags.ExcludePaths &&
+		flags.BakefileFlags.ExcludePaths {
 		return nil, errors.New("nothing to do - all paths excluded")
 	}
 
@@ -161,21 +262,55 @@ func DefaultImageFormatter(flags *Flags) (generate.IImageFormatter, error) {
 		dockerfileImageFormatter     = format.NewDockerfileImageFormatter()
 		composefileImageFormatter    = format.NewComposefileImageFormatter()
 		kubernetesfileImageFormatter = format.NewKubernetesfileImageFormatter()
+		helmchartImageFormatter      = format.NewHelmchartImageFormatter()
+		kustomizationImageFormatter  = format.NewKustomizationImageFormatter()
+		bakefileImageFormatter       = format.NewBakefileImageFormatter()
 	)
 
 	return generate.NewImageFormatter(
 		dockerfileImageFormatter, composefileImageFormatter,
-		kubernetesfileImageFormatter,
+		kubernetesfileImageFormatter, helmchartImageFormatter,
+		kustomizationImageFormatter, bakefileImageFormatter,
 	)
 }
 
+// DefaultImagePolicyChecker creates an IImagePolicyChecker that checks
+// images against "PolicyRules", or allows every image if it is nil. If
+// "Strict" is true, images with unresolved variables are rejected.
+func DefaultImagePolicyChecker(
+	flags *Flags,
+) (generate.IImagePolicyChecker, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	rules := flags.PolicyRules
+	if rules == nil {
+		rules = &policy.Rules{}
+	}
+
+	imagePolicyChecker, err := policy.NewImagePolicyChecker(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return generate.NewImagePolicyChecker(imagePolicyChecker, flags.Strict)
+}
+
 // DefaultImageDigestUpdater creates an IImageDigestUpdater that works with
-// Dockerfiles, Composefiles, and Kubernetesfiles.
+// Dockerfiles, Composefiles, Kubernetesfiles, Helm charts, Kustomizations,
+// and Bakefiles.
 //
-// If all "ExcludePaths" are true or any of the three's flags,
+// If "RecordCreated" is true, the time that the image with each digest was
+// created is recorded as well.
+//
+// Digests are cached in digestCache, unless it is nil.
+//
+// If all "ExcludePaths" are true or any of the six's flags,
 // are nil, an error is returned.
 func DefaultImageDigestUpdater(
 	flags *Flags,
+	digestCache update.IDigestCache,
 ) (generate.IImageDigestUpdater, error) {
 	if err := ensureFlagsNotNil(flags); err != nil {
 		return nil, err
@@ -183,23 +318,228 @@ func DefaultImageDigestUpdater(
 
 	if flags.DockerfileFlags.ExcludePaths &&
 		flags.ComposefileFlags.ExcludePaths &&
-		flags.KubernetesfileFlags.ExcludePaths {
+		flags.KubernetesfileFlags.ExcludePaths &&
+		flags.HelmchartFlags.ExcludePaths &&
+		flags.KustomizationFlags.ExcludePaths &&
+		flags.BakefileFlags.ExcludePaths {
 		return nil, errors.New("nothing to do - all paths excluded")
 	}
 
-	digestRequester := update.NewDigestRequester()
+	digestRequester, err := DefaultDigestRequester(flags, digestCache)
+	if err != nil {
+		return nil, err
+	}
 
 	imageDigestUpdater, err := update.NewImageDigestUpdater(
 		digestRequester, flags.FlagsWithSharedValues.IgnoreMissingDigests,
 		flags.FlagsWithSharedValues.UpdateExistingDigests,
+		flags.FlagsWithSharedValues.PlatformDigests,
+		flags.FlagsWithSharedValues.MaxConcurrency,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if flags.FlagsWithSharedValues.RecordCreated {
+		createdRequester, ok := digestRequester.(update.ICreatedRequester)
+		if !ok {
+			return nil, errors.New(
+				"'digestRequester' cannot query creation times",
+			)
+		}
+
+		imageDigestUpdater, err = update.NewImageCreatedUpdater(
+			imageDigestUpdater, createdRequester,
+			flags.FlagsWithSharedValues.MaxConcurrency,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return generate.NewImageDigestUpdater(imageDigestUpdater)
 }
 
+// DefaultDigestRequester creates an IDigestRequester for docker-lock's cli.
+// Requests to each registry are limited to "RateLimit" per second and
+// retried up to "MaxRetries" times if they are throttled or fail with a
+// server error. Unless digestCache is nil, digests are cached in it.
+//
+// If "OfflineSources" is not empty, digests are resolved from the OCI image
+// layouts or tarballs in it instead of registries, and are not cached.
+//
+// Registries in "RegistryMirrors" are queried through their mirrors.
+//
+// Credentials for registries are read from environment variables, as
+// described in update.NewEnvKeychain, then from "CredentialsFile", then from
+// "CredentialHelpers", and finally from docker's config file.
+func DefaultDigestRequester(
+	flags *Flags,
+	digestCache update.IDigestCache,
+) (update.IDigestRequester, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	digestRequester, err := sourceDigestRequester(flags.FlagsWithSharedValues)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(flags.FlagsWithSharedValues.RegistryMirrors) != 0 {
+		digestRequester, err = update.NewMirroredDigestRequester(
+			digestRequester, flags.FlagsWithSharedValues.RegistryMirrors,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if digestCache == nil ||
+		len(flags.FlagsWithSharedValues.OfflineSources) != 0 {
+		return digestRequester, nil
+	}
+
+	return update.NewCachedDigestRequester(digestRequester, digestCache)
+}
+
+// DefaultDigestCache creates an IDigestCache for docker-lock's cli, in the
+// file "digests.json" in "CacheDir", or the user cache directory if
+// "CacheDir" is empty. Digests are cached for "CacheTTL", and digests that
+// are already cached are queried again if "Refresh" is true.
+//
+// If "NoCache" is true or "OfflineSources" is not empty, nil is returned, as
+// digests are not cached.
+func DefaultDigestCache(flags *Flags) (update.IDigestCache, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	if flags.FlagsWithSharedValues.NoCache ||
+		len(flags.FlagsWithSharedValues.OfflineSources) != 0 {
+		return nil, nil
+	}
+
+	cacheDir := flags.FlagsWithSharedValues.CacheDir
+
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			fmt.Printf(
+				"warning: unable to find the user cache directory "+
+					"with err: %v - digests will not be cached\n", err,
+			)
+
+			return nil, nil
+		}
+
+		cacheDir = filepath.Join(userCacheDir, "docker-lock")
+	}
+
+	return update.NewDigestCache(
+		filepath.Join(cacheDir, "digests.json"),
+		flags.FlagsWithSharedValues.CacheTTL,
+		flags.FlagsWithSharedValues.Refresh,
+	)
+}
+
+// FlushDigestCache saves the digests queried through digestCache, unless it
+// is nil.
+func FlushDigestCache(digestCache update.IDigestCache) error {
+	if digestCache == nil {
+		return nil
+	}
+
+	return digestCache.Flush()
+}
+
+// DefaultTagLister creates an ITagLister for docker-lock's cli. Requests to
+// registries are limited, retried, and authenticated as described in
+// DefaultDigestRequester, and registries in "RegistryMirrors" are queried
+// through their mirrors. Tags cannot be listed from offline sources or
+// cached.
+func DefaultTagLister(flags *Flags) (update.ITagLister, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	if len(flags.FlagsWithSharedValues.OfflineSources) != 0 {
+		return nil, errors.New("tags cannot be listed from offline sources")
+	}
+
+	transport := update.NewRegistryTransport(
+		http.DefaultTransport, flags.FlagsWithSharedValues.RateLimit,
+		flags.FlagsWithSharedValues.MaxRetries, time.Second,
+	)
+
+	keychain, err := defaultKeychain(flags.FlagsWithSharedValues)
+	if err != nil {
+		return nil, err
+	}
+
+	tagLister := update.NewTagLister(transport, keychain)
+
+	if len(flags.FlagsWithSharedValues.RegistryMirrors) == 0 {
+		return tagLister, nil
+	}
+
+	return update.NewMirroredTagLister(
+		tagLister, flags.FlagsWithSharedValues.RegistryMirrors,
+	)
+}
+
+// sourceDigestRequester creates an IDigestRequester that queries the offline
+// sources, if any, or otherwise registries.
+func sourceDigestRequester(
+	flags *FlagsWithSharedValues,
+) (update.IDigestRequester, error) {
+	if len(flags.OfflineSources) != 0 {
+		return update.NewOfflineDigestRequester(flags.OfflineSources)
+	}
+
+	transport := update.NewRegistryTransport(
+		http.DefaultTransport, flags.RateLimit, flags.MaxRetries, time.Second,
+	)
+
+	keychain, err := defaultKeychain(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return update.NewDigestRequester(transport, keychain), nil
+}
+
+// defaultKeychain creates an authn.Keychain that reads credentials from
+// environment variables, the credentials file, credential helpers, and
+// docker's config file, in that order.
+func defaultKeychain(flags *FlagsWithSharedValues) (authn.Keychain, error) {
+	keychains := []authn.Keychain{update.NewEnvKeychain(nil)}
+
+	if flags.CredentialsFile != "" {
+		fileKeychain, err := update.NewFileKeychain(flags.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		keychains = append(keychains, fileKeychain)
+	}
+
+	if len(flags.CredentialHelpers) != 0 {
+		credentialHelperKeychain, err := update.NewCredentialHelperKeychain(
+			flags.CredentialHelpers,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		keychains = append(keychains, credentialHelperKeychain)
+	}
+
+	keychains = append(keychains, authn.DefaultKeychain)
+
+	return authn.NewMultiKeychain(keychains...), nil
+}
+
 func ensureFlagsNotNil(flags *Flags) error {
 	if flags == nil {
 		return errors.New("'flags' cannot be nil")
@@ -217,6 +557,18 @@ func ensureFlagsNotNil(flags *Flags) error {
 		return errors.New("flags.KubernetesfileFlags cannot be nil")
 	}
 
+	if flags.HelmchartFlags == nil {
+		return errors.New("flags.HelmchartFlags cannot be nil")
+	}
+
+	if flags.KustomizationFlags == nil {
+		return errors.New("flags.KustomizationFlags cannot be nil")
+	}
+
+	if flags.BakefileFlags == nil {
+		return errors.New("flags.BakefileFlags cannot be nil")
+	}
+
 	if flags.FlagsWithSharedValues == nil {
 		return errors.New("flags.FlagsWithSharedValues cannot be nil")
 	}
@@ -1,23 +1,50 @@
 package generate
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
 )
 
 // FlagsWithSharedValues represents flags whose values
-// are the same for Dockerfiles, Composefiles and Kubernetesfiles.
+// are the same for Dockerfiles, Composefiles, Kubernetesfiles,
+// Helm charts, Kustomizations, and Bakefiles.
 type FlagsWithSharedValues struct {
 	BaseDir               string
 	LockfileName          string
 	IgnoreMissingDigests  bool
 	UpdateExistingDigests bool
+	PlatformDigests       bool
+	RecordCreated         bool
+	RegistryFlags
+}
+
+// RegistryFlags represents flags for querying registries and caching their
+// digests, which are shared by every command that queries digests.
+type RegistryFlags struct {
+	CacheDir          string
+	CacheTTL          time.Duration
+	NoCache           bool
+	Refresh           bool
+	MaxConcurrency    int
+	RateLimit         float64
+	MaxRetries        int
+	OfflineSources    []string
+	RegistryMirrors   map[string]string
+	CredentialsFile   string
+	CredentialHelpers map[string]string
 }
 
 // FlagsWithSharedNames represents flags whose values
-// differ for Dockerfiles, Composefiles, and Kubernetesfiles.
+// differ for Dockerfiles, Composefiles, Kubernetesfiles, Helm charts,
+// Kustomizations, and Bakefiles.
 type FlagsWithSharedNames struct {
 	ManualPaths  []string
 	Globs        []string
@@ -26,26 +53,47 @@ type FlagsWithSharedNames struct {
 }
 
 // Flags holds all command line options for Dockerfiles, Composefiles,
-// and Kubernetesfiles.
+// Kubernetesfiles, Helm charts, Kustomizations, and Bakefiles, as well as
+// the build args of Dockerfiles and the rules of the policy that images are
+// checked against. If Strict is true, images with unresolved variables fail
+// generation instead of printing warnings. If BuildArgs is nil, Dockerfiles
+// are parsed without build args. If PolicyRules is nil, every image is
+// allowed.
 type Flags struct {
 	FlagsWithSharedValues *FlagsWithSharedValues
 	DockerfileFlags       *FlagsWithSharedNames
 	ComposefileFlags      *FlagsWithSharedNames
 	KubernetesfileFlags   *FlagsWithSharedNames
+	HelmchartFlags        *FlagsWithSharedNames
+	KustomizationFlags    *FlagsWithSharedNames
+	BakefileFlags         *FlagsWithSharedNames
+	Strict                bool
+	BuildArgs             *parse.BuildArgs
+	PolicyRules           *policy.Rules
 }
 
 // NewFlagsWithSharedValues returns Flags that are shared among Dockerfiles,
-// Composefiles, and Kubernetesfiles, after validating its fields.
+// Composefiles, Kubernetesfiles, Helm charts, Kustomizations, and Bakefiles,
+// after validating its fields.
 //
 // baseDir must be the current working directory or a sub directory.
 // Absolute paths are not supported.
 //
 // lockfileName may not contain slashes.
+//
+// registryFlags are validated as in RegistryFlags.Validate. If
+// registryFlags is nil, the zero value of RegistryFlags is used.
+//
+// recordCreated cannot be true if there are offline sources, as creation
+// times are queried from registries.
 func NewFlagsWithSharedValues(
 	baseDir string,
 	lockfileName string,
 	ignoreMissingDigests bool,
 	updateExistingDigests bool,
+	platformDigests bool,
+	recordCreated bool,
+	registryFlags *RegistryFlags,
 ) (*FlagsWithSharedValues, error) {
 	if baseDir != "" {
 		if err := validateBaseDirectory(baseDir); err != nil {
@@ -59,17 +107,73 @@ func NewFlagsWithSharedValues(
 		}
 	}
 
+	if registryFlags == nil {
+		registryFlags = &RegistryFlags{}
+	}
+
+	if err := registryFlags.Validate(); err != nil {
+		return nil, err
+	}
+
+	if recordCreated && len(registryFlags.OfflineSources) != 0 {
+		return nil, errors.New(
+			"record-created cannot be used with offline-source",
+		)
+	}
+
 	return &FlagsWithSharedValues{
 		BaseDir:               baseDir,
 		LockfileName:          lockfileName,
 		IgnoreMissingDigests:  ignoreMissingDigests,
 		UpdateExistingDigests: updateExistingDigests,
+		PlatformDigests:       platformDigests,
+		RecordCreated:         recordCreated,
+		RegistryFlags:         *registryFlags,
 	}, nil
 }
 
+// DefaultRegistryFlags returns the RegistryFlags that docker-lock's cli uses
+// if their flags are not set.
+func DefaultRegistryFlags() *RegistryFlags {
+	return &RegistryFlags{
+		CacheTTL:          time.Hour,
+		MaxConcurrency:    defaultMaxConcurrency,
+		RateLimit:         defaultRateLimit,
+		MaxRetries:        defaultMaxRetries,
+		OfflineSources:    []string{},
+		RegistryMirrors:   map[string]string{},
+		CredentialHelpers: map[string]string{},
+	}
+}
+
+// Validate returns an error if CacheTTL, MaxConcurrency, RateLimit, or
+// MaxRetries is negative, or if a registry prefix or mirror in
+// RegistryMirrors is empty.
+func (r *RegistryFlags) Validate() error {
+	if r.CacheTTL < 0 {
+		return fmt.Errorf("'%s' cache-ttl cannot be negative", r.CacheTTL)
+	}
+
+	if r.MaxConcurrency < 0 {
+		return fmt.Errorf(
+			"'%d' max-concurrency cannot be negative", r.MaxConcurrency,
+		)
+	}
+
+	if r.RateLimit < 0 {
+		return fmt.Errorf("'%v' rate-limit cannot be negative", r.RateLimit)
+	}
+
+	if r.MaxRetries < 0 {
+		return fmt.Errorf("'%d' max-retries cannot be negative", r.MaxRetries)
+	}
+
+	return update.ValidateMirrors(r.RegistryMirrors)
+}
+
 // NewFlagsWithSharedNames returns Flags whose values differ
-// between Dockerfiles, Composefiles, and Kubernetesfiles, after
-// validating its fields.
+// between Dockerfiles, Composefiles, Kubernetesfiles, Helm charts,
+// Kustomizations, and Bakefiles, after validating its fields.
 //
 // baseDir must be the current working directory or a sub directory.
 //
@@ -110,64 +214,79 @@ func NewFlagsWithSharedNames(
 	}, nil
 }
 
-// NewFlags returns Flags for Dockerfiles, Composefiles, and Kubernetesfiles,
-// subject to the validation logic in NewFlagsWithSharedNames and
-// NewFlagsWithSharedValues.
+// NewFlags returns Flags for Dockerfiles, Composefiles, Kubernetesfiles,
+// Helm charts, Kustomizations, and Bakefiles, after validating
+// flagsWithSharedValues as in NewFlagsWithSharedValues, and the
+// FlagsWithSharedNames of each kind as in NewFlagsWithSharedNames with the
+// BaseDir of flagsWithSharedValues. None of them can be nil.
+//
+// The paths in buildArgs must be in the current working directory or in a
+// sub directory.
 func NewFlags(
-	baseDir string,
-	lockfileName string,
-	ignoreMissingDigests bool,
-	updateExistingDigests bool,
-	dockerfilePaths []string,
-	composefilePaths []string,
-	kubernetesfilePaths []string,
-	dockerfileGlobs []string,
-	composefileGlobs []string,
-	kubernetesfileGlobs []string,
-	dockerfileRecursive bool,
-	composefileRecursive bool,
-	kubernetesfileRecursive bool,
-	dockerfileExcludeAll bool,
-	composefileExcludeAll bool,
-	kubernetesfileExcludeAll bool,
+	flagsWithSharedValues *FlagsWithSharedValues,
+	dockerfileFlags *FlagsWithSharedNames,
+	composefileFlags *FlagsWithSharedNames,
+	kubernetesfileFlags *FlagsWithSharedNames,
+	helmchartFlags *FlagsWithSharedNames,
+	kustomizationFlags *FlagsWithSharedNames,
+	bakefileFlags *FlagsWithSharedNames,
+	strict bool,
+	buildArgs *parse.BuildArgs,
+	policyRules *policy.Rules,
 ) (*Flags, error) {
-	sharedFlags, err := NewFlagsWithSharedValues(
-		baseDir, lockfileName, ignoreMissingDigests, updateExistingDigests,
-	)
-	if err != nil {
-		return nil, err
+	flags := &Flags{
+		FlagsWithSharedValues: flagsWithSharedValues,
+		DockerfileFlags:       dockerfileFlags,
+		ComposefileFlags:      composefileFlags,
+		KubernetesfileFlags:   kubernetesfileFlags,
+		HelmchartFlags:        helmchartFlags,
+		KustomizationFlags:    kustomizationFlags,
+		BakefileFlags:         bakefileFlags,
+		Strict:                strict,
+		BuildArgs:             buildArgs,
+		PolicyRules:           policyRules,
 	}
 
-	dockerfileFlags, err := NewFlagsWithSharedNames(
-		baseDir, dockerfilePaths, dockerfileGlobs,
-		dockerfileRecursive, dockerfileExcludeAll,
-	)
-	if err != nil {
+	if err := ensureFlagsNotNil(flags); err != nil {
 		return nil, err
 	}
 
-	composefileFlags, err := NewFlagsWithSharedNames(
-		baseDir, composefilePaths, composefileGlobs,
-		composefileRecursive, composefileExcludeAll,
-	)
-	if err != nil {
+	if _, err := NewFlagsWithSharedValues(
+		flagsWithSharedValues.BaseDir, flagsWithSharedValues.LockfileName,
+		flagsWithSharedValues.IgnoreMissingDigests,
+		flagsWithSharedValues.UpdateExistingDigests,
+		flagsWithSharedValues.PlatformDigests,
+		flagsWithSharedValues.RecordCreated,
+		&flagsWithSharedValues.RegistryFlags,
+	); err != nil {
 		return nil, err
 	}
 
-	kubernetesfileFlags, err := NewFlagsWithSharedNames(
-		baseDir, kubernetesfilePaths, kubernetesfileGlobs,
-		kubernetesfileRecursive, kubernetesfileExcludeAll,
-	)
-	if err != nil {
-		return nil, err
+	for _, flagsWithSharedNames := range []*FlagsWithSharedNames{
+		dockerfileFlags, composefileFlags, kubernetesfileFlags,
+		helmchartFlags, kustomizationFlags, bakefileFlags,
+	} {
+		if _, err := NewFlagsWithSharedNames(
+			flagsWithSharedValues.BaseDir, flagsWithSharedNames.ManualPaths,
+			flagsWithSharedNames.Globs, flagsWithSharedNames.Recursive,
+			flagsWithSharedNames.ExcludePaths,
+		); err != nil {
+			return nil, err
+		}
 	}
 
-	return &Flags{
-		FlagsWithSharedValues: sharedFlags,
-		DockerfileFlags:       dockerfileFlags,
-		ComposefileFlags:      composefileFlags,
-		KubernetesfileFlags:   kubernetesfileFlags,
-	}, nil
+	if buildArgs != nil {
+		buildArgsPaths := make([]string, 0, len(buildArgs.Paths))
+		for path := range buildArgs.Paths {
+			buildArgsPaths = append(buildArgsPaths, path)
+		}
+
+		if err := validateManualPaths("", buildArgsPaths); err != nil {
+			return nil, err
+		}
+	}
+
+	return flags, nil
 }
 
 func validateBaseDirectory(baseDir string) error {
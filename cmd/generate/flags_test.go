@@ -3,9 +3,12 @@ package generate_test
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/safe-waters/docker-lock/cmd/generate"
 	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
 )
 
 func TestFlagsWithSharedNames(t *testing.T) {
@@ -151,6 +154,137 @@ func TestFlagsWithSharedValues(t *testing.T) {
 				LockfileName: "docker-lock.json",
 			},
 		},
+		{
+			Name: "Negative Cache TTL",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					CacheTTL: -time.Hour,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Negative Max Concurrency",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					MaxConcurrency: -1,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Negative Rate Limit",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					RateLimit: -1,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Registry Limits",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					MaxConcurrency: 10,
+					RateLimit:      2.5,
+					MaxRetries:     3,
+				},
+			},
+		},
+		{
+			Name: "Offline Sources",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					OfflineSources: []string{"images.tar", "layout"},
+				},
+			},
+		},
+		{
+			Name: "Registry Mirrors",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					RegistryMirrors: map[string]string{
+						"docker.io": "mirror.internal:5000",
+					},
+				},
+			},
+		},
+		{
+			Name: "Credentials",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					CredentialsFile: "credentials.json",
+					CredentialHelpers: map[string]string{
+						"ghcr.io": "pass",
+					},
+				},
+			},
+		},
+		{
+			Name: "Empty Registry Mirror",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					RegistryMirrors: map[string]string{"docker.io": ""},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Cache",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:      ".",
+				LockfileName: "docker-lock.json",
+				RegistryFlags: generate.RegistryFlags{
+					CacheDir: "cache",
+					CacheTTL: time.Hour,
+					Refresh:  true,
+				},
+			},
+		},
+		{
+			Name: "Platform Digests",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:         ".",
+				LockfileName:    "docker-lock.json",
+				PlatformDigests: true,
+			},
+		},
+		{
+			Name: "Record Created",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:       ".",
+				LockfileName:  "docker-lock.json",
+				RecordCreated: true,
+			},
+		},
+		{
+			Name: "Record Created With Offline Sources",
+			Expected: &generate.FlagsWithSharedValues{
+				BaseDir:       ".",
+				LockfileName:  "docker-lock.json",
+				RecordCreated: true,
+				RegistryFlags: generate.RegistryFlags{
+					OfflineSources: []string{"image.tar"},
+				},
+			},
+			ShouldFail: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -163,6 +297,9 @@ func TestFlagsWithSharedValues(t *testing.T) {
 				test.Expected.BaseDir, test.Expected.LockfileName,
 				test.Expected.IgnoreMissingDigests,
 				test.Expected.UpdateExistingDigests,
+				test.Expected.PlatformDigests,
+				test.Expected.RecordCreated,
+				&test.Expected.RegistryFlags,
 			)
 			if test.ShouldFail {
 				if err == nil {
@@ -200,6 +337,21 @@ func TestFlags(t *testing.T) {
 				DockerfileFlags:     &generate.FlagsWithSharedNames{},
 				ComposefileFlags:    &generate.FlagsWithSharedNames{},
 				KubernetesfileFlags: &generate.FlagsWithSharedNames{},
+				HelmchartFlags:      &generate.FlagsWithSharedNames{},
+				KustomizationFlags:  &generate.FlagsWithSharedNames{},
+				BakefileFlags:       &generate.FlagsWithSharedNames{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Nil Dockerfile Flags",
+			Expected: &generate.Flags{
+				FlagsWithSharedValues: &generate.FlagsWithSharedValues{},
+				ComposefileFlags:      &generate.FlagsWithSharedNames{},
+				KubernetesfileFlags:   &generate.FlagsWithSharedNames{},
+				HelmchartFlags:        &generate.FlagsWithSharedNames{},
+				KustomizationFlags:    &generate.FlagsWithSharedNames{},
+				BakefileFlags:         &generate.FlagsWithSharedNames{},
 			},
 			ShouldFail: true,
 		},
@@ -212,6 +364,9 @@ func TestFlags(t *testing.T) {
 				},
 				ComposefileFlags:    &generate.FlagsWithSharedNames{},
 				KubernetesfileFlags: &generate.FlagsWithSharedNames{},
+				HelmchartFlags:      &generate.FlagsWithSharedNames{},
+				KustomizationFlags:  &generate.FlagsWithSharedNames{},
+				BakefileFlags:       &generate.FlagsWithSharedNames{},
 			},
 			ShouldFail: true,
 		},
@@ -224,6 +379,9 @@ func TestFlags(t *testing.T) {
 					ManualPaths: []string{testutils.GetAbsPath(t)},
 				},
 				KubernetesfileFlags: &generate.FlagsWithSharedNames{},
+				HelmchartFlags:      &generate.FlagsWithSharedNames{},
+				KustomizationFlags:  &generate.FlagsWithSharedNames{},
+				BakefileFlags:       &generate.FlagsWithSharedNames{},
 			},
 			ShouldFail: true,
 		},
@@ -236,6 +394,72 @@ func TestFlags(t *testing.T) {
 				KubernetesfileFlags: &generate.FlagsWithSharedNames{
 					ManualPaths: []string{testutils.GetAbsPath(t)},
 				},
+				HelmchartFlags:     &generate.FlagsWithSharedNames{},
+				KustomizationFlags: &generate.FlagsWithSharedNames{},
+				BakefileFlags:      &generate.FlagsWithSharedNames{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Helmchart Absolute Paths",
+			Expected: &generate.Flags{
+				FlagsWithSharedValues: &generate.FlagsWithSharedValues{},
+				DockerfileFlags:       &generate.FlagsWithSharedNames{},
+				ComposefileFlags:      &generate.FlagsWithSharedNames{},
+				KubernetesfileFlags:   &generate.FlagsWithSharedNames{},
+				HelmchartFlags: &generate.FlagsWithSharedNames{
+					ManualPaths: []string{testutils.GetAbsPath(t)},
+				},
+				KustomizationFlags: &generate.FlagsWithSharedNames{},
+				BakefileFlags:      &generate.FlagsWithSharedNames{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Kustomization Absolute Paths",
+			Expected: &generate.Flags{
+				FlagsWithSharedValues: &generate.FlagsWithSharedValues{},
+				DockerfileFlags:       &generate.FlagsWithSharedNames{},
+				ComposefileFlags:      &generate.FlagsWithSharedNames{},
+				KubernetesfileFlags:   &generate.FlagsWithSharedNames{},
+				HelmchartFlags:        &generate.FlagsWithSharedNames{},
+				KustomizationFlags: &generate.FlagsWithSharedNames{
+					ManualPaths: []string{testutils.GetAbsPath(t)},
+				},
+				BakefileFlags: &generate.FlagsWithSharedNames{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Bakefile Absolute Paths",
+			Expected: &generate.Flags{
+				FlagsWithSharedValues: &generate.FlagsWithSharedValues{},
+				DockerfileFlags:       &generate.FlagsWithSharedNames{},
+				ComposefileFlags:      &generate.FlagsWithSharedNames{},
+				KubernetesfileFlags:   &generate.FlagsWithSharedNames{},
+				HelmchartFlags:        &generate.FlagsWithSharedNames{},
+				KustomizationFlags:    &generate.FlagsWithSharedNames{},
+				BakefileFlags: &generate.FlagsWithSharedNames{
+					ManualPaths: []string{testutils.GetAbsPath(t)},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Build Args Path Outside CWD",
+			Expected: &generate.Flags{
+				FlagsWithSharedValues: &generate.FlagsWithSharedValues{},
+				DockerfileFlags:       &generate.FlagsWithSharedNames{},
+				ComposefileFlags:      &generate.FlagsWithSharedNames{},
+				KubernetesfileFlags:   &generate.FlagsWithSharedNames{},
+				HelmchartFlags:        &generate.FlagsWithSharedNames{},
+				KustomizationFlags:    &generate.FlagsWithSharedNames{},
+				BakefileFlags:         &generate.FlagsWithSharedNames{},
+				BuildArgs: &parse.BuildArgs{
+					Paths: map[string]map[string]string{
+						filepath.Join("..", "Dockerfile"): {"VERSION": "1.0"},
+					},
+				},
 			},
 			ShouldFail: true,
 		},
@@ -255,6 +479,26 @@ func TestFlags(t *testing.T) {
 				KubernetesfileFlags: &generate.FlagsWithSharedNames{
 					ManualPaths: []string{"pod.yaml"},
 				},
+				HelmchartFlags: &generate.FlagsWithSharedNames{
+					ManualPaths: []string{"values.yaml"},
+				},
+				KustomizationFlags: &generate.FlagsWithSharedNames{
+					ManualPaths: []string{"kustomization.yaml"},
+				},
+				BakefileFlags: &generate.FlagsWithSharedNames{
+					ManualPaths: []string{"docker-bake.hcl"},
+				},
+				Strict: true,
+				BuildArgs: &parse.BuildArgs{
+					Global: map[string]string{"REGISTRY": "ghcr.io/org"},
+					Paths: map[string]map[string]string{
+						"Dockerfile": {"VERSION": "1.0"},
+					},
+				},
+				PolicyRules: &policy.Rules{
+					ForbidLatest:      true,
+					AllowedRegistries: []string{"docker.io"},
+				},
 			},
 		},
 	}
@@ -266,22 +510,16 @@ func TestFlags(t *testing.T) {
 			t.Parallel()
 
 			got, err := generate.NewFlags(
-				test.Expected.FlagsWithSharedValues.BaseDir,
-				test.Expected.FlagsWithSharedValues.LockfileName,
-				test.Expected.FlagsWithSharedValues.IgnoreMissingDigests,
-				test.Expected.FlagsWithSharedValues.UpdateExistingDigests,
-				test.Expected.DockerfileFlags.ManualPaths,
-				test.Expected.ComposefileFlags.ManualPaths,
-				test.Expected.KubernetesfileFlags.ManualPaths,
-				test.Expected.DockerfileFlags.Globs,
-				test.Expected.ComposefileFlags.Globs,
-				test.Expected.KubernetesfileFlags.Globs,
-				test.Expected.DockerfileFlags.Recursive,
-				test.Expected.ComposefileFlags.Recursive,
-				test.Expected.KubernetesfileFlags.Recursive,
-				test.Expected.DockerfileFlags.ExcludePaths,
-				test.Expected.ComposefileFlags.ExcludePaths,
-				test.Expected.KubernetesfileFlags.ExcludePaths,
+				test.Expected.FlagsWithSharedValues,
+				test.Expected.DockerfileFlags,
+				test.Expected.ComposefileFlags,
+				test.Expected.KubernetesfileFlags,
+				test.Expected.HelmchartFlags,
+				test.Expected.KustomizationFlags,
+				test.Expected.BakefileFlags,
+				test.Expected.Strict,
+				test.Expected.BuildArgs,
+				test.Expected.PolicyRules,
 			)
 
 			if test.ShouldFail {
@@ -8,11 +8,36 @@ import (
 	"os"
 
 	"github.com/safe-waters/docker-lock/pkg/generate"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-const namespace = "generate"
+const (
+	namespace             = "generate"
+	policyKey             = "policy"
+	pathBuildArgsKey      = "path-build-args"
+	defaultMaxConcurrency = 10
+	defaultRateLimit      = 10
+	defaultMaxRetries     = 3
+)
+
+// registryFlagNames are the names of the flags added by AddRegistryFlags.
+var registryFlagNames = []string{ // nolint: gochecknoglobals
+	"cache-dir",
+	"cache-ttl",
+	"no-cache",
+	"refresh",
+	"max-concurrency",
+	"rate-limit",
+	"max-retries",
+	"offline-source",
+	"registry-mirrors",
+	"credentials-file",
+	"credential-helpers",
+}
 
 // NewGenerateCmd creates the command 'generate' used in 'docker lock generate'.
 func NewGenerateCmd() (*cobra.Command, error) {
@@ -20,24 +45,45 @@ func NewGenerateCmd() (*cobra.Command, error) {
 		Use:   "generate",
 		Short: "Generate a Lockfile to track image digests",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return bindPFlags(cmd, []string{
+			if err := bindPFlags(cmd, []string{
 				"base-dir",
 				"dockerfiles",
 				"composefiles",
 				"kubernetesfiles",
+				"helmcharts",
+				"kustomizations",
+				"bakefiles",
 				"lockfile-name",
 				"dockerfile-globs",
 				"composefile-globs",
 				"kubernetesfile-globs",
+				"helmchart-globs",
+				"kustomization-globs",
+				"bakefile-globs",
 				"dockerfile-recursive",
 				"composefile-recursive",
 				"kubernetesfile-recursive",
+				"helmchart-recursive",
+				"kustomization-recursive",
+				"bakefile-recursive",
 				"exclude-all-dockerfiles",
 				"exclude-all-composefiles",
 				"exclude-all-kubernetesfiles",
+				"exclude-all-helmcharts",
+				"exclude-all-kustomizations",
+				"exclude-all-bakefiles",
 				"ignore-missing-digests",
 				"update-existing-digests",
-			})
+				"platform-digests",
+				"record-created",
+				"build-arg",
+				"build-arg-file",
+				"strict",
+			}); err != nil {
+				return err
+			}
+
+			return BindRegistryFlags(cmd, namespace)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			flags, err := parseFlags()
@@ -45,14 +91,25 @@ func NewGenerateCmd() (*cobra.Command, error) {
 				return err
 			}
 
-			generator, err := SetupGenerator(flags)
+			digestCache, err := DefaultDigestCache(flags)
+			if err != nil {
+				return err
+			}
+
+			generator, err := SetupGenerator(flags, digestCache)
 			if err != nil {
 				return err
 			}
 
 			var lockfileByt bytes.Buffer
 
+			// Flush the cache even if generating fails, so that the
+			// digests that were queried are not queried again.
 			err = generator.GenerateLockfile(&lockfileByt)
+			if flushErr := FlushDigestCache(digestCache); err == nil {
+				err = flushErr
+			}
+
 			if err != nil {
 				return err
 			}
@@ -91,6 +148,16 @@ func NewGenerateCmd() (*cobra.Command, error) {
 	generateCmd.Flags().StringSlice(
 		"kubernetesfiles", []string{}, "Paths to kubernetes files",
 	)
+	generateCmd.Flags().StringSlice(
+		"helmcharts", []string{},
+		"Paths to Helm chart values files and templates",
+	)
+	generateCmd.Flags().StringSlice(
+		"kustomizations", []string{}, "Paths to kustomization files",
+	)
+	generateCmd.Flags().StringSlice(
+		"bakefiles", []string{}, "Paths to docker buildx bake files",
+	)
 	generateCmd.Flags().String(
 		"lockfile-name", "docker-lock.json",
 		"Lockfile name to be output in the current working directory",
@@ -106,6 +173,18 @@ func NewGenerateCmd() (*cobra.Command, error) {
 		"kubernetesfile-globs", []string{},
 		"Glob pattern to select kubernetes files",
 	)
+	generateCmd.Flags().StringSlice(
+		"helmchart-globs", []string{},
+		"Glob pattern to select Helm chart values files and templates",
+	)
+	generateCmd.Flags().StringSlice(
+		"kustomization-globs", []string{},
+		"Glob pattern to select kustomization files",
+	)
+	generateCmd.Flags().StringSlice(
+		"bakefile-globs", []string{},
+		"Glob pattern to select docker buildx bake files",
+	)
 	generateCmd.Flags().Bool(
 		"dockerfile-recursive", false, "Recursively collect Dockerfiles",
 	)
@@ -117,9 +196,22 @@ func NewGenerateCmd() (*cobra.Command, error) {
 		"kubernetesfile-recursive", false,
 		"Recursively collect kubernetes files",
 	)
+	generateCmd.Flags().Bool(
+		"helmchart-recursive", false,
+		"Recursively collect Helm chart values files",
+	)
+	generateCmd.Flags().Bool(
+		"kustomization-recursive", false,
+		"Recursively collect kustomization files",
+	)
+	generateCmd.Flags().Bool(
+		"bakefile-recursive", false,
+		"Recursively collect docker buildx bake files",
+	)
 	generateCmd.Flags().Bool(
 		"exclude-all-dockerfiles", false,
-		"Do not collect Dockerfiles unless referenced by docker-compose files",
+		"Do not collect Dockerfiles unless referenced by docker-compose "+
+			"or docker buildx bake files",
 	)
 	generateCmd.Flags().Bool(
 		"exclude-all-composefiles", false,
@@ -129,6 +221,18 @@ func NewGenerateCmd() (*cobra.Command, error) {
 		"exclude-all-kubernetesfiles", false,
 		"Do not collect kubernetes files",
 	)
+	generateCmd.Flags().Bool(
+		"exclude-all-helmcharts", false,
+		"Do not collect Helm chart values files and templates",
+	)
+	generateCmd.Flags().Bool(
+		"exclude-all-kustomizations", false,
+		"Do not collect kustomization files",
+	)
+	generateCmd.Flags().Bool(
+		"exclude-all-bakefiles", false,
+		"Do not collect docker buildx bake files",
+	)
 	generateCmd.Flags().Bool(
 		"ignore-missing-digests", false,
 		"Do not fail if unable to find digests",
@@ -137,13 +241,38 @@ func NewGenerateCmd() (*cobra.Command, error) {
 		"update-existing-digests", false,
 		"Query registries for new digests even if they are hardcoded in files",
 	)
+	generateCmd.Flags().Bool(
+		"platform-digests", false,
+		"Record the digest of each platform in multi-architecture images",
+	)
+	generateCmd.Flags().Bool(
+		"record-created", false,
+		"Record the time that the image with each digest was created",
+	)
+	AddRegistryFlags(generateCmd, DefaultRegistryFlags(), true)
+	generateCmd.Flags().StringSlice(
+		"build-arg", []string{},
+		"Build args of Dockerfiles that are not built by docker-compose "+
+			"files, such as 'REGISTRY=ghcr.io/org'",
+	)
+	generateCmd.Flags().StringSlice(
+		"build-arg-file", []string{},
+		"Files with a build arg, such as 'REGISTRY=ghcr.io/org', on each line",
+	)
+	generateCmd.Flags().Bool(
+		"strict", false,
+		"Fail if a variable in an image reference is not set, instead of "+
+			"printing a warning",
+	)
 
 	return generateCmd, nil
 }
 
 // SetupGenerator creates a Generator configured for docker-lock's cli.
+// Digests are cached in digestCache, unless it is nil.
 func SetupGenerator(
 	flags *Flags,
+	digestCache update.IDigestCache,
 ) (generate.IGenerator, error) {
 	if err := ensureFlagsNotNil(flags); err != nil {
 		return nil, err
@@ -159,7 +288,12 @@ func SetupGenerator(
 		return nil, err
 	}
 
-	updater, err := DefaultImageDigestUpdater(flags)
+	checker, err := DefaultImagePolicyChecker(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	updater, err := DefaultImageDigestUpdater(flags, digestCache)
 	if err != nil {
 		return nil, err
 	}
@@ -169,7 +303,9 @@ func SetupGenerator(
 		return nil, err
 	}
 
-	generator, err := generate.NewGenerator(collector, parser, updater, sorter)
+	generator, err := generate.NewGenerator(
+		collector, parser, checker, updater, sorter,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +342,15 @@ func parseFlags() (*Flags, error) {
 		kubernetesfilePaths = viper.GetStringSlice(
 			fmt.Sprintf("%s.%s", namespace, "kubernetesfiles"),
 		)
+		helmchartPaths = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "helmcharts"),
+		)
+		kustomizationPaths = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "kustomizations"),
+		)
+		bakefilePaths = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "bakefiles"),
+		)
 		dockerfileGlobs = viper.GetStringSlice(
 			fmt.Sprintf("%s.%s", namespace, "dockerfile-globs"),
 		)
@@ -215,6 +360,15 @@ func parseFlags() (*Flags, error) {
 		kubernetesfileGlobs = viper.GetStringSlice(
 			fmt.Sprintf("%s.%s", namespace, "kubernetesfile-globs"),
 		)
+		helmchartGlobs = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "helmchart-globs"),
+		)
+		kustomizationGlobs = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "kustomization-globs"),
+		)
+		bakefileGlobs = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "bakefile-globs"),
+		)
 		dockerfileRecursive = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "dockerfile-recursive"),
 		)
@@ -224,6 +378,15 @@ func parseFlags() (*Flags, error) {
 		kubernetesfileRecursive = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "kubernetesfile-recursive"),
 		)
+		helmchartRecursive = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "helmchart-recursive"),
+		)
+		kustomizationRecursive = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "kustomization-recursive"),
+		)
+		bakefileRecursive = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "bakefile-recursive"),
+		)
 		dockerfileExcludeAll = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "exclude-all-dockerfiles"),
 		)
@@ -233,19 +396,310 @@ func parseFlags() (*Flags, error) {
 		kubernetesfileExcludeAll = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "exclude-all-kubernetesfiles"),
 		)
+		helmchartExcludeAll = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "exclude-all-helmcharts"),
+		)
+		kustomizationExcludeAll = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "exclude-all-kustomizations"),
+		)
+		bakefileExcludeAll = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "exclude-all-bakefiles"),
+		)
 		ignoreMissingDigests = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "ignore-missing-digests"),
 		)
 		updateExistingDigests = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "update-existing-digests"),
 		)
+		platformDigests = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "platform-digests"),
+		)
+		recordCreated = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "record-created"),
+		)
+		strict = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "strict"),
+		)
 	)
 
+	registryFlags, err := ParseRegistryFlags(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	buildArgs, err := ParseBuildArgs(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	policyRules, err := ParsePolicyRules()
+	if err != nil {
+		return nil, err
+	}
+
 	return NewFlags(
-		baseDir, lockfileName, ignoreMissingDigests, updateExistingDigests,
-		dockerfilePaths, composefilePaths, kubernetesfilePaths,
-		dockerfileGlobs, composefileGlobs, kubernetesfileGlobs,
-		dockerfileRecursive, composefileRecursive, kubernetesfileRecursive,
-		dockerfileExcludeAll, composefileExcludeAll, kubernetesfileExcludeAll,
+		&FlagsWithSharedValues{
+			BaseDir:               baseDir,
+			LockfileName:          lockfileName,
+			IgnoreMissingDigests:  ignoreMissingDigests,
+			UpdateExistingDigests: updateExistingDigests,
+			PlatformDigests:       platformDigests,
+			RecordCreated:         recordCreated,
+			RegistryFlags:         *registryFlags,
+		},
+		&FlagsWithSharedNames{
+			ManualPaths:  dockerfilePaths,
+			Globs:        dockerfileGlobs,
+			Recursive:    dockerfileRecursive,
+			ExcludePaths: dockerfileExcludeAll,
+		},
+		&FlagsWithSharedNames{
+			ManualPaths:  composefilePaths,
+			Globs:        composefileGlobs,
+			Recursive:    composefileRecursive,
+			ExcludePaths: composefileExcludeAll,
+		},
+		&FlagsWithSharedNames{
+			ManualPaths:  kubernetesfilePaths,
+			Globs:        kubernetesfileGlobs,
+			Recursive:    kubernetesfileRecursive,
+			ExcludePaths: kubernetesfileExcludeAll,
+		},
+		&FlagsWithSharedNames{
+			ManualPaths:  helmchartPaths,
+			Globs:        helmchartGlobs,
+			Recursive:    helmchartRecursive,
+			ExcludePaths: helmchartExcludeAll,
+		},
+		&FlagsWithSharedNames{
+			ManualPaths:  kustomizationPaths,
+			Globs:        kustomizationGlobs,
+			Recursive:    kustomizationRecursive,
+			ExcludePaths: kustomizationExcludeAll,
+		},
+		&FlagsWithSharedNames{
+			ManualPaths:  bakefilePaths,
+			Globs:        bakefileGlobs,
+			Recursive:    bakefileRecursive,
+			ExcludePaths: bakefileExcludeAll,
+		},
+		strict, buildArgs, policyRules,
+	)
+}
+
+// AddRegistryFlags adds the flags of RegistryFlags to cmd, with the fields of
+// defaults as their default values. "offline-source" is only added if
+// offlineSources is true, for commands that can resolve digests without
+// registries.
+func AddRegistryFlags(
+	cmd *cobra.Command,
+	defaults *RegistryFlags,
+	offlineSources bool,
+) {
+	cmd.Flags().String(
+		"cache-dir", defaults.CacheDir,
+		"Directory of the digest cache (default is the user cache directory)",
+	)
+	cmd.Flags().Duration(
+		"cache-ttl", defaults.CacheTTL, "Time that digests are cached for",
+	)
+	cmd.Flags().Bool(
+		"no-cache", defaults.NoCache, "Do not read or write the digest cache",
+	)
+	cmd.Flags().Bool(
+		"refresh", defaults.Refresh,
+		"Ignore cached digests, replacing them with newly queried digests",
+	)
+	cmd.Flags().Int(
+		"max-concurrency", defaults.MaxConcurrency,
+		"Maximum number of images to query registries for at the same time "+
+			"(0 for no limit)",
+	)
+	cmd.Flags().Float64(
+		"rate-limit", defaults.RateLimit,
+		"Maximum number of requests per second to each registry "+
+			"(0 for no limit)",
+	)
+	cmd.Flags().Int(
+		"max-retries", defaults.MaxRetries,
+		"Maximum number of retries for requests that are throttled or fail "+
+			"with a server error",
+	)
+
+	if offlineSources {
+		cmd.Flags().StringSlice(
+			"offline-source", defaults.OfflineSources,
+			"Resolve digests from OCI image layouts or tarballs instead of "+
+				"registries",
+		)
+	}
+
+	cmd.Flags().StringToString(
+		"registry-mirrors", defaults.RegistryMirrors,
+		"Mirrors to query instead of registries, such as "+
+			"'docker.io=mirror.internal:5000'",
+	)
+	cmd.Flags().String(
+		"credentials-file", defaults.CredentialsFile,
+		"JSON file with the credentials of registries",
+	)
+	cmd.Flags().StringToString(
+		"credential-helpers", defaults.CredentialHelpers,
+		"Docker credential helpers to get the credentials of registries "+
+			"from, such as 'ghcr.io=pass'",
+	)
+}
+
+// BindRegistryFlags binds the flags added by AddRegistryFlags to the keys of
+// the namespace of the command, such as "generate.cache-dir".
+func BindRegistryFlags(cmd *cobra.Command, namespace string) error {
+	for _, name := range registryFlagNames {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			continue
+		}
+
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), flag,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseRegistryFlags reads the flags added by AddRegistryFlags from the keys
+// of the namespace of the command, such as "generate.cache-dir", and
+// validates them as in RegistryFlags.Validate.
+func ParseRegistryFlags(namespace string) (*RegistryFlags, error) {
+	registryFlags := &RegistryFlags{
+		CacheDir: viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "cache-dir"),
+		),
+		CacheTTL: viper.GetDuration(
+			fmt.Sprintf("%s.%s", namespace, "cache-ttl"),
+		),
+		NoCache: viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "no-cache"),
+		),
+		Refresh: viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "refresh"),
+		),
+		MaxConcurrency: viper.GetInt(
+			fmt.Sprintf("%s.%s", namespace, "max-concurrency"),
+		),
+		RateLimit: viper.GetFloat64(
+			fmt.Sprintf("%s.%s", namespace, "rate-limit"),
+		),
+		MaxRetries: viper.GetInt(
+			fmt.Sprintf("%s.%s", namespace, "max-retries"),
+		),
+		OfflineSources: viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "offline-source"),
+		),
+		RegistryMirrors: viper.GetStringMapString(
+			fmt.Sprintf("%s.%s", namespace, "registry-mirrors"),
+		),
+		CredentialsFile: viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "credentials-file"),
+		),
+		CredentialHelpers: viper.GetStringMapString(
+			fmt.Sprintf("%s.%s", namespace, "credential-helpers"),
+		),
+	}
+
+	if err := registryFlags.Validate(); err != nil {
+		return nil, err
+	}
+
+	return registryFlags, nil
+}
+
+// ParsePolicyRules reads the rules of the policy from the "policy" key of
+// the config file. The policy is shared by all commands, so the key is not
+// namespaced. If the key is not set, nil is returned.
+func ParsePolicyRules() (*policy.Rules, error) {
+	if !viper.IsSet(policyKey) {
+		return nil, nil
+	}
+
+	var rules policy.Rules
+	if err := viper.UnmarshalKey(policyKey, &rules); err != nil {
+		return nil, fmt.Errorf(
+			"'%s' in the config file is malformed with err: %v",
+			policyKey, err,
+		)
+	}
+
+	return &rules, nil
+}
+
+// pathBuildArgs are the build args of the Dockerfile at a path, in the
+// "path-build-args" key of the config file.
+type pathBuildArgs struct {
+	Path          string   `mapstructure:"path"`
+	BuildArgs     []string `mapstructure:"build-arg"`
+	BuildArgFiles []string `mapstructure:"build-arg-file"`
+}
+
+// ParseBuildArgs reads the build args of Dockerfiles from the "build-arg"
+// and "build-arg-file" flags of the command of the namespace, such as
+// "generate", as well as the build args of each path from the
+// "path-build-args" key of the config file. Dockerfiles must be parsed the
+// same way by every command, so the key is not namespaced. If there are no
+// build args, nil is returned.
+func ParseBuildArgs(namespace string) (*parse.BuildArgs, error) {
+	var (
+		buildArgs = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "build-arg"),
+		)
+		buildArgFiles = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "build-arg-file"),
+		)
 	)
+
+	var allPathBuildArgs []*pathBuildArgs
+
+	if viper.IsSet(pathBuildArgsKey) {
+		if err := viper.UnmarshalKey(
+			pathBuildArgsKey, &allPathBuildArgs,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"'%s' in the config file is malformed with err: %v",
+				pathBuildArgsKey, err,
+			)
+		}
+	}
+
+	if len(buildArgs) == 0 && len(buildArgFiles) == 0 &&
+		len(allPathBuildArgs) == 0 {
+		return nil, nil
+	}
+
+	globalBuildArgs, err := parse.ReadBuildArgs(buildArgs, buildArgFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]map[string]string, len(allPathBuildArgs))
+
+	for _, scopedBuildArgs := range allPathBuildArgs {
+		if scopedBuildArgs == nil || scopedBuildArgs.Path == "" {
+			return nil, fmt.Errorf(
+				"'%s' in the config file must have a 'path'", pathBuildArgsKey,
+			)
+		}
+
+		pathArgs, err := parse.ReadBuildArgs(
+			scopedBuildArgs.BuildArgs, scopedBuildArgs.BuildArgFiles,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		paths[scopedBuildArgs.Path] = pathArgs
+	}
+
+	return &parse.BuildArgs{Global: globalBuildArgs, Paths: paths}, nil
 }
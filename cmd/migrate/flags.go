@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Flags holds all command line options for migrating a Lockfile.
+type Flags struct {
+	LockfileName string
+}
+
+// NewFlags returns Flags after validating its fields.
+// lockfileName may not contain slashes.
+func NewFlags(lockfileName string) (*Flags, error) {
+	if err := validateLockfileName(lockfileName); err != nil {
+		return nil, err
+	}
+
+	return &Flags{LockfileName: lockfileName}, nil
+}
+
+func validateLockfileName(lockfileName string) error {
+	if filepath.IsAbs(lockfileName) {
+		return fmt.Errorf(
+			"'%s' lockfile-name does not support absolute paths", lockfileName,
+		)
+	}
+
+	lockfileName = filepath.Join(".", lockfileName)
+
+	if strings.ContainsAny(lockfileName, `/\`) {
+		return fmt.Errorf(
+			"'%s' lockfile-name cannot contain slashes", lockfileName,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package migrate_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/cmd/migrate"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Expected   *migrate.Flags
+		ShouldFail bool
+	}{
+		{
+			Name: "Lockfile Name With Slashes",
+			Expected: &migrate.Flags{
+				LockfileName: filepath.Join("lockfile", "path"),
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Normal",
+			Expected: &migrate.Flags{
+				LockfileName: "docker-lock.json",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := migrate.NewFlags(test.Expected.LockfileName)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertFlagsEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package migrate provides the "migrate" command.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const namespace = "migrate"
+
+// NewMigrateCmd creates the command 'migrate' used in 'docker lock migrate'.
+func NewMigrateCmd() (*cobra.Command, error) {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade a Lockfile to the latest schema version",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindPFlags(cmd, []string{
+				"lockfile-name",
+			})
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags, err := parseFlags()
+			if err != nil {
+				return err
+			}
+
+			fromVersion, err := MigrateLockfile(flags)
+			if err != nil {
+				return err
+			}
+
+			if fromVersion == lockfile.SchemaVersion {
+				fmt.Printf(
+					"lockfile already has the latest schemaVersion '%d'\n",
+					lockfile.SchemaVersion,
+				)
+
+				return nil
+			}
+
+			fmt.Printf(
+				"successfully migrated lockfile from schemaVersion '%d' "+
+					"to '%d'!\n",
+				fromVersion, lockfile.SchemaVersion,
+			)
+
+			return nil
+		},
+	}
+	migrateCmd.Flags().String(
+		"lockfile-name", "docker-lock.json", "Lockfile to migrate",
+	)
+
+	return migrateCmd, nil
+}
+
+// MigrateLockfile upgrades the Lockfile to the latest schema version in
+// place, returning the schema version it had before. The Lockfile is not
+// written if it already has the latest schema version.
+func MigrateLockfile(flags *Flags) (int, error) {
+	if flags == nil {
+		return 0, errors.New("'flags' cannot be nil")
+	}
+
+	lockfileByt, err := ioutil.ReadFile(flags.LockfileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf(
+				"lockfile '%s' does not exist", flags.LockfileName,
+			)
+		}
+
+		return 0, err
+	}
+
+	var existingLockfile lockfile.Lockfile
+	if err := json.Unmarshal(lockfileByt, &existingLockfile); err != nil {
+		return 0, err
+	}
+
+	fromVersion := existingLockfile.SchemaVersion
+
+	if err := existingLockfile.Migrate(); err != nil {
+		return 0, err
+	}
+
+	if fromVersion == existingLockfile.SchemaVersion {
+		return fromVersion, nil
+	}
+
+	var migratedByt bytes.Buffer
+	if err := existingLockfile.Write(&migratedByt); err != nil {
+		return 0, err
+	}
+
+	fileInfo, err := os.Stat(flags.LockfileName)
+	if err != nil {
+		return 0, err
+	}
+
+	return fromVersion, ioutil.WriteFile(
+		flags.LockfileName, migratedByt.Bytes(), fileInfo.Mode(),
+	)
+}
+
+func bindPFlags(cmd *cobra.Command, flagNames []string) error {
+	for _, name := range flagNames {
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), cmd.Flags().Lookup(name),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFlags() (*Flags, error) {
+	lockfileName := viper.GetString(
+		fmt.Sprintf("%s.%s", namespace, "lockfile-name"),
+	)
+
+	return NewFlags(lockfileName)
+}
@@ -0,0 +1,84 @@
+package outdated
+
+import (
+	"errors"
+	"fmt"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/pkg/outdated"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+// Flags holds all command line options for reporting images with newer tags
+// in a Lockfile, and bumping them.
+type Flags struct {
+	FlagsWithSharedValues *cmd_generate.FlagsWithSharedValues
+	Names                 []string
+	Registries            []string
+	Paths                 []string
+	Output                string
+	Write                 string
+	TempDir               string
+}
+
+// NewFlags returns Flags after validating their fields.
+//
+// names and paths must be valid globs, as described in refresh.NewSelector.
+//
+// output must be one of "text" or "json".
+//
+// write, if not empty, must be one of "patch", "minor", or "major".
+//
+// lockfileName and registryFlags are validated as in
+// cmd_generate.NewFlagsWithSharedValues. The OfflineSources of registryFlags
+// are ignored, as tags are listed from registries.
+func NewFlags(
+	lockfileName string,
+	names []string,
+	registries []string,
+	paths []string,
+	output string,
+	write string,
+	tempDir string,
+	registryFlags *cmd_generate.RegistryFlags,
+) (*Flags, error) {
+	if registryFlags == nil {
+		return nil, errors.New("'registryFlags' cannot be nil")
+	}
+
+	onlineRegistryFlags := *registryFlags
+	onlineRegistryFlags.OfflineSources = nil
+
+	flagsWithSharedValues, err := cmd_generate.NewFlagsWithSharedValues(
+		"", lockfileName, false, true, false, false, &onlineRegistryFlags,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := refresh.NewSelector(names, registries, paths); err != nil {
+		return nil, err
+	}
+
+	if output != "text" && output != "json" {
+		return nil, fmt.Errorf(
+			"'%s' output must be one of 'text' or 'json'", output,
+		)
+	}
+
+	if write != "" {
+		if err := outdated.ValidateLevel(outdated.Level(write)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Flags{
+		FlagsWithSharedValues: flagsWithSharedValues,
+		Names:                 names,
+		Registries:            registries,
+		Paths:                 paths,
+		Output:                output,
+		Write:                 write,
+		TempDir:               tempDir,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package outdated_test
+
+import (
+	"testing"
+	"time"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/cmd/outdated"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Expected   *outdated.Flags
+		ShouldFail bool
+	}{
+		{
+			Name: "Lockfile Name With Slashes",
+			Expected: &outdated.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "lockfile/path",
+				},
+				Output: "text",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Name Glob",
+			Expected: &outdated.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "docker-lock.json",
+				},
+				Names:  []string{"["},
+				Output: "text",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Output",
+			Expected: &outdated.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "docker-lock.json",
+				},
+				Output: "yaml",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Write Level",
+			Expected: &outdated.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "docker-lock.json",
+				},
+				Output: "text",
+				Write:  "latest",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Normal",
+			Expected: &outdated.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName:          "docker-lock.json",
+					UpdateExistingDigests: true,
+					RegistryFlags: cmd_generate.RegistryFlags{
+						CacheTTL:       time.Hour,
+						MaxConcurrency: 10,
+					},
+				},
+				Names:   []string{"redis", "ghcr.io/org/*"},
+				Paths:   []string{"services/*/Dockerfile"},
+				Output:  "json",
+				Write:   "minor",
+				TempDir: ".",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			shared := test.Expected.FlagsWithSharedValues
+
+			got, err := outdated.NewFlags(
+				shared.LockfileName,
+				test.Expected.Names,
+				test.Expected.Registries,
+				test.Expected.Paths,
+				test.Expected.Output,
+				test.Expected.Write,
+				test.Expected.TempDir,
+				&shared.RegistryFlags,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertFlagsEqual(t, test.Expected, got)
+		})
+	}
+}
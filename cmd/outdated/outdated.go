@@ -0,0 +1,363 @@
+// Package outdated provides the "outdated" command.
+package outdated
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	cmd_rewrite "github.com/safe-waters/docker-lock/cmd/rewrite"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/outdated"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const namespace = "outdated"
+
+// NewOutdatedCmd creates the command 'outdated' used in
+// 'docker lock outdated'.
+func NewOutdatedCmd() (*cobra.Command, error) {
+	outdatedCmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "Report images in a Lockfile with newer semver tags",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindPFlags(cmd, []string{
+				"lockfile-name",
+				"name",
+				"registry",
+				"path",
+				"output",
+				"write",
+				"tempdir",
+			}); err != nil {
+				return err
+			}
+
+			return cmd_generate.BindRegistryFlags(cmd, namespace)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags, err := parseFlags()
+			if err != nil {
+				return err
+			}
+
+			report, err := CheckLockfile(flags)
+			if err != nil {
+				return err
+			}
+
+			reportWriter, err := SetupReportWriter(flags)
+			if err != nil {
+				return err
+			}
+
+			if err := reportWriter.WriteReport(
+				report, os.Stdout,
+			); err != nil {
+				return err
+			}
+
+			if flags.Write == "" || len(report.Images) == 0 {
+				return nil
+			}
+
+			if err := BumpLockfile(flags, report); err != nil {
+				return err
+			}
+
+			if flags.Output == "text" {
+				fmt.Printf(
+					"successfully bumped %s tags in lockfile and files!\n",
+					flags.Write,
+				)
+			}
+
+			return nil
+		},
+	}
+	outdatedCmd.Flags().String(
+		"lockfile-name", "docker-lock.json", "Lockfile to read from",
+	)
+	outdatedCmd.Flags().StringSlice(
+		"name", []string{},
+		"Globs of image names to check, such as 'redis' or 'ghcr.io/org/*'",
+	)
+	outdatedCmd.Flags().StringSlice(
+		"registry", []string{},
+		"Registries of images to check, such as 'ghcr.io' or 'docker.io'",
+	)
+	outdatedCmd.Flags().StringSlice(
+		"path", []string{},
+		"Globs of paths in the Lockfile whose images to check, such as "+
+			"'services/*/Dockerfile'",
+	)
+	outdatedCmd.Flags().String(
+		"output", "text", "Format of the report, one of 'text' or 'json'",
+	)
+	outdatedCmd.Flags().String(
+		"write", "",
+		"Bump tags to the newest 'patch', 'minor', or 'major' version, "+
+			"updating the Lockfile and rewriting the files it references",
+	)
+	outdatedCmd.Flags().String(
+		"tempdir", ".",
+		"Directory where a temporary directory will be created/deleted "+
+			"during a rewrite transaction",
+	)
+	cmd_generate.AddRegistryFlags(
+		outdatedCmd, cmd_generate.DefaultRegistryFlags(), false,
+	)
+
+	return outdatedCmd, nil
+}
+
+// SetupChecker creates a Checker configured for docker-lock's cli.
+func SetupChecker(flags *Flags) (outdated.IChecker, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	tagLister, err := cmd_generate.DefaultTagLister(generateFlags(flags))
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := refresh.NewSelector(
+		flags.Names, flags.Registries, flags.Paths,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return outdated.NewChecker(
+		tagLister, selector, flags.FlagsWithSharedValues.MaxConcurrency,
+	)
+}
+
+// SetupBumper creates a Bumper configured for docker-lock's cli. Digests
+// are cached in digestCache, unless it is nil.
+func SetupBumper(
+	flags *Flags,
+	digestCache update.IDigestCache,
+) (outdated.IBumper, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	digestRequester, err := cmd_generate.DefaultDigestRequester(
+		generateFlags(flags), digestCache,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return outdated.NewBumper(
+		digestRequester, flags.FlagsWithSharedValues.MaxConcurrency,
+	)
+}
+
+// SetupReportWriter creates a ReportWriter for the output format in flags.
+func SetupReportWriter(flags *Flags) (outdated.IReportWriter, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	switch flags.Output {
+	case "text":
+		return outdated.NewTextReportWriter(), nil
+	case "json":
+		return outdated.NewJSONReportWriter(), nil
+	}
+
+	return nil, fmt.Errorf(
+		"'%s' output must be one of 'text' or 'json'", flags.Output,
+	)
+}
+
+// CheckLockfile reports the images in the Lockfile with newer tags.
+func CheckLockfile(flags *Flags) (*outdated.Report, error) {
+	checker, err := SetupChecker(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := os.Open(flags.FlagsWithSharedValues.LockfileName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return checker.CheckLockfile(reader)
+}
+
+// BumpLockfile bumps the tags of the images in the Report to the Level in
+// flags, rewrites the files with bumped images to use the new tags and
+// digests, and then writes the Lockfile in place. The Lockfile is only
+// written if the files were rewritten.
+func BumpLockfile(flags *Flags, report *outdated.Report) error {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return err
+	}
+
+	digestCache, err := cmd_generate.DefaultDigestCache(generateFlags(flags))
+	if err != nil {
+		return err
+	}
+
+	bumper, err := SetupBumper(flags, digestCache)
+	if err != nil {
+		return err
+	}
+
+	lockfileName := flags.FlagsWithSharedValues.LockfileName
+
+	fileInfo, err := os.Stat(lockfileName)
+	if err != nil {
+		return err
+	}
+
+	lockfileByt, err := ioutil.ReadFile(lockfileName)
+	if err != nil {
+		return err
+	}
+
+	var bumpedByt bytes.Buffer
+
+	err = bumper.BumpLockfile(
+		bytes.NewReader(lockfileByt), &bumpedByt, report,
+		outdated.Level(flags.Write),
+	)
+	if flushErr := cmd_generate.FlushDigestCache(digestCache); err == nil {
+		err = flushErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// The Dockerfiles are written with the build args in the configuration
+	// file, such as "path-build-args", as they were parsed.
+	buildArgs, err := cmd_generate.ParseBuildArgs(namespace)
+	if err != nil {
+		return err
+	}
+
+	rewriteFlags, err := cmd_rewrite.NewFlags(
+		lockfileName, flags.TempDir, false, "",
+		flags.FlagsWithSharedValues.RegistryMirrors, buildArgs,
+	)
+	if err != nil {
+		return err
+	}
+
+	rewriter, err := cmd_rewrite.SetupRewriter(rewriteFlags)
+	if err != nil {
+		return err
+	}
+
+	// Only the files with bumped images are rewritten, so that the other
+	// files are unchanged.
+	bumpedLockfile, err := lockfile.Read(bytes.NewReader(bumpedByt.Bytes()))
+	if err != nil {
+		return err
+	}
+
+	if err := outdated.SelectBumpedPaths(
+		bumpedLockfile, report, outdated.Level(flags.Write),
+	); err != nil {
+		return err
+	}
+
+	var bumpedPathsByt bytes.Buffer
+	if err := bumpedLockfile.Write(&bumpedPathsByt); err != nil {
+		return err
+	}
+
+	if err := rewriter.RewriteLockfile(
+		&bumpedPathsByt, flags.TempDir,
+	); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(
+		lockfileName, bumpedByt.Bytes(), fileInfo.Mode(),
+	)
+}
+
+func generateFlags(flags *Flags) *cmd_generate.Flags {
+	return &cmd_generate.Flags{
+		FlagsWithSharedValues: flags.FlagsWithSharedValues,
+		DockerfileFlags:       &cmd_generate.FlagsWithSharedNames{},
+		ComposefileFlags:      &cmd_generate.FlagsWithSharedNames{},
+		KubernetesfileFlags:   &cmd_generate.FlagsWithSharedNames{},
+		HelmchartFlags:        &cmd_generate.FlagsWithSharedNames{},
+		KustomizationFlags:    &cmd_generate.FlagsWithSharedNames{},
+		BakefileFlags:         &cmd_generate.FlagsWithSharedNames{},
+	}
+}
+
+func ensureFlagsNotNil(flags *Flags) error {
+	if flags == nil {
+		return errors.New("'flags' cannot be nil")
+	}
+
+	if flags.FlagsWithSharedValues == nil {
+		return errors.New("flags.FlagsWithSharedValues cannot be nil")
+	}
+
+	return nil
+}
+
+func bindPFlags(cmd *cobra.Command, flagNames []string) error {
+	for _, name := range flagNames {
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), cmd.Flags().Lookup(name),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFlags() (*Flags, error) {
+	var (
+		lockfileName = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "lockfile-name"),
+		)
+		names = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "name"),
+		)
+		registries = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "registry"),
+		)
+		paths = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "path"),
+		)
+		output = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "output"),
+		)
+		write = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "write"),
+		)
+		tempDir = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "tempdir"),
+		)
+	)
+
+	registryFlags, err := cmd_generate.ParseRegistryFlags(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFlags(
+		lockfileName, names, registries, paths, output, write, tempDir,
+		registryFlags,
+	)
+}
@@ -4,31 +4,51 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
 )
 
 // Flags holds all command line options for Dockerfiles, Composefiles,
 // and Kubernetesfiles.
 type Flags struct {
-	LockfileName string
-	TempDir      string
-	ExcludeTags  bool
+	LockfileName    string
+	TempDir         string
+	ExcludeTags     bool
+	Platform        string
+	RegistryMirrors map[string]string
+	BuildArgs       *parse.BuildArgs
 }
 
 // NewFlags returns Flags after validating its fields.
-// lockfileName may not contain slashes.
+// lockfileName may not contain slashes. platform, if not empty, selects the
+// digest of a platform for multi-architecture images. registryMirrors, if
+// not empty, replaces image names with their names in registry mirrors.
+// buildArgs should be the same as those of generate, so that ARGs in
+// Dockerfiles expand to the images in the Lockfile.
 func NewFlags(
 	lockfileName string,
 	tempDir string,
 	excludeTags bool,
+	platform string,
+	registryMirrors map[string]string,
+	buildArgs *parse.BuildArgs,
 ) (*Flags, error) {
 	if err := validateLockfileName(lockfileName); err != nil {
 		return nil, err
 	}
 
+	if err := update.ValidateMirrors(registryMirrors); err != nil {
+		return nil, err
+	}
+
 	return &Flags{
-		LockfileName: lockfileName,
-		TempDir:      tempDir,
-		ExcludeTags:  excludeTags,
+		LockfileName:    lockfileName,
+		TempDir:         tempDir,
+		ExcludeTags:     excludeTags,
+		Platform:        platform,
+		RegistryMirrors: registryMirrors,
+		BuildArgs:       buildArgs,
 	}, nil
 }
 
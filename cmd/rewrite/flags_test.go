@@ -6,6 +6,7 @@ import (
 
 	"github.com/safe-waters/docker-lock/cmd/rewrite"
 	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 )
 
 func TestFlags(t *testing.T) {
@@ -29,6 +30,39 @@ func TestFlags(t *testing.T) {
 				LockfileName: "docker-lock.json",
 			},
 		},
+		{
+			Name: "Platform",
+			Expected: &rewrite.Flags{
+				LockfileName: "docker-lock.json",
+				Platform:     "linux/arm64/v8",
+			},
+		},
+		{
+			Name: "Registry Mirrors",
+			Expected: &rewrite.Flags{
+				LockfileName: "docker-lock.json",
+				RegistryMirrors: map[string]string{
+					"docker.io": "mirror.internal:5000",
+				},
+			},
+		},
+		{
+			Name: "Build Args",
+			Expected: &rewrite.Flags{
+				LockfileName: "docker-lock.json",
+				BuildArgs: &parse.BuildArgs{
+					Global: map[string]string{"BUILDER": "builder"},
+				},
+			},
+		},
+		{
+			Name: "Empty Registry Mirror",
+			Expected: &rewrite.Flags{
+				LockfileName:    "docker-lock.json",
+				RegistryMirrors: map[string]string{"docker.io": ""},
+			},
+			ShouldFail: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -41,6 +75,9 @@ func TestFlags(t *testing.T) {
 				test.Expected.LockfileName,
 				test.Expected.TempDir,
 				test.Expected.ExcludeTags,
+				test.Expected.Platform,
+				test.Expected.RegistryMirrors,
+				test.Expected.BuildArgs,
 			)
 			if test.ShouldFail {
 				if err == nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
 	"github.com/safe-waters/docker-lock/pkg/rewrite"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/preprocess"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
@@ -25,6 +26,10 @@ func NewRewriteCmd() (*cobra.Command, error) {
 				"lockfile-name",
 				"tempdir",
 				"exclude-tags",
+				"platform",
+				"registry-mirrors",
+				"build-arg",
+				"build-arg-file",
 			})
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -65,6 +70,25 @@ func NewRewriteCmd() (*cobra.Command, error) {
 	rewriteCmd.Flags().Bool(
 		"exclude-tags", false, "Exclude image tags from rewritten files",
 	)
+	rewriteCmd.Flags().String(
+		"platform", "",
+		"Platform such as 'linux/arm64/v8' whose digest should be used for "+
+			"multi-architecture images recorded with platform digests",
+	)
+	rewriteCmd.Flags().StringToString(
+		"registry-mirrors", map[string]string{},
+		"Mirrors whose references should be written instead of registries, "+
+			"such as 'docker.io=mirror.internal:5000'",
+	)
+	rewriteCmd.Flags().StringSlice(
+		"build-arg", []string{},
+		"Build args of Dockerfiles that are not built by docker-compose "+
+			"files, such as 'REGISTRY=ghcr.io/org'",
+	)
+	rewriteCmd.Flags().StringSlice(
+		"build-arg-file", []string{},
+		"Files with a build arg, such as 'REGISTRY=ghcr.io/org', on each line",
+	)
 
 	return rewriteCmd, nil
 }
@@ -85,7 +109,9 @@ func SetupRewriter(flags *Flags) (rewrite.IRewriter, error) {
 		return nil, err
 	}
 
-	dockerfileWriter := write.NewDockerfileWriter(flags.ExcludeTags)
+	dockerfileWriter := write.NewDockerfileWriter(
+		flags.ExcludeTags, flags.BuildArgs,
+	)
 
 	composefileWriter, err := write.NewComposefileWriter(
 		dockerfileWriter, flags.ExcludeTags,
@@ -96,8 +122,15 @@ func SetupRewriter(flags *Flags) (rewrite.IRewriter, error) {
 
 	kubernetesfileWriter := write.NewKubernetesfileWriter(flags.ExcludeTags)
 
+	helmchartWriter := write.NewHelmchartWriter(flags.ExcludeTags)
+
+	kustomizationWriter := write.NewKustomizationWriter(flags.ExcludeTags)
+
+	bakefileWriter := write.NewBakefileWriter(flags.ExcludeTags)
+
 	writer, err := rewrite.NewWriter(
 		dockerfileWriter, composefileWriter, kubernetesfileWriter,
+		helmchartWriter, kustomizationWriter, bakefileWriter,
 	)
 	if err != nil {
 		return nil, err
@@ -107,7 +140,34 @@ func SetupRewriter(flags *Flags) (rewrite.IRewriter, error) {
 
 	composefilePreprocessor := preprocess.NewComposefilePreprocessor()
 
-	preprocessor, err := rewrite.NewPreprocessor(composefilePreprocessor)
+	bakefilePreprocessor := preprocess.NewBakefilePreprocessor()
+
+	var platformPreprocessor preprocess.IPreprocessor
+
+	if flags.Platform != "" {
+		platformPreprocessor, err = preprocess.NewPlatformPreprocessor(
+			flags.Platform,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mirrorPreprocessor preprocess.IPreprocessor
+
+	if len(flags.RegistryMirrors) != 0 {
+		mirrorPreprocessor, err = preprocess.NewMirrorPreprocessor(
+			flags.RegistryMirrors,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	preprocessor, err := rewrite.NewPreprocessor(
+		composefilePreprocessor, bakefilePreprocessor, platformPreprocessor,
+		mirrorPreprocessor,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +198,21 @@ func parseFlags() (*Flags, error) {
 		excludeTags = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "exclude-tags"),
 		)
+		platform = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "platform"),
+		)
+		registryMirrors = viper.GetStringMapString(
+			fmt.Sprintf("%s.%s", namespace, "registry-mirrors"),
+		)
 	)
 
-	return NewFlags(lockfileName, tempDir, excludeTags)
+	buildArgs, err := cmd_generate.ParseBuildArgs(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFlags(
+		lockfileName, tempDir, excludeTags, platform, registryMirrors,
+		buildArgs,
+	)
 }
@@ -0,0 +1,47 @@
+package update
+
+import (
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+// Flags holds all command line options for refreshing the digests of
+// selected images in a Lockfile.
+type Flags struct {
+	FlagsWithSharedValues *cmd_generate.FlagsWithSharedValues
+	Names                 []string
+	Registries            []string
+	Paths                 []string
+}
+
+// NewFlags returns Flags after validating their fields.
+//
+// names and paths must be valid globs, as described in refresh.NewSelector.
+//
+// lockfileName and registryFlags are validated as in
+// cmd_generate.NewFlagsWithSharedValues.
+func NewFlags(
+	lockfileName string,
+	names []string,
+	registries []string,
+	paths []string,
+	registryFlags *cmd_generate.RegistryFlags,
+) (*Flags, error) {
+	flagsWithSharedValues, err := cmd_generate.NewFlagsWithSharedValues(
+		"", lockfileName, false, true, false, false, registryFlags,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := refresh.NewSelector(names, registries, paths); err != nil {
+		return nil, err
+	}
+
+	return &Flags{
+		FlagsWithSharedValues: flagsWithSharedValues,
+		Names:                 names,
+		Registries:            registries,
+		Paths:                 paths,
+	}, nil
+}
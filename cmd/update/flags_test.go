@@ -0,0 +1,99 @@
+package update_test
+
+import (
+	"testing"
+	"time"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/cmd/update"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+)
+
+func TestFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Expected   *update.Flags
+		ShouldFail bool
+	}{
+		{
+			Name: "Lockfile Name With Slashes",
+			Expected: &update.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "lockfile/path",
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Name Glob",
+			Expected: &update.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "docker-lock.json",
+				},
+				Names: []string{"["},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Negative Cache TTL",
+			Expected: &update.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName: "docker-lock.json",
+					RegistryFlags: cmd_generate.RegistryFlags{
+						CacheTTL: -time.Hour,
+					},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Normal",
+			Expected: &update.Flags{
+				FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+					LockfileName:          "docker-lock.json",
+					UpdateExistingDigests: true,
+					RegistryFlags: cmd_generate.RegistryFlags{
+						CacheTTL:       time.Hour,
+						MaxConcurrency: 10,
+					},
+				},
+				Names:      []string{"redis", "ghcr.io/org/*"},
+				Registries: []string{"ghcr.io"},
+				Paths:      []string{"services/*/Dockerfile"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			shared := test.Expected.FlagsWithSharedValues
+
+			got, err := update.NewFlags(
+				shared.LockfileName,
+				test.Expected.Names,
+				test.Expected.Registries,
+				test.Expected.Paths,
+				&shared.RegistryFlags,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutils.AssertFlagsEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package update provides the "update" command.
+package update
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	generate_update "github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const namespace = "update"
+
+// NewUpdateCmd creates the command 'update' used in 'docker lock update'.
+func NewUpdateCmd() (*cobra.Command, error) {
+	updateCmd := &cobra.Command{
+		Use:   "update",
+		Short: "Refresh the digests of selected images in a Lockfile",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindPFlags(cmd, []string{
+				"lockfile-name",
+				"name",
+				"registry",
+				"path",
+			}); err != nil {
+				return err
+			}
+
+			return cmd_generate.BindRegistryFlags(cmd, namespace)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags, err := parseFlags()
+			if err != nil {
+				return err
+			}
+
+			if err := UpdateLockfile(flags); err != nil {
+				return err
+			}
+
+			fmt.Println("successfully updated lockfile!")
+
+			return nil
+		},
+	}
+	updateCmd.Flags().String(
+		"lockfile-name", "docker-lock.json", "Lockfile to update",
+	)
+	updateCmd.Flags().StringSlice(
+		"name", []string{},
+		"Globs of image names to update, such as 'redis' or 'ghcr.io/org/*'",
+	)
+	updateCmd.Flags().StringSlice(
+		"registry", []string{},
+		"Registries of images to update, such as 'ghcr.io' or 'docker.io'",
+	)
+	updateCmd.Flags().StringSlice(
+		"path", []string{},
+		"Globs of paths in the Lockfile whose images to update, such as "+
+			"'services/*/Dockerfile'",
+	)
+
+	// Digests are queried from registries unless refresh is disabled.
+	registryFlags := cmd_generate.DefaultRegistryFlags()
+	registryFlags.Refresh = true
+	cmd_generate.AddRegistryFlags(updateCmd, registryFlags, true)
+	updateCmd.Flags().Lookup("refresh").Usage = "Ignore cached digests, " +
+		"replacing them with newly queried digests - set to false to " +
+		"update from cached digests"
+
+	return updateCmd, nil
+}
+
+// SetupRefresher creates a Refresher configured for docker-lock's cli.
+// Digests are cached in digestCache, unless it is nil.
+func SetupRefresher(
+	flags *Flags,
+	digestCache generate_update.IDigestCache,
+) (refresh.IRefresher, error) {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return nil, err
+	}
+
+	digestRequester, err := cmd_generate.DefaultDigestRequester(
+		generateFlags(flags), digestCache,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := refresh.NewSelector(
+		flags.Names, flags.Registries, flags.Paths,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return refresh.NewRefresher(
+		digestRequester, selector,
+		flags.FlagsWithSharedValues.MaxConcurrency,
+	)
+}
+
+// UpdateLockfile refreshes the digests of the selected images in the
+// Lockfile in place. The Lockfile is only written if every selected image
+// was refreshed.
+func UpdateLockfile(flags *Flags) error {
+	if err := ensureFlagsNotNil(flags); err != nil {
+		return err
+	}
+
+	digestCache, err := cmd_generate.DefaultDigestCache(generateFlags(flags))
+	if err != nil {
+		return err
+	}
+
+	refresher, err := SetupRefresher(flags, digestCache)
+	if err != nil {
+		return err
+	}
+
+	lockfileName := flags.FlagsWithSharedValues.LockfileName
+
+	fileInfo, err := os.Stat(lockfileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("lockfile '%s' does not exist", lockfileName)
+		}
+
+		return err
+	}
+
+	lockfileByt, err := ioutil.ReadFile(lockfileName)
+	if err != nil {
+		return err
+	}
+
+	var updatedByt bytes.Buffer
+
+	err = refresher.RefreshLockfile(bytes.NewReader(lockfileByt), &updatedByt)
+	if flushErr := cmd_generate.FlushDigestCache(digestCache); err == nil {
+		err = flushErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(
+		lockfileName, updatedByt.Bytes(), fileInfo.Mode(),
+	)
+}
+
+func generateFlags(flags *Flags) *cmd_generate.Flags {
+	return &cmd_generate.Flags{
+		FlagsWithSharedValues: flags.FlagsWithSharedValues,
+		DockerfileFlags:       &cmd_generate.FlagsWithSharedNames{},
+		ComposefileFlags:      &cmd_generate.FlagsWithSharedNames{},
+		KubernetesfileFlags:   &cmd_generate.FlagsWithSharedNames{},
+		HelmchartFlags:        &cmd_generate.FlagsWithSharedNames{},
+		KustomizationFlags:    &cmd_generate.FlagsWithSharedNames{},
+		BakefileFlags:         &cmd_generate.FlagsWithSharedNames{},
+	}
+}
+
+func ensureFlagsNotNil(flags *Flags) error {
+	if flags == nil {
+		return errors.New("'flags' cannot be nil")
+	}
+
+	if flags.FlagsWithSharedValues == nil {
+		return errors.New("flags.FlagsWithSharedValues cannot be nil")
+	}
+
+	return nil
+}
+
+func bindPFlags(cmd *cobra.Command, flagNames []string) error {
+	for _, name := range flagNames {
+		if err := viper.BindPFlag(
+			fmt.Sprintf("%s.%s", namespace, name), cmd.Flags().Lookup(name),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFlags() (*Flags, error) {
+	var (
+		lockfileName = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "lockfile-name"),
+		)
+		names = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "name"),
+		)
+		registries = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "registry"),
+		)
+		paths = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "path"),
+		)
+	)
+
+	registryFlags, err := cmd_generate.ParseRegistryFlags(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFlags(
+		lockfileName, names, registries, paths, registryFlags,
+	)
+}
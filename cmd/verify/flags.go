@@ -1,9 +1,15 @@
 package verify
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
 )
 
 // Flags holds all command line options for Dockerfiles, Composefiles,
@@ -13,27 +19,92 @@ type Flags struct {
 	IgnoreMissingDigests  bool
 	UpdateExistingDigests bool
 	ExcludeTags           bool
+	Output                string
+	MaxAge                time.Duration
+	WarnStale             bool
+	PublicKeys            []string
+	Strict                bool
+	BuildArgs             *parse.BuildArgs
+	PolicyRules           *policy.Rules
+	cmd_generate.RegistryFlags
 }
 
 // NewFlags returns Flags for Dockerfiles, Composefiles, and Kubernetesfiles,
 // after validating their fields.
 //
 // lockfileName may not contain slashes.
+//
+// registryFlags are validated as in cmd_generate.RegistryFlags.Validate, and
+// cannot be nil.
+//
+// output must be one of "text", "json", "sarif", or "junit".
+//
+// maxAge cannot be negative. If maxAge is 0, the age of images is not
+// verified. If warnStale is true, images older than maxAge are reported as
+// warnings instead of errors.
+//
+// If publicKeys is not empty, the digest of each image must be signed with
+// one of the keys. Signatures cannot be queried from offline sources.
+//
+// If strict is true, images with unresolved variables fail verification
+// instead of printing warnings.
+//
+// If buildArgs is nil, Dockerfiles are parsed without build args.
+//
+// If policyRules is nil, every image is allowed.
 func NewFlags(
 	lockfileName string,
 	ignoreMissingDigests bool,
 	updateExistingDigests bool,
 	excludeTags bool,
+	registryFlags *cmd_generate.RegistryFlags,
+	output string,
+	maxAge time.Duration,
+	warnStale bool,
+	publicKeys []string,
+	strict bool,
+	buildArgs *parse.BuildArgs,
+	policyRules *policy.Rules,
 ) (*Flags, error) {
 	if err := validateLockfileName(lockfileName); err != nil {
 		return nil, err
 	}
 
+	if registryFlags == nil {
+		return nil, errors.New("'registryFlags' cannot be nil")
+	}
+
+	if err := registryFlags.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateOutput(output); err != nil {
+		return nil, err
+	}
+
+	if maxAge < 0 {
+		return nil, fmt.Errorf("'%s' max-age cannot be negative", maxAge)
+	}
+
+	if len(publicKeys) != 0 && len(registryFlags.OfflineSources) != 0 {
+		return nil, errors.New(
+			"public-key cannot be used with offline-source",
+		)
+	}
+
 	return &Flags{
 		LockfileName:          lockfileName,
 		IgnoreMissingDigests:  ignoreMissingDigests,
 		UpdateExistingDigests: updateExistingDigests,
 		ExcludeTags:           excludeTags,
+		Output:                output,
+		MaxAge:                maxAge,
+		WarnStale:             warnStale,
+		PublicKeys:            publicKeys,
+		Strict:                strict,
+		BuildArgs:             buildArgs,
+		PolicyRules:           policyRules,
+		RegistryFlags:         *registryFlags,
 	}, nil
 }
 
@@ -54,3 +125,15 @@ func validateLockfileName(lockfileName string) error {
 
 	return nil
 }
+
+func validateOutput(output string) error {
+	switch output {
+	case "text", "json", "sarif", "junit":
+		return nil
+	default:
+		return fmt.Errorf(
+			"'%s' output must be one of 'text', 'json', 'sarif', or 'junit'",
+			output,
+		)
+	}
+}
@@ -3,9 +3,12 @@ package verify_test
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
 	"github.com/safe-waters/docker-lock/cmd/verify"
 	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 )
 
 func TestFlags(t *testing.T) {
@@ -23,10 +26,95 @@ func TestFlags(t *testing.T) {
 			},
 			ShouldFail: true,
 		},
+		{
+			Name: "Negative Cache TTL",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				RegistryFlags: cmd_generate.RegistryFlags{
+					CacheTTL: -time.Hour,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Negative Max Retries",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				RegistryFlags: cmd_generate.RegistryFlags{
+					MaxRetries: -1,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Output",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "xml",
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Negative Max Age",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "text",
+				MaxAge:       -time.Hour,
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Public Key With Offline Source",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "text",
+				PublicKeys:   []string{"cosign.pub"},
+				RegistryFlags: cmd_generate.RegistryFlags{
+					OfflineSources: []string{"images.tar"},
+				},
+			},
+			ShouldFail: true,
+		},
 		{
 			Name: "Normal",
 			Expected: &verify.Flags{
 				LockfileName: "docker-lock.json",
+				Output:       "text",
+			},
+		},
+		{
+			Name: "Max Age",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "text",
+				MaxAge:       30 * 24 * time.Hour,
+				WarnStale:    true,
+			},
+		},
+		{
+			Name: "Public Keys",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "text",
+				PublicKeys:   []string{"cosign.pub", "release.pub"},
+			},
+		},
+		{
+			Name: "Strict",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "text",
+				Strict:       true,
+			},
+		},
+		{
+			Name: "Build Args",
+			Expected: &verify.Flags{
+				LockfileName: "docker-lock.json",
+				Output:       "text",
+				BuildArgs: &parse.BuildArgs{
+					Global: map[string]string{"REGISTRY": "ghcr.io/org"},
+				},
 			},
 		},
 	}
@@ -42,6 +130,14 @@ func TestFlags(t *testing.T) {
 				test.Expected.IgnoreMissingDigests,
 				test.Expected.UpdateExistingDigests,
 				test.Expected.ExcludeTags,
+				&test.Expected.RegistryFlags,
+				test.Expected.Output,
+				test.Expected.MaxAge,
+				test.Expected.WarnStale,
+				test.Expected.PublicKeys,
+				test.Expected.Strict,
+				test.Expected.BuildArgs,
+				test.Expected.PolicyRules,
 			)
 			if test.ShouldFail {
 				if err == nil {
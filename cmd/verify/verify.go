@@ -2,16 +2,18 @@
 package verify
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"strings"
 
 	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/verify"
 	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+	"github.com/safe-waters/docker-lock/pkg/verify/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -24,20 +26,43 @@ func NewVerifyCmd() (*cobra.Command, error) {
 		Use:   "verify",
 		Short: "Verify that a Lockfile is up-to-date",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return bindPFlags(cmd, []string{
+			if err := bindPFlags(cmd, []string{
 				"lockfile-name",
 				"ignore-missing-digests",
 				"update-existing-digests",
 				"exclude-tags",
-			})
+				"output",
+				"max-age",
+				"warn-stale",
+				"public-key",
+				"build-arg",
+				"build-arg-file",
+				"strict",
+			}); err != nil {
+				return err
+			}
+
+			return cmd_generate.BindRegistryFlags(cmd, namespace)
 		},
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			flags, err := parseFlags()
 			if err != nil {
 				return err
 			}
 
-			verifier, err := SetupVerifier(flags)
+			digestCache, err := SetupDigestCache(flags)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				flushErr := cmd_generate.FlushDigestCache(digestCache)
+				if err == nil {
+					err = flushErr
+				}
+			}()
+
+			verifier, err := SetupVerifier(flags, digestCache)
 			if err != nil {
 				return err
 			}
@@ -48,12 +73,51 @@ func NewVerifyCmd() (*cobra.Command, error) {
 			}
 			defer reader.Close()
 
-			err = verifier.VerifyLockfile(reader)
-			if err == nil {
+			if flags.Output == "text" {
+				if err := verifier.VerifyLockfile(reader); err != nil {
+					return err
+				}
+
+				if err := VerifyAge(flags); err != nil {
+					return err
+				}
+
+				if err := VerifySignatures(
+					flags, digestCache,
+				); err != nil {
+					return err
+				}
+
 				fmt.Println("successfully verified lockfile!")
+
+				return nil
 			}
 
-			return err
+			reportWriter, err := SetupReportWriter(flags)
+			if err != nil {
+				return err
+			}
+
+			report, err := verifier.ReportLockfile(reader)
+			if err != nil {
+				return err
+			}
+
+			if err := reportWriter.WriteReport(report, os.Stdout); err != nil {
+				return err
+			}
+
+			if !report.Verified() {
+				return fmt.Errorf(
+					"lockfile '%s' is not up-to-date", flags.LockfileName,
+				)
+			}
+
+			if err := VerifyAge(flags); err != nil {
+				return err
+			}
+
+			return VerifySignatures(flags, digestCache)
 		},
 	}
 	verifyCmd.Flags().String(
@@ -71,11 +135,78 @@ func NewVerifyCmd() (*cobra.Command, error) {
 		"exclude-tags", false, "Exclude image tags from verification",
 	)
 
+	// Lockfiles are verified against registries unless the cache is enabled.
+	registryFlags := cmd_generate.DefaultRegistryFlags()
+	registryFlags.NoCache = true
+	cmd_generate.AddRegistryFlags(verifyCmd, registryFlags, true)
+	verifyCmd.Flags().Lookup("no-cache").Usage = "Do not read or write the " +
+		"digest cache - set to false to verify against cached digests"
+	verifyCmd.Flags().String(
+		"output", "text",
+		"Format of the verification report: text, json, sarif, or junit",
+	)
+	verifyCmd.Flags().Duration(
+		"max-age", 0,
+		"Fail if an image was created longer ago than this, such as '720h' "+
+			"(0 for no limit)",
+	)
+	verifyCmd.Flags().Bool(
+		"warn-stale", false,
+		"Warn instead of failing if an image is older than max-age",
+	)
+	verifyCmd.Flags().StringSlice(
+		"public-key", []string{},
+		"PEM encoded public keys, such as 'cosign.pub' - if set, fail if an "+
+			"image does not have a signature or attestation from one of them",
+	)
+	verifyCmd.Flags().StringSlice(
+		"build-arg", []string{},
+		"Build args of Dockerfiles that are not built by docker-compose "+
+			"files, such as 'REGISTRY=ghcr.io/org'",
+	)
+	verifyCmd.Flags().StringSlice(
+		"build-arg-file", []string{},
+		"Files with a build arg, such as 'REGISTRY=ghcr.io/org', on each line",
+	)
+	verifyCmd.Flags().Bool(
+		"strict", false,
+		"Fail if a variable in an image reference is not set, instead of "+
+			"printing a warning",
+	)
+
 	return verifyCmd, nil
 }
 
+// SetupDigestCache creates the IDigestCache for docker-lock's cli, as
+// described in cmd_generate.DefaultDigestCache. Unlike the other commands,
+// verify does not cache digests by default, so that Lockfiles are verified
+// against registries.
+func SetupDigestCache(flags *Flags) (update.IDigestCache, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	return cmd_generate.DefaultDigestCache(
+		&cmd_generate.Flags{
+			FlagsWithSharedValues: &cmd_generate.FlagsWithSharedValues{
+				RegistryFlags: flags.RegistryFlags,
+			},
+			DockerfileFlags:     &cmd_generate.FlagsWithSharedNames{},
+			ComposefileFlags:    &cmd_generate.FlagsWithSharedNames{},
+			KubernetesfileFlags: &cmd_generate.FlagsWithSharedNames{},
+			HelmchartFlags:      &cmd_generate.FlagsWithSharedNames{},
+			KustomizationFlags:  &cmd_generate.FlagsWithSharedNames{},
+			BakefileFlags:       &cmd_generate.FlagsWithSharedNames{},
+		},
+	)
+}
+
 // SetupVerifier creates a Verifier configured for docker-lock's cli.
-func SetupVerifier(flags *Flags) (verify.IVerifier, error) {
+// Digests are cached in digestCache, unless it is nil.
+func SetupVerifier(
+	flags *Flags,
+	digestCache update.IDigestCache,
+) (verify.IVerifier, error) {
 	if flags == nil {
 		return nil, errors.New("'flags' cannot be nil")
 	}
@@ -90,56 +221,66 @@ func SetupVerifier(flags *Flags) (verify.IVerifier, error) {
 		return nil, err
 	}
 
-	existingLByt, err := ioutil.ReadFile(flags.LockfileName)
+	lockfileReader, err := os.Open(flags.LockfileName)
 	if err != nil {
 		return nil, err
 	}
+	defer lockfileReader.Close()
 
-	var existingLockfile map[kind.Kind]map[string][]interface{}
-	if err = json.Unmarshal(existingLByt, &existingLockfile); err != nil {
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
 		return nil, err
 	}
 
 	var (
-		dockerfilePaths = make(
-			[]string, len(existingLockfile[kind.Dockerfile]),
-		)
-		composefilePaths = make(
-			[]string, len(existingLockfile[kind.Composefile]),
-		)
-		kubernetesfilePaths = make(
-			[]string, len(existingLockfile[kind.Kubernetesfile]),
-		)
-		i, j, k int
+		dockerfilePaths     = existingLockfile.Paths(kind.Dockerfile)
+		composefilePaths    = existingLockfile.Paths(kind.Composefile)
+		kubernetesfilePaths = existingLockfile.Paths(kind.Kubernetesfile)
+		helmchartPaths      = existingLockfile.Paths(kind.Helmchart)
+		kustomizationPaths  = existingLockfile.Paths(kind.Kustomization)
+		bakefilePaths       = existingLockfile.Paths(kind.Bakefile)
 	)
 
-	for p := range existingLockfile[kind.Dockerfile] {
-		dockerfilePaths[i] = p
-		i++
-	}
-
-	for p := range existingLockfile[kind.Composefile] {
-		composefilePaths[j] = p
-		j++
-	}
-
-	for p := range existingLockfile[kind.Kubernetesfile] {
-		kubernetesfilePaths[k] = p
-		k++
-	}
-
 	generatorFlags, err := cmd_generate.NewFlags(
-		".", "", flags.IgnoreMissingDigests, flags.UpdateExistingDigests,
-		dockerfilePaths, composefilePaths, kubernetesfilePaths,
-		nil, nil, nil, false, false, false,
-		len(dockerfilePaths) == 0, len(composefilePaths) == 0,
-		len(kubernetesfilePaths) == 0,
+		&cmd_generate.FlagsWithSharedValues{
+			BaseDir:               ".",
+			IgnoreMissingDigests:  flags.IgnoreMissingDigests,
+			UpdateExistingDigests: flags.UpdateExistingDigests,
+			RegistryFlags:         flags.RegistryFlags,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths:  dockerfilePaths,
+			ExcludePaths: len(dockerfilePaths) == 0,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths:  composefilePaths,
+			ExcludePaths: len(composefilePaths) == 0,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths:  kubernetesfilePaths,
+			ExcludePaths: len(kubernetesfilePaths) == 0,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths:  helmchartPaths,
+			ExcludePaths: len(helmchartPaths) == 0,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths:  kustomizationPaths,
+			ExcludePaths: len(kustomizationPaths) == 0,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths:  bakefilePaths,
+			ExcludePaths: len(bakefilePaths) == 0,
+		},
+		flags.Strict, flags.BuildArgs, flags.PolicyRules,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	generator, err := cmd_generate.SetupGenerator(generatorFlags)
+	generator, err := cmd_generate.SetupGenerator(
+		generatorFlags, digestCache,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -154,12 +295,179 @@ func SetupVerifier(flags *Flags) (verify.IVerifier, error) {
 		kubernetesfileDifferentiator = diff.NewKubernetesfileDifferentiator(
 			flags.ExcludeTags,
 		)
+		helmchartDifferentiator = diff.NewHelmchartDifferentiator(
+			flags.ExcludeTags,
+		)
+		kustomizationDifferentiator = diff.NewKustomizationDifferentiator(
+			flags.ExcludeTags,
+		)
+		bakefileDifferentiator = diff.NewBakefileDifferentiator(
+			flags.ExcludeTags,
+		)
 	)
 
 	return verify.NewVerifier(
 		generator, dockerfileDifferentiator, composefileDifferentiator,
-		kubernetesfileDifferentiator,
+		kubernetesfileDifferentiator, helmchartDifferentiator,
+		kustomizationDifferentiator, bakefileDifferentiator,
+	)
+}
+
+// VerifyAge reads the Lockfile and returns an error listing the images whose
+// digests are older than "MaxAge", or whose creation times were not
+// recorded. If "WarnStale" is true, the images are printed to stderr as a
+// warning instead. If "MaxAge" is 0, the age of images is not verified.
+func VerifyAge(flags *Flags) error {
+	if flags == nil {
+		return errors.New("'flags' cannot be nil")
+	}
+
+	if flags.MaxAge == 0 {
+		return nil
+	}
+
+	ageVerifier, err := verify.NewAgeVerifier(flags.MaxAge, nil)
+	if err != nil {
+		return err
+	}
+
+	reader, err := os.Open(flags.LockfileName)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	staleImages, err := ageVerifier.StaleImages(reader)
+	if err != nil {
+		return err
+	}
+
+	if len(staleImages) == 0 {
+		return nil
+	}
+
+	staleImageMsgs := make([]string, len(staleImages))
+	for i, staleImage := range staleImages {
+		staleImageMsgs[i] = staleImage.String()
+	}
+
+	msg := fmt.Sprintf(
+		"%d image(s) not verified to be newer than the max-age of %s:\n%s",
+		len(staleImages), flags.MaxAge, strings.Join(staleImageMsgs, "\n"),
 	)
+
+	if flags.WarnStale {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+
+		return nil
+	}
+
+	return errors.New(msg)
+}
+
+// VerifySignatures reads the Lockfile and returns an error listing the
+// images whose digests do not have a signature or attestation from one of
+// the "PublicKeys". If "PublicKeys" is empty, signatures are not verified.
+//
+// Signatures are queried from registries as digests are, through the
+// "RegistryMirrors" and with the same credentials. Digests are cached in
+// digestCache, unless it is nil.
+func VerifySignatures(flags *Flags, digestCache update.IDigestCache) error {
+	if flags == nil {
+		return errors.New("'flags' cannot be nil")
+	}
+
+	if len(flags.PublicKeys) == 0 {
+		return nil
+	}
+
+	publicKeys, err := verify.LoadPublicKeys(flags.PublicKeys)
+	if err != nil {
+		return err
+	}
+
+	registryFlags := flags.RegistryFlags
+	registryFlags.OfflineSources = nil
+
+	flagsWithSharedValues, err := cmd_generate.NewFlagsWithSharedValues(
+		"", flags.LockfileName, false, false, false, false, &registryFlags,
+	)
+	if err != nil {
+		return err
+	}
+
+	digestRequester, err := cmd_generate.DefaultDigestRequester(
+		&cmd_generate.Flags{
+			FlagsWithSharedValues: flagsWithSharedValues,
+			DockerfileFlags:       &cmd_generate.FlagsWithSharedNames{},
+			ComposefileFlags:      &cmd_generate.FlagsWithSharedNames{},
+			KubernetesfileFlags:   &cmd_generate.FlagsWithSharedNames{},
+			HelmchartFlags:        &cmd_generate.FlagsWithSharedNames{},
+			KustomizationFlags:    &cmd_generate.FlagsWithSharedNames{},
+			BakefileFlags:         &cmd_generate.FlagsWithSharedNames{},
+		}, digestCache,
+	)
+	if err != nil {
+		return err
+	}
+
+	artifactRequester, ok := digestRequester.(update.IArtifactRequester)
+	if !ok {
+		return errors.New("signatures cannot be queried from offline sources")
+	}
+
+	signatureVerifier, err := verify.NewSignatureVerifier(
+		artifactRequester, publicKeys, flags.MaxConcurrency,
+	)
+	if err != nil {
+		return err
+	}
+
+	reader, err := os.Open(flags.LockfileName)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	unsignedImages, err := signatureVerifier.UnsignedImages(reader)
+	if err != nil {
+		return err
+	}
+
+	if len(unsignedImages) == 0 {
+		return nil
+	}
+
+	unsignedImageMsgs := make([]string, len(unsignedImages))
+	for i, unsignedImage := range unsignedImages {
+		unsignedImageMsgs[i] = unsignedImage.String()
+	}
+
+	return fmt.Errorf(
+		"%d image(s) not signed with the public keys:\n%s",
+		len(unsignedImages), strings.Join(unsignedImageMsgs, "\n"),
+	)
+}
+
+// SetupReportWriter creates an IReportWriter for the output format of
+// the Flags.
+func SetupReportWriter(flags *Flags) (output.IReportWriter, error) {
+	if flags == nil {
+		return nil, errors.New("'flags' cannot be nil")
+	}
+
+	switch flags.Output {
+	case "json":
+		return output.NewJSONReportWriter(), nil
+	case "sarif":
+		return output.NewSARIFReportWriter(), nil
+	case "junit":
+		return output.NewJUnitReportWriter(), nil
+	default:
+		return nil, fmt.Errorf(
+			"output '%s' does not have a report writer", flags.Output,
+		)
+	}
 }
 
 func bindPFlags(cmd *cobra.Command, flagNames []string) error {
@@ -188,10 +496,41 @@ func parseFlags() (*Flags, error) {
 		excludeTags = viper.GetBool(
 			fmt.Sprintf("%s.%s", namespace, "exclude-tags"),
 		)
+		outputFormat = viper.GetString(
+			fmt.Sprintf("%s.%s", namespace, "output"),
+		)
+		maxAge = viper.GetDuration(
+			fmt.Sprintf("%s.%s", namespace, "max-age"),
+		)
+		warnStale = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "warn-stale"),
+		)
+		publicKeys = viper.GetStringSlice(
+			fmt.Sprintf("%s.%s", namespace, "public-key"),
+		)
+		strict = viper.GetBool(
+			fmt.Sprintf("%s.%s", namespace, "strict"),
+		)
 	)
 
+	registryFlags, err := cmd_generate.ParseRegistryFlags(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	buildArgs, err := cmd_generate.ParseBuildArgs(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	policyRules, err := cmd_generate.ParsePolicyRules()
+	if err != nil {
+		return nil, err
+	}
+
 	return NewFlags(
-		lockfileName, ignoreMissingDigests,
-		updateExistingDigests, excludeTags,
+		lockfileName, ignoreMissingDigests, updateExistingDigests,
+		excludeTags, registryFlags, outputFormat, maxAge, warnStale,
+		publicKeys, strict, buildArgs, policyRules,
 	)
 }
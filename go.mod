@@ -5,10 +5,12 @@ go 1.16
 require (
 	github.com/compose-spec/compose-go v0.0.0-20210901090333-feb401cda7f7
 	github.com/google/go-containerregistry v0.5.1
+	github.com/hashicorp/hcl/v2 v2.10.1
 	github.com/mattn/go-zglob v0.0.3
 	github.com/moby/buildkit v0.8.3
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/viper v1.8.0
+	github.com/zclconf/go-cty v1.8.0
 	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/client-go v0.21.1
 )
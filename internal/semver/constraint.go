@@ -0,0 +1,172 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a range of Versions, such as ">=1.16, <2 || ^3".
+//
+// Ranges separated by "||" are alternatives, and comparisons within a range,
+// separated by commas or spaces, must all be satisfied. The supported
+// operators are "=", "!=", ">", ">=", "<", "<=", "~" for versions with the
+// same minor version, and "^" for versions with the same major version.
+// A comparison without an operator is the same as "=". "=" and "!="
+// compare only the components of the constraint's version, so "=1.2"
+// matches "1.2.3".
+type Constraint struct {
+	original     string
+	alternatives [][]*comparison
+}
+
+type comparison struct {
+	operator string
+	version  *Version
+}
+
+// operators is ordered so that longer operators are matched first.
+var operators = []string{ // nolint: gochecknoglobals
+	">=", "<=", "!=", ">", "<", "=", "~", "^",
+}
+
+// ParseConstraint returns the Constraint of a range, or an error if it is
+// malformed.
+func ParseConstraint(constraint string) (*Constraint, error) {
+	parsed := &Constraint{original: constraint}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		fields := strings.FieldsFunc(alternative, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+
+		if len(fields) == 0 {
+			return nil, fmt.Errorf(
+				"constraint '%s' has an empty range", constraint,
+			)
+		}
+
+		var comparisons []*comparison
+
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+
+			operator := "="
+
+			for _, candidate := range operators {
+				if strings.HasPrefix(field, candidate) {
+					operator = candidate
+					field = strings.TrimPrefix(field, candidate)
+
+					break
+				}
+			}
+
+			// Allow a space between the operator and the version,
+			// as in ">= 1.2".
+			if field == "" && i+1 < len(fields) {
+				i++
+				field = fields[i]
+			}
+
+			version, err := Parse(field)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"constraint '%s' is malformed with err: %v",
+					constraint, err,
+				)
+			}
+
+			comparisons = append(comparisons, &comparison{
+				operator: operator,
+				version:  version,
+			})
+		}
+
+		parsed.alternatives = append(parsed.alternatives, comparisons)
+	}
+
+	return parsed, nil
+}
+
+// Check returns true if the Version satisfies the Constraint.
+func (c *Constraint) Check(version *Version) bool {
+	for _, comparisons := range c.alternatives {
+		satisfied := true
+
+		for _, comparison := range comparisons {
+			if !comparison.check(version) {
+				satisfied = false
+				break
+			}
+		}
+
+		if satisfied {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the Constraint as it was parsed.
+func (c *Constraint) String() string {
+	return c.original
+}
+
+func (c *comparison) check(version *Version) bool {
+	switch c.operator {
+	case "=":
+		return c.matchesPrefix(version)
+	case "!=":
+		return !c.matchesPrefix(version)
+	case ">":
+		return version.Compare(c.version) > 0
+	case ">=":
+		return version.Compare(c.version) >= 0
+	case "<":
+		return version.Compare(c.version) < 0
+	case "<=":
+		return version.Compare(c.version) <= 0
+	case "~":
+		upper := &Version{Major: c.version.Major + 1}
+		if c.version.Precision > 1 {
+			upper = &Version{
+				Major: c.version.Major, Minor: c.version.Minor + 1,
+			}
+		}
+
+		return version.Compare(c.version) >= 0 && version.Compare(upper) < 0
+	case "^":
+		var upper *Version
+
+		switch {
+		case c.version.Major != 0 || c.version.Precision == 1:
+			upper = &Version{Major: c.version.Major + 1}
+		case c.version.Minor != 0 || c.version.Precision == 2:
+			upper = &Version{Minor: c.version.Minor + 1}
+		default:
+			upper = &Version{Patch: c.version.Patch + 1}
+		}
+
+		return version.Compare(c.version) >= 0 && version.Compare(upper) < 0
+	default:
+		return false
+	}
+}
+
+// matchesPrefix returns true if the Version has the same components as the
+// comparison's version, up to the comparison's Precision.
+func (c *comparison) matchesPrefix(version *Version) bool {
+	constraintComponents := []int{
+		c.version.Major, c.version.Minor, c.version.Patch,
+	}
+	versionComponents := []int{version.Major, version.Minor, version.Patch}
+
+	for i := 0; i < c.version.Precision; i++ {
+		if constraintComponents[i] != versionComponents[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+// Package semver provides functionality to parse image tags as semantic
+// versions and to check them against version constraints.
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionRegex matches tags such as "1", "v1.2", "1.2.3", and
+// "1.2.3-alpine".
+var versionRegex = regexp.MustCompile( // nolint: gochecknoglobals
+	`^v?(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?(?:\.(0|[1-9]\d*))?(?:-([0-9A-Za-z.-]+))?$`, // nolint: lll
+)
+
+// Version is a tag in the form "[v]MAJOR[.MINOR[.PATCH]][-SUFFIX]".
+//
+// Image tags commonly use the suffix for the variant of an image, such as
+// "alpine", rather than for a pre-release, so the suffix does not affect
+// how Versions are ordered.
+type Version struct {
+	Major  int
+	Minor  int
+	Patch  int
+	Suffix string
+	// Precision is the number of numeric components in the tag, so that
+	// "1.2" has a Precision of 2.
+	Precision int
+}
+
+// Parse returns the Version of a tag, or an error if the tag is not a
+// semantic version.
+func Parse(tag string) (*Version, error) {
+	matches := versionRegex.FindStringSubmatch(tag)
+	if matches == nil {
+		return nil, fmt.Errorf("'%s' is not a semantic version", tag)
+	}
+
+	version := &Version{Suffix: matches[4]}
+
+	for i, component := range []*int{
+		&version.Major, &version.Minor, &version.Patch,
+	} {
+		if matches[i+1] == "" {
+			break
+		}
+
+		number, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"'%s' is not a semantic version with err: %v", tag, err,
+			)
+		}
+
+		*component = number
+		version.Precision++
+	}
+
+	return version, nil
+}
+
+// Compare returns -1, 0, or 1 if the Version is less than, equal to, or
+// greater than another Version. Missing components are treated as 0 and
+// suffixes are ignored.
+func (v *Version) Compare(other *Version) int {
+	for _, pair := range [][2]int{
+		{v.Major, other.Major},
+		{v.Minor, other.Minor},
+		{v.Patch, other.Patch},
+	} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// String returns the numeric components of the Version, up to its
+// Precision, followed by its suffix.
+func (v *Version) String() string {
+	components := []string{
+		strconv.Itoa(v.Major), strconv.Itoa(v.Minor), strconv.Itoa(v.Patch),
+	}
+
+	version := strings.Join(components[:v.Precision], ".")
+
+	if v.Suffix != "" {
+		version = fmt.Sprintf("%s-%s", version, v.Suffix)
+	}
+
+	return version
+}
@@ -0,0 +1,207 @@
+package semver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/semver"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name        string
+		Tag         string
+		Expected    *semver.Version
+		ShouldError bool
+	}{
+		{
+			Name: "Major",
+			Tag:  "3",
+			Expected: &semver.Version{
+				Major: 3, Precision: 1,
+			},
+		},
+		{
+			Name: "Major Minor With Prefix",
+			Tag:  "v1.16",
+			Expected: &semver.Version{
+				Major: 1, Minor: 16, Precision: 2,
+			},
+		},
+		{
+			Name: "Major Minor Patch With Suffix",
+			Tag:  "3.9.7-alpine3.14",
+			Expected: &semver.Version{
+				Major: 3, Minor: 9, Patch: 7, Suffix: "alpine3.14",
+				Precision: 3,
+			},
+		},
+		{
+			Name:        "Latest",
+			Tag:         "latest",
+			ShouldError: true,
+		},
+		{
+			Name:        "Leading Zero",
+			Tag:         "1.02",
+			ShouldError: true,
+		},
+		{
+			Name:        "Too Many Components",
+			Tag:         "1.2.3.4",
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := semver.Parse(test.Tag)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(test.Expected, got) {
+				t.Fatalf("expected %+v, got %+v", test.Expected, got)
+			}
+
+			if got.String() != trimPrefix(test.Tag) {
+				t.Fatalf("expected %s, got %s", trimPrefix(test.Tag), got)
+			}
+		})
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name        string
+		Constraint  string
+		Tags        map[string]bool
+		ShouldError bool
+	}{
+		{
+			Name:       "Range",
+			Constraint: ">=1.16, <2",
+			Tags: map[string]bool{
+				"1.15.9": false,
+				"1.16":   true,
+				"1.17.1": true,
+				"2.0.0":  false,
+			},
+		},
+		{
+			Name:       "Range With Spaces After Operators",
+			Constraint: ">= 1.16 < 2",
+			Tags: map[string]bool{
+				"1.15": false,
+				"1.16": true,
+				"2":    false,
+			},
+		},
+		{
+			Name:       "Alternatives",
+			Constraint: "~3.8 || ^4.1",
+			Tags: map[string]bool{
+				"3.8.12": true,
+				"3.9":    false,
+				"4.0":    false,
+				"4.9.1":  true,
+				"5":      false,
+			},
+		},
+		{
+			Name:       "Caret With Zero Major",
+			Constraint: "^0.2.3",
+			Tags: map[string]bool{
+				"0.2.2": false,
+				"0.2.9": true,
+				"0.3.0": false,
+			},
+		},
+		{
+			Name:       "Equal Matches Prefix",
+			Constraint: "3.9",
+			Tags: map[string]bool{
+				"3.9-alpine": true,
+				"3.9.7":      true,
+				"3.10":       false,
+			},
+		},
+		{
+			Name:       "Not Equal",
+			Constraint: "!=1.2",
+			Tags: map[string]bool{
+				"1.2.1": false,
+				"1.3":   true,
+			},
+		},
+		{
+			Name:        "Malformed Version",
+			Constraint:  ">=latest",
+			ShouldError: true,
+		},
+		{
+			Name:        "Empty Range",
+			Constraint:  ">=1 ||",
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			constraint, err := semver.ParseConstraint(test.Constraint)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for tag, expected := range test.Tags {
+				version, err := semver.Parse(tag)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := constraint.Check(version); got != expected {
+					t.Fatalf(
+						"expected '%s' satisfies '%s' to be %t, got %t",
+						tag, constraint, expected, got,
+					)
+				}
+			}
+		})
+	}
+}
+
+func trimPrefix(tag string) string {
+	if len(tag) > 0 && tag[0] == 'v' {
+		return tag[1:]
+	}
+
+	return tag
+}
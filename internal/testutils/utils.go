@@ -12,6 +12,7 @@ import (
 	"sort"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/safe-waters/docker-lock/pkg/generate/collect"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
@@ -22,6 +23,11 @@ const (
 	BusyboxLatestSHA = "bae015c28bc7cdee3b7ef20d35db4299e3068554a769070950229d9f53f58572" // nolint: lll
 	GolangLatestSHA  = "6cb55c08bbf44793f16e3572bd7d2ae18f7a858f6ae4faa474c0a6eae1174a5d" // nolint: lll
 	RedisLatestSHA   = "09c33840ec47815dc0351f1eca3befe741d7105b3e95bc8fdb9a7e4985b9e1e5" // nolint: lll
+
+	BusyboxLatestAMD64SHA = "2ca5e69e244d2da7368f7088ea3ad0653c3ce7aaccd0b8823d11b0d5de956002" // nolint: lll
+	BusyboxLatestARM64SHA = "4cd3d8f32fbe2d7d3fbeb1b6e1f3e8c3c6f8b2e0aa1c24b5b05f1e9f5e6b0d3c" // nolint: lll
+
+	LatestCreated = "2021-03-01T12:00:00Z"
 )
 
 type mockDigestRequester struct {
@@ -58,6 +64,55 @@ func (m *mockDigestRequester) Digest(name string, tag string) (string, error) {
 	}
 }
 
+func (m *mockDigestRequester) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	if m.numNetworkCalls != nil {
+		atomic.AddUint64(m.numNetworkCalls, 1)
+	}
+
+	switch imageLine {
+	case "busybox:latest", fmt.Sprintf("busybox@sha256:%s", BusyboxLatestSHA):
+		return BusyboxLatestSHA, []*parse.PlatformDigest{
+			{
+				OS:           "linux",
+				Architecture: "amd64",
+				Digest:       BusyboxLatestAMD64SHA,
+			},
+			{
+				OS:           "linux",
+				Architecture: "arm64",
+				Variant:      "v8",
+				Digest:       BusyboxLatestARM64SHA,
+			},
+		}, nil
+	case "redis:latest":
+		return RedisLatestSHA, nil, nil
+	case "golang:latest":
+		return GolangLatestSHA, nil, nil
+	default:
+		return "", nil, fmt.Errorf("no digest found for %s", imageLine)
+	}
+}
+
+func (m *mockDigestRequester) Created(
+	name string,
+	digest string,
+) (time.Time, error) {
+	if m.numNetworkCalls != nil {
+		atomic.AddUint64(m.numNetworkCalls, 1)
+	}
+
+	switch digest {
+	case BusyboxLatestSHA, RedisLatestSHA, GolangLatestSHA:
+		return time.Parse(time.RFC3339, LatestCreated)
+	default:
+		return time.Time{}, fmt.Errorf(
+			"no image found for %s@sha256:%s", name, digest,
+		)
+	}
+}
+
 func AssertImagesEqual(
 	t *testing.T,
 	expected []parse.IImage,
@@ -307,6 +362,54 @@ func SortKubernetesfileImages(t *testing.T, images []parse.IImage) {
 	})
 }
 
+func SortHelmchartImages(t *testing.T, images []parse.IImage) {
+	t.Helper()
+
+	sort.Slice(images, func(i, j int) bool {
+		var (
+			path1, _     = images[i].Metadata()["path"].(string)
+			path2, _     = images[j].Metadata()["path"].(string)
+			position1, _ = images[i].Metadata()["position"].(int)
+			position2, _ = images[j].Metadata()["position"].(int)
+		)
+
+		switch {
+		case path1 != path2:
+			return path1 < path2
+		default:
+			return position1 < position2
+		}
+	})
+}
+
+func SortKustomizationImages(t *testing.T, images []parse.IImage) {
+	t.Helper()
+
+	sort.Slice(images, func(i, j int) bool {
+		var (
+			path1, _          = images[i].Metadata()["path"].(string)
+			path2, _          = images[j].Metadata()["path"].(string)
+			manifestPath1, _  = images[i].Metadata()["manifestPath"].(string)
+			manifestPath2, _  = images[j].Metadata()["manifestPath"].(string)
+			docPosition1, _   = images[i].Metadata()["docPosition"].(int)
+			docPosition2, _   = images[j].Metadata()["docPosition"].(int)
+			imagePosition1, _ = images[i].Metadata()["imagePosition"].(int)
+			imagePosition2, _ = images[j].Metadata()["imagePosition"].(int)
+		)
+
+		switch {
+		case path1 != path2:
+			return path1 < path2
+		case manifestPath1 != manifestPath2:
+			return manifestPath1 < manifestPath2
+		case docPosition1 != docPosition2:
+			return docPosition1 < docPosition2
+		default:
+			return imagePosition1 < imagePosition2
+		}
+	})
+}
+
 func SortComposefileImages(t *testing.T, images []parse.IImage) {
 	t.Helper()
 
@@ -331,6 +434,38 @@ func SortComposefileImages(t *testing.T, images []parse.IImage) {
 	})
 }
 
+func SortBakefileImages(t *testing.T, images []parse.IImage) {
+	t.Helper()
+
+	sort.Slice(images, func(i, j int) bool {
+		var (
+			path1, _           = images[i].Metadata()["path"].(string)
+			path2, _           = images[j].Metadata()["path"].(string)
+			targetName1, _     = images[i].Metadata()["targetName"].(string)
+			targetName2, _     = images[j].Metadata()["targetName"].(string)
+			dockerfilePath1, _ = images[i].Metadata()["dockerfilePath"].(string)
+			dockerfilePath2, _ = images[j].Metadata()["dockerfilePath"].(string)
+			contextName1, _    = images[i].Metadata()["contextName"].(string)
+			contextName2, _    = images[j].Metadata()["contextName"].(string)
+			targetPosition1, _ = images[i].Metadata()["targetPosition"].(int)
+			targetPosition2, _ = images[j].Metadata()["targetPosition"].(int)
+		)
+
+		switch {
+		case path1 != path2:
+			return path1 < path2
+		case targetName1 != targetName2:
+			return targetName1 < targetName2
+		case dockerfilePath1 != dockerfilePath2:
+			return dockerfilePath1 < dockerfilePath2
+		case contextName1 != contextName2:
+			return contextName1 < contextName2
+		default:
+			return targetPosition1 < targetPosition2
+		}
+	})
+}
+
 func SortPaths(t *testing.T, paths []collect.IPath) {
 	t.Helper()
 
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type auditor struct {
+	database    IDatabase
+	minSeverity Severity
+}
+
+// NewAuditor returns an IAuditor after validating its fields. database
+// cannot be nil. Only vulnerabilities that are at least as severe as
+// minSeverity are reported.
+func NewAuditor(database IDatabase, minSeverity Severity) (IAuditor, error) {
+	if database == nil || reflect.ValueOf(database).IsNil() {
+		return nil, errors.New("'database' cannot be nil")
+	}
+
+	if _, err := ParseSeverity(string(minSeverity)); err != nil {
+		return nil, err
+	}
+
+	return &auditor{database: database, minSeverity: minSeverity}, nil
+}
+
+// AuditLockfile reads an existing Lockfile and reports the vulnerabilities
+// of each image with a digest. An image is matched by its digest, and by
+// the digest of each of its platforms, if they were recorded. Images
+// without digests, such as "scratch", are not audited.
+func (a *auditor) AuditLockfile(lockfileReader io.Reader) (*Report, error) {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return nil, errors.New("'lockfileReader' cannot be nil")
+	}
+
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Vulnerable: []*Image{}, Unscanned: []*Image{}}
+
+	for _, image := range lockfileImages(existingLockfile) {
+		if image.image.Digest == "" {
+			continue
+		}
+
+		var (
+			vulnerabilities []*Vulnerability
+			scanned         bool
+		)
+
+		for _, digest := range image.digests {
+			digestVulnerabilities, ok := a.database.Vulnerabilities(digest)
+			if !ok {
+				continue
+			}
+
+			scanned = true
+			vulnerabilities = append(vulnerabilities, digestVulnerabilities...)
+		}
+
+		if !scanned {
+			report.Unscanned = append(report.Unscanned, image.image)
+			continue
+		}
+
+		for _, vulnerability := range uniqueVulnerabilities(vulnerabilities) {
+			if vulnerability.Severity.AtLeast(a.minSeverity) {
+				image.image.Vulnerabilities = append(
+					image.image.Vulnerabilities, vulnerability,
+				)
+			}
+		}
+
+		if len(image.image.Vulnerabilities) != 0 {
+			report.Vulnerable = append(report.Vulnerable, image.image)
+		}
+	}
+
+	return report, nil
+}
+
+// lockfileImage is an image in a Lockfile and the digests that it may have
+// been scanned by, which are its digest and the digests of its platforms.
+type lockfileImage struct {
+	image   *Image
+	digests []string
+}
+
+// lockfileImages returns the images of every kind in the Lockfile, sorted by
+// kind, path, and index.
+func lockfileImages(l *lockfile.Lockfile) []*lockfileImage {
+	images := l.Images()
+	allImages := make([]*lockfileImage, 0, len(images))
+
+	for _, image := range images {
+		auditImage := &Image{
+			Kind:   image.Kind,
+			Path:   image.Path,
+			Index:  image.Index,
+			Name:   *image.Name,
+			Tag:    *image.Tag,
+			Digest: *image.Digest,
+		}
+
+		switch image := image.Image.(type) {
+		case *lockfile.ComposefileImage:
+			auditImage.ServiceName = image.ServiceName
+		case *lockfile.KubernetesfileImage:
+			auditImage.ContainerName = image.ContainerName
+		case *lockfile.KustomizationImage:
+			auditImage.ContainerName = image.ContainerName
+		case *lockfile.BakefileImage:
+			auditImage.TargetName = image.TargetName
+		}
+
+		digests := []string{auditImage.Digest}
+		for _, platform := range *image.Platforms {
+			digests = append(digests, platform.Digest)
+		}
+
+		allImages = append(allImages, &lockfileImage{
+			image:   auditImage,
+			digests: digests,
+		})
+	}
+
+	return allImages
+}
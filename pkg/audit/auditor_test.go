@@ -0,0 +1,209 @@
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/audit"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type fixedDatabase struct {
+	vulnerabilities map[string][]*audit.Vulnerability
+}
+
+func (f *fixedDatabase) Vulnerabilities(
+	digest string,
+) ([]*audit.Vulnerability, bool) {
+	vulnerabilities, ok := f.vulnerabilities[digest]
+
+	return vulnerabilities, ok
+}
+
+func TestAuditor(t *testing.T) {
+	t.Parallel()
+
+	var (
+		critical = &audit.Vulnerability{
+			ID:               "CVE-2021-3711",
+			Severity:         audit.Critical,
+			Package:          "openssl",
+			InstalledVersion: "1.1.1d",
+			FixedVersion:     "1.1.1l",
+		}
+		low = &audit.Vulnerability{
+			ID:               "CVE-2021-0001",
+			Severity:         audit.Low,
+			Package:          "zlib",
+			InstalledVersion: "1.2.11",
+		}
+	)
+
+	database := &fixedDatabase{
+		vulnerabilities: map[string][]*audit.Vulnerability{
+			"golang":       {low},
+			"golang-arm64": {critical, low},
+			"redis":        {low},
+			"python":       {},
+		},
+	}
+
+	existingLockfile := &lockfile.Lockfile{
+		SchemaVersion: lockfile.SchemaVersion,
+		Dockerfiles: map[string][]*lockfile.DockerfileImage{
+			"Dockerfile": {
+				{
+					Name: "golang", Tag: "1.16", Digest: "golang",
+					Platforms: []*lockfile.PlatformDigest{
+						{
+							OS: "linux", Architecture: "arm64",
+							Digest: "golang-arm64",
+						},
+					},
+				},
+				{Name: "busybox", Tag: "latest", Digest: "busybox"},
+				{Name: "scratch"},
+			},
+		},
+		Composefiles: map[string][]*lockfile.ComposefileImage{
+			"docker-compose.yml": {
+				{
+					Name: "python", Tag: "3.8", Digest: "python",
+					ServiceName: "web",
+				},
+			},
+		},
+		Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+			"pod.yml": {
+				{
+					Name: "redis", Tag: "6.0", Digest: "redis",
+					ContainerName: "cache",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		Name        string
+		MinSeverity audit.Severity
+		Expected    *audit.Report
+		ShouldError bool
+	}{
+		{
+			Name:        "All Severities",
+			MinSeverity: audit.Unknown,
+			Expected: &audit.Report{
+				Vulnerable: []*audit.Image{
+					{
+						Kind:            kind.Dockerfile,
+						Path:            "Dockerfile",
+						Index:           0,
+						Name:            "golang",
+						Tag:             "1.16",
+						Digest:          "golang",
+						Vulnerabilities: []*audit.Vulnerability{critical, low},
+					},
+					{
+						Kind:            kind.Kubernetesfile,
+						Path:            "pod.yml",
+						Index:           0,
+						ContainerName:   "cache",
+						Name:            "redis",
+						Tag:             "6.0",
+						Digest:          "redis",
+						Vulnerabilities: []*audit.Vulnerability{low},
+					},
+				},
+				Unscanned: []*audit.Image{
+					{
+						Kind:   kind.Dockerfile,
+						Path:   "Dockerfile",
+						Index:  1,
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+				},
+			},
+		},
+		{
+			Name:        "High Severity",
+			MinSeverity: audit.High,
+			Expected: &audit.Report{
+				Vulnerable: []*audit.Image{
+					{
+						Kind:            kind.Dockerfile,
+						Path:            "Dockerfile",
+						Index:           0,
+						Name:            "golang",
+						Tag:             "1.16",
+						Digest:          "golang",
+						Vulnerabilities: []*audit.Vulnerability{critical},
+					},
+				},
+				Unscanned: []*audit.Image{
+					{
+						Kind:   kind.Dockerfile,
+						Path:   "Dockerfile",
+						Index:  1,
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+				},
+			},
+		},
+		{
+			Name:        "Invalid Severity",
+			MinSeverity: "severe",
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			auditor, err := audit.NewAuditor(database, test.MinSeverity)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := existingLockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := auditor.AuditLockfile(&lockfileByt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != string(gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
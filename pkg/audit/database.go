@@ -0,0 +1,289 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+type database struct {
+	vulnerabilities map[string][]*Vulnerability
+}
+
+// trivyReport is the subset of Trivy's JSON report, from
+// "trivy image --format json", that identifies an image and its
+// vulnerabilities.
+type trivyReport struct {
+	ArtifactName string `json:"ArtifactName"`
+	Metadata     struct {
+		RepoDigests []string `json:"RepoDigests"`
+	} `json:"Metadata"`
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// grypeReport is the subset of Grype's JSON report, from "grype -o json",
+// that identifies an image and its vulnerabilities.
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID          string `json:"id"`
+			Severity    string `json:"severity"`
+			Description string `json:"description"`
+			Fix         struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+	Source struct {
+		Target struct {
+			ManifestDigest string   `json:"manifestDigest"`
+			RepoDigests    []string `json:"repoDigests"`
+		} `json:"target"`
+	} `json:"source"`
+}
+
+// sqliteHeader starts every SQLite database file.
+const sqliteHeader = "SQLite format 3\x00"
+
+// NewDatabase returns an IDatabase of the vulnerabilities in the JSON
+// reports of scanners at paths. Each file may hold a report, or an array of
+// reports, from Trivy ("trivy image --format json") or Grype
+// ("grype -o json"). Images are identified by their repository digests, so
+// reports must be of images pulled from registries.
+//
+// Only JSON reports are supported. The SQLite databases that Trivy and Grype
+// download, which hold advisories for packages instead of the
+// vulnerabilities of images, return an error.
+func NewDatabase(paths []string) (IDatabase, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("'paths' cannot be empty")
+	}
+
+	d := &database{vulnerabilities: map[string][]*Vulnerability{}}
+
+	for _, path := range paths {
+		byt, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := d.load(byt); err != nil {
+			return nil, fmt.Errorf(
+				"'%s' failed to load with err: %v", path, err,
+			)
+		}
+	}
+
+	for digest, vulnerabilities := range d.vulnerabilities {
+		d.vulnerabilities[digest] = uniqueVulnerabilities(vulnerabilities)
+	}
+
+	return d, nil
+}
+
+// Vulnerabilities returns the vulnerabilities of the image with the digest,
+// sorted from most to least severe, and whether the image was scanned.
+func (d *database) Vulnerabilities(digest string) ([]*Vulnerability, bool) {
+	vulnerabilities, ok := d.vulnerabilities[trimDigest(digest)]
+
+	return vulnerabilities, ok
+}
+
+// load adds the reports in a file to the database.
+func (d *database) load(byt []byte) error {
+	if bytes.HasPrefix(byt, []byte(sqliteHeader)) {
+		return errors.New(
+			"SQLite databases are not supported, use the JSON report of " +
+				"'trivy image --format json' or 'grype -o json' instead",
+		)
+	}
+
+	byt = bytes.TrimSpace(byt)
+
+	var rawReports []json.RawMessage
+
+	if bytes.HasPrefix(byt, []byte("[")) {
+		if err := json.Unmarshal(byt, &rawReports); err != nil {
+			return err
+		}
+	} else {
+		rawReports = []json.RawMessage{byt}
+	}
+
+	for _, rawReport := range rawReports {
+		if err := d.loadReport(rawReport); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadReport adds a Trivy or Grype report to the database, detecting which
+// scanner wrote it from its fields.
+func (d *database) loadReport(rawReport json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rawReport, &fields); err != nil {
+		return err
+	}
+
+	switch {
+	case fields["ArtifactName"] != nil:
+		var report trivyReport
+		if err := json.Unmarshal(rawReport, &report); err != nil {
+			return err
+		}
+
+		d.loadTrivyReport(&report)
+	case fields["matches"] != nil:
+		var report grypeReport
+		if err := json.Unmarshal(rawReport, &report); err != nil {
+			return err
+		}
+
+		d.loadGrypeReport(&report)
+	default:
+		return errors.New("report is not from a known scanner")
+	}
+
+	return nil
+}
+
+func (d *database) loadTrivyReport(report *trivyReport) {
+	vulnerabilities := []*Vulnerability{}
+
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			severity, err := ParseSeverity(v.Severity)
+			if err != nil {
+				severity = Unknown
+			}
+
+			vulnerabilities = append(vulnerabilities, &Vulnerability{
+				ID:               v.VulnerabilityID,
+				Severity:         severity,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Title:            v.Title,
+			})
+		}
+	}
+
+	d.add(report.Metadata.RepoDigests, vulnerabilities)
+}
+
+func (d *database) loadGrypeReport(report *grypeReport) {
+	vulnerabilities := []*Vulnerability{}
+
+	for _, match := range report.Matches {
+		severity, err := ParseSeverity(match.Vulnerability.Severity)
+		if err != nil {
+			severity = Unknown
+		}
+
+		vulnerabilities = append(vulnerabilities, &Vulnerability{
+			ID:               match.Vulnerability.ID,
+			Severity:         severity,
+			Package:          match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+			FixedVersion: strings.Join(
+				match.Vulnerability.Fix.Versions, ", ",
+			),
+			Title: match.Vulnerability.Description,
+		})
+	}
+
+	digests := append(
+		[]string{report.Source.Target.ManifestDigest},
+		report.Source.Target.RepoDigests...,
+	)
+
+	d.add(digests, vulnerabilities)
+}
+
+// add records the vulnerabilities for each digest. Digests may be
+// references, such as "golang@sha256:...", or digests, such as
+// "sha256:...".
+func (d *database) add(digests []string, vulnerabilities []*Vulnerability) {
+	for _, digest := range digests {
+		if digest = trimDigest(digest); digest == "" {
+			continue
+		}
+
+		d.vulnerabilities[digest] = append(
+			d.vulnerabilities[digest], vulnerabilities...,
+		)
+	}
+}
+
+// trimDigest returns the hex of a sha256 digest in a reference, such as
+// "golang@sha256:...", or digest, such as "sha256:...".
+func trimDigest(digest string) string {
+	if i := strings.LastIndex(digest, "@"); i != -1 {
+		digest = digest[i+1:]
+	}
+
+	return strings.TrimPrefix(digest, "sha256:")
+}
+
+// uniqueVulnerabilities removes vulnerabilities of the same package that
+// were reported more than once, such as by both Trivy and Grype, and sorts
+// them from most to least severe, then by ID and package.
+func uniqueVulnerabilities(
+	vulnerabilities []*Vulnerability,
+) []*Vulnerability {
+	type key struct {
+		id               string
+		pkg              string
+		installedVersion string
+	}
+
+	seen := map[key]struct{}{}
+	unique := []*Vulnerability{}
+
+	for _, v := range vulnerabilities {
+		k := key{
+			id:               v.ID,
+			pkg:              v.Package,
+			installedVersion: v.InstalledVersion,
+		}
+
+		if _, ok := seen[k]; ok {
+			continue
+		}
+
+		seen[k] = struct{}{}
+		unique = append(unique, v)
+	}
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		switch {
+		case unique[i].Severity != unique[j].Severity:
+			return unique[j].Severity.rank() < unique[i].Severity.rank()
+		case unique[i].ID != unique[j].ID:
+			return unique[i].ID < unique[j].ID
+		default:
+			return unique[i].Package < unique[j].Package
+		}
+	})
+
+	return unique
+}
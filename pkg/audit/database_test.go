@@ -0,0 +1,233 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/audit"
+)
+
+const databaseTestDir = "database-tests"
+
+const trivyReport = `{
+	"SchemaVersion": 2,
+	"ArtifactName": "golang:1.16",
+	"ArtifactType": "container_image",
+	"Metadata": {
+		"RepoDigests": ["golang@sha256:golang"]
+	},
+	"Results": [
+		{
+			"Target": "golang:1.16 (debian 10.9)",
+			"Vulnerabilities": [
+				{
+					"VulnerabilityID": "CVE-2021-0001",
+					"PkgName": "zlib",
+					"InstalledVersion": "1.2.11",
+					"Severity": "LOW"
+				},
+				{
+					"VulnerabilityID": "CVE-2021-3711",
+					"PkgName": "openssl",
+					"InstalledVersion": "1.1.1d",
+					"FixedVersion": "1.1.1l",
+					"Severity": "CRITICAL",
+					"Title": "openssl: SM2 decryption buffer overflow"
+				}
+			]
+		}
+	]
+}`
+
+const grypeReport = `{
+	"matches": [
+		{
+			"vulnerability": {
+				"id": "CVE-2021-3711",
+				"severity": "Critical",
+				"fix": {"versions": ["1.1.1l"], "state": "fixed"}
+			},
+			"artifact": {"name": "openssl", "version": "1.1.1d"}
+		},
+		{
+			"vulnerability": {
+				"id": "CVE-2021-0002",
+				"severity": "Medium",
+				"fix": {"versions": [], "state": "not-fixed"}
+			},
+			"artifact": {"name": "bash", "version": "5.0"}
+		}
+	],
+	"source": {
+		"type": "image",
+		"target": {
+			"manifestDigest": "sha256:golang-amd64",
+			"repoDigests": ["golang@sha256:golang"]
+		}
+	}
+}`
+
+func TestDatabase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Contents   []string
+		Digest     string
+		Expected   []*audit.Vulnerability
+		Scanned    bool
+		ShouldFail bool
+	}{
+		{
+			Name:     "Trivy",
+			Contents: []string{trivyReport},
+			Digest:   "golang",
+			Expected: []*audit.Vulnerability{
+				{
+					ID:               "CVE-2021-3711",
+					Severity:         audit.Critical,
+					Package:          "openssl",
+					InstalledVersion: "1.1.1d",
+					FixedVersion:     "1.1.1l",
+					Title:            "openssl: SM2 decryption buffer overflow",
+				},
+				{
+					ID:               "CVE-2021-0001",
+					Severity:         audit.Low,
+					Package:          "zlib",
+					InstalledVersion: "1.2.11",
+				},
+			},
+			Scanned: true,
+		},
+		{
+			Name:     "Grype Manifest Digest",
+			Contents: []string{grypeReport},
+			Digest:   "golang-amd64",
+			Expected: []*audit.Vulnerability{
+				{
+					ID:               "CVE-2021-3711",
+					Severity:         audit.Critical,
+					Package:          "openssl",
+					InstalledVersion: "1.1.1d",
+					FixedVersion:     "1.1.1l",
+				},
+				{
+					ID:               "CVE-2021-0002",
+					Severity:         audit.Medium,
+					Package:          "bash",
+					InstalledVersion: "5.0",
+				},
+			},
+			Scanned: true,
+		},
+		{
+			Name:     "Trivy And Grype",
+			Contents: []string{"[" + trivyReport + "]", grypeReport},
+			Digest:   "sha256:golang",
+			Expected: []*audit.Vulnerability{
+				{
+					ID:               "CVE-2021-3711",
+					Severity:         audit.Critical,
+					Package:          "openssl",
+					InstalledVersion: "1.1.1d",
+					FixedVersion:     "1.1.1l",
+					Title:            "openssl: SM2 decryption buffer overflow",
+				},
+				{
+					ID:               "CVE-2021-0002",
+					Severity:         audit.Medium,
+					Package:          "bash",
+					InstalledVersion: "5.0",
+				},
+				{
+					ID:               "CVE-2021-0001",
+					Severity:         audit.Low,
+					Package:          "zlib",
+					InstalledVersion: "1.2.11",
+				},
+			},
+			Scanned: true,
+		},
+		{
+			Name:     "Not Scanned",
+			Contents: []string{trivyReport},
+			Digest:   "busybox",
+		},
+		{
+			Name:       "Unknown Scanner",
+			Contents:   []string{`{"images": []}`},
+			ShouldFail: true,
+		},
+		{
+			Name:       "SQLite Database",
+			Contents:   []string{"SQLite format 3\x00"},
+			ShouldFail: true,
+		},
+		{
+			Name:       "Invalid JSON",
+			Contents:   []string{`{`},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, databaseTestDir)
+			defer os.RemoveAll(tempDir)
+
+			paths := make([]string, len(test.Contents))
+
+			for i, contents := range test.Contents {
+				paths[i] = filepath.Join(tempDir, fmt.Sprintf("%d.json", i))
+
+				if err := ioutil.WriteFile(
+					paths[i], []byte(contents), 0600,
+				); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			database, err := audit.NewDatabase(paths)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, scanned := database.Vulnerabilities(test.Digest)
+			if test.Scanned != scanned {
+				t.Fatalf("expected scanned %t, got %t", test.Scanned, scanned)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != string(gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
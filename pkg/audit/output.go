@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+type textReportWriter struct{}
+
+type jsonReportWriter struct{}
+
+// NewTextReportWriter returns an IReportWriter that writes a human readable
+// list of the vulnerable images, grouped by kind and path, followed by the
+// images that were not in the database, such as:
+//
+//	dockerfiles
+//	  Dockerfile
+//	    image 0 golang:1.16@sha256:...
+//	      CVE-2021-3711 critical openssl 1.1.1d (fixed in 1.1.1l): ...
+//	found 1 vulnerability in 1 image
+func NewTextReportWriter() IReportWriter {
+	return &textReportWriter{}
+}
+
+// NewJSONReportWriter returns an IReportWriter that writes the Report as
+// indented JSON.
+func NewJSONReportWriter() IReportWriter {
+	return &jsonReportWriter{}
+}
+
+// WriteReport writes the Report as text.
+func (t *textReportWriter) WriteReport(
+	report *Report,
+	writer io.Writer,
+) error {
+	if err := ensureReportWriterArgsNotNil(report, writer); err != nil {
+		return err
+	}
+
+	var (
+		builder            strings.Builder
+		numVulnerabilities int
+	)
+
+	writeImages(&builder, report.Vulnerable, func(image *Image) {
+		for _, v := range image.Vulnerabilities {
+			numVulnerabilities++
+
+			fmt.Fprintf(
+				&builder, "      %s %s %s %s",
+				v.ID, v.Severity, v.Package, v.InstalledVersion,
+			)
+
+			if v.FixedVersion != "" {
+				fmt.Fprintf(&builder, " (fixed in %s)", v.FixedVersion)
+			}
+
+			if v.Title != "" {
+				fmt.Fprintf(&builder, ": %s", v.Title)
+			}
+
+			fmt.Fprintln(&builder)
+		}
+	})
+
+	if len(report.Vulnerable) == 0 {
+		fmt.Fprintln(&builder, "no vulnerabilities found")
+	} else {
+		fmt.Fprintf(
+			&builder, "found %d %s in %d %s\n",
+			numVulnerabilities, plural(numVulnerabilities, "vulnerability"),
+			len(report.Vulnerable), plural(len(report.Vulnerable), "image"),
+		)
+	}
+
+	if len(report.Unscanned) != 0 {
+		fmt.Fprintf(
+			&builder, "%d %s not in the database:\n",
+			len(report.Unscanned), plural(len(report.Unscanned), "image"),
+		)
+		writeImages(&builder, report.Unscanned, nil)
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+
+	return err
+}
+
+// WriteReport writes the Report as indented JSON.
+func (j *jsonReportWriter) WriteReport(
+	report *Report,
+	writer io.Writer,
+) error {
+	if err := ensureReportWriterArgsNotNil(report, writer); err != nil {
+		return err
+	}
+
+	byt, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(writer, string(byt))
+
+	return err
+}
+
+// writeImages writes images grouped by kind and path, calling writeDetails
+// after each image, if it is not nil.
+func writeImages(
+	builder *strings.Builder,
+	images []*Image,
+	writeDetails func(image *Image),
+) {
+	var (
+		currentKind kind.Kind
+		currentPath string
+	)
+
+	for i, image := range images {
+		if i == 0 || image.Kind != currentKind {
+			currentKind, currentPath = image.Kind, ""
+			fmt.Fprintln(builder, image.Kind)
+		}
+
+		if image.Path != currentPath {
+			currentPath = image.Path
+			fmt.Fprintf(builder, "  %s\n", image.Path)
+		}
+
+		location := fmt.Sprintf("image %d", image.Index)
+
+		switch {
+		case image.ServiceName != "":
+			location = fmt.Sprintf("service %s, %s", image.ServiceName, location)
+		case image.ContainerName != "":
+			location = fmt.Sprintf(
+				"container %s, %s", image.ContainerName, location,
+			)
+		case image.TargetName != "":
+			location = fmt.Sprintf("target %s, %s", image.TargetName, location)
+		}
+
+		reference := image.Name
+		if image.Tag != "" {
+			reference = fmt.Sprintf("%s:%s", reference, image.Tag)
+		}
+
+		fmt.Fprintf(
+			builder, "    %s %s@sha256:%s\n", location, reference, image.Digest,
+		)
+
+		if writeDetails != nil {
+			writeDetails(image)
+		}
+	}
+}
+
+func plural(n int, noun string) string {
+	switch {
+	case n == 1:
+		return noun
+	case strings.HasSuffix(noun, "y"):
+		return fmt.Sprintf("%sies", strings.TrimSuffix(noun, "y"))
+	default:
+		return fmt.Sprintf("%ss", noun)
+	}
+}
+
+func ensureReportWriterArgsNotNil(report *Report, writer io.Writer) error {
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	return nil
+}
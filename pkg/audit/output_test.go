@@ -0,0 +1,100 @@
+package audit_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/audit"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+func TestTextReportWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Report   *audit.Report
+		Expected string
+	}{
+		{
+			Name: "Vulnerable Images",
+			Report: &audit.Report{
+				Vulnerable: []*audit.Image{
+					{
+						Kind:   kind.Composefile,
+						Path:   "docker-compose.yml",
+						Index:  0,
+						Name:   "golang",
+						Tag:    "1.16",
+						Digest: "golang",
+
+						ServiceName: "web",
+						Vulnerabilities: []*audit.Vulnerability{
+							{
+								ID:               "CVE-2021-3711",
+								Severity:         audit.Critical,
+								Package:          "openssl",
+								InstalledVersion: "1.1.1d",
+								FixedVersion:     "1.1.1l",
+								Title:            "buffer overflow",
+							},
+							{
+								ID:               "CVE-2021-0001",
+								Severity:         audit.Low,
+								Package:          "zlib",
+								InstalledVersion: "1.2.11",
+							},
+						},
+					},
+				},
+				Unscanned: []*audit.Image{
+					{
+						Kind:          kind.Kubernetesfile,
+						Path:          "pod.yml",
+						Index:         0,
+						ContainerName: "cache",
+						Name:          "redis",
+						Tag:           "6.0",
+						Digest:        "redis",
+					},
+				},
+			},
+			Expected: `composefiles
+  docker-compose.yml
+    service web, image 0 golang:1.16@sha256:golang
+      CVE-2021-3711 critical openssl 1.1.1d (fixed in 1.1.1l): buffer overflow
+      CVE-2021-0001 low zlib 1.2.11
+found 2 vulnerabilities in 1 image
+1 image not in the database:
+kubernetesfiles
+  pod.yml
+    container cache, image 0 redis:6.0@sha256:redis
+`,
+		},
+		{
+			Name:     "No Vulnerabilities",
+			Report:   &audit.Report{},
+			Expected: "no vulnerabilities found\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var got bytes.Buffer
+
+			if err := audit.NewTextReportWriter().WriteReport(
+				test.Report, &got,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.Expected != got.String() {
+				t.Fatalf("expected %s, got %s", test.Expected, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// Severity is how severe a Vulnerability is.
+type Severity string
+
+// Severities of Vulnerabilities, from least to most severe.
+const (
+	Unknown    Severity = "unknown"
+	Negligible Severity = "negligible"
+	Low        Severity = "low"
+	Medium     Severity = "medium"
+	High       Severity = "high"
+	Critical   Severity = "critical"
+)
+
+// severities is every Severity, from least to most severe.
+var severities = []Severity{ // nolint: gochecknoglobals
+	Unknown, Negligible, Low, Medium, High, Critical,
+}
+
+// Report is the result of auditing a Lockfile. Vulnerable holds the images
+// with vulnerabilities, and Unscanned holds the images whose digests are not
+// in the database. Both are sorted by kind, path, and index.
+type Report struct {
+	Vulnerable []*Image `json:"vulnerable"`
+	Unscanned  []*Image `json:"unscanned"`
+}
+
+// Image is an image in a Lockfile, located by its kind, path, and index, as
+// well as its service, container, or target, if its kind has them.
+type Image struct {
+	Kind            kind.Kind        `json:"kind"`
+	Path            string           `json:"path"`
+	Index           int              `json:"index"`
+	ServiceName     string           `json:"service,omitempty"`
+	ContainerName   string           `json:"container,omitempty"`
+	TargetName      string           `json:"target,omitempty"`
+	Name            string           `json:"name"`
+	Tag             string           `json:"tag"`
+	Digest          string           `json:"digest"`
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// Vulnerability is a vulnerability in a package of an image. FixedVersion
+// is empty if there is no fix.
+type Vulnerability struct {
+	ID               string   `json:"id"`
+	Severity         Severity `json:"severity"`
+	Package          string   `json:"package"`
+	InstalledVersion string   `json:"installedVersion"`
+	FixedVersion     string   `json:"fixedVersion,omitempty"`
+	Title            string   `json:"title,omitempty"`
+}
+
+// ParseSeverity returns the Severity named by severity, ignoring case, as
+// used by scanners such as Trivy and Grype. An error is returned if the
+// Severity is not known.
+func ParseSeverity(severity string) (Severity, error) {
+	for _, s := range severities {
+		if strings.EqualFold(string(s), severity) {
+			return s, nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"'%s' severity must be one of 'unknown', 'negligible', 'low', "+
+			"'medium', 'high', or 'critical'", severity,
+	)
+}
+
+// AtLeast reports whether the Severity is as severe as other or more.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// rank orders Severities from least to most severe. Severities that are
+// not known rank as Unknown.
+func (s Severity) rank() int {
+	for i, severity := range severities {
+		if s == severity {
+			return i
+		}
+	}
+
+	return 0
+}
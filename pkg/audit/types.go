@@ -0,0 +1,23 @@
+// Package audit provides functionality to match the images in a Lockfile
+// against a local database of vulnerabilities.
+package audit
+
+import "io"
+
+// IDatabase provides an interface for Databases, which hold the
+// vulnerabilities of images found by scanners, keyed by digest.
+type IDatabase interface {
+	Vulnerabilities(digest string) ([]*Vulnerability, bool)
+}
+
+// IAuditor provides an interface for Auditors, which are responsible for
+// reporting the vulnerabilities of the images in a Lockfile.
+type IAuditor interface {
+	AuditLockfile(lockfileReader io.Reader) (*Report, error)
+}
+
+// IReportWriter provides an interface for ReportWriters, which are
+// responsible for writing Reports in a format.
+type IReportWriter interface {
+	WriteReport(report *Report, writer io.Writer) error
+}
@@ -0,0 +1,185 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+// cycloneDXSpecVersion is the version of the CycloneDX specification that
+// documents conform to.
+const cycloneDXSpecVersion = "1.4"
+
+type cycloneDXExporter struct {
+	documentName string
+	toolVersion  string
+	now          func() time.Time
+}
+
+type cycloneDXDocument struct {
+	BOMFormat   string                `json:"bomFormat"`
+	SpecVersion string                `json:"specVersion"`
+	Version     int                   `json:"version"`
+	Metadata    *cycloneDXMetadata    `json:"metadata"`
+	Components  []*cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp"`
+	Tools     []*cycloneDXTool    `json:"tools"`
+	Component *cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref"`
+	Type       string               `json:"type"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	Hashes     []*cycloneDXHash     `json:"hashes,omitempty"`
+	PURL       string               `json:"purl,omitempty"`
+	Properties []*cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewCycloneDXExporter returns an IExporter that writes a CycloneDX JSON
+// document, after validating its fields. documentName, such as the name of
+// the repository, cannot be empty and describes the application that the
+// images belong to. toolVersion is the version of docker-lock. now returns
+// the time the document was created. If now is nil, time.Now is used.
+func NewCycloneDXExporter(
+	documentName string,
+	toolVersion string,
+	now func() time.Time,
+) (IExporter, error) {
+	if documentName == "" {
+		return nil, errors.New("'documentName' cannot be empty")
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &cycloneDXExporter{
+		documentName: documentName,
+		toolVersion:  toolVersion,
+		now:          now,
+	}, nil
+}
+
+// ExportLockfile reads an existing Lockfile and writes a CycloneDX document
+// with a container component for each unique image. The component's
+// version is the image's tag, its hash is the image's digest, and its
+// properties record the paths, services, containers, and platforms of the
+// image.
+func (c *cycloneDXExporter) ExportLockfile(
+	lockfileReader io.Reader,
+	writer io.Writer,
+) error {
+	if err := ensureExportArgsNotNil(lockfileReader, writer); err != nil {
+		return err
+	}
+
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
+		return err
+	}
+
+	components, err := inventory(existingLockfile)
+	if err != nil {
+		return err
+	}
+
+	document := &cycloneDXDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Metadata: &cycloneDXMetadata{
+			Timestamp: c.now().UTC().Format(time.RFC3339),
+			Tools: []*cycloneDXTool{
+				{
+					Vendor:  "safe-waters",
+					Name:    "docker-lock",
+					Version: c.toolVersion,
+				},
+			},
+			Component: &cycloneDXComponent{
+				BOMRef: c.documentName,
+				Type:   "application",
+				Name:   c.documentName,
+			},
+		},
+		Components: []*cycloneDXComponent{},
+	}
+
+	for _, component := range components {
+		cycloneDXComponent := &cycloneDXComponent{
+			BOMRef:  component.reference(),
+			Type:    "container",
+			Name:    component.name,
+			Version: component.tag,
+			PURL:    component.purl,
+		}
+
+		if component.digest != "" {
+			cycloneDXComponent.Hashes = []*cycloneDXHash{
+				{Algorithm: "SHA-256", Content: component.digest},
+			}
+		}
+
+		for _, p := range component.properties {
+			cycloneDXComponent.Properties = append(
+				cycloneDXComponent.Properties,
+				&cycloneDXProperty{Name: p.name, Value: p.value},
+			)
+		}
+
+		document.Components = append(
+			document.Components, cycloneDXComponent,
+		)
+	}
+
+	return writeJSON(document, writer)
+}
+
+func writeJSON(document interface{}, writer io.Writer) error {
+	byt, err := json.MarshalIndent(document, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(writer, string(byt))
+
+	return err
+}
+
+func ensureExportArgsNotNil(lockfileReader io.Reader, writer io.Writer) error {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return errors.New("'lockfileReader' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	return nil
+}
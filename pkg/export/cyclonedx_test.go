@@ -0,0 +1,198 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/export"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+func TestCycloneDXExporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name        string
+		Lockfile    *lockfile.Lockfile
+		Expected    string
+		ShouldError bool
+	}{
+		{
+			Name: "Images",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{
+							Name: "golang", Tag: "1.16", Digest: "golang",
+							Platforms: []*lockfile.PlatformDigest{
+								{
+									OS: "linux", Architecture: "arm64",
+									Variant: "v8", Digest: "golang-arm64",
+								},
+							},
+						},
+						{Name: "scratch"},
+					},
+				},
+				Composefiles: map[string][]*lockfile.ComposefileImage{
+					"docker-compose.yml": {
+						{
+							Name: "golang", Tag: "1.16", Digest: "golang",
+							ServiceName: "web",
+						},
+					},
+				},
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+					"pod.yml": {
+						{
+							Name: "ghcr.io/org/app", Tag: "v1", Digest: "app",
+							ContainerName: "app",
+						},
+					},
+				},
+			},
+			Expected: `{
+	"bomFormat": "CycloneDX",
+	"specVersion": "1.4",
+	"version": 1,
+	"metadata": {
+		"timestamp": "2021-03-01T12:00:00Z",
+		"tools": [
+			{"vendor": "safe-waters", "name": "docker-lock", "version": "v1.0.0"}
+		],
+		"component": {"bom-ref": "repo", "type": "application", "name": "repo"}
+	},
+	"components": [
+		{
+			"bom-ref": "ghcr.io/org/app:v1@sha256:app",
+			"type": "container",
+			"name": "ghcr.io/org/app",
+			"version": "v1",
+			"hashes": [{"alg": "SHA-256", "content": "app"}],
+			"purl": "pkg:docker/org/app@sha256:app?repository_url=ghcr.io",
+			"properties": [
+				{"name": "docker-lock:container", "value": "app"},
+				{"name": "docker-lock:path", "value": "pod.yml"}
+			]
+		},
+		{
+			"bom-ref": "golang:1.16@sha256:golang",
+			"type": "container",
+			"name": "golang",
+			"version": "1.16",
+			"hashes": [{"alg": "SHA-256", "content": "golang"}],
+			"purl": "pkg:docker/golang@sha256:golang",
+			"properties": [
+				{"name": "docker-lock:path", "value": "Dockerfile"},
+				{"name": "docker-lock:path", "value": "docker-compose.yml"},
+				{
+					"name": "docker-lock:platform",
+					"value": "linux/arm64/v8@sha256:golang-arm64"
+				},
+				{"name": "docker-lock:service", "value": "web"}
+			]
+		},
+		{
+			"bom-ref": "scratch",
+			"type": "container",
+			"name": "scratch",
+			"purl": "pkg:docker/scratch",
+			"properties": [
+				{"name": "docker-lock:path", "value": "Dockerfile"}
+			]
+		}
+	]
+}`,
+		},
+		{
+			Name:     "Empty Lockfile",
+			Lockfile: &lockfile.Lockfile{SchemaVersion: lockfile.SchemaVersion},
+			Expected: `{
+	"bomFormat": "CycloneDX",
+	"specVersion": "1.4",
+	"version": 1,
+	"metadata": {
+		"timestamp": "2021-03-01T12:00:00Z",
+		"tools": [
+			{"vendor": "safe-waters", "name": "docker-lock", "version": "v1.0.0"}
+		],
+		"component": {"bom-ref": "repo", "type": "application", "name": "repo"}
+	},
+	"components": []
+}`,
+		},
+		{
+			Name: "Invalid Image Name",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {{Name: "Golang", Tag: "1.16"}},
+				},
+			},
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter, err := export.NewCycloneDXExporter(
+				"repo", "v1.0.0", fixedNow,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := test.Lockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+
+			err = exporter.ExportLockfile(&lockfileByt, &got)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertJSONEqual(t, test.Expected, got.String())
+		})
+	}
+}
+
+func fixedNow() time.Time {
+	return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func assertJSONEqual(t *testing.T, expected string, got string) {
+	t.Helper()
+
+	var expectedJSON, gotJSON interface{}
+
+	if err := json.Unmarshal([]byte(expected), &expectedJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(got), &gotJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expectedJSON, gotJSON) {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+}
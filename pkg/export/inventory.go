@@ -0,0 +1,223 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+// Property names that record where an image appears.
+const (
+	pathProperty      = "docker-lock:path"
+	serviceProperty   = "docker-lock:service"
+	containerProperty = "docker-lock:container"
+	targetProperty    = "docker-lock:target"
+	platformProperty  = "docker-lock:platform"
+)
+
+// dockerHubRegistry is the registry that package URLs default to.
+const dockerHubRegistry = "docker.io"
+
+// component is a unique image in a Lockfile, along with every path,
+// service, container, and target that it appears in.
+type component struct {
+	name       string
+	tag        string
+	digest     string
+	purl       string
+	properties []*property
+}
+
+type property struct {
+	name  string
+	value string
+}
+
+// reference returns the image as it would be pulled, such as
+// "golang:1.16@sha256:...". It is unique among the components.
+func (c *component) reference() string {
+	reference := c.name
+	if c.tag != "" {
+		reference = fmt.Sprintf("%s:%s", reference, c.tag)
+	}
+
+	if c.digest != "" {
+		reference = fmt.Sprintf("%s@sha256:%s", reference, c.digest)
+	}
+
+	return reference
+}
+
+// inventory returns a component for each unique name, tag, and digest in
+// the Lockfile, sorted by name, tag, and digest. The properties of each
+// component are sorted and do not repeat.
+func inventory(l *lockfile.Lockfile) ([]*component, error) {
+	components := map[string]*component{}
+
+	add := func(
+		imageName string,
+		tag string,
+		digest string,
+		platforms []*lockfile.PlatformDigest,
+		properties ...*property,
+	) error {
+		c := &component{name: imageName, tag: tag, digest: digest}
+
+		if existing, ok := components[c.reference()]; ok {
+			c = existing
+		} else {
+			purl, err := packageURL(imageName, tag, digest)
+			if err != nil {
+				return err
+			}
+
+			c.purl = purl
+			components[c.reference()] = c
+		}
+
+		for _, platform := range platforms {
+			p := fmt.Sprintf("%s/%s", platform.OS, platform.Architecture)
+			if platform.Variant != "" {
+				p = fmt.Sprintf("%s/%s", p, platform.Variant)
+			}
+
+			properties = append(properties, &property{
+				name:  platformProperty,
+				value: fmt.Sprintf("%s@sha256:%s", p, platform.Digest),
+			})
+		}
+
+		for _, p := range properties {
+			if p.value != "" {
+				c.properties = append(c.properties, p)
+			}
+		}
+
+		return nil
+	}
+
+	for _, image := range l.Images() {
+		properties := []*property{{name: pathProperty, value: image.Path}}
+
+		switch image := image.Image.(type) {
+		case *lockfile.ComposefileImage:
+			properties = append(properties, &property{
+				name: serviceProperty, value: image.ServiceName,
+			})
+		case *lockfile.KubernetesfileImage:
+			properties = append(properties, &property{
+				name: containerProperty, value: image.ContainerName,
+			})
+		case *lockfile.KustomizationImage:
+			properties = append(properties, &property{
+				name: containerProperty, value: image.ContainerName,
+			})
+		case *lockfile.BakefileImage:
+			properties = append(properties, &property{
+				name: targetProperty, value: image.TargetName,
+			})
+		}
+
+		if err := add(
+			*image.Name, *image.Tag, *image.Digest, *image.Platforms,
+			properties...,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	sortedComponents := make([]*component, 0, len(components))
+
+	for _, c := range components {
+		c.properties = uniqueProperties(c.properties)
+		sortedComponents = append(sortedComponents, c)
+	}
+
+	sort.Slice(sortedComponents, func(i, j int) bool {
+		first, second := sortedComponents[i], sortedComponents[j]
+
+		switch {
+		case first.name != second.name:
+			return first.name < second.name
+		case first.tag != second.tag:
+			return first.tag < second.tag
+		default:
+			return first.digest < second.digest
+		}
+	})
+
+	return sortedComponents, nil
+}
+
+// packageURL returns the package URL of an image, as specified in
+// https://github.com/package-url/purl-spec, such as
+// "pkg:docker/golang@sha256:..." or
+// "pkg:docker/org/app@sha256:...?repository_url=ghcr.io".
+//
+// The version is the digest. If the image does not have a digest, the tag
+// is used instead.
+func packageURL(imageName string, tag string, digest string) (string, error) {
+	repository, err := name.NewRepository(imageName)
+	if err != nil {
+		return "", err
+	}
+
+	registry := update.NormalizeRegistry(repository.RegistryStr())
+
+	repositoryPath := repository.RepositoryStr()
+	if registry == dockerHubRegistry {
+		repositoryPath = strings.TrimPrefix(repositoryPath, "library/")
+	}
+
+	segments := strings.Split(repositoryPath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	purl := fmt.Sprintf("pkg:docker/%s", strings.Join(segments, "/"))
+
+	switch {
+	case digest != "":
+		purl = fmt.Sprintf("%s@sha256:%s", purl, digest)
+	case tag != "":
+		purl = fmt.Sprintf("%s@%s", purl, url.PathEscape(tag))
+	}
+
+	if registry != dockerHubRegistry {
+		purl = fmt.Sprintf(
+			"%s?repository_url=%s", purl, url.QueryEscape(registry),
+		)
+	}
+
+	return purl, nil
+}
+
+func uniqueProperties(properties []*property) []*property {
+	seen := map[property]struct{}{}
+
+	var unique []*property
+
+	for _, p := range properties {
+		if _, ok := seen[*p]; ok {
+			continue
+		}
+
+		seen[*p] = struct{}{}
+		unique = append(unique, p)
+	}
+
+	sort.Slice(unique, func(i, j int) bool {
+		if unique[i].name != unique[j].name {
+			return unique[i].name < unique[j].name
+		}
+
+		return unique[i].value < unique[j].value
+	})
+
+	return unique
+}
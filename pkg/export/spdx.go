@@ -0,0 +1,199 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+// spdxVersion is the version of the SPDX specification that documents
+// conform to.
+const spdxVersion = "SPDX-2.2"
+
+// spdxNoAssertion is the value of SPDX fields that docker-lock cannot know,
+// such as the licenses of images.
+const spdxNoAssertion = "NOASSERTION"
+
+type spdxExporter struct {
+	documentName string
+	toolVersion  string
+	now          func() time.Time
+}
+
+type spdxDocument struct {
+	SPDXVersion       string            `json:"spdxVersion"`
+	DataLicense       string            `json:"dataLicense"`
+	SPDXID            string            `json:"SPDXID"`
+	Name              string            `json:"name"`
+	DocumentNamespace string            `json:"documentNamespace"`
+	CreationInfo      *spdxCreationInfo `json:"creationInfo"`
+	DocumentDescribes []string          `json:"documentDescribes"`
+	Packages          []*spdxPackage    `json:"packages"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string             `json:"SPDXID"`
+	Name             string             `json:"name"`
+	VersionInfo      string             `json:"versionInfo,omitempty"`
+	DownloadLocation string             `json:"downloadLocation"`
+	FilesAnalyzed    bool               `json:"filesAnalyzed"`
+	LicenseConcluded string             `json:"licenseConcluded"`
+	LicenseDeclared  string             `json:"licenseDeclared"`
+	CopyrightText    string             `json:"copyrightText"`
+	Checksums        []*spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []*spdxExternalRef `json:"externalRefs,omitempty"`
+	Annotations      []*spdxAnnotation  `json:"annotations,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxAnnotation struct {
+	AnnotationDate string `json:"annotationDate"`
+	AnnotationType string `json:"annotationType"`
+	Annotator      string `json:"annotator"`
+	Comment        string `json:"comment"`
+}
+
+// NewSPDXExporter returns an IExporter that writes an SPDX JSON document,
+// after validating its fields. documentName, such as the name of the
+// repository, cannot be empty. toolVersion is the version of docker-lock.
+// now returns the time the document was created. If now is nil, time.Now
+// is used.
+func NewSPDXExporter(
+	documentName string,
+	toolVersion string,
+	now func() time.Time,
+) (IExporter, error) {
+	if documentName == "" {
+		return nil, errors.New("'documentName' cannot be empty")
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &spdxExporter{
+		documentName: documentName,
+		toolVersion:  toolVersion,
+		now:          now,
+	}, nil
+}
+
+// ExportLockfile reads an existing Lockfile and writes an SPDX document
+// with a package for each unique image. The package's version is the
+// image's tag, its checksum is the image's digest, and its annotations
+// record the paths, services, containers, and platforms of the image.
+//
+// The document namespace is derived from the document name and a hash of
+// the Lockfile, so exporting the same Lockfile twice results in the same
+// namespace.
+func (s *spdxExporter) ExportLockfile(
+	lockfileReader io.Reader,
+	writer io.Writer,
+) error {
+	if err := ensureExportArgsNotNil(lockfileReader, writer); err != nil {
+		return err
+	}
+
+	lockfileByt, err := ioutil.ReadAll(lockfileReader)
+	if err != nil {
+		return err
+	}
+
+	existingLockfile, err := lockfile.Read(bytes.NewReader(lockfileByt))
+	if err != nil {
+		return err
+	}
+
+	components, err := inventory(existingLockfile)
+	if err != nil {
+		return err
+	}
+
+	var (
+		created = s.now().UTC().Format(time.RFC3339)
+		creator = fmt.Sprintf("Tool: docker-lock-%s", s.toolVersion)
+	)
+
+	document := &spdxDocument{
+		SPDXVersion: spdxVersion,
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        s.documentName,
+		DocumentNamespace: fmt.Sprintf(
+			"https://spdx.org/spdxdocs/%s-%x",
+			url.PathEscape(s.documentName), sha256.Sum256(lockfileByt),
+		),
+		CreationInfo: &spdxCreationInfo{
+			Created:  created,
+			Creators: []string{creator},
+		},
+		DocumentDescribes: []string{},
+		Packages:          []*spdxPackage{},
+	}
+
+	for i, component := range components {
+		spdxPackage := &spdxPackage{
+			SPDXID:           fmt.Sprintf("SPDXRef-Image-%d", i),
+			Name:             component.name,
+			VersionInfo:      component.tag,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+			ExternalRefs: []*spdxExternalRef{
+				{
+					ReferenceCategory: "PACKAGE-MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  component.purl,
+				},
+			},
+		}
+
+		if component.digest != "" {
+			spdxPackage.Checksums = []*spdxChecksum{
+				{Algorithm: "SHA256", ChecksumValue: component.digest},
+			}
+		}
+
+		for _, p := range component.properties {
+			spdxPackage.Annotations = append(
+				spdxPackage.Annotations,
+				&spdxAnnotation{
+					AnnotationDate: created,
+					AnnotationType: "OTHER",
+					Annotator:      creator,
+					Comment:        fmt.Sprintf("%s=%s", p.name, p.value),
+				},
+			)
+		}
+
+		document.DocumentDescribes = append(
+			document.DocumentDescribes, spdxPackage.SPDXID,
+		)
+		document.Packages = append(document.Packages, spdxPackage)
+	}
+
+	return writeJSON(document, writer)
+}
@@ -0,0 +1,163 @@
+package export_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/export"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+func TestSPDXExporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name        string
+		Lockfile    *lockfile.Lockfile
+		Expected    string
+		ShouldError bool
+	}{
+		{
+			Name: "Images",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "golang", Tag: "1.16", Digest: "golang"},
+					},
+				},
+				Kustomizations: map[string][]*lockfile.KustomizationImage{
+					"kustomization.yaml": {
+						{
+							Name: "golang", Tag: "1.16", Digest: "golang",
+							ManifestPath:  "deployment.yaml",
+							ContainerName: "app",
+						},
+					},
+				},
+			},
+			Expected: `{
+	"spdxVersion": "SPDX-2.2",
+	"dataLicense": "CC0-1.0",
+	"SPDXID": "SPDXRef-DOCUMENT",
+	"name": "repo",
+	"documentNamespace": "https://spdx.org/spdxdocs/repo-%x",
+	"creationInfo": {
+		"created": "2021-03-01T12:00:00Z",
+		"creators": ["Tool: docker-lock-v1.0.0"]
+	},
+	"documentDescribes": ["SPDXRef-Image-0"],
+	"packages": [
+		{
+			"SPDXID": "SPDXRef-Image-0",
+			"name": "golang",
+			"versionInfo": "1.16",
+			"downloadLocation": "NOASSERTION",
+			"filesAnalyzed": false,
+			"licenseConcluded": "NOASSERTION",
+			"licenseDeclared": "NOASSERTION",
+			"copyrightText": "NOASSERTION",
+			"checksums": [{"algorithm": "SHA256", "checksumValue": "golang"}],
+			"externalRefs": [
+				{
+					"referenceCategory": "PACKAGE-MANAGER",
+					"referenceType": "purl",
+					"referenceLocator": "pkg:docker/golang@sha256:golang"
+				}
+			],
+			"annotations": [
+				{
+					"annotationDate": "2021-03-01T12:00:00Z",
+					"annotationType": "OTHER",
+					"annotator": "Tool: docker-lock-v1.0.0",
+					"comment": "docker-lock:container=app"
+				},
+				{
+					"annotationDate": "2021-03-01T12:00:00Z",
+					"annotationType": "OTHER",
+					"annotator": "Tool: docker-lock-v1.0.0",
+					"comment": "docker-lock:path=Dockerfile"
+				},
+				{
+					"annotationDate": "2021-03-01T12:00:00Z",
+					"annotationType": "OTHER",
+					"annotator": "Tool: docker-lock-v1.0.0",
+					"comment": "docker-lock:path=kustomization.yaml"
+				}
+			]
+		}
+	]
+}`,
+		},
+		{
+			Name:     "Empty Lockfile",
+			Lockfile: &lockfile.Lockfile{SchemaVersion: lockfile.SchemaVersion},
+			Expected: `{
+	"spdxVersion": "SPDX-2.2",
+	"dataLicense": "CC0-1.0",
+	"SPDXID": "SPDXRef-DOCUMENT",
+	"name": "repo",
+	"documentNamespace": "https://spdx.org/spdxdocs/repo-%x",
+	"creationInfo": {
+		"created": "2021-03-01T12:00:00Z",
+		"creators": ["Tool: docker-lock-v1.0.0"]
+	},
+	"documentDescribes": [],
+	"packages": []
+}`,
+		},
+		{
+			Name: "Invalid Image Name",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {{Name: "Golang", Tag: "1.16"}},
+				},
+			},
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter, err := export.NewSPDXExporter(
+				"repo", "v1.0.0", fixedNow,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := test.Lockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			lockfileHash := sha256.Sum256(lockfileByt.Bytes())
+
+			var got bytes.Buffer
+
+			err = exporter.ExportLockfile(&lockfileByt, &got)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertJSONEqual(
+				t, fmt.Sprintf(test.Expected, lockfileHash), got.String(),
+			)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// Package export provides functionality to convert a Lockfile into a
+// software bill of materials, such as a CycloneDX or SPDX document.
+package export
+
+import "io"
+
+// IExporter provides an interface for Exporters, which are responsible for
+// writing the images in a Lockfile as a document in some format.
+type IExporter interface {
+	ExportLockfile(lockfileReader io.Reader, writer io.Writer) error
+}
@@ -0,0 +1,146 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
+)
+
+type imagePolicyChecker struct {
+	checker policy.IImagePolicyChecker
+	strict  bool
+}
+
+// NewImagePolicyChecker creates an IImagePolicyChecker from an
+// IImagePolicyChecker. If strict is true, images with unresolved variables
+// in their references are rejected, rather than only warned about.
+func NewImagePolicyChecker(
+	checker policy.IImagePolicyChecker,
+	strict bool,
+) (IImagePolicyChecker, error) {
+	if checker == nil || reflect.ValueOf(checker).IsNil() {
+		return nil, errors.New("'checker' cannot be nil")
+	}
+
+	return &imagePolicyChecker{checker: checker, strict: strict}, nil
+}
+
+// CheckImages checks every image against the policy, as well as for
+// unresolved variables in its reference. If all images satisfy the policy
+// and, in strict mode, have no unresolved variables, they are passed on.
+// Otherwise, a single image is passed on with an error that describes every
+// violation and unresolved variable, so that no registries are queried for
+// images that would be rejected. Outside of strict mode, a warning is
+// printed for each unresolved variable.
+func (i *imagePolicyChecker) CheckImages(
+	images <-chan parse.IImage,
+	done <-chan struct{},
+) <-chan parse.IImage {
+	if images == nil {
+		return nil
+	}
+
+	var (
+		waitGroup     sync.WaitGroup
+		checkedImages = make(chan parse.IImage)
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		var (
+			allImages  []parse.IImage
+			violations []string
+			unresolved []string
+			seen       = map[string]bool{}
+		)
+
+		for image := range images {
+			if image.Err() != nil {
+				select {
+				case <-done:
+				case checkedImages <- image:
+				}
+
+				return
+			}
+
+			for _, violation := range i.checker.CheckImage(image) {
+				violations = append(violations, violation.String())
+			}
+
+			// Dockerfiles may be parsed more than once, such as when
+			// referenced by several services in a docker-compose file.
+			for _, variable := range parse.UnresolvedVariables(image) {
+				if seen[variable.String()] {
+					continue
+				}
+
+				seen[variable.String()] = true
+
+				if !i.strict {
+					fmt.Printf(
+						"warning: %s, so it expands to an empty string\n",
+						variable,
+					)
+
+					continue
+				}
+
+				unresolved = append(unresolved, variable.String())
+			}
+
+			allImages = append(allImages, image)
+		}
+
+		var errMsgs []string
+
+		if len(violations) != 0 {
+			errMsgs = append(errMsgs, fmt.Sprintf(
+				"%d policy violation(s) found:\n%s",
+				len(violations), strings.Join(violations, "\n"),
+			))
+		}
+
+		if len(unresolved) != 0 {
+			errMsgs = append(errMsgs, fmt.Sprintf(
+				"%d unresolved variable(s) found:\n%s",
+				len(unresolved), strings.Join(unresolved, "\n"),
+			))
+		}
+
+		if len(errMsgs) != 0 {
+			select {
+			case <-done:
+			case checkedImages <- parse.NewImage(
+				allImages[0].Kind(), "", "", "", nil,
+				errors.New(strings.Join(errMsgs, "\n")),
+			):
+			}
+
+			return
+		}
+
+		for _, image := range allImages {
+			select {
+			case <-done:
+				return
+			case checkedImages <- image:
+			}
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(checkedImages)
+	}()
+
+	return checkedImages
+}
@@ -0,0 +1,184 @@
+package generate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+func TestImagePolicyChecker(t *testing.T) {
+	t.Parallel()
+
+	images := func() []parse.IImage {
+		return []parse.IImage{
+			parse.NewImage(
+				kind.Dockerfile, "redis", "6.2", "",
+				map[string]interface{}{
+					"position": 0,
+					"path":     "Dockerfile",
+				}, nil,
+			),
+			parse.NewImage(
+				kind.Composefile, "busybox", "latest", "",
+				map[string]interface{}{
+					"position":    0,
+					"path":        "docker-compose.yml",
+					"serviceName": "svc",
+				}, nil,
+			),
+			parse.NewImage(
+				kind.Kubernetesfile, "ghcr.io/org/app", "latest", "",
+				map[string]interface{}{
+					"path":          "pod.yml",
+					"containerName": "app",
+					"docPosition":   0,
+					"imagePosition": 0,
+				}, nil,
+			),
+		}
+	}
+
+	unresolvedImages := func() []parse.IImage {
+		unresolved := []*parse.UnresolvedVariable{
+			{Path: "Dockerfile", Line: 2, Name: "REGISTRY"},
+		}
+
+		return []parse.IImage{
+			parse.NewImage(
+				kind.Dockerfile, "redis", "6.2", "",
+				map[string]interface{}{
+					"position":            0,
+					"path":                "Dockerfile",
+					"unresolvedVariables": unresolved,
+				}, nil,
+			),
+			parse.NewImage(
+				kind.Composefile, "redis", "6.2", "",
+				map[string]interface{}{
+					"position":            0,
+					"path":                "docker-compose.yml",
+					"serviceName":         "svc",
+					"dockerfilePath":      "Dockerfile",
+					"unresolvedVariables": unresolved,
+				}, nil,
+			),
+		}
+	}
+
+	tests := []struct {
+		Name             string
+		Rules            *policy.Rules
+		Strict           bool
+		Images           []parse.IImage
+		Expected         []parse.IImage
+		ExpectedErrLines []string
+	}{
+		{
+			Name:     "No Violations",
+			Rules:    &policy.Rules{},
+			Images:   images(),
+			Expected: images(),
+		},
+		{
+			Name: "Violations",
+			Rules: &policy.Rules{
+				ForbidLatest:      true,
+				AllowedRegistries: []string{"docker.io"},
+			},
+			Images: images(),
+			ExpectedErrLines: []string{
+				"3 policy violation(s) found:",
+				"on path 'docker-compose.yml' of kind 'composefiles', " +
+					"service 'svc', image 'busybox:latest' violates rule " +
+					"'forbid-latest': the tag 'latest' is forbidden",
+				"on path 'pod.yml' of kind 'kubernetesfiles', " +
+					"container 'app', image 'ghcr.io/org/app:latest' " +
+					"violates rule 'allowed-registries': the registry " +
+					"'ghcr.io' is not allowed",
+				"on path 'pod.yml' of kind 'kubernetesfiles', " +
+					"container 'app', image 'ghcr.io/org/app:latest' " +
+					"violates rule 'forbid-latest': the tag 'latest' is " +
+					"forbidden",
+			},
+		},
+		{
+			Name:     "Unresolved Variables",
+			Rules:    &policy.Rules{},
+			Images:   unresolvedImages(),
+			Expected: unresolvedImages(),
+		},
+		{
+			Name:   "Strict Unresolved Variables",
+			Rules:  &policy.Rules{},
+			Strict: true,
+			Images: unresolvedImages(),
+			ExpectedErrLines: []string{
+				"1 unresolved variable(s) found:",
+				"'Dockerfile' line 2: variable 'REGISTRY' is not set",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			innerChecker, err := policy.NewImagePolicyChecker(test.Rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checker, err := generate.NewImagePolicyChecker(
+				innerChecker, test.Strict,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			defer close(done)
+
+			imagesToCheck := make(chan parse.IImage, len(test.Images))
+
+			for _, anyImage := range test.Images {
+				imagesToCheck <- anyImage
+			}
+			close(imagesToCheck)
+
+			var got []parse.IImage
+
+			for image := range checker.CheckImages(imagesToCheck, done) {
+				if image.Err() != nil {
+					if test.ExpectedErrLines == nil {
+						t.Fatal(image.Err())
+					}
+
+					expectedErr := strings.Join(test.ExpectedErrLines, "\n")
+					if image.Err().Error() != expectedErr {
+						t.Fatalf(
+							"expected error %s, got %s",
+							expectedErr, image.Err(),
+						)
+					}
+
+					return
+				}
+
+				got = append(got, image)
+			}
+
+			if test.ExpectedErrLines != nil {
+				t.Fatal("expected error but did not get one")
+			}
+
+			testutils.AssertImagesEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package format
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type bakefileImageFormatter struct {
+	kind kind.Kind
+}
+
+type formattedBakefileImage struct {
+	image          *lockfile.BakefileImage
+	targetPosition int
+}
+
+// NewBakefileImageFormatter returns an IImageFormatter for Bakefiles.
+func NewBakefileImageFormatter() IImageFormatter {
+	return &bakefileImageFormatter{kind: kind.Bakefile}
+}
+
+// Kind is a getter for the kind.
+func (b *bakefileImageFormatter) Kind() kind.Kind {
+	return b.kind
+}
+
+// FormatImages returns a Lockfile with the images, keyed by filepath and
+// sorted by target. Images of named contexts, which have no Dockerfile, are
+// sorted by context name before the images in the target's Dockerfile.
+func (b *bakefileImageFormatter) FormatImages(
+	images <-chan parse.IImage,
+) (*lockfile.Lockfile, error) {
+	if images == nil {
+		return nil, errors.New("'images' cannot be nil")
+	}
+
+	formattedImages := map[string][]*formattedBakefileImage{}
+
+	for image := range images {
+		if image.Err() != nil {
+			return nil, image.Err()
+		}
+
+		metadata := image.Metadata()
+		if metadata == nil {
+			return nil, errors.New("'metadata' cannot be nil")
+		}
+
+		path, ok := metadata["path"].(string)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'path' in bakefile image metadata",
+			)
+		}
+
+		path = filepath.ToSlash(path)
+
+		dockerfilePath, _ := metadata["dockerfilePath"].(string)
+		dockerfilePath = filepath.ToSlash(dockerfilePath)
+
+		targetName, ok := metadata["targetName"].(string)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'targetName' in bakefile image metadata",
+			)
+		}
+
+		targetPosition, ok := metadata["targetPosition"].(int)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'targetPosition' in bakefile image metadata",
+			)
+		}
+
+		contextName, _ := metadata["contextName"].(string)
+		instruction, _ := metadata["instruction"].(string)
+		platform, _ := metadata["platform"].(string)
+		platforms, _ := metadata["platforms"].([]*parse.PlatformDigest)
+
+		formattedImage := &formattedBakefileImage{
+			image: &lockfile.BakefileImage{
+				Name:           image.Name(),
+				Tag:            image.Tag(),
+				Digest:         image.Digest(),
+				Created:        created(metadata),
+				DockerfilePath: dockerfilePath,
+				Instruction:    instruction,
+				ContextName:    contextName,
+				TargetName:     targetName,
+				Platform:       platform,
+				Platforms:      platforms,
+			},
+			targetPosition: targetPosition,
+		}
+
+		formattedImages[path] = append(formattedImages[path], formattedImage)
+	}
+
+	var waitGroup sync.WaitGroup
+
+	for _, images := range formattedImages {
+		images := images
+
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			sort.Slice(images, func(i, j int) bool {
+				image1 := images[i]
+				image2 := images[j]
+
+				switch {
+				case image1.image.TargetName != image2.image.TargetName:
+					return image1.image.TargetName < image2.image.TargetName
+				case image1.image.DockerfilePath != image2.image.DockerfilePath:
+					return image1.image.DockerfilePath < image2.image.DockerfilePath
+				case image1.image.ContextName != image2.image.ContextName:
+					return image1.image.ContextName < image2.image.ContextName
+				default:
+					return image1.targetPosition < image2.targetPosition
+				}
+			})
+		}()
+	}
+
+	waitGroup.Wait()
+
+	l := lockfile.New()
+
+	for path, images := range formattedImages {
+		l.Bakefiles[path] = make([]*lockfile.BakefileImage, len(images))
+
+		for i, image := range images {
+			l.Bakefiles[path][i] = image.image
+		}
+	}
+
+	return l, nil
+}
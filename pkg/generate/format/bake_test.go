@@ -0,0 +1,156 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/format"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+func TestBakefileImageFormatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Images   []parse.IImage
+		Expected map[string][]*lockfile.BakefileImage
+	}{
+		{
+			Name: "Sort Bakefile Images",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "redis", "latest", "",
+					map[string]interface{}{
+						"targetPosition": 1,
+						"path":           "docker-bake.hcl",
+						"targetName":     "app",
+						"dockerfilePath": "Dockerfile",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "golang", "latest",
+					testutils.GolangLatestSHA, map[string]interface{}{
+						"targetPosition": 0,
+						"path":           "docker-bake.hcl",
+						"targetName":     "app",
+						"dockerfilePath": "Dockerfile",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "node", "latest", "",
+					map[string]interface{}{
+						"targetPosition": 0,
+						"path":           "docker-bake.hcl",
+						"targetName":     "app",
+						"contextName":    "node",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "alpine", "3.14", "",
+					map[string]interface{}{
+						"targetPosition": 0,
+						"path":           "docker-bake.hcl",
+						"targetName":     "app",
+						"contextName":    "alpine",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "busybox", "latest", "",
+					map[string]interface{}{
+						"targetPosition": 0,
+						"path":           "docker-bake.json",
+						"targetName":     "base",
+						"dockerfilePath": "Dockerfile",
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.BakefileImage{
+				"docker-bake.hcl": {
+					{
+						Name:        "alpine",
+						Tag:         "3.14",
+						Digest:      "",
+						ContextName: "alpine",
+						TargetName:  "app",
+					},
+					{
+						Name:        "node",
+						Tag:         "latest",
+						Digest:      "",
+						ContextName: "node",
+						TargetName:  "app",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         testutils.GolangLatestSHA,
+						DockerfilePath: "Dockerfile",
+						TargetName:     "app",
+					},
+					{
+						Name:           "redis",
+						Tag:            "latest",
+						Digest:         "",
+						DockerfilePath: "Dockerfile",
+						TargetName:     "app",
+					},
+				},
+				"docker-bake.json": {
+					{
+						Name:           "busybox",
+						Tag:            "latest",
+						Digest:         "",
+						DockerfilePath: "Dockerfile",
+						TargetName:     "base",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests { // nolint: dupl
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			formatter := format.NewBakefileImageFormatter()
+
+			images := make(chan parse.IImage, len(test.Images))
+
+			for _, image := range test.Images {
+				images <- image
+			}
+			close(images)
+
+			formattedLockfile, err := formatter.FormatImages(images)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(
+				formattedLockfile.Bakefiles, "", "\t",
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(expectedByt, gotByt) {
+				t.Fatalf(
+					"expected %s\ngot %s",
+					string(expectedByt), string(gotByt),
+				)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type composefileImageFormatter struct {
@@ -15,11 +16,7 @@ type composefileImageFormatter struct {
 }
 
 type formattedComposefileImage struct {
-	Name            string `json:"name"`
-	Tag             string `json:"tag"`
-	Digest          string `json:"digest"`
-	DockerfilePath  string `json:"dockerfile,omitempty"`
-	ServiceName     string `json:"service"`
+	image           *lockfile.ComposefileImage
 	servicePosition int
 }
 
@@ -33,16 +30,16 @@ func (c *composefileImageFormatter) Kind() kind.Kind {
 	return c.kind
 }
 
-// FormatImages returns a map with a key of filepath and a slice of images
-// formatted for a Lockfile.
+// FormatImages returns a Lockfile with the images, keyed by filepath and
+// sorted by their position in the file.
 func (c *composefileImageFormatter) FormatImages(
 	images <-chan parse.IImage,
-) (map[string][]interface{}, error) {
+) (*lockfile.Lockfile, error) {
 	if images == nil {
 		return nil, errors.New("'images' cannot be nil")
 	}
 
-	formattedImages := map[string][]interface{}{}
+	formattedImages := map[string][]*formattedComposefileImage{}
 
 	for image := range images {
 		if image.Err() != nil {
@@ -80,12 +77,22 @@ func (c *composefileImageFormatter) FormatImages(
 			)
 		}
 
+		instruction, _ := metadata["instruction"].(string)
+		platform, _ := metadata["platform"].(string)
+		platforms, _ := metadata["platforms"].([]*parse.PlatformDigest)
+
 		formattedImage := &formattedComposefileImage{
-			Name:            image.Name(),
-			Tag:             image.Tag(),
-			Digest:          image.Digest(),
-			DockerfilePath:  dockerfilePath,
-			ServiceName:     serviceName,
+			image: &lockfile.ComposefileImage{
+				Name:           image.Name(),
+				Tag:            image.Tag(),
+				Digest:         image.Digest(),
+				Created:        created(metadata),
+				DockerfilePath: dockerfilePath,
+				Instruction:    instruction,
+				ServiceName:    serviceName,
+				Platform:       platform,
+				Platforms:      platforms,
+			},
 			servicePosition: servicePosition,
 		}
 
@@ -103,14 +110,14 @@ func (c *composefileImageFormatter) FormatImages(
 			defer waitGroup.Done()
 
 			sort.Slice(images, func(i, j int) bool {
-				image1 := images[i].(*formattedComposefileImage)
-				image2 := images[j].(*formattedComposefileImage)
+				image1 := images[i]
+				image2 := images[j]
 
 				switch {
-				case image1.ServiceName != image2.ServiceName:
-					return image1.ServiceName < image2.ServiceName
-				case image1.DockerfilePath != image2.DockerfilePath:
-					return image1.DockerfilePath < image2.DockerfilePath
+				case image1.image.ServiceName != image2.image.ServiceName:
+					return image1.image.ServiceName < image2.image.ServiceName
+				case image1.image.DockerfilePath != image2.image.DockerfilePath:
+					return image1.image.DockerfilePath < image2.image.DockerfilePath
 				default:
 					return image1.servicePosition < image2.servicePosition
 				}
@@ -120,5 +127,15 @@ func (c *composefileImageFormatter) FormatImages(
 
 	waitGroup.Wait()
 
-	return formattedImages, nil
+	l := lockfile.New()
+
+	for path, images := range formattedImages {
+		l.Composefiles[path] = make([]*lockfile.ComposefileImage, len(images))
+
+		for i, image := range images {
+			l.Composefiles[path][i] = image.image
+		}
+	}
+
+	return l, nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 func TestComposefileImageFormatter(t *testing.T) {
@@ -17,7 +18,7 @@ func TestComposefileImageFormatter(t *testing.T) {
 	tests := []struct {
 		Name     string
 		Images   []parse.IImage
-		Expected map[string][]interface{}
+		Expected map[string][]*lockfile.ComposefileImage
 	}{
 		{
 			Name: "Sort Composefile Images",
@@ -57,35 +58,35 @@ func TestComposefileImageFormatter(t *testing.T) {
 					}, nil,
 				),
 			},
-			Expected: map[string][]interface{}{
+			Expected: map[string][]*lockfile.ComposefileImage{
 				"docker-compose-one.yml": {
-					map[string]string{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     testutils.GolangLatestSHA,
-						"dockerfile": "Dockerfile",
-						"service":    "anothersvc",
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         testutils.GolangLatestSHA,
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "anothersvc",
 					},
-					map[string]string{
-						"name":       "redis",
-						"tag":        "latest",
-						"digest":     "",
-						"dockerfile": "Dockerfile",
-						"service":    "anothersvc",
+					{
+						Name:           "redis",
+						Tag:            "latest",
+						Digest:         "",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "anothersvc",
 					},
-					map[string]string{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "",
-						"service": "svc",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "",
+						ServiceName: "svc",
 					},
 				},
 				"docker-compose-two.yml": {
-					map[string]string{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "",
-						"service": "svc",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "",
+						ServiceName: "svc",
 					},
 				},
 			},
@@ -107,22 +108,14 @@ func TestComposefileImageFormatter(t *testing.T) {
 			}
 			close(images)
 
-			formattedImages, err := formatter.FormatImages(images)
+			formattedLockfile, err := formatter.FormatImages(images)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			formattedByt, err := json.Marshal(formattedImages)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			got := map[string][]interface{}{}
-			if err = json.Unmarshal(formattedByt, &got); err != nil {
-				t.Fatal(err)
-			}
-
-			gotByt, err := json.MarshalIndent(got, "", "\t")
+			gotByt, err := json.MarshalIndent(
+				formattedLockfile.Composefiles, "", "\t",
+			)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,14 @@
+package format
+
+import "time"
+
+// created returns the time in the metadata under the key "created", or nil
+// if the time was not recorded.
+func created(metadata map[string]interface{}) *time.Time {
+	createdTime, ok := metadata["created"].(time.Time)
+	if !ok {
+		return nil
+	}
+
+	return &createdTime
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type dockerfileImageFormatter struct {
@@ -15,9 +16,7 @@ type dockerfileImageFormatter struct {
 }
 
 type formattedDockerfileImage struct {
-	Name     string `json:"name"`
-	Tag      string `json:"tag"`
-	Digest   string `json:"digest"`
+	image    *lockfile.DockerfileImage
 	position int
 }
 
@@ -31,16 +30,16 @@ func (d *dockerfileImageFormatter) Kind() kind.Kind {
 	return d.kind
 }
 
-// FormatImages returns a map with a key of filepath and a slice of images
-// formatted for a Lockfile.
+// FormatImages returns a Lockfile with the images, keyed by filepath and
+// sorted by their position in the file.
 func (d *dockerfileImageFormatter) FormatImages(
 	images <-chan parse.IImage,
-) (map[string][]interface{}, error) {
+) (*lockfile.Lockfile, error) {
 	if images == nil {
 		return nil, errors.New("'images' cannot be nil")
 	}
 
-	formattedImages := map[string][]interface{}{}
+	formattedImages := map[string][]*formattedDockerfileImage{}
 
 	for image := range images {
 		if image.Err() != nil {
@@ -64,10 +63,20 @@ func (d *dockerfileImageFormatter) FormatImages(
 			return nil, errors.New("malformed 'position' in dockerfile image")
 		}
 
+		instruction, _ := metadata["instruction"].(string)
+		platform, _ := metadata["platform"].(string)
+		platforms, _ := metadata["platforms"].([]*parse.PlatformDigest)
+
 		formattedImage := &formattedDockerfileImage{
-			Name:     image.Name(),
-			Tag:      image.Tag(),
-			Digest:   image.Digest(),
+			image: &lockfile.DockerfileImage{
+				Name:        image.Name(),
+				Tag:         image.Tag(),
+				Digest:      image.Digest(),
+				Created:     created(metadata),
+				Instruction: instruction,
+				Platform:    platform,
+				Platforms:   platforms,
+			},
 			position: position,
 		}
 
@@ -85,8 +94,8 @@ func (d *dockerfileImageFormatter) FormatImages(
 			defer waitGroup.Done()
 
 			sort.Slice(images, func(i int, j int) bool {
-				image1 := images[i].(*formattedDockerfileImage)
-				image2 := images[j].(*formattedDockerfileImage)
+				image1 := images[i]
+				image2 := images[j]
 
 				return image1.position < image2.position
 			})
@@ -95,5 +104,15 @@ func (d *dockerfileImageFormatter) FormatImages(
 
 	waitGroup.Wait()
 
-	return formattedImages, nil
+	l := lockfile.New()
+
+	for path, images := range formattedImages {
+		l.Dockerfiles[path] = make([]*lockfile.DockerfileImage, len(images))
+
+		for i, image := range images {
+			l.Dockerfiles[path][i] = image.image
+		}
+	}
+
+	return l, nil
 }
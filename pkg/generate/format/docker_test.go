@@ -4,19 +4,23 @@ import (
 	"bytes"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 func TestDockerfileImageFormatter(t *testing.T) {
 	t.Parallel()
 
+	created := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		Name     string
 		Images   []parse.IImage
-		Expected map[string][]interface{}
+		Expected map[string][]*lockfile.DockerfileImage
 	}{
 		{
 			Name: "Sort Dockerfile Images",
@@ -50,29 +54,145 @@ func TestDockerfileImageFormatter(t *testing.T) {
 					}, nil,
 				),
 			},
-			Expected: map[string][]interface{}{
+			Expected: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile1": {
-					map[string]string{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "",
 					},
-					map[string]string{
-						"name":   "golang",
-						"tag":    "latest",
-						"digest": "",
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "",
 					},
-					map[string]string{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "",
 					},
 				},
 				"Dockerfile2": {
-					map[string]string{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "",
+					},
+				},
+			},
+		},
+		{
+			Name: "Platform",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "busybox",
+					map[string]interface{}{
+						"position": 0,
+						"path":     "Dockerfile",
+						"platform": "linux/arm64",
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:     "busybox",
+						Tag:      "latest",
+						Digest:   "busybox",
+						Platform: "linux/arm64",
+					},
+				},
+			},
+		},
+		{
+			Name: "Instruction",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "busybox",
+					map[string]interface{}{
+						"position":    0,
+						"path":        "Dockerfile",
+						"instruction": lockfile.CopyInstruction,
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						Instruction: lockfile.CopyInstruction,
+					},
+				},
+			},
+		},
+		{
+			Name: "Created",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "busybox",
+					map[string]interface{}{
+						"position": 0,
+						"path":     "Dockerfile",
+						"created":  created,
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:    "busybox",
+						Tag:     "latest",
+						Digest:  "busybox",
+						Created: &created,
+					},
+				},
+			},
+		},
+		{
+			Name: "Platforms",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "busybox",
+					map[string]interface{}{
+						"position": 0,
+						"path":     "Dockerfile",
+						"platforms": []*parse.PlatformDigest{
+							{
+								OS:           "linux",
+								Architecture: "amd64",
+								Digest:       "amd64",
+							},
+							{
+								OS:           "linux",
+								Architecture: "arm64",
+								Variant:      "v8",
+								Digest:       "arm64",
+							},
+						},
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Platforms: []*lockfile.PlatformDigest{
+							{
+								OS:           "linux",
+								Architecture: "amd64",
+								Digest:       "amd64",
+							},
+							{
+								OS:           "linux",
+								Architecture: "arm64",
+								Variant:      "v8",
+								Digest:       "arm64",
+							},
+						},
 					},
 				},
 			},
@@ -94,22 +214,14 @@ func TestDockerfileImageFormatter(t *testing.T) {
 			}
 			close(images)
 
-			formattedImages, err := formatter.FormatImages(images)
+			formattedLockfile, err := formatter.FormatImages(images)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			formattedByt, err := json.Marshal(formattedImages)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			got := map[string][]interface{}{}
-			if err = json.Unmarshal(formattedByt, &got); err != nil {
-				t.Fatal(err)
-			}
-
-			gotByt, err := json.MarshalIndent(got, "", "\t")
+			gotByt, err := json.MarshalIndent(
+				formattedLockfile.Dockerfiles, "", "\t",
+			)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,117 @@
+package format
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type helmchartImageFormatter struct {
+	kind kind.Kind
+}
+
+type formattedHelmchartImage struct {
+	image    *lockfile.HelmchartImage
+	position int
+}
+
+// NewHelmchartImageFormatter returns an IImageFormatter for Helm charts.
+func NewHelmchartImageFormatter() IImageFormatter {
+	return &helmchartImageFormatter{kind: kind.Helmchart}
+}
+
+// Kind is a getter for the kind.
+func (h *helmchartImageFormatter) Kind() kind.Kind {
+	return h.kind
+}
+
+// FormatImages returns a Lockfile with the images, keyed by filepath and
+// sorted by their position in the file.
+func (h *helmchartImageFormatter) FormatImages(
+	images <-chan parse.IImage,
+) (*lockfile.Lockfile, error) {
+	if images == nil {
+		return nil, errors.New("'images' cannot be nil")
+	}
+
+	formattedImages := map[string][]*formattedHelmchartImage{}
+
+	for image := range images {
+		if image.Err() != nil {
+			return nil, image.Err()
+		}
+
+		metadata := image.Metadata()
+		if metadata == nil {
+			return nil, errors.New("'metadata' cannot be nil")
+		}
+
+		path, ok := metadata["path"].(string)
+		if !ok {
+			return nil, errors.New("malformed 'path' in helmchart image")
+		}
+
+		path = filepath.ToSlash(path)
+
+		position, ok := metadata["position"].(int)
+		if !ok {
+			return nil, errors.New("malformed 'position' in helmchart image")
+		}
+
+		key, _ := metadata["key"].(string)
+
+		platforms, _ := metadata["platforms"].([]*parse.PlatformDigest)
+
+		formattedImage := &formattedHelmchartImage{
+			image: &lockfile.HelmchartImage{
+				Name:      image.Name(),
+				Tag:       image.Tag(),
+				Digest:    image.Digest(),
+				Created:   created(metadata),
+				Key:       key,
+				Platforms: platforms,
+			},
+			position: position,
+		}
+
+		formattedImages[path] = append(formattedImages[path], formattedImage)
+	}
+
+	var waitGroup sync.WaitGroup
+
+	for _, images := range formattedImages {
+		images := images
+
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			sort.Slice(images, func(i int, j int) bool {
+				image1 := images[i]
+				image2 := images[j]
+
+				return image1.position < image2.position
+			})
+		}()
+	}
+
+	waitGroup.Wait()
+
+	l := lockfile.New()
+
+	for path, images := range formattedImages {
+		l.Helmcharts[path] = make([]*lockfile.HelmchartImage, len(images))
+
+		for i, image := range images {
+			l.Helmcharts[path][i] = image.image
+		}
+	}
+
+	return l, nil
+}
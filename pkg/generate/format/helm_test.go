@@ -0,0 +1,115 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/format"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+func TestHelmchartImageFormatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Images   []parse.IImage
+		Expected map[string][]*lockfile.HelmchartImage
+	}{
+		{
+			Name: "Sort Helmchart Images",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "redis", "latest", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 1,
+						"key":      "redis.image",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Helmchart, "golang", "latest", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 0,
+						"key":      "image",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Helmchart, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "templates/pod.yaml",
+						"position": 0,
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "",
+						Key:    "image",
+					},
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "",
+						Key:    "redis.image",
+					},
+				},
+				"templates/pod.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests { // nolint: dupl
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			formatter := format.NewHelmchartImageFormatter()
+
+			images := make(chan parse.IImage, len(test.Images))
+
+			for _, image := range test.Images {
+				images <- image
+			}
+			close(images)
+
+			formattedLockfile, err := formatter.FormatImages(images)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(
+				formattedLockfile.Helmcharts, "", "\t",
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(expectedByt, gotByt) {
+				t.Fatalf(
+					"expected %s\ngot %s",
+					string(expectedByt), string(gotByt),
+				)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type kubernetesfileImageFormatter struct {
@@ -15,10 +16,7 @@ type kubernetesfileImageFormatter struct {
 }
 
 type formattedKubernetesfileImage struct {
-	Name          string `json:"name"`
-	Tag           string `json:"tag"`
-	Digest        string `json:"digest"`
-	ContainerName string `json:"container"`
+	image         *lockfile.KubernetesfileImage
 	imagePosition int
 	docPosition   int
 }
@@ -34,16 +32,16 @@ func (k *kubernetesfileImageFormatter) Kind() kind.Kind {
 	return k.kind
 }
 
-// FormatImages returns a map with a key of filepath and a slice of images
-// formatted for a Lockfile.
+// FormatImages returns a Lockfile with the images, keyed by filepath and
+// sorted by their position in the file.
 func (k *kubernetesfileImageFormatter) FormatImages(
 	images <-chan parse.IImage,
-) (map[string][]interface{}, error) {
+) (*lockfile.Lockfile, error) {
 	if images == nil {
 		return nil, errors.New("'images' cannot be nil")
 	}
 
-	formattedImages := map[string][]interface{}{}
+	formattedImages := map[string][]*formattedKubernetesfileImage{}
 
 	for image := range images {
 		if image.Err() != nil {
@@ -83,11 +81,17 @@ func (k *kubernetesfileImageFormatter) FormatImages(
 			)
 		}
 
+		platforms, _ := metadata["platforms"].([]*parse.PlatformDigest)
+
 		formattedImage := &formattedKubernetesfileImage{
-			Name:          image.Name(),
-			Tag:           image.Tag(),
-			Digest:        image.Digest(),
-			ContainerName: containerName,
+			image: &lockfile.KubernetesfileImage{
+				Name:          image.Name(),
+				Tag:           image.Tag(),
+				Digest:        image.Digest(),
+				Created:       created(metadata),
+				ContainerName: containerName,
+				Platforms:     platforms,
+			},
 			imagePosition: imagePosition,
 			docPosition:   docPosition,
 		}
@@ -106,8 +110,8 @@ func (k *kubernetesfileImageFormatter) FormatImages(
 			defer waitGroup.Done()
 
 			sort.Slice(images, func(i, j int) bool {
-				image1 := images[i].(*formattedKubernetesfileImage)
-				image2 := images[j].(*formattedKubernetesfileImage)
+				image1 := images[i]
+				image2 := images[j]
 
 				switch {
 				case image1.docPosition != image2.docPosition:
@@ -121,5 +125,15 @@ func (k *kubernetesfileImageFormatter) FormatImages(
 
 	waitGroup.Wait()
 
-	return formattedImages, nil
+	l := lockfile.New()
+
+	for path, images := range formattedImages {
+		l.Kubernetesfiles[path] = make([]*lockfile.KubernetesfileImage, len(images))
+
+		for i, image := range images {
+			l.Kubernetesfiles[path][i] = image.image
+		}
+	}
+
+	return l, nil
 }
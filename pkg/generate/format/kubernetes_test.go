@@ -8,6 +8,7 @@ import (
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 func TestKubernetesfileImageFormatter(t *testing.T) {
@@ -16,7 +17,7 @@ func TestKubernetesfileImageFormatter(t *testing.T) {
 	tests := []struct {
 		Name     string
 		Images   []parse.IImage
-		Expected map[string][]interface{}
+		Expected map[string][]*lockfile.KubernetesfileImage
 	}{
 		{
 			Name: "Sort Kubernetesfile Images",
@@ -58,33 +59,33 @@ func TestKubernetesfileImageFormatter(t *testing.T) {
 					}, nil,
 				),
 			},
-			Expected: map[string][]interface{}{
+			Expected: map[string][]*lockfile.KubernetesfileImage{
 				"pod.yml": {
-					map[string]string{
-						"name":      "golang",
-						"tag":       "latest",
-						"digest":    "",
-						"container": "golang",
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "",
+						ContainerName: "golang",
 					},
-					map[string]string{
-						"name":      "redis",
-						"tag":       "latest",
-						"digest":    "",
-						"container": "redis",
+					{
+						Name:          "redis",
+						Tag:           "latest",
+						Digest:        "",
+						ContainerName: "redis",
 					},
-					map[string]string{
-						"name":      "busybox",
-						"tag":       "latest",
-						"digest":    "",
-						"container": "busybox",
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "",
+						ContainerName: "busybox",
 					},
 				},
 				"deployment.yml": {
-					map[string]string{
-						"name":      "golang",
-						"tag":       "latest",
-						"digest":    "",
-						"container": "golang",
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "",
+						ContainerName: "golang",
 					},
 				},
 			},
@@ -106,22 +107,14 @@ func TestKubernetesfileImageFormatter(t *testing.T) {
 			}
 			close(images)
 
-			formattedImages, err := formatter.FormatImages(images)
+			formattedLockfile, err := formatter.FormatImages(images)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			formattedByt, err := json.Marshal(formattedImages)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			got := map[string][]interface{}{}
-			if err = json.Unmarshal(formattedByt, &got); err != nil {
-				t.Fatal(err)
-			}
-
-			gotByt, err := json.MarshalIndent(got, "", "\t")
+			gotByt, err := json.MarshalIndent(
+				formattedLockfile.Kubernetesfiles, "", "\t",
+			)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,153 @@
+package format
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type kustomizationImageFormatter struct {
+	kind kind.Kind
+}
+
+type formattedKustomizationImage struct {
+	image         *lockfile.KustomizationImage
+	docPosition   int
+	imagePosition int
+}
+
+// NewKustomizationImageFormatter returns an IImageFormatter for
+// Kustomizations.
+func NewKustomizationImageFormatter() IImageFormatter {
+	return &kustomizationImageFormatter{kind: kind.Kustomization}
+}
+
+// Kind is a getter for the kind.
+func (k *kustomizationImageFormatter) Kind() kind.Kind {
+	return k.kind
+}
+
+// FormatImages returns a Lockfile with the images, keyed by filepath and
+// sorted by their position in the file.
+func (k *kustomizationImageFormatter) FormatImages(
+	images <-chan parse.IImage,
+) (*lockfile.Lockfile, error) {
+	if images == nil {
+		return nil, errors.New("'images' cannot be nil")
+	}
+
+	formattedImages := map[string][]*formattedKustomizationImage{}
+
+	for image := range images {
+		if image.Err() != nil {
+			return nil, image.Err()
+		}
+
+		metadata := image.Metadata()
+		if metadata == nil {
+			return nil, errors.New("'metadata' cannot be nil")
+		}
+
+		path, ok := metadata["path"].(string)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'path' in kustomization image metadata",
+			)
+		}
+
+		path = filepath.ToSlash(path)
+
+		manifestPath, ok := metadata["manifestPath"].(string)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'manifestPath' in kustomization image metadata",
+			)
+		}
+
+		manifestPath = filepath.ToSlash(manifestPath)
+
+		containerName, ok := metadata["containerName"].(string)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'containerName' in kustomization image metadata",
+			)
+		}
+
+		docPosition, ok := metadata["docPosition"].(int)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'docPosition' in kustomization image metadata",
+			)
+		}
+
+		imagePosition, ok := metadata["imagePosition"].(int)
+		if !ok {
+			return nil, errors.New(
+				"malformed 'imagePosition' in kustomization image metadata",
+			)
+		}
+
+		platforms, _ := metadata["platforms"].([]*parse.PlatformDigest)
+
+		formattedImage := &formattedKustomizationImage{
+			image: &lockfile.KustomizationImage{
+				Name:          image.Name(),
+				Tag:           image.Tag(),
+				Digest:        image.Digest(),
+				Created:       created(metadata),
+				ManifestPath:  manifestPath,
+				ContainerName: containerName,
+				Platforms:     platforms,
+			},
+			docPosition:   docPosition,
+			imagePosition: imagePosition,
+		}
+
+		formattedImages[path] = append(formattedImages[path], formattedImage)
+	}
+
+	var waitGroup sync.WaitGroup
+
+	for _, images := range formattedImages {
+		images := images
+
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			sort.Slice(images, func(i, j int) bool {
+				image1 := images[i]
+				image2 := images[j]
+
+				switch {
+				case image1.image.ManifestPath != image2.image.ManifestPath:
+					return image1.image.ManifestPath < image2.image.ManifestPath
+				case image1.docPosition != image2.docPosition:
+					return image1.docPosition < image2.docPosition
+				default:
+					return image1.imagePosition < image2.imagePosition
+				}
+			})
+		}()
+	}
+
+	waitGroup.Wait()
+
+	l := lockfile.New()
+
+	for path, images := range formattedImages {
+		l.Kustomizations[path] = make([]*lockfile.KustomizationImage, len(images))
+
+		for i, image := range images {
+			l.Kustomizations[path][i] = image.image
+		}
+	}
+
+	return l, nil
+}
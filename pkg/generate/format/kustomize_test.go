@@ -0,0 +1,124 @@
+package format_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/format"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+func TestKustomizationImageFormatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Images   []parse.IImage
+		Expected map[string][]*lockfile.KustomizationImage
+	}{
+		{
+			Name: "Sort Kustomization Images",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Kustomization, "redis", "latest", "",
+					map[string]interface{}{
+						"path":          "overlays/prod/kustomization.yaml",
+						"manifestPath":  "base/pod.yaml",
+						"containerName": "redis",
+						"docPosition":   0,
+						"imagePosition": 1,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Kustomization, "golang", "latest", "",
+					map[string]interface{}{
+						"path":          "overlays/prod/kustomization.yaml",
+						"manifestPath":  "base/pod.yaml",
+						"containerName": "golang",
+						"docPosition":   0,
+						"imagePosition": 0,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Kustomization, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":          "overlays/prod/kustomization.yaml",
+						"manifestPath":  "base/deployment.yaml",
+						"containerName": "busybox",
+						"docPosition":   0,
+						"imagePosition": 0,
+					}, nil,
+				),
+			},
+			Expected: map[string][]*lockfile.KustomizationImage{
+				"overlays/prod/kustomization.yaml": {
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "",
+						ManifestPath:  "base/deployment.yaml",
+						ContainerName: "busybox",
+					},
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "golang",
+					},
+					{
+						Name:          "redis",
+						Tag:           "latest",
+						Digest:        "",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "redis",
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests { // nolint: dupl
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			formatter := format.NewKustomizationImageFormatter()
+
+			images := make(chan parse.IImage, len(test.Images))
+
+			for _, image := range test.Images {
+				images <- image
+			}
+			close(images)
+
+			formattedLockfile, err := formatter.FormatImages(images)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(
+				formattedLockfile.Kustomizations, "", "\t",
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(expectedByt, gotByt) {
+				t.Fatalf(
+					"expected %s\ngot %s",
+					string(expectedByt), string(gotByt),
+				)
+			}
+		})
+	}
+}
@@ -4,11 +4,13 @@ package format
 import (
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 // IImageFormatter provides an interface for ImageFormatters, which
-// ensure images are properly formatted for a Lockfile.
+// ensure images are properly formatted for a Lockfile. The Lockfile
+// returned by FormatImages only has images of the formatter's kind.
 type IImageFormatter interface {
 	Kind() kind.Kind
-	FormatImages(images <-chan parse.IImage) (map[string][]interface{}, error)
+	FormatImages(images <-chan parse.IImage) (*lockfile.Lockfile, error)
 }
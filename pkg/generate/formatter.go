@@ -9,6 +9,7 @@ import (
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type imageFormatter struct {
@@ -16,9 +17,8 @@ type imageFormatter struct {
 }
 
 type formattedResult struct {
-	kind            kind.Kind
-	formattedImages map[string][]interface{}
-	err             error
+	lockfile *lockfile.Lockfile
+	err      error
 }
 
 // NewImageFormatter creates an IImageFormatter from IImageFormatters for
@@ -46,7 +46,7 @@ func NewImageFormatter(
 func (i *imageFormatter) FormatImages(
 	images <-chan parse.IImage,
 	done <-chan struct{},
-) (map[kind.Kind]map[string][]interface{}, error) {
+) (*lockfile.Lockfile, error) {
 	if images == nil {
 		return nil, errors.New("'images' cannot be nil")
 	}
@@ -108,7 +108,7 @@ func (i *imageFormatter) FormatImages(
 		go func() {
 			defer waitGroup.Done()
 
-			formattedImages, err := i.formatters[kind].FormatImages(images)
+			formattedLockfile, err := i.formatters[kind].FormatImages(images)
 			if err != nil {
 				select {
 				case <-done:
@@ -118,13 +118,11 @@ func (i *imageFormatter) FormatImages(
 				return
 			}
 
-			if len(formattedImages) > 0 {
-				select {
-				case <-done:
-				case formattedResults <- &formattedResult{
-					kind: kind, formattedImages: formattedImages,
-				}:
-				}
+			select {
+			case <-done:
+			case formattedResults <- &formattedResult{
+				lockfile: formattedLockfile,
+			}:
 			}
 		}()
 	}
@@ -134,16 +132,43 @@ func (i *imageFormatter) FormatImages(
 		close(formattedResults)
 	}()
 
-	formattedKindImages := map[kind.Kind]map[string][]interface{}{}
+	formattedLockfile := lockfile.New()
 
 	for formattedResult := range formattedResults {
 		if formattedResult.err != nil {
 			return nil, formattedResult.err
 		}
 
-		kind := formattedResult.kind
-		formattedKindImages[kind] = formattedResult.formattedImages
+		mergeLockfile(formattedLockfile, formattedResult.lockfile)
+	}
+
+	return formattedLockfile, nil
+}
+
+// mergeLockfile adds the paths of every kind in src to dst. Each formatter
+// only returns images of its own kind, so paths are never overwritten.
+func mergeLockfile(dst *lockfile.Lockfile, src *lockfile.Lockfile) {
+	for path, images := range src.Dockerfiles {
+		dst.Dockerfiles[path] = images
 	}
 
-	return formattedKindImages, nil
+	for path, images := range src.Composefiles {
+		dst.Composefiles[path] = images
+	}
+
+	for path, images := range src.Kubernetesfiles {
+		dst.Kubernetesfiles[path] = images
+	}
+
+	for path, images := range src.Helmcharts {
+		dst.Helmcharts[path] = images
+	}
+
+	for path, images := range src.Kustomizations {
+		dst.Kustomizations[path] = images
+	}
+
+	for path, images := range src.Bakefiles {
+		dst.Bakefiles[path] = images
+	}
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 func TestImageFormatter(t *testing.T) {
@@ -18,7 +19,7 @@ func TestImageFormatter(t *testing.T) {
 	tests := []struct {
 		Name     string
 		Images   []parse.IImage
-		Expected map[string]map[string][]interface{}
+		Expected *lockfile.Lockfile
 	}{
 		{
 			Name: "All Images",
@@ -122,92 +123,93 @@ func TestImageFormatter(t *testing.T) {
 					}, nil,
 				),
 			},
-			Expected: map[string]map[string][]interface{}{
-				"dockerfiles": {
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
 					"Dockerfile1": {
-						map[string]string{
-							"name":   "redis",
-							"tag":    "latest",
-							"digest": "",
+						{
+							Name:   "redis",
+							Tag:    "latest",
+							Digest: "",
 						},
-						map[string]string{
-							"name":   "golang",
-							"tag":    "latest",
-							"digest": "",
+						{
+							Name:   "golang",
+							Tag:    "latest",
+							Digest: "",
 						},
-						map[string]string{
-							"name":   "busybox",
-							"tag":    "latest",
-							"digest": "",
+						{
+							Name:   "busybox",
+							Tag:    "latest",
+							Digest: "",
 						},
 					},
 					"Dockerfile2": {
-						map[string]string{
-							"name":   "busybox",
-							"tag":    "latest",
-							"digest": "",
+						{
+							Name:   "busybox",
+							Tag:    "latest",
+							Digest: "",
 						},
 					},
 				},
-				"composefiles": {
+				Composefiles: map[string][]*lockfile.ComposefileImage{
 					"docker-compose-one.yml": {
-						map[string]string{
-							"name":       "golang",
-							"tag":        "latest",
-							"digest":     testutils.GolangLatestSHA,
-							"dockerfile": "Dockerfile",
-							"service":    "anothersvc",
+						{
+							Name:           "golang",
+							Tag:            "latest",
+							Digest:         testutils.GolangLatestSHA,
+							DockerfilePath: "Dockerfile",
+							ServiceName:    "anothersvc",
 						},
-						map[string]string{
-							"name":       "redis",
-							"tag":        "latest",
-							"digest":     "",
-							"dockerfile": "Dockerfile",
-							"service":    "anothersvc",
+						{
+							Name:           "redis",
+							Tag:            "latest",
+							Digest:         "",
+							DockerfilePath: "Dockerfile",
+							ServiceName:    "anothersvc",
 						},
-						map[string]string{
-							"name":    "busybox",
-							"tag":     "latest",
-							"digest":  "",
-							"service": "svc",
+						{
+							Name:        "busybox",
+							Tag:         "latest",
+							Digest:      "",
+							ServiceName: "svc",
 						},
 					},
 					"docker-compose-two.yml": {
-						map[string]string{
-							"name":    "busybox",
-							"tag":     "latest",
-							"digest":  "",
-							"service": "svc",
+						{
+							Name:        "busybox",
+							Tag:         "latest",
+							Digest:      "",
+							ServiceName: "svc",
 						},
 					},
 				},
-				"kubernetesfiles": {
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
 					"pod.yml": {
-						map[string]string{
-							"name":      "golang",
-							"tag":       "latest",
-							"digest":    "",
-							"container": "golang",
+						{
+							Name:          "golang",
+							Tag:           "latest",
+							Digest:        "",
+							ContainerName: "golang",
 						},
-						map[string]string{
-							"name":      "redis",
-							"tag":       "latest",
-							"digest":    "",
-							"container": "redis",
+						{
+							Name:          "redis",
+							Tag:           "latest",
+							Digest:        "",
+							ContainerName: "redis",
 						},
-						map[string]string{
-							"name":      "busybox",
-							"tag":       "latest",
-							"digest":    "",
-							"container": "busybox",
+						{
+							Name:          "busybox",
+							Tag:           "latest",
+							Digest:        "",
+							ContainerName: "busybox",
 						},
 					},
 					"deployment.yml": {
-						map[string]string{
-							"name":      "golang",
-							"tag":       "latest",
-							"digest":    "",
-							"container": "golang",
+						{
+							Name:          "golang",
+							Tag:           "latest",
+							Digest:        "",
+							ContainerName: "golang",
 						},
 					},
 				},
@@ -242,22 +244,12 @@ func TestImageFormatter(t *testing.T) {
 			done := make(chan struct{})
 			defer close(done)
 
-			formattedImages, err := formatter.FormatImages(images, done)
+			formattedLockfile, err := formatter.FormatImages(images, done)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			formattedByt, err := json.Marshal(formattedImages)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			got := map[string]map[string][]interface{}{}
-			if err = json.Unmarshal(formattedByt, &got); err != nil {
-				t.Fatal(err)
-			}
-
-			gotByt, err := json.MarshalIndent(got, "", "\t")
+			gotByt, err := json.MarshalIndent(formattedLockfile, "", "\t")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1,7 +1,6 @@
 package generate
 
 import (
-	"encoding/json"
 	"errors"
 	"io"
 	"reflect"
@@ -10,6 +9,7 @@ import (
 type generator struct {
 	pathCollector      IPathCollector
 	imageParser        IImageParser
+	imagePolicyChecker IImagePolicyChecker
 	imageDigestUpdater IImageDigestUpdater
 	imageFormatter     IImageFormatter
 }
@@ -18,6 +18,7 @@ type generator struct {
 func NewGenerator(
 	pathCollector IPathCollector,
 	imageParser IImageParser,
+	imagePolicyChecker IImagePolicyChecker,
 	imageDigestUpdater IImageDigestUpdater,
 	imageFormatter IImageFormatter,
 ) (IGenerator, error) {
@@ -29,6 +30,11 @@ func NewGenerator(
 		return nil, errors.New("'imageParser' may not be nil")
 	}
 
+	if imagePolicyChecker == nil ||
+		reflect.ValueOf(imagePolicyChecker).IsNil() {
+		return nil, errors.New("'imagePolicyChecker' may not be nil")
+	}
+
 	if imageDigestUpdater == nil ||
 		reflect.ValueOf(imageDigestUpdater).IsNil() {
 		return nil, errors.New("'imageDigestUpdater' may not be nil")
@@ -42,6 +48,7 @@ func NewGenerator(
 	return &generator{
 		pathCollector:      pathCollector,
 		imageParser:        imageParser,
+		imagePolicyChecker: imagePolicyChecker,
 		imageDigestUpdater: imageDigestUpdater,
 		imageFormatter:     imageFormatter,
 	}, nil
@@ -58,23 +65,17 @@ func (g *generator) GenerateLockfile(lockfileWriter io.Writer) error {
 
 	paths := g.pathCollector.CollectPaths(done)
 	images := g.imageParser.ParseFiles(paths, done)
+	images = g.imagePolicyChecker.CheckImages(images, done)
 	images = g.imageDigestUpdater.UpdateDigests(images, done)
 
-	formattedImages, err := g.imageFormatter.FormatImages(images, done)
+	formattedLockfile, err := g.imageFormatter.FormatImages(images, done)
 	if err != nil {
 		return err
 	}
 
-	if len(formattedImages) == 0 {
+	if len(formattedLockfile.Kinds()) == 0 {
 		return nil
 	}
 
-	byt, err := json.MarshalIndent(formattedImages, "", "\t")
-	if err != nil {
-		return err
-	}
-
-	_, err = lockfileWriter.Write(byt)
-
-	return err
+	return formattedLockfile.Write(lockfileWriter)
 }
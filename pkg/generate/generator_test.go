@@ -12,8 +12,10 @@ import (
 	"github.com/safe-waters/docker-lock/pkg/generate/collect"
 	"github.com/safe-waters/docker-lock/pkg/generate/format"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
 	"github.com/safe-waters/docker-lock/pkg/generate/update"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 func TestGenerator(t *testing.T) {
@@ -23,7 +25,7 @@ func TestGenerator(t *testing.T) {
 		Name          string
 		PathsToCreate []string
 		Contents      [][]byte
-		Expected      map[kind.Kind]map[string][]interface{}
+		Expected      *lockfile.Lockfile
 	}{
 		{
 			Name:          "One Kind",
@@ -35,18 +37,18 @@ FROM busybox
 `,
 				),
 			},
-			Expected: map[kind.Kind]map[string][]interface{}{
-				kind.Dockerfile: {
+			Expected: &lockfile.Lockfile{
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
 					"Dockerfile": {
-						map[string]string{
-							"name":   "golang",
-							"tag":    "latest",
-							"digest": testutils.GolangLatestSHA,
+						{
+							Name:   "golang",
+							Tag:    "latest",
+							Digest: testutils.GolangLatestSHA,
 						},
-						map[string]string{
-							"name":   "busybox",
-							"tag":    "latest",
-							"digest": testutils.BusyboxLatestSHA,
+						{
+							Name:   "busybox",
+							Tag:    "latest",
+							Digest: testutils.BusyboxLatestSHA,
 						},
 					},
 				},
@@ -90,58 +92,58 @@ spec:
     - containerPort: 88
 `),
 			},
-			Expected: map[kind.Kind]map[string][]interface{}{
-				kind.Dockerfile: {
+			Expected: &lockfile.Lockfile{
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
 					"Dockerfile": {
-						map[string]string{
-							"name":   "golang",
-							"tag":    "latest",
-							"digest": testutils.GolangLatestSHA,
+						{
+							Name:   "golang",
+							Tag:    "latest",
+							Digest: testutils.GolangLatestSHA,
 						},
-						map[string]string{
-							"name":   "busybox",
-							"tag":    "latest",
-							"digest": testutils.BusyboxLatestSHA,
+						{
+							Name:   "busybox",
+							Tag:    "latest",
+							Digest: testutils.BusyboxLatestSHA,
 						},
 					},
 				},
-				kind.Composefile: {
+				Composefiles: map[string][]*lockfile.ComposefileImage{
 					"docker-compose.yml": {
-						map[string]string{
-							"name":       "golang",
-							"tag":        "latest",
-							"digest":     testutils.GolangLatestSHA,
-							"service":    "database",
-							"dockerfile": "Dockerfile",
+						{
+							Name:           "golang",
+							Tag:            "latest",
+							Digest:         testutils.GolangLatestSHA,
+							ServiceName:    "database",
+							DockerfilePath: "Dockerfile",
 						},
-						map[string]string{
-							"name":       "busybox",
-							"tag":        "latest",
-							"digest":     testutils.BusyboxLatestSHA,
-							"service":    "database",
-							"dockerfile": "Dockerfile",
+						{
+							Name:           "busybox",
+							Tag:            "latest",
+							Digest:         testutils.BusyboxLatestSHA,
+							ServiceName:    "database",
+							DockerfilePath: "Dockerfile",
 						},
-						map[string]string{
-							"name":    "golang",
-							"tag":     "latest",
-							"digest":  testutils.GolangLatestSHA,
-							"service": "web",
+						{
+							Name:        "golang",
+							Tag:         "latest",
+							Digest:      testutils.GolangLatestSHA,
+							ServiceName: "web",
 						},
 					},
 				},
-				kind.Kubernetesfile: {
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
 					"pod.yml": {
-						map[string]string{
-							"name":      "busybox",
-							"tag":       "v1",
-							"digest":    "busybox",
-							"container": "busybox",
+						{
+							Name:          "busybox",
+							Tag:           "v1",
+							Digest:        "busybox",
+							ContainerName: "busybox",
 						},
-						map[string]string{
-							"name":      "golang",
-							"tag":       "",
-							"digest":    "golang",
-							"container": "golang",
+						{
+							Name:          "golang",
+							Tag:           "",
+							Digest:        "golang",
+							ContainerName: "golang",
 						},
 					},
 				},
@@ -194,7 +196,7 @@ spec:
 				t.Fatal(err)
 			}
 
-			dockerfileImageParser := parse.NewDockerfileImageParser()
+			dockerfileImageParser := parse.NewDockerfileImageParser(nil)
 			composefileImageParser, err := parse.NewComposefileImageParser(
 				dockerfileImageParser,
 			)
@@ -218,7 +220,7 @@ spec:
 				t, &gotNumNetworkCalls,
 			)
 			innerUpdater, err := update.NewImageDigestUpdater(
-				digestRequester, false, false,
+				digestRequester, false, false, false, 0,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -229,6 +231,18 @@ spec:
 				t.Fatal(err)
 			}
 
+			innerChecker, err := policy.NewImagePolicyChecker(&policy.Rules{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checker, err := generate.NewImagePolicyChecker(
+				innerChecker, false,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
 			dockerfileFormatter := format.NewDockerfileImageFormatter()
 			composefileFormatter := format.NewComposefileImageFormatter()
 			kubernetesfileFormatter := format.NewKubernetesfileImageFormatter()
@@ -242,7 +256,7 @@ spec:
 			}
 
 			generator, err := generate.NewGenerator(
-				collector, parser, updater, formatter,
+				collector, parser, checker, updater, formatter,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -255,28 +269,40 @@ spec:
 				t.Fatal(err)
 			}
 
-			sortedGot := map[kind.Kind]map[string][]interface{}{}
-
-			if err = json.Unmarshal(gotByt.Bytes(), &sortedGot); err != nil {
+			gotLockfile, err := lockfile.Read(&gotByt)
+			if err != nil {
 				t.Fatal(err)
 			}
 
-			expectedWithTempDir := map[kind.Kind]map[string][]interface{}{}
-			for k, pathImages := range test.Expected {
-				expectedWithTempDir[k] = map[string][]interface{}{}
-				for path, images := range pathImages {
-					for _, image := range images {
-						image := image.(map[string]string)
-						if dockerfile, ok := image["dockerfile"]; ok {
-							image["dockerfile"] = filepath.ToSlash(
-								filepath.Join(tempDir, dockerfile),
-							)
-						}
-					}
+			if gotLockfile.SchemaVersion != lockfile.SchemaVersion {
+				t.Fatalf(
+					"expected schemaVersion %d, got %d",
+					lockfile.SchemaVersion, gotLockfile.SchemaVersion,
+				)
+			}
+
+			tempPath := func(path string) string {
+				return filepath.ToSlash(filepath.Join(tempDir, path))
+			}
+
+			expectedWithTempDir := lockfile.New()
 
-					tempPath := filepath.ToSlash(filepath.Join(tempDir, path))
-					expectedWithTempDir[k][tempPath] = pathImages[path]
+			for path, images := range test.Expected.Dockerfiles {
+				expectedWithTempDir.Dockerfiles[tempPath(path)] = images
+			}
+
+			for path, images := range test.Expected.Composefiles {
+				for _, image := range images {
+					if image.DockerfilePath != "" {
+						image.DockerfilePath = tempPath(image.DockerfilePath)
+					}
 				}
+
+				expectedWithTempDir.Composefiles[tempPath(path)] = images
+			}
+
+			for path, images := range test.Expected.Kubernetesfiles {
+				expectedWithTempDir.Kubernetesfiles[tempPath(path)] = images
 			}
 
 			expected, err := json.MarshalIndent(expectedWithTempDir, "", "\t")
@@ -284,7 +310,7 @@ spec:
 				t.Fatal(err)
 			}
 
-			got, err := json.MarshalIndent(sortedGot, "", "\t")
+			got, err := json.MarshalIndent(gotLockfile, "", "\t")
 			if err != nil {
 				t.Fatal(err)
 			}
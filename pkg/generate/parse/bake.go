@@ -0,0 +1,266 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/collect"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+type bakefileImageParser struct {
+	kind                  kind.Kind
+	dockerfileImageParser IDockerfileImageParser
+}
+
+// NewBakefileImageParser returns an IImageParser for Bakefiles.
+// dockerfileImageParser cannot be nil as it is responsible for parsing
+// Dockerfiles referenced by Bakefiles.
+func NewBakefileImageParser(
+	dockerfileImageParser IDockerfileImageParser,
+) (IBakefileImageParser, error) {
+	if dockerfileImageParser == nil ||
+		reflect.ValueOf(dockerfileImageParser).IsNil() {
+		return nil, errors.New("'dockerfileImageParser' cannot be nil")
+	}
+
+	return &bakefileImageParser{
+		kind:                  kind.Bakefile,
+		dockerfileImageParser: dockerfileImageParser,
+	}, nil
+}
+
+// Kind is a getter for the kind.
+func (b *bakefileImageParser) Kind() kind.Kind {
+	return b.kind
+}
+
+// ParseFiles parses IImages from Bakefiles.
+func (b *bakefileImageParser) ParseFiles(
+	paths <-chan collect.IPath,
+	done <-chan struct{},
+) <-chan IImage {
+	if paths == nil {
+		return nil
+	}
+
+	var (
+		waitGroup      sync.WaitGroup
+		bakefileImages = make(chan IImage)
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for path := range paths {
+			waitGroup.Add(1)
+
+			go b.ParseFile(path, bakefileImages, done, &waitGroup)
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(bakefileImages)
+	}()
+
+	return bakefileImages
+}
+
+// ParseFile parses IImages from a Bakefile. Images of a target are the
+// images of its "docker-image://" named contexts and the images in its
+// Dockerfile, parsed with the target's "args" as build args. Images in the
+// Dockerfile that are replaced by named contexts are not parsed.
+func (b *bakefileImageParser) ParseFile(
+	path collect.IPath,
+	bakefileImages chan<- IImage,
+	done <-chan struct{},
+	waitGroup *sync.WaitGroup,
+) {
+	defer waitGroup.Done()
+
+	if path == nil || reflect.ValueOf(path).IsNil() || bakefileImages == nil {
+		return
+	}
+
+	if path.Err() != nil {
+		select {
+		case <-done:
+		case bakefileImages <- NewImage(b.kind, "", "", "", nil, path.Err()):
+		}
+
+		return
+	}
+
+	targets, err := LoadBakefile(path.Val())
+	if err != nil {
+		select {
+		case <-done:
+		case bakefileImages <- NewImage(
+			b.kind, "", "", "", nil,
+			fmt.Errorf("'%s' failed to parse with err: %v", path.Val(), err)):
+		}
+
+		return
+	}
+
+	for _, target := range targets {
+		waitGroup.Add(1)
+
+		go b.parseTarget(target, path, bakefileImages, waitGroup, done)
+	}
+}
+
+func (b *bakefileImageParser) parseTarget(
+	target *BakeTarget,
+	path collect.IPath,
+	bakefileImages chan<- IImage,
+	waitGroup *sync.WaitGroup,
+	done <-chan struct{},
+) {
+	defer waitGroup.Done()
+
+	contextNames := target.ContextNames()
+
+	for _, contextName := range contextNames {
+		imageLine, ok := target.Contexts[contextName].ImageLine()
+		if !ok {
+			continue
+		}
+
+		metadata := map[string]interface{}{
+			"targetName":     target.Name,
+			"targetPosition": 0,
+			"contextName":    contextName,
+			"path":           path.Val(),
+		}
+
+		image := NewImage(b.kind, "", "", "", metadata, nil)
+
+		image.SetNameTagDigestFromImageLine(imageLine)
+
+		select {
+		case <-done:
+			return
+		case bakefileImages <- image:
+		}
+	}
+
+	dockerfilePath, ok := target.DockerfilePath(path.Val())
+	if !ok {
+		fmt.Printf("warning: '%s' with a target named '%s' has an inline "+
+			"Dockerfile or a remote context - skipping its Dockerfile\n",
+			path.Val(), target.Name,
+		)
+
+		return
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		select {
+		case <-done:
+		case bakefileImages <- NewImage(b.kind, "", "", "", nil, err):
+		}
+
+		return
+	}
+
+	relPath := strings.TrimPrefix(
+		dockerfilePath, fmt.Sprintf("%s%s", wd, string(filepath.Separator)),
+	)
+
+	if mode, err := os.Stat(relPath); err != nil || mode.IsDir() {
+		fmt.Printf("warning: '%s' with a target named '%s' references "+
+			"'%s' - skipping because the file does not exist\n",
+			path.Val(), target.Name, relPath,
+		)
+
+		return
+	}
+
+	var (
+		dockerfileImageWaitGroup sync.WaitGroup
+		dockerfileImages         = make(chan IImage)
+	)
+
+	dockerfileImageWaitGroup.Add(1)
+
+	go b.dockerfileImageParser.ParseFile(
+		collect.NewPath(b.kind, relPath, nil), target.Args,
+		dockerfileImages, done, &dockerfileImageWaitGroup,
+	)
+
+	go func() {
+		dockerfileImageWaitGroup.Wait()
+		close(dockerfileImages)
+	}()
+
+	for dockerfileImage := range dockerfileImages {
+		dockerfileImage.SetKind(b.kind)
+
+		if dockerfileImage.Err() != nil {
+			select {
+			case <-done:
+			case bakefileImages <- dockerfileImage:
+			}
+
+			return
+		}
+
+		dockerfileImageMetadata := dockerfileImage.Metadata()
+		if dockerfileImageMetadata == nil {
+			select {
+			case <-done:
+			case bakefileImages <- NewImage(
+				dockerfileImage.Kind(), "", "", "", nil,
+				errors.New("'metadata' cannot be nil"),
+			):
+			}
+
+			return
+		}
+
+		instruction, hasInstruction := dockerfileImageMetadata["instruction"]
+
+		// The frontend image is not replaced by named contexts.
+		if instruction != "syntax" &&
+			IsNamedContext(dockerfileImage.ImageLine(), contextNames) {
+			continue
+		}
+
+		metadata := map[string]interface{}{
+			"dockerfilePath": dockerfileImageMetadata["path"],
+			"targetPosition": dockerfileImageMetadata["position"],
+			"targetName":     target.Name,
+			"path":           path.Val(),
+		}
+
+		if platform, ok := dockerfileImageMetadata["platform"]; ok {
+			metadata["platform"] = platform
+		}
+
+		if hasInstruction {
+			metadata["instruction"] = instruction
+		}
+
+		if unresolved, ok := dockerfileImageMetadata["unresolvedVariables"]; ok {
+			metadata["unresolvedVariables"] = unresolved
+		}
+
+		dockerfileImage.SetMetadata(metadata)
+
+		select {
+		case <-done:
+			return
+		case bakefileImages <- dockerfileImage:
+		}
+	}
+}
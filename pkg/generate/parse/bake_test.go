@@ -0,0 +1,417 @@
+package parse_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/collect"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+const bakefileImageParserTestDir = "bakefileParser-tests"
+
+func TestBakefileImageParser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                 string
+		EnvironmentVariables map[string]string
+		BakefilePaths        []string
+		BakefileContents     [][]byte
+		DockerfilePaths      []string
+		DockerfileContents   [][]byte
+		Expected             []parse.IImage
+	}{
+		{
+			Name:          "Dockerfile",
+			BakefilePaths: []string{"docker-bake.hcl"},
+			BakefileContents: [][]byte{
+				[]byte(`
+target "app" {}
+`),
+			},
+			DockerfilePaths:    []string{"Dockerfile"},
+			DockerfileContents: [][]byte{[]byte(`FROM busybox`)},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "busybox", "latest", "",
+					map[string]interface{}{
+						"dockerfilePath": "Dockerfile",
+						"targetPosition": 0,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:          "Context And Dockerfile",
+			BakefilePaths: []string{"docker-bake.hcl"},
+			BakefileContents: [][]byte{
+				[]byte(`
+target "app" {
+  context = "app"
+  dockerfile = "app.Dockerfile"
+}
+`),
+			},
+			DockerfilePaths: []string{
+				filepath.Join("app", "app.Dockerfile"),
+			},
+			DockerfileContents: [][]byte{[]byte(`FROM busybox`)},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "busybox", "latest", "",
+					map[string]interface{}{
+						"dockerfilePath": filepath.Join(
+							"app", "app.Dockerfile",
+						),
+						"targetPosition": 0,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:          "Args And Variables",
+			BakefilePaths: []string{"docker-bake.hcl"},
+			BakefileContents: [][]byte{
+				[]byte(`
+variable "GO_VERSION" {
+  default = "1.16"
+}
+
+IMAGE = "golang"
+
+target "app" {
+  args = {
+    IMAGE = "${IMAGE}:${GO_VERSION}"
+  }
+}
+`),
+			},
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG IMAGE=busybox
+FROM ${IMAGE}
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "golang", "1.16", "",
+					map[string]interface{}{
+						"dockerfilePath": "Dockerfile",
+						"targetPosition": 0,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name: "Environment Variable",
+			EnvironmentVariables: map[string]string{
+				"DOCKER_LOCK_BAKE_TEST_NODE_VERSION": "16",
+			},
+			BakefilePaths: []string{"docker-bake.hcl"},
+			BakefileContents: [][]byte{
+				[]byte(`
+variable "DOCKER_LOCK_BAKE_TEST_NODE_VERSION" {
+  default = "14"
+}
+
+target "app" {
+  args = {
+    VERSION = DOCKER_LOCK_BAKE_TEST_NODE_VERSION
+  }
+}
+`),
+			},
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG VERSION
+FROM node:${VERSION}
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "node", "16", "",
+					map[string]interface{}{
+						"dockerfilePath": "Dockerfile",
+						"targetPosition": 0,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:          "Inherits",
+			BakefilePaths: []string{"docker-bake.hcl"},
+			BakefileContents: [][]byte{
+				[]byte(`
+target "base" {
+  dockerfile = "base.Dockerfile"
+  args = {
+    IMAGE = "redis"
+  }
+}
+
+target "app" {
+  inherits = ["base"]
+  args = {
+    TAG = "6"
+  }
+}
+`),
+			},
+			DockerfilePaths: []string{"base.Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG IMAGE
+ARG TAG=latest
+FROM ${IMAGE}:${TAG}
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "redis", "6", "",
+					map[string]interface{}{
+						"dockerfilePath": "base.Dockerfile",
+						"targetPosition": 0,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "redis", "latest", "",
+					map[string]interface{}{
+						"dockerfilePath": "base.Dockerfile",
+						"targetPosition": 0,
+						"targetName":     "base",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:          "Named Contexts",
+			BakefilePaths: []string{"docker-bake.hcl"},
+			BakefileContents: [][]byte{
+				[]byte(`
+target "app" {
+  contexts = {
+    alpine = "docker-image://alpine:3.14"
+    base   = "target:base"
+    src    = "./src"
+  }
+}
+
+target "base" {
+  dockerfile-inline = "FROM scratch"
+}
+`),
+			},
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`# syntax=docker/dockerfile:1.3
+FROM alpine
+FROM base
+FROM busybox
+COPY --from=alpine /etc/passwd /etc/passwd
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "alpine", "3.14", "",
+					map[string]interface{}{
+						"targetName":     "app",
+						"targetPosition": 0,
+						"contextName":    "alpine",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "docker/dockerfile", "1.3", "",
+					map[string]interface{}{
+						"dockerfilePath": "Dockerfile",
+						"targetPosition": 0,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+						"instruction":    "syntax",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Bakefile, "busybox", "latest", "",
+					map[string]interface{}{
+						"dockerfilePath": "Dockerfile",
+						"targetPosition": 3,
+						"targetName":     "app",
+						"path":           "docker-bake.hcl",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:          "JSON",
+			BakefilePaths: []string{"docker-bake.json"},
+			BakefileContents: [][]byte{
+				[]byte(`
+{
+  "target": {
+    "app": {
+      "contexts": {
+        "golang": "docker-image://golang:1.16"
+      }
+    }
+  }
+}
+`),
+			},
+			DockerfilePaths:    []string{"Dockerfile"},
+			DockerfileContents: [][]byte{[]byte(`FROM golang`)},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Bakefile, "golang", "1.16", "",
+					map[string]interface{}{
+						"targetName":     "app",
+						"targetPosition": 0,
+						"contextName":    "golang",
+						"path":           "docker-bake.json",
+					}, nil,
+				),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, bakefileImageParserTestDir)
+			defer os.RemoveAll(tempDir)
+
+			for k, v := range test.EnvironmentVariables {
+				os.Setenv(k, v)
+			}
+
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, test.DockerfilePaths,
+			)
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, test.BakefilePaths,
+			)
+
+			_ = testutils.WriteFilesToTempDir(
+				t, tempDir, test.DockerfilePaths, test.DockerfileContents,
+			)
+			pathsToParse := testutils.WriteFilesToTempDir(
+				t, tempDir, test.BakefilePaths, test.BakefileContents,
+			)
+
+			pathsToParseCh := make(chan collect.IPath, len(pathsToParse))
+			for _, path := range pathsToParse {
+				pathsToParseCh <- collect.NewPath(kind.Bakefile, path, nil)
+			}
+			close(pathsToParseCh)
+
+			done := make(chan struct{})
+			defer close(done)
+
+			parser, err := parse.NewBakefileImageParser(
+				parse.NewDockerfileImageParser(nil),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			images := parser.ParseFiles(pathsToParseCh, done)
+
+			var got []parse.IImage
+
+			for image := range images {
+				if image.Err() != nil {
+					t.Fatal(image.Err())
+				}
+				got = append(got, image)
+			}
+
+			for _, image := range test.Expected {
+				metadata := image.Metadata()
+
+				metadata["path"] = filepath.Join(
+					tempDir, image.Metadata()["path"].(string),
+				)
+
+				if dockerfilePath, ok := metadata["dockerfilePath"]; ok {
+					metadata["dockerfilePath"] = filepath.Join(
+						tempDir, dockerfilePath.(string),
+					)
+				}
+
+				image.SetMetadata(metadata)
+			}
+
+			testutils.SortBakefileImages(t, got)
+
+			testutils.AssertImagesEqual(t, test.Expected, got)
+		})
+	}
+}
+
+func TestIsNamedContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		ImageLine    string
+		ContextNames []string
+		Expected     bool
+	}{
+		{
+			Name:         "Same Name",
+			ImageLine:    "alpine",
+			ContextNames: []string{"alpine"},
+			Expected:     true,
+		},
+		{
+			Name:         "Same Name And Tag",
+			ImageLine:    "alpine:3.14",
+			ContextNames: []string{"src", "alpine:3.14"},
+			Expected:     true,
+		},
+		{
+			Name:         "Different Tag",
+			ImageLine:    "alpine:3.14",
+			ContextNames: []string{"alpine"},
+			Expected:     false,
+		},
+		{
+			Name:         "No Contexts",
+			ImageLine:    "alpine",
+			ContextNames: nil,
+			Expected:     false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got := parse.IsNamedContext(test.ImageLine, test.ContextNames)
+			if test.Expected != got {
+				t.Fatalf("expected %t, got %t", test.Expected, got)
+			}
+		})
+	}
+}
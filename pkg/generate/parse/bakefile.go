@@ -0,0 +1,687 @@
+package parse
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/userfunc"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// BakeImageContextPrefix is the prefix of named contexts that are images,
+// such as "docker-image://alpine:3.14".
+const BakeImageContextPrefix = "docker-image://"
+
+// BakeTarget is a target in a Bakefile, with its variables, functions, and
+// inherited targets evaluated, as in "docker buildx bake --print".
+type BakeTarget struct {
+	Name             string
+	Context          string
+	Dockerfile       string
+	DockerfileInline string
+	Args             map[string]string
+	Contexts         map[string]*BakeContext
+}
+
+// BakeContext is the value of a named context of a BakeTarget, such as
+// "docker-image://alpine:3.14", and the byte offsets of its expression in
+// the Bakefile. If the contexts of the target are computed by an expression
+// other than a map, such as a function call, Start and End are -1.
+type BakeContext struct {
+	Value string
+	Start int
+	End   int
+}
+
+// ImageLine returns the image of a "docker-image://" named context, such as
+// "alpine:3.14". ok is false if the named context is not an image, as in
+// "target:base" or a local directory.
+func (b *BakeContext) ImageLine() (imageLine string, ok bool) {
+	if !strings.HasPrefix(b.Value, BakeImageContextPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(b.Value, BakeImageContextPrefix), true
+}
+
+// ContextNames returns the names of the named contexts of the target,
+// sorted.
+func (b *BakeTarget) ContextNames() []string {
+	names := make([]string, 0, len(b.Contexts))
+
+	for name := range b.Contexts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// DockerfilePath returns the path to the Dockerfile of the target. As in
+// "docker buildx bake", the context is relative to the directory of the
+// Bakefile, and the Dockerfile is relative to the context. ok is false if
+// the Dockerfile is inline or the context is remote, such as a git
+// repository, so that there is no Dockerfile to parse.
+func (b *BakeTarget) DockerfilePath(bakefilePath string) (string, bool) {
+	if b.DockerfileInline != "" || strings.Contains(b.Context, "://") ||
+		strings.HasPrefix(b.Context, "git@") {
+		return "", false
+	}
+
+	if filepath.IsAbs(b.Dockerfile) {
+		return b.Dockerfile, true
+	}
+
+	context := b.Context
+	if !filepath.IsAbs(context) {
+		context = filepath.Join(filepath.Dir(bakefilePath), context)
+	}
+
+	return filepath.Join(context, b.Dockerfile), true
+}
+
+// IsNamedContext reports whether an image line in a Dockerfile, such as
+// "alpine" in "FROM alpine", is replaced by a named context of the same
+// name, so that it does not refer to an image in the Dockerfile.
+func IsNamedContext(imageLine string, contextNames []string) bool {
+	image := NewImage("", "", "", "", nil, nil)
+	image.SetNameTagDigestFromImageLine(imageLine)
+
+	for _, name := range contextNames {
+		contextImage := NewImage("", "", "", "", nil, nil)
+		contextImage.SetNameTagDigestFromImageLine(name)
+
+		if image.Name() == contextImage.Name() &&
+			image.Tag() == contextImage.Tag() &&
+			image.Digest() == contextImage.Digest() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bakefileEvaluator evaluates the targets of a Bakefile.
+type bakefileEvaluator struct {
+	evalContext  *hcl.EvalContext
+	targetBlocks map[string][]*hcl.Block
+	targets      map[string]*BakeTarget
+	evaluating   map[string]bool
+}
+
+// LoadBakefile evaluates the targets of a Bakefile, in HCL or, if its
+// extension is ".json", in JSON. As in "docker buildx bake", the values of
+// variables are overridden by environment variables of the same name,
+// targets inherit the attributes of the targets in "inherits", and
+// functions from "function" blocks and the standard library may be called.
+// Targets are sorted by name.
+func LoadBakefile(path string) ([]*BakeTarget, error) {
+	bakefileByt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		parser = hclparse.NewParser()
+		file   *hcl.File
+		diags  hcl.Diagnostics
+	)
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		file, diags = parser.ParseJSON(bakefileByt, path)
+	} else {
+		file, diags = parser.ParseHCL(bakefileByt, path)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	evaluator := &bakefileEvaluator{
+		evalContext: &hcl.EvalContext{
+			Variables: map[string]cty.Value{},
+			Functions: bakefileFunctions(),
+		},
+		targetBlocks: map[string][]*hcl.Block{},
+		targets:      map[string]*BakeTarget{},
+		evaluating:   map[string]bool{},
+	}
+
+	userFunctions, body, diags := userfunc.DecodeUserFunctions(
+		file.Body, "function", func() *hcl.EvalContext {
+			return evaluator.evalContext
+		},
+	)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	for name, userFunction := range userFunctions {
+		evaluator.evalContext.Functions[name] = userFunction
+	}
+
+	content, body, diags := body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "target", LabelNames: []string{"name"}},
+			{Type: "group", LabelNames: []string{"name"}},
+		},
+	})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// Attributes outside of blocks are variables that cannot be overridden
+	// by environment variables. JustAttributes of an HCL body fails if it
+	// has any blocks, even those in the schema, so its attributes are read
+	// directly.
+	var attributes hcl.Attributes
+
+	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
+		attributes = hcl.Attributes{}
+
+		for name, attribute := range syntaxBody.Attributes {
+			attributes[name] = attribute.AsHCLAttribute()
+		}
+	} else {
+		attributes, diags = body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	if err := evaluator.evaluateVariables(
+		content.Blocks, attributes,
+	); err != nil {
+		return nil, err
+	}
+
+	for _, block := range content.Blocks {
+		if block.Type == "target" {
+			name := block.Labels[0]
+			evaluator.targetBlocks[name] = append(
+				evaluator.targetBlocks[name], block,
+			)
+		}
+	}
+
+	names := make([]string, 0, len(evaluator.targetBlocks))
+	for name := range evaluator.targetBlocks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	targets := make([]*BakeTarget, len(names))
+
+	for i, name := range names {
+		target, err := evaluator.target(name)
+		if err != nil {
+			return nil, err
+		}
+
+		targets[i] = target
+	}
+
+	return targets, nil
+}
+
+// evaluateVariables adds the values of variables to the evaluation context,
+// in an order such that variables are evaluated after the variables that
+// they reference.
+func (b *bakefileEvaluator) evaluateVariables(
+	blocks hcl.Blocks,
+	attributes hcl.Attributes,
+) error {
+	unevaluated := map[string]hcl.Expression{}
+
+	for _, block := range blocks {
+		if block.Type != "variable" {
+			continue
+		}
+
+		name := block.Labels[0]
+
+		content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{{Name: "default"}},
+		})
+		if diags.HasErrors() {
+			return diags
+		}
+
+		switch val, ok := os.LookupEnv(name); {
+		case ok:
+			b.evalContext.Variables[name] = cty.StringVal(val)
+		case content.Attributes["default"] != nil:
+			unevaluated[name] = content.Attributes["default"].Expr
+		default:
+			b.evalContext.Variables[name] = cty.StringVal("")
+		}
+	}
+
+	for name, attribute := range attributes {
+		if _, ok := b.evalContext.Variables[name]; ok {
+			return fmt.Errorf("variable '%s' is defined more than once", name)
+		}
+
+		if _, ok := unevaluated[name]; ok {
+			return fmt.Errorf("variable '%s' is defined more than once", name)
+		}
+
+		unevaluated[name] = attribute.Expr
+	}
+
+	for len(unevaluated) != 0 {
+		names := make([]string, 0, len(unevaluated))
+		for name := range unevaluated {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		var numEvaluated int
+
+	nameLoop:
+		for _, name := range names {
+			expr := unevaluated[name]
+
+			for _, traversal := range expr.Variables() {
+				if _, ok := unevaluated[traversal.RootName()]; ok {
+					continue nameLoop
+				}
+			}
+
+			val, diags := expr.Value(b.evalContext)
+			if diags.HasErrors() {
+				return diags
+			}
+
+			b.evalContext.Variables[name] = val
+			delete(unevaluated, name)
+
+			numEvaluated++
+		}
+
+		if numEvaluated == 0 {
+			return fmt.Errorf(
+				"variables '%s' reference each other",
+				strings.Join(names, "', '"),
+			)
+		}
+	}
+
+	return nil
+}
+
+// target evaluates the target of the name, after the targets that it
+// inherits from. Attributes of the target override those that it inherits,
+// except for "args" and "contexts", which are merged.
+func (b *bakefileEvaluator) target(name string) (*BakeTarget, error) {
+	if target, ok := b.targets[name]; ok {
+		return target, nil
+	}
+
+	blocks, ok := b.targetBlocks[name]
+	if !ok {
+		return nil, fmt.Errorf("target '%s' does not exist", name)
+	}
+
+	if b.evaluating[name] {
+		return nil, fmt.Errorf("target '%s' inherits from itself", name)
+	}
+
+	b.evaluating[name] = true
+	defer delete(b.evaluating, name)
+
+	target := &BakeTarget{
+		Name:     name,
+		Args:     map[string]string{},
+		Contexts: map[string]*BakeContext{},
+	}
+
+	allAttributes := make([]hcl.Attributes, len(blocks))
+
+	for i, block := range blocks {
+		attributes, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		allAttributes[i] = attributes
+	}
+
+	for _, attributes := range allAttributes {
+		if attribute, ok := attributes["inherits"]; ok {
+			parents, err := b.strings(attribute)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, parentName := range parents {
+				parent, err := b.target(parentName)
+				if err != nil {
+					return nil, err
+				}
+
+				target.merge(parent)
+			}
+		}
+	}
+
+	for _, attributes := range allAttributes {
+		if err := b.evaluateTarget(target, attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	if target.Context == "" {
+		target.Context = "."
+	}
+
+	if target.Dockerfile == "" {
+		target.Dockerfile = "Dockerfile"
+	}
+
+	b.targets[name] = target
+
+	return target, nil
+}
+
+// evaluateTarget sets the fields of the target from its attributes.
+func (b *bakefileEvaluator) evaluateTarget(
+	target *BakeTarget,
+	attributes hcl.Attributes,
+) error {
+	for _, field := range []struct {
+		name string
+		dst  *string
+	}{
+		{name: "context", dst: &target.Context},
+		{name: "dockerfile", dst: &target.Dockerfile},
+		{name: "dockerfile-inline", dst: &target.DockerfileInline},
+	} {
+		attribute, ok := attributes[field.name]
+		if !ok {
+			continue
+		}
+
+		val, diags := attribute.Expr.Value(b.evalContext)
+		if diags.HasErrors() {
+			return diags
+		}
+
+		s, ok, err := ctyString(val)
+		if err != nil {
+			return fmt.Errorf(
+				"target '%s' has malformed '%s' with err: %v",
+				target.Name, field.name, err,
+			)
+		}
+
+		if ok {
+			*field.dst = s
+		}
+	}
+
+	if attribute, ok := attributes["args"]; ok {
+		args, _, err := b.stringMap(attribute)
+		if err != nil {
+			return fmt.Errorf(
+				"target '%s' has malformed 'args' with err: %v",
+				target.Name, err,
+			)
+		}
+
+		for key, val := range args {
+			target.Args[key] = val
+		}
+	}
+
+	if attribute, ok := attributes["contexts"]; ok {
+		contexts, ranges, err := b.stringMap(attribute)
+		if err != nil {
+			return fmt.Errorf(
+				"target '%s' has malformed 'contexts' with err: %v",
+				target.Name, err,
+			)
+		}
+
+		for name, val := range contexts {
+			context := &BakeContext{Value: val, Start: -1, End: -1}
+
+			if rng, ok := ranges[name]; ok {
+				context.Start, context.End = rng.Start.Byte, rng.End.Byte
+			}
+
+			target.Contexts[name] = context
+		}
+	}
+
+	return nil
+}
+
+// merge adds the attributes of a target that it inherits from.
+func (b *BakeTarget) merge(parent *BakeTarget) {
+	for _, field := range []struct {
+		src string
+		dst *string
+	}{
+		{src: parent.Context, dst: &b.Context},
+		{src: parent.Dockerfile, dst: &b.Dockerfile},
+		{src: parent.DockerfileInline, dst: &b.DockerfileInline},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+
+	for key, val := range parent.Args {
+		b.Args[key] = val
+	}
+
+	for name, context := range parent.Contexts {
+		b.Contexts[name] = context
+	}
+}
+
+// strings evaluates an attribute that is a list of strings.
+func (b *bakefileEvaluator) strings(attribute *hcl.Attribute) ([]string, error) {
+	val, diags := attribute.Expr.Value(b.evalContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if val.IsNull() {
+		return nil, nil
+	}
+
+	if !val.CanIterateElements() {
+		return nil, fmt.Errorf("'%s' must be a list", attribute.Name)
+	}
+
+	var strs []string
+
+	for iter := val.ElementIterator(); iter.Next(); {
+		_, elem := iter.Element()
+
+		s, ok, err := ctyString(elem)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"'%s' is malformed with err: %v", attribute.Name, err,
+			)
+		}
+
+		if ok {
+			strs = append(strs, s)
+		}
+	}
+
+	return strs, nil
+}
+
+// stringMap evaluates an attribute that is a map of strings. Null values
+// are skipped. If the attribute is a map expression, rather than an
+// expression that computes a map, the ranges of the expressions of its
+// values are returned as well.
+func (b *bakefileEvaluator) stringMap(
+	attribute *hcl.Attribute,
+) (map[string]string, map[string]hcl.Range, error) {
+	val, diags := attribute.Expr.Value(b.evalContext)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	if val.IsNull() {
+		return nil, nil, nil
+	}
+
+	if !val.Type().IsMapType() && !val.Type().IsObjectType() {
+		return nil, nil, fmt.Errorf("'%s' must be a map", attribute.Name)
+	}
+
+	strMap := map[string]string{}
+
+	for iter := val.ElementIterator(); iter.Next(); {
+		key, elem := iter.Element()
+
+		s, ok, err := ctyString(elem)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ok {
+			strMap[key.AsString()] = s
+		}
+	}
+
+	ranges := map[string]hcl.Range{}
+
+	if pairs, diags := hcl.ExprMap(attribute.Expr); !diags.HasErrors() {
+		for _, pair := range pairs {
+			key, diags := pair.Key.Value(b.evalContext)
+			if diags.HasErrors() {
+				return nil, nil, diags
+			}
+
+			if s, ok, err := ctyString(key); err == nil && ok {
+				ranges[s] = pair.Value.Range()
+			}
+		}
+	}
+
+	return strMap, ranges, nil
+}
+
+// ctyString converts a value to a string. ok is false if the value is null.
+func ctyString(val cty.Value) (s string, ok bool, err error) {
+	if val.IsNull() {
+		return "", false, nil
+	}
+
+	if !val.IsWhollyKnown() {
+		return "", false, fmt.Errorf("value is unknown")
+	}
+
+	val, err = convert.Convert(val, cty.String)
+	if err != nil {
+		return "", false, err
+	}
+
+	return val.AsString(), true, nil
+}
+
+// bakefileFunctions returns the functions of the standard library that
+// "docker buildx bake" provides.
+func bakefileFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"absolute":               stdlib.AbsoluteFunc,
+		"add":                    stdlib.AddFunc,
+		"and":                    stdlib.AndFunc,
+		"byteslen":               stdlib.BytesLenFunc,
+		"bytesslice":             stdlib.BytesSliceFunc,
+		"chomp":                  stdlib.ChompFunc,
+		"chunklist":              stdlib.ChunklistFunc,
+		"coalesce":               stdlib.CoalesceFunc,
+		"coalescelist":           stdlib.CoalesceListFunc,
+		"compact":                stdlib.CompactFunc,
+		"concat":                 stdlib.ConcatFunc,
+		"contains":               stdlib.ContainsFunc,
+		"csvdecode":              stdlib.CSVDecodeFunc,
+		"distinct":               stdlib.DistinctFunc,
+		"divide":                 stdlib.DivideFunc,
+		"element":                stdlib.ElementFunc,
+		"equal":                  stdlib.EqualFunc,
+		"flatten":                stdlib.FlattenFunc,
+		"floor":                  stdlib.FloorFunc,
+		"format":                 stdlib.FormatFunc,
+		"formatdate":             stdlib.FormatDateFunc,
+		"formatlist":             stdlib.FormatListFunc,
+		"greaterthan":            stdlib.GreaterThanFunc,
+		"greaterthanorequalto":   stdlib.GreaterThanOrEqualToFunc,
+		"hasindex":               stdlib.HasIndexFunc,
+		"indent":                 stdlib.IndentFunc,
+		"index":                  stdlib.IndexFunc,
+		"int":                    stdlib.IntFunc,
+		"join":                   stdlib.JoinFunc,
+		"jsondecode":             stdlib.JSONDecodeFunc,
+		"jsonencode":             stdlib.JSONEncodeFunc,
+		"keys":                   stdlib.KeysFunc,
+		"length":                 stdlib.LengthFunc,
+		"lessthan":               stdlib.LessThanFunc,
+		"lessthanorequalto":      stdlib.LessThanOrEqualToFunc,
+		"log":                    stdlib.LogFunc,
+		"lookup":                 stdlib.LookupFunc,
+		"lower":                  stdlib.LowerFunc,
+		"max":                    stdlib.MaxFunc,
+		"merge":                  stdlib.MergeFunc,
+		"min":                    stdlib.MinFunc,
+		"modulo":                 stdlib.ModuloFunc,
+		"multiply":               stdlib.MultiplyFunc,
+		"negate":                 stdlib.NegateFunc,
+		"not":                    stdlib.NotFunc,
+		"notequal":               stdlib.NotEqualFunc,
+		"or":                     stdlib.OrFunc,
+		"parseint":               stdlib.ParseIntFunc,
+		"pow":                    stdlib.PowFunc,
+		"range":                  stdlib.RangeFunc,
+		"regex":                  stdlib.RegexFunc,
+		"regex_replace":          stdlib.RegexReplaceFunc,
+		"regexall":               stdlib.RegexAllFunc,
+		"replace":                stdlib.ReplaceFunc,
+		"reverse":                stdlib.ReverseFunc,
+		"reverselist":            stdlib.ReverseListFunc,
+		"sethaselement":          stdlib.SetHasElementFunc,
+		"setintersection":        stdlib.SetIntersectionFunc,
+		"setproduct":             stdlib.SetProductFunc,
+		"setsubtract":            stdlib.SetSubtractFunc,
+		"setsymmetricdifference": stdlib.SetSymmetricDifferenceFunc,
+		"setunion":               stdlib.SetUnionFunc,
+		"signum":                 stdlib.SignumFunc,
+		"slice":                  stdlib.SliceFunc,
+		"sort":                   stdlib.SortFunc,
+		"split":                  stdlib.SplitFunc,
+		"strlen":                 stdlib.StrlenFunc,
+		"substr":                 stdlib.SubstrFunc,
+		"subtract":               stdlib.SubtractFunc,
+		"timeadd":                stdlib.TimeAddFunc,
+		"title":                  stdlib.TitleFunc,
+		"trim":                   stdlib.TrimFunc,
+		"trimprefix":             stdlib.TrimPrefixFunc,
+		"trimspace":              stdlib.TrimSpaceFunc,
+		"trimsuffix":             stdlib.TrimSuffixFunc,
+		"upper":                  stdlib.UpperFunc,
+		"values":                 stdlib.ValuesFunc,
+		"zipmap":                 stdlib.ZipmapFunc,
+	}
+}
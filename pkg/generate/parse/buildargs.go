@@ -0,0 +1,120 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildArgs are the values of ARGs in Dockerfiles that are not built by
+// docker-compose files, as in "docker build --build-arg". Docker-compose
+// files specify the build args of their own services.
+//
+// Global applies to every Dockerfile. Paths maps the path of a Dockerfile
+// to build args that only apply to it, overriding those in Global.
+type BuildArgs struct {
+	Global map[string]string
+	Paths  map[string]map[string]string
+}
+
+// ForPath returns the build args of the Dockerfile at the path.
+func (b *BuildArgs) ForPath(path string) map[string]string {
+	if b == nil {
+		return nil
+	}
+
+	buildArgs := make(map[string]string, len(b.Global))
+
+	for key, val := range b.Global {
+		buildArgs[key] = val
+	}
+
+	path = filepath.ToSlash(filepath.Clean(path))
+
+	for buildArgsPath, pathBuildArgs := range b.Paths {
+		if filepath.ToSlash(filepath.Clean(buildArgsPath)) != path {
+			continue
+		}
+
+		for key, val := range pathBuildArgs {
+			buildArgs[key] = val
+		}
+	}
+
+	return buildArgs
+}
+
+// ReadBuildArgs returns build args from "KEY=VAL" pairs, as well as from
+// files with a pair on each line. In files, empty lines and lines that
+// start with "#" are ignored. Pairs override the values in files, and later
+// files override earlier ones.
+//
+// As in "docker build --build-arg KEY", a KEY without a value takes the
+// value of the environment variable of the same name, if it is set.
+func ReadBuildArgs(
+	pairs []string,
+	paths []string,
+) (map[string]string, error) {
+	buildArgs := map[string]string{}
+
+	for _, path := range paths {
+		if err := readBuildArgFile(path, buildArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pair := range pairs {
+		if err := addBuildArg(pair, buildArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	return buildArgs, nil
+}
+
+func readBuildArgFile(path string, buildArgs map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := addBuildArg(line, buildArgs); err != nil {
+			return fmt.Errorf("'%s' is malformed with err: %v", path, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func addBuildArg(pair string, buildArgs map[string]string) error {
+	const keyValLen = 2
+
+	keyVal := strings.SplitN(pair, "=", keyValLen)
+
+	key := strings.TrimSpace(keyVal[0])
+	if key == "" {
+		return fmt.Errorf("'%s' build arg must be of the form KEY=VAL", pair)
+	}
+
+	if len(keyVal) == keyValLen {
+		buildArgs[key] = keyVal[1]
+		return nil
+	}
+
+	if val, ok := os.LookupEnv(key); ok {
+		buildArgs[key] = val
+	}
+
+	return nil
+}
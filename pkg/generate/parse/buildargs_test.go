@@ -0,0 +1,170 @@
+package parse_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
+
+const buildArgsTestDir = "buildArgs-tests"
+
+func TestReadBuildArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Pairs        []string
+		FilePaths    []string
+		FileContents [][]byte
+		Expected     map[string]string
+		ShouldFail   bool
+	}{
+		{
+			Name:  "Pairs",
+			Pairs: []string{"REGISTRY=ghcr.io/org", "EMPTY=", "EQUALS=a=b"},
+			Expected: map[string]string{
+				"REGISTRY": "ghcr.io/org",
+				"EMPTY":    "",
+				"EQUALS":   "a=b",
+			},
+		},
+		{
+			Name:      "Files",
+			FilePaths: []string{"one.env", "two.env"},
+			FileContents: [][]byte{
+				[]byte(`# registry of the base images
+REGISTRY=ghcr.io/org
+
+VERSION=1.0
+`),
+				[]byte(`VERSION=2.0`),
+			},
+			Expected: map[string]string{
+				"REGISTRY": "ghcr.io/org",
+				"VERSION":  "2.0",
+			},
+		},
+		{
+			Name:         "Pairs Override Files",
+			Pairs:        []string{"VERSION=3.0"},
+			FilePaths:    []string{"build.env"},
+			FileContents: [][]byte{[]byte(`VERSION=2.0`)},
+			Expected:     map[string]string{"VERSION": "3.0"},
+		},
+		{
+			Name:     "Unset Environment Variable",
+			Pairs:    []string{"DOCKER_LOCK_UNSET_BUILD_ARG"},
+			Expected: map[string]string{},
+		},
+		{
+			Name:       "Missing Key",
+			Pairs:      []string{"=ghcr.io/org"},
+			ShouldFail: true,
+		},
+		{
+			Name:         "Malformed File",
+			FilePaths:    []string{"build.env"},
+			FileContents: [][]byte{[]byte(`=2.0`)},
+			ShouldFail:   true,
+		},
+		{
+			Name:       "Missing File",
+			FilePaths:  []string{"build.env"},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, buildArgsTestDir)
+			defer os.RemoveAll(tempDir)
+
+			paths := testutils.WriteFilesToTempDir(
+				t, tempDir, test.FilePaths[:len(test.FileContents)],
+				test.FileContents,
+			)
+
+			for _, path := range test.FilePaths[len(test.FileContents):] {
+				paths = append(paths, filepath.Join(tempDir, path))
+			}
+
+			got, err := parse.ReadBuildArgs(test.Pairs, paths)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(test.Expected, got) {
+				t.Fatalf("expected %v, got %v", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildArgsForPath(t *testing.T) {
+	t.Parallel()
+
+	buildArgs := &parse.BuildArgs{
+		Global: map[string]string{"REGISTRY": "ghcr.io/org", "VERSION": "1.0"},
+		Paths: map[string]map[string]string{
+			"./api/Dockerfile": {"VERSION": "2.0"},
+		},
+	}
+
+	tests := []struct {
+		Name      string
+		BuildArgs *parse.BuildArgs
+		Path      string
+		Expected  map[string]string
+	}{
+		{
+			Name:      "Path",
+			BuildArgs: buildArgs,
+			Path:      filepath.Join("api", "Dockerfile"),
+			Expected: map[string]string{
+				"REGISTRY": "ghcr.io/org", "VERSION": "2.0",
+			},
+		},
+		{
+			Name:      "Other Path",
+			BuildArgs: buildArgs,
+			Path:      "Dockerfile",
+			Expected: map[string]string{
+				"REGISTRY": "ghcr.io/org", "VERSION": "1.0",
+			},
+		},
+		{
+			Name: "Nil",
+			Path: "Dockerfile",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got := test.BuildArgs.ForPath(test.Path)
+
+			if !reflect.DeepEqual(test.Expected, got) {
+				t.Fatalf("expected %v, got %v", test.Expected, got)
+			}
+		})
+	}
+}
@@ -3,14 +3,17 @@ package parse
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/compose-spec/compose-go/cli"
 	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/template"
 	"github.com/compose-spec/compose-go/types"
 	"github.com/safe-waters/docker-lock/pkg/generate/collect"
 	"github.com/safe-waters/docker-lock/pkg/kind"
@@ -114,15 +117,99 @@ func (c *composefileImageParser) ParseFile(
 		return
 	}
 
+	unresolvedVariables, err := c.unresolvedImageVariables(
+		path.Val(), project.Environment,
+	)
+	if err != nil {
+		select {
+		case <-done:
+		case composefileImages <- NewImage(
+			c.kind, "", "", "", nil,
+			fmt.Errorf("'%s' failed to parse with err: %v", path.Val(), err)):
+		}
+
+		return
+	}
+
 	for _, serviceConfig := range project.Services {
 		waitGroup.Add(1)
 
 		go c.parseService(
-			serviceConfig, path, composefileImages, waitGroup, done,
+			serviceConfig, path, unresolvedVariables[serviceConfig.Name],
+			composefileImages, waitGroup, done,
 		)
 	}
 }
 
+// unresolvedImageVariables maps the names of services to the variables
+// in their "image" keys that interpolate to an empty string, because they
+// are neither in the environment nor have a default value.
+//
+// The loaded project only has interpolated values, so the raw values are
+// read from the Composefile. The line of a variable is the first line with
+// the raw value in an "image" key.
+func (c *composefileImageParser) unresolvedImageVariables(
+	path string,
+	environment map[string]string,
+) (map[string][]*UnresolvedVariable, error) {
+	composefileByt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := loader.ParseYAML(composefileByt)
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := config["services"].(map[string]interface{})
+	lines := strings.Split(string(composefileByt), "\n")
+	unresolvedVariables := map[string][]*UnresolvedVariable{}
+
+	for serviceName, service := range services {
+		service, _ := service.(map[string]interface{})
+
+		rawImageLine, ok := service["image"].(string)
+		if !ok {
+			continue
+		}
+
+		var names []string
+
+		for name, variable := range template.ExtractVariables(
+			map[string]interface{}{"image": rawImageLine}, nil,
+		) {
+			if environment[name] == "" && variable.DefaultValue == "" &&
+				!variable.Required {
+				names = append(names, name)
+			}
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		sort.Strings(names)
+
+		var lineNumber int
+
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "image:") &&
+				strings.Contains(line, rawImageLine) {
+				lineNumber = i + 1
+				break
+			}
+		}
+
+		unresolvedVariables[serviceName] = newUnresolvedVariables(
+			path, lineNumber, names,
+		)
+	}
+
+	return unresolvedVariables, nil
+}
+
 func (c *composefileImageParser) loadNewProject(
 	path string,
 ) (project *types.Project, err error) {
@@ -161,6 +248,7 @@ func (c *composefileImageParser) loadNewProject(
 func (c *composefileImageParser) parseService(
 	serviceConfig types.ServiceConfig,
 	path collect.IPath,
+	unresolvedVariables []*UnresolvedVariable,
 	composefileImages chan<- IImage,
 	waitGroup *sync.WaitGroup,
 	done <-chan struct{},
@@ -172,11 +260,17 @@ func (c *composefileImageParser) parseService(
 			return
 		}
 
-		image := NewImage(c.kind, "", "", "", map[string]interface{}{
+		metadata := map[string]interface{}{
 			"serviceName":     serviceConfig.Name,
 			"servicePosition": 0,
 			"path":            path.Val(),
-		}, nil)
+		}
+
+		if len(unresolvedVariables) != 0 {
+			metadata["unresolvedVariables"] = unresolvedVariables
+		}
+
+		image := NewImage(c.kind, "", "", "", metadata, nil)
 
 		image.SetNameTagDigestFromImageLine(serviceConfig.Image)
 
@@ -207,11 +301,17 @@ func (c *composefileImageParser) parseService(
 
 	switch {
 	case err != nil && serviceConfig.Image != "":
-		image := NewImage(c.kind, "", "", "", map[string]interface{}{
+		metadata := map[string]interface{}{
 			"serviceName":     serviceConfig.Name,
 			"servicePosition": 0,
 			"path":            path.Val(),
-		}, nil)
+		}
+
+		if len(unresolvedVariables) != 0 {
+			metadata["unresolvedVariables"] = unresolvedVariables
+		}
+
+		image := NewImage(c.kind, "", "", "", metadata, nil)
 
 		image.SetNameTagDigestFromImageLine(serviceConfig.Image)
 
@@ -288,12 +388,26 @@ func (c *composefileImageParser) parseService(
 			return
 		}
 
-		dockerfileImage.SetMetadata(map[string]interface{}{
+		metadata := map[string]interface{}{
 			"dockerfilePath":  dockerfileImageMetadata["path"],
 			"servicePosition": dockerfileImageMetadata["position"],
 			"serviceName":     serviceConfig.Name,
 			"path":            path.Val(),
-		})
+		}
+
+		if platform, ok := dockerfileImageMetadata["platform"]; ok {
+			metadata["platform"] = platform
+		}
+
+		if instruction, ok := dockerfileImageMetadata["instruction"]; ok {
+			metadata["instruction"] = instruction
+		}
+
+		if unresolved, ok := dockerfileImageMetadata["unresolvedVariables"]; ok {
+			metadata["unresolvedVariables"] = unresolved
+		}
+
+		dockerfileImage.SetMetadata(metadata)
 
 		select {
 		case <-done:
@@ -545,6 +545,62 @@ services:
 				),
 			},
 		},
+		{
+			Name:             "Unresolved Variables",
+			ComposefilePaths: []string{"docker-compose.yml"},
+			ComposefileContents: [][]byte{
+				[]byte(`
+version: '3'
+services:
+  svc-one:
+    image: ${DOCKER_LOCK_UNSET_REGISTRY}busybox:${DOCKER_LOCK_UNSET_TAG:-1}
+  svc-two:
+    build:
+      context: ./two
+      args:
+        - VERSION=${DOCKER_LOCK_UNSET_VERSION}
+`),
+			},
+			DockerfilePaths: []string{filepath.Join("two", "Dockerfile")},
+			DockerfileContents: [][]byte{
+				[]byte(`ARG VERSION
+FROM golang:1.16${VERSION}
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(kind.Composefile, "busybox", "1", "",
+					map[string]interface{}{
+						"path":            "docker-compose.yml",
+						"servicePosition": 0,
+						"serviceName":     "svc-one",
+						"unresolvedVariables": []*parse.UnresolvedVariable{
+							{
+								Path: "docker-compose.yml",
+								Line: 5,
+								Name: "DOCKER_LOCK_UNSET_REGISTRY",
+							},
+						},
+					}, nil,
+				),
+				parse.NewImage(kind.Composefile, "golang", "1.16", "",
+					map[string]interface{}{
+						"path":            "docker-compose.yml",
+						"servicePosition": 0,
+						"serviceName":     "svc-two",
+						"dockerfilePath": filepath.Join(
+							"two", "Dockerfile",
+						),
+						"unresolvedVariables": []*parse.UnresolvedVariable{
+							{
+								Path: filepath.Join("two", "Dockerfile"),
+								Line: 2,
+								Name: "VERSION",
+							},
+						},
+					}, nil,
+				),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -595,7 +651,7 @@ services:
 			defer close(done)
 
 			parser, err := parse.NewComposefileImageParser(
-				parse.NewDockerfileImageParser(),
+				parse.NewDockerfileImageParser(nil),
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -628,6 +684,10 @@ services:
 				}
 
 				image.SetMetadata(metadata)
+
+				for _, unresolved := range parse.UnresolvedVariables(image) {
+					unresolved.Path = filepath.Join(tempDir, unresolved.Path)
+				}
 			}
 
 			testutils.SortComposefileImages(t, got)
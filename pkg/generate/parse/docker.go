@@ -1,25 +1,34 @@
 package parse
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/moby/buildkit/frontend/dockerfile/dockerfile2llb"
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
 	"github.com/safe-waters/docker-lock/pkg/generate/collect"
 	"github.com/safe-waters/docker-lock/pkg/kind"
 )
 
 type dockerfileImageParser struct {
-	kind kind.Kind
+	kind      kind.Kind
+	buildArgs *BuildArgs
 }
 
 // NewDockerfileImageParser returns an IImageParser for Dockerfiles.
-func NewDockerfileImageParser() IDockerfileImageParser {
+// buildArgs are passed to the Dockerfiles in ParseFiles. If buildArgs is
+// nil, Dockerfiles are parsed without build args.
+func NewDockerfileImageParser(buildArgs *BuildArgs) IDockerfileImageParser {
 	return &dockerfileImageParser{
-		kind: kind.Dockerfile,
+		kind:      kind.Dockerfile,
+		buildArgs: buildArgs,
 	}
 }
 
@@ -28,7 +37,8 @@ func (d *dockerfileImageParser) Kind() kind.Kind {
 	return d.kind
 }
 
-// ParseFiles parses IImages from Dockerfiles.
+// ParseFiles parses IImages from Dockerfiles, with the build args of each
+// Dockerfile's path.
 func (d *dockerfileImageParser) ParseFiles(
 	paths <-chan collect.IPath,
 	done <-chan struct{},
@@ -50,8 +60,13 @@ func (d *dockerfileImageParser) ParseFiles(
 		for path := range paths {
 			waitGroup.Add(1)
 
+			var buildArgs map[string]string
+			if path != nil && !reflect.ValueOf(path).IsNil() {
+				buildArgs = d.buildArgs.ForPath(path.Val())
+			}
+
 			go d.ParseFile(
-				path, nil, dockerfileImages, done, &waitGroup,
+				path, buildArgs, dockerfileImages, done, &waitGroup,
 			)
 		}
 	}()
@@ -64,7 +79,11 @@ func (d *dockerfileImageParser) ParseFiles(
 	return dockerfileImages
 }
 
-// ParseFile parses IImages from a Dockerfile.
+// ParseFile parses IImages from a Dockerfile. Besides the images in FROM
+// instructions, the frontend image in the "# syntax" parser directive and
+// images referenced by "COPY --from" and by the "from" option of
+// "RUN --mount" are parsed, with their "instruction" in the metadata.
+// References to stages, by name or index, are not images.
 func (d *dockerfileImageParser) ParseFile(
 	path collect.IPath,
 	buildArgs map[string]string,
@@ -87,7 +106,7 @@ func (d *dockerfileImageParser) ParseFile(
 		return
 	}
 
-	dockerfile, err := os.Open(path.Val())
+	dockerfileByt, err := ioutil.ReadFile(path.Val())
 	if err != nil {
 		select {
 		case <-done:
@@ -96,9 +115,8 @@ func (d *dockerfileImageParser) ParseFile(
 
 		return
 	}
-	defer dockerfile.Close()
 
-	loadedDockerfile, err := parser.Parse(dockerfile)
+	loadedDockerfile, err := parser.Parse(bytes.NewReader(dockerfileByt))
 	if err != nil {
 		select {
 		case <-done:
@@ -112,16 +130,48 @@ func (d *dockerfileImageParser) ParseFile(
 		return
 	}
 
+	globalArgs, err := DockerfileGlobalArgs(loadedDockerfile.AST)
+	if err != nil {
+		select {
+		case <-done:
+		case dockerfileImages <- NewImage(
+			d.kind, "", "", "", nil,
+			fmt.Errorf("%v in Dockerfile '%s'", err, path.Val()),
+		):
+		}
+
+		return
+	}
+
 	var (
-		position      int                   // order of image in Dockerfile
-		stages        = map[string]bool{}   // FROM <image line> as <stage>
-		globalArgs    = map[string]string{} // ARGs before the first FROM
-		globalContext = true                // true if before first FROM
+		position int                 // order of image in Dockerfile
+		stages   = map[string]bool{} // FROM <image line> as <stage>, lowercased
 	)
 
+	// # syntax=<image>
+	if imageLine, _, _, ok := dockerfile2llb.DetectSyntax(
+		bytes.NewReader(dockerfileByt),
+	); ok {
+		metadata := map[string]interface{}{
+			"position":    position,
+			"path":        path.Val(),
+			"instruction": "syntax",
+		}
+
+		image := NewImage(d.kind, "", "", "", metadata, nil)
+		image.SetNameTagDigestFromImageLine(imageLine)
+
+		select {
+		case <-done:
+			return
+		case dockerfileImages <- image:
+			position++
+		}
+	}
+
 	for _, child := range loadedDockerfile.AST.Children {
 		switch child.Value {
-		case "arg":
+		case "from":
 			var raw []string
 
 			for n := child.Next; n != nil; n = n.Next {
@@ -130,7 +180,7 @@ func (d *dockerfileImageParser) ParseFile(
 
 			if len(raw) == 0 {
 				err := fmt.Errorf(
-					"invalid arg instruction in Dockerfile '%s'", path,
+					"invalid FROM instruction in Dockerfile '%s'", path,
 				)
 
 				select {
@@ -141,57 +191,88 @@ func (d *dockerfileImageParser) ParseFile(
 				return
 			}
 
-			if globalContext {
-				if strings.Contains(raw[0], "=") {
-					// ARG VAR=VAL
-					const (
-						argValLen = 2
-						varIndex  = 0
-						valIndex  = 1
-					)
-
-					varVal := strings.SplitN(raw[0], "=", argValLen)
-
-					strippedVar := d.stripQuotes(varVal[varIndex])
-					strippedVal := d.stripQuotes(varVal[valIndex])
+			// Stage names are case insensitive, as in Docker.
+			if !stages[strings.ToLower(raw[0])] {
+				metadata := map[string]interface{}{
+					"position": position,
+					"path":     path.Val(),
+				}
 
-					globalArgs[strippedVar] = strippedVal
-				} else {
-					// ARG VAR1
-					strippedVar := d.stripQuotes(raw[0])
+				platform, err := dockerfilePlatform(
+					child.Flags, globalArgs, buildArgs,
+				)
+				if err != nil {
+					select {
+					case <-done:
+					case dockerfileImages <- NewImage(
+						d.kind, "", "", "", nil, fmt.Errorf(
+							"in Dockerfile '%s', %v", path.Val(), err,
+						),
+					):
+					}
 
-					globalArgs[strippedVar] = ""
+					return
 				}
-			}
-		case "from":
-			var raw []string
 
-			for n := child.Next; n != nil; n = n.Next {
-				raw = append(raw, n.Value)
-			}
+				if platform != "" {
+					metadata["platform"] = platform
+				}
 
-			if len(raw) == 0 {
-				err := fmt.Errorf(
-					"invalid FROM instruction in Dockerfile '%s'", path,
+				imageLine, unresolved := ExpandDockerfileArgs(
+					raw[0], globalArgs, buildArgs,
 				)
+				if len(unresolved) != 0 {
+					metadata["unresolvedVariables"] = newUnresolvedVariables(
+						path.Val(), child.StartLine, unresolved,
+					)
+				}
+
+				image := NewImage(d.kind, "", "", "", metadata, nil)
+				image.SetNameTagDigestFromImageLine(imageLine)
 
 				select {
 				case <-done:
-				case dockerfileImages <- NewImage(d.kind, "", "", "", nil, err):
+					return
+				case dockerfileImages <- image:
+					position++
 				}
+			}
 
-				return
+			// <image> AS <stage>
+			// <stage> AS <another stage>
+			const maxNumFields = 3
+			if len(raw) == maxNumFields {
+				const stageIndex = 2
+
+				stage := strings.ToLower(raw[stageIndex])
+				stages[stage] = true
 			}
+		case "copy", "run":
+			// COPY --from=<image or stage> ...
+			// RUN --mount=type=bind,from=<image or stage> ...
+			for _, rawImageLine := range d.fromFlagValues(
+				child.Value, child.Flags,
+			) {
+				imageLine, unresolved, isImage := FromFlagImageLine(
+					rawImageLine, stages, globalArgs, buildArgs,
+				)
+				if !isImage {
+					continue
+				}
 
-			globalContext = false
+				metadata := map[string]interface{}{
+					"position":    position,
+					"path":        path.Val(),
+					"instruction": child.Value,
+				}
 
-			if !stages[raw[0]] {
-				image := NewImage(d.kind, "", "", "", map[string]interface{}{
-					"position": position,
-					"path":     path.Val(),
-				}, nil)
-				imageLine := d.expandField(raw[0], globalArgs, buildArgs)
+				if len(unresolved) != 0 {
+					metadata["unresolvedVariables"] = newUnresolvedVariables(
+						path.Val(), child.StartLine, unresolved,
+					)
+				}
 
+				image := NewImage(d.kind, "", "", "", metadata, nil)
 				image.SetNameTagDigestFromImageLine(imageLine)
 
 				select {
@@ -201,21 +282,132 @@ func (d *dockerfileImageParser) ParseFile(
 					position++
 				}
 			}
+		}
+	}
+}
 
-			// <image> AS <stage>
-			// <stage> AS <another stage>
-			const maxNumFields = 3
-			if len(raw) == maxNumFields {
-				const stageIndex = 2
+// fromFlagValues returns the values of the flags of a COPY or RUN
+// instruction that refer to an image or a stage, in order, such as
+// "golang:1.16" in "--from=golang:1.16" or "alpine" in
+// "--mount=type=bind,from=alpine".
+func (d *dockerfileImageParser) fromFlagValues(
+	instruction string,
+	flags []string,
+) []string {
+	const (
+		copyFromFlag = "--from="
+		runMountFlag = "--mount="
+		mountFromOpt = "from="
+	)
 
-				stage := raw[stageIndex]
-				stages[stage] = true
+	var values []string
+
+	for _, flag := range flags {
+		switch {
+		case instruction == "copy" && strings.HasPrefix(flag, copyFromFlag):
+			values = append(values, strings.TrimPrefix(flag, copyFromFlag))
+		case instruction == "run" && strings.HasPrefix(flag, runMountFlag):
+			for _, opt := range strings.Split(
+				strings.TrimPrefix(flag, runMountFlag), ",",
+			) {
+				if strings.HasPrefix(opt, mountFromOpt) {
+					values = append(
+						values, strings.TrimPrefix(opt, mountFromOpt),
+					)
+				}
+			}
+		}
+	}
+
+	return values
+}
+
+// DockerfileGlobalArgs returns the ARGs declared before the first FROM
+// instruction of a parsed Dockerfile, with their default values.
+func DockerfileGlobalArgs(
+	dockerfile *parser.Node,
+) (map[string]string, error) {
+	if dockerfile == nil {
+		return nil, errors.New("'dockerfile' cannot be nil")
+	}
+
+	var (
+		globalArgs    = map[string]string{}
+		globalContext = true // true if before first FROM
+	)
+
+	for _, child := range dockerfile.Children {
+		switch child.Value {
+		case "from":
+			globalContext = false
+		case "arg":
+			if child.Next == nil {
+				return nil, errors.New("invalid arg instruction")
+			}
+
+			if !globalContext {
+				continue
+			}
+
+			raw := child.Next.Value
+
+			if strings.Contains(raw, "=") {
+				// ARG VAR=VAL
+				const (
+					argValLen = 2
+					varIndex  = 0
+					valIndex  = 1
+				)
+
+				varVal := strings.SplitN(raw, "=", argValLen)
+
+				strippedVar := stripQuotes(varVal[varIndex])
+				strippedVal := stripQuotes(varVal[valIndex])
+
+				globalArgs[strippedVar] = strippedVal
+			} else {
+				// ARG VAR1
+				strippedVar := stripQuotes(raw)
+
+				globalArgs[strippedVar] = ""
 			}
 		}
 	}
+
+	return globalArgs, nil
 }
 
-func (d *dockerfileImageParser) stripQuotes(s string) string {
+// FromFlagImageLine expands the ARGs in the value of a "COPY --from" flag or
+// of the "from" option of a "RUN --mount" flag, and reports whether the
+// value refers to an image, rather than to a stage by its name or index.
+// The names of stages must be lowercased, as Docker matches them case
+// insensitively. The parser and the writer of Dockerfiles both use it, so
+// that they agree on which values are images.
+func FromFlagImageLine(
+	value string,
+	stages map[string]bool,
+	globalArgs map[string]string,
+	buildArgs map[string]string,
+) (imageLine string, unresolved []string, isImage bool) {
+	imageLine, unresolved = ExpandDockerfileArgs(value, globalArgs, buildArgs)
+
+	if imageLine == "" || stages[strings.ToLower(imageLine)] ||
+		isStageIndex(imageLine) {
+		return imageLine, unresolved, false
+	}
+
+	return imageLine, unresolved, true
+}
+
+// isStageIndex reports whether a reference to a stage is by its index, as
+// in "COPY --from=0", rather than by its name.
+func isStageIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+
+	return err == nil
+}
+
+func stripQuotes(s string) string {
 	// Valid in a Dockerfile - any number of quotes if quote is on either side.
 	// ARG "IMAGE"="busybox"
 	// ARG "IMAGE"""""="busybox"""""""""""""
@@ -226,22 +418,148 @@ func (d *dockerfileImageParser) stripQuotes(s string) string {
 	return s
 }
 
-func (d *dockerfileImageParser) expandField(
+// dockerfilePlatform returns the expanded value of the "--platform" flag of
+// a FROM instruction, or an empty string if the flag is not set. It is also
+// empty if the flag uses platform ARGs, such as "$TARGETPLATFORM", that are
+// not set by build args, so that the image's digest is the digest of its
+// manifest list, rather than of the platform of the current machine.
+func dockerfilePlatform(
+	flags []string,
+	globalArgs map[string]string,
+	buildArgs map[string]string,
+) (string, error) {
+	const platformFlag = "--platform="
+
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, platformFlag) {
+			continue
+		}
+
+		rawPlatform := strings.TrimPrefix(flag, platformFlag)
+		platform, unresolved := ExpandDockerfileArgs(
+			rawPlatform, globalArgs, buildArgs,
+		)
+
+		for _, arg := range unresolved {
+			if _, ok := platformArgNames[arg]; ok {
+				return "", nil
+			}
+		}
+
+		// os/architecture[/variant]
+		fields := strings.Split(platform, "/")
+
+		const minNumFields, maxNumFields = 2, 3
+		if len(fields) < minNumFields || len(fields) > maxNumFields ||
+			strings.Contains(platform, "//") ||
+			strings.HasPrefix(platform, "/") ||
+			strings.HasSuffix(platform, "/") {
+			return "", fmt.Errorf(
+				"platform '%s' expanded to invalid platform '%s'",
+				rawPlatform, platform,
+			)
+		}
+
+		return platform, nil
+	}
+
+	return "", nil
+}
+
+// platformArgNames are the names of the platform ARGs that Docker defines
+// automatically in the global scope.
+var platformArgNames = map[string]struct{}{ // nolint: gochecknoglobals
+	"BUILDPLATFORM":  {},
+	"BUILDOS":        {},
+	"BUILDARCH":      {},
+	"BUILDVARIANT":   {},
+	"TARGETPLATFORM": {},
+	"TARGETOS":       {},
+	"TARGETARCH":     {},
+	"TARGETVARIANT":  {},
+}
+
+// platformArgs returns the platform ARGs, such as TARGETOS and TARGETARCH,
+// derived from the BUILDPLATFORM and TARGETPLATFORM build args. Docker
+// defines them from the current machine and the platform of the build, but
+// then the Lockfile would depend on the machine that generated it, so they
+// are only defined if the build and target platforms are set as build args.
+// Variants are optional, so BUILDVARIANT and TARGETVARIANT are always
+// defined, even if empty.
+func platformArgs(buildArgs map[string]string) map[string]string {
+	args := map[string]string{
+		"BUILDVARIANT":  "",
+		"TARGETVARIANT": "",
+	}
+
+	for _, prefix := range []string{"BUILD", "TARGET"} {
+		platform, ok := buildArgs[fmt.Sprintf("%sPLATFORM", prefix)]
+		if !ok || platform == "" {
+			continue
+		}
+
+		// os/architecture[/variant]
+		const maxNumFields = 3
+
+		fields := strings.SplitN(platform, "/", maxNumFields)
+		for len(fields) < maxNumFields {
+			fields = append(fields, "")
+		}
+
+		args[fmt.Sprintf("%sPLATFORM", prefix)] = platform
+		args[fmt.Sprintf("%sOS", prefix)] = fields[0]
+		args[fmt.Sprintf("%sARCH", prefix)] = fields[1]
+		args[fmt.Sprintf("%sVARIANT", prefix)] = fields[2]
+	}
+
+	return args
+}
+
+// ExpandDockerfileArgs expands the ARGs in a field of a Dockerfile, such as
+// an image line, with the ARGs declared before the first FROM and the build
+// args. It also returns the names of the ARGs that expanded to an empty
+// string, because they are not declared before the first FROM or have
+// neither a default nor a build arg. Platform ARGs are declared
+// automatically, and are unresolved unless their platform is set as a build
+// arg. ARGs such as TARGETVARIANT may be empty, so they are never unresolved.
+func ExpandDockerfileArgs(
 	field string,
 	globalArgs map[string]string,
 	buildArgs map[string]string,
-) string {
-	return os.Expand(field, func(arg string) string {
+) (string, []string) {
+	var (
+		platformArgs = platformArgs(buildArgs)
+		unresolved   []string
+		seen         = map[string]bool{}
+	)
+
+	expanded := os.Expand(field, func(arg string) string {
 		globalVal, ok := globalArgs[arg]
-		if !ok {
-			return ""
+
+		_, isPlatformArg := platformArgNames[arg]
+		platformVal, isDefinedPlatformArg := platformArgs[arg]
+
+		// Platform ARGs are available without being declared, and keep
+		// their value if declared without one.
+		if isPlatformArg && globalVal == "" {
+			globalVal, ok = platformVal, true
 		}
 
-		buildVal, ok := buildArgs[arg]
-		if !ok {
-			return globalVal
+		val := globalVal
+
+		if ok {
+			if buildVal, isBuildArg := buildArgs[arg]; isBuildArg {
+				val = buildVal
+			}
+		}
+
+		if val == "" && !isDefinedPlatformArg && !seen[arg] {
+			seen[arg] = true
+			unresolved = append(unresolved, arg)
 		}
 
-		return buildVal
+		return val
 	})
+
+	return expanded, unresolved
 }
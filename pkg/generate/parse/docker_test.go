@@ -20,6 +20,8 @@ func TestDockerfileImageParser(t *testing.T) {
 		Name               string
 		DockerfilePaths    []string
 		DockerfileContents [][]byte
+		BuildArgs          map[string]string
+		PathBuildArgs      map[string]map[string]string
 		Expected           []parse.IImage
 		ShouldFail         bool
 	}{
@@ -111,6 +113,78 @@ FROM --platform=$BUILDPLATFORM ubuntu@sha256:bae015c28bc7
 				),
 			},
 		},
+		{
+			Name:            "Platform Build Args",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+FROM --platform=$BUILDPLATFORM golang AS build
+FROM --platform=$TARGETOS/$TARGETARCH busybox
+`),
+			},
+			BuildArgs: map[string]string{
+				"BUILDPLATFORM":  "linux/amd64",
+				"TARGETPLATFORM": "linux/arm64/v8",
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "golang", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 0,
+						"platform": "linux/amd64",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 1,
+						"platform": "linux/arm64",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Platform Arg",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG OS=linux
+ARG ARCH=arm64
+FROM --platform=${OS}/${ARCH}/v8 busybox AS base
+FROM base
+FROM --platform=$TARGETOS/$ARCH golang
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 0,
+						"platform": "linux/arm64/v8",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "golang", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 1,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Invalid Platform",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+FROM --platform=$UNDEFINED busybox
+`),
+			},
+			ShouldFail: true,
+		},
 		{
 			Name:            "Tag And Digest",
 			DockerfilePaths: []string{"Dockerfile"},
@@ -202,6 +276,213 @@ FROM ubuntu as worker
 				),
 			},
 		},
+		{
+			Name:            "Mixed Case Stage",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+FROM golang AS Builder
+FROM builder AS Tester
+FROM ubuntu
+COPY --from=builder /bin/app /bin/app
+RUN --mount=from=TESTER,target=/test ls /test
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "golang", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 0,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "ubuntu", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 1,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Copy From And Run Mount",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG GO_IMAGE=golang:1.16
+FROM busybox AS build
+COPY --from=${GO_IMAGE} /usr/local/go /usr/local/go
+RUN --mount=type=bind,from=alpine:3.13,target=/alpine \
+    --mount=type=cache,target=/root/.cache ls /alpine
+FROM ubuntu
+COPY --from=build /bin/sh /bin/sh
+COPY --from=0 /bin/ls /bin/ls
+RUN --mount=from=build,target=/build ls /build
+COPY --chown=1000 --from=redis@sha256:bae015c28bc7 /data /data
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 0,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "golang", "1.16", "",
+					map[string]interface{}{
+						"path":        "Dockerfile",
+						"position":    1,
+						"instruction": "copy",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "alpine", "3.13", "",
+					map[string]interface{}{
+						"path":        "Dockerfile",
+						"position":    2,
+						"instruction": "run",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "ubuntu", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 3,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "redis", "", "bae015c28bc7",
+					map[string]interface{}{
+						"path":        "Dockerfile",
+						"position":    4,
+						"instruction": "copy",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Syntax Directive",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`# syntax=docker/dockerfile:1.4
+# escape=\
+FROM busybox
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "docker/dockerfile", "1.4", "",
+					map[string]interface{}{
+						"path":        "Dockerfile",
+						"position":    0,
+						"instruction": "syntax",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 1,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Syntax Comment After Instruction",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`FROM busybox
+# syntax=docker/dockerfile:1.4
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 0,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Build Args",
+			DockerfilePaths: []string{"Dockerfile-one", "Dockerfile-two"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG REGISTRY
+ARG VERSION=latest
+FROM ${REGISTRY}/base:${VERSION}
+`),
+				[]byte(`
+ARG REGISTRY
+ARG VERSION=latest
+FROM ${REGISTRY}/base:${VERSION}
+`),
+			},
+			BuildArgs: map[string]string{
+				"REGISTRY":   "ghcr.io/org",
+				"VERSION":    "1.0",
+				"UNDECLARED": "busybox",
+			},
+			PathBuildArgs: map[string]map[string]string{
+				"Dockerfile-two": {"VERSION": "2.0"},
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "ghcr.io/org/base", "1.0", "",
+					map[string]interface{}{
+						"path":     "Dockerfile-one",
+						"position": 0,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "ghcr.io/org/base", "2.0", "",
+					map[string]interface{}{
+						"path":     "Dockerfile-two",
+						"position": 0,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:            "Unresolved Variables",
+			DockerfilePaths: []string{"Dockerfile"},
+			DockerfileContents: [][]byte{
+				[]byte(`
+ARG REGISTRY
+ARG VARIANT=alpine
+FROM ${REGISTRY}golang:1.16-${VARIANT}${TARGETVARIANT}
+COPY --from=builder${SUFFIX}${SUFFIX} / /
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "golang", "1.16-alpine", "",
+					map[string]interface{}{
+						"path":     "Dockerfile",
+						"position": 0,
+						"unresolvedVariables": []*parse.UnresolvedVariable{
+							{Path: "Dockerfile", Line: 4, Name: "REGISTRY"},
+						},
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "builder", "latest", "",
+					map[string]interface{}{
+						"path":        "Dockerfile",
+						"position":    1,
+						"instruction": "copy",
+						"unresolvedVariables": []*parse.UnresolvedVariable{
+							{Path: "Dockerfile", Line: 5, Name: "SUFFIX"},
+						},
+					}, nil,
+				),
+			},
+		},
 		{
 			Name:            "Multiple Files",
 			DockerfilePaths: []string{"Dockerfile-one", "Dockerfile-two"},
@@ -294,7 +575,15 @@ FROM
 			done := make(chan struct{})
 			defer close(done)
 
-			parser := parse.NewDockerfileImageParser()
+			buildArgs := &parse.BuildArgs{
+				Global: test.BuildArgs,
+				Paths:  map[string]map[string]string{},
+			}
+			for path, pathBuildArgs := range test.PathBuildArgs {
+				buildArgs.Paths[filepath.Join(tempDir, path)] = pathBuildArgs
+			}
+
+			parser := parse.NewDockerfileImageParser(buildArgs)
 			images := parser.ParseFiles(pathsToParseCh, done)
 
 			var got []parse.IImage
@@ -316,12 +605,15 @@ FROM
 			}
 
 			for _, image := range test.Expected {
-				image.SetMetadata(map[string]interface{}{
-					"path": filepath.Join(
-						tempDir, image.Metadata()["path"].(string),
-					),
-					"position": image.Metadata()["position"],
-				})
+				metadata := image.Metadata()
+				metadata["path"] = filepath.Join(
+					tempDir, metadata["path"].(string),
+				)
+				image.SetMetadata(metadata)
+
+				for _, unresolved := range parse.UnresolvedVariables(image) {
+					unresolved.Path = filepath.Join(tempDir, unresolved.Path)
+				}
 			}
 
 			testutils.SortDockerfileImages(t, got)
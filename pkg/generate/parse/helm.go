@@ -0,0 +1,465 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/collect"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"gopkg.in/yaml.v2"
+)
+
+type helmchartImageParser struct {
+	kind kind.Kind
+}
+
+// NewHelmchartImageParser returns an IImageParser for Helm charts.
+func NewHelmchartImageParser() IHelmchartImageParser {
+	return &helmchartImageParser{
+		kind: kind.Helmchart,
+	}
+}
+
+// Kind is a getter for the kind.
+func (h *helmchartImageParser) Kind() kind.Kind {
+	return h.kind
+}
+
+// ParseFiles parses IImages from Helm charts.
+func (h *helmchartImageParser) ParseFiles(
+	paths <-chan collect.IPath,
+	done <-chan struct{},
+) <-chan IImage {
+	if paths == nil {
+		return nil
+	}
+
+	var (
+		waitGroup       sync.WaitGroup
+		helmchartImages = make(chan IImage)
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for path := range paths {
+			waitGroup.Add(1)
+
+			go h.ParseFile(
+				path, helmchartImages, done, &waitGroup,
+			)
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(helmchartImages)
+	}()
+
+	return helmchartImages
+}
+
+// ParseFile parses IImages from a file in a Helm chart. Values files
+// ("values.yaml", "values.yml", or "values-*.yaml") are decoded as yaml and
+// images are found in "image" keys and in maps with "repository" and "tag"
+// keys. All other files, such as templates, are scanned line by line for
+// "image:" keys, so that templated yaml that cannot be decoded is supported.
+// Image lines that contain template actions are skipped.
+func (h *helmchartImageParser) ParseFile(
+	path collect.IPath,
+	helmchartImages chan<- IImage,
+	done <-chan struct{},
+	waitGroup *sync.WaitGroup,
+) {
+	defer waitGroup.Done()
+
+	if path == nil || reflect.ValueOf(path).IsNil() ||
+		helmchartImages == nil {
+		return
+	}
+
+	if path.Err() != nil {
+		select {
+		case <-done:
+		case helmchartImages <- NewImage(h.kind, "", "", "", nil, path.Err()):
+		}
+
+		return
+	}
+
+	byt, err := ioutil.ReadFile(path.Val())
+	if err != nil {
+		select {
+		case <-done:
+		case helmchartImages <- NewImage(h.kind, "", "", "", nil, err):
+		}
+
+		return
+	}
+
+	if IsHelmValuesFile(path.Val()) {
+		h.parseValuesFile(path, byt, helmchartImages, done)
+		return
+	}
+
+	h.parseTemplateFile(path, byt, helmchartImages, done)
+}
+
+// IsHelmValuesFile reports whether a path refers to a Helm chart's
+// values file, as opposed to a template or rendered manifest.
+func IsHelmValuesFile(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+
+	if ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+
+	name := strings.TrimSuffix(base, ext)
+
+	return name == "values" || strings.HasPrefix(name, "values-")
+}
+
+// HelmTemplateImageLine returns the image line from a line in a
+// Helm chart template, such as "image: busybox:latest". ok is false if the
+// line does not contain an "image" key, or if the image line contains
+// template actions and cannot be resolved without rendering the chart.
+func HelmTemplateImageLine(
+	line string,
+) (imageLine string, ok bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	trimmed = strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " \t")
+
+	if !strings.HasPrefix(trimmed, "image:") {
+		return "", false
+	}
+
+	imageLine = strings.TrimSpace(strings.TrimPrefix(trimmed, "image:"))
+
+	if i := strings.Index(imageLine, " #"); i != -1 {
+		imageLine = strings.TrimSpace(imageLine[:i])
+	}
+
+	imageLine = strings.Trim(imageLine, `"'`)
+
+	if imageLine == "" || strings.Contains(imageLine, "{{") {
+		return "", false
+	}
+
+	return imageLine, true
+}
+
+func (h *helmchartImageParser) parseTemplateFile(
+	path collect.IPath,
+	byt []byte,
+	helmchartImages chan<- IImage,
+	done <-chan struct{},
+) {
+	var (
+		position int
+		scanner  = bufio.NewScanner(bytes.NewReader(byt))
+	)
+
+	for scanner.Scan() {
+		imageLine, ok := HelmTemplateImageLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		image := NewImage(h.kind, "", "", "", map[string]interface{}{
+			"path":     path.Val(),
+			"position": position,
+		}, nil)
+		image.SetNameTagDigestFromImageLine(imageLine)
+
+		select {
+		case <-done:
+			return
+		case helmchartImages <- image:
+		}
+
+		position++
+	}
+
+	if err := scanner.Err(); err != nil {
+		select {
+		case <-done:
+		case helmchartImages <- NewImage(h.kind, "", "", "", nil, err):
+		}
+	}
+}
+
+func (h *helmchartImageParser) parseValuesFile(
+	path collect.IPath,
+	byt []byte,
+	helmchartImages chan<- IImage,
+	done <-chan struct{},
+) {
+	appVersion := h.appVersion(path.Val())
+
+	var (
+		position int
+		dec      = yaml.NewDecoder(bytes.NewReader(byt))
+	)
+
+	for {
+		var doc yaml.MapSlice
+
+		if err := dec.Decode(&doc); err != nil {
+			if err != io.EOF {
+				select {
+				case <-done:
+				case helmchartImages <- NewImage(
+					h.kind, "", "", "", nil, fmt.Errorf(
+						"'%s' yaml decoder failed with err: %v", path.Val(),
+						err,
+					),
+				):
+				}
+
+				return
+			}
+
+			break
+		}
+
+		valuesImages, err := h.valuesImages(doc, appVersion)
+		if err != nil {
+			select {
+			case <-done:
+			case helmchartImages <- NewImage(
+				h.kind, "", "", "", nil,
+				fmt.Errorf("'%s' %v", path.Val(), err),
+			):
+			}
+
+			return
+		}
+
+		for _, valuesImage := range valuesImages {
+			image := NewImage(h.kind, "", "", "", map[string]interface{}{
+				"path":     path.Val(),
+				"position": position,
+				"key":      valuesImage.key,
+			}, nil)
+			image.SetNameTagDigestFromImageLine(valuesImage.imageLine)
+
+			select {
+			case <-done:
+				return
+			case helmchartImages <- image:
+			}
+
+			position++
+		}
+	}
+}
+
+// appVersion returns the "appVersion" from the Chart.yaml next to a values
+// file. Charts commonly default image tags to the appVersion.
+func (h *helmchartImageParser) appVersion(valuesPath string) string {
+	dir := filepath.Dir(valuesPath)
+
+	for _, name := range []string{"Chart.yaml", "Chart.yml"} {
+		byt, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return ""
+		}
+
+		// appVersion is decoded as a string, so that the literal text of
+		// an unquoted version, such as 3.10, is kept instead of 3.1.
+		var chart struct {
+			AppVersion string `yaml:"appVersion"`
+		}
+
+		if err := yaml.Unmarshal(byt, &chart); err != nil {
+			return ""
+		}
+
+		return chart.AppVersion
+	}
+
+	return ""
+}
+
+// helmchartValuesImage is an image found in a Helm chart's values file.
+// key is the dotted path to the image in the values file, such as
+// "image" or "redis.image".
+type helmchartValuesImage struct {
+	key       string
+	imageLine string
+}
+
+// valuesImages returns all images in a decoded values document, in
+// the order that they appear. An image is either a string under an "image"
+// key or a map with a "repository" key and optional "registry", "tag", and
+// "digest" keys. If a "repository" has no "tag", appVersion is used.
+func (h *helmchartImageParser) valuesImages(
+	doc interface{},
+	appVersion string,
+) ([]*helmchartValuesImage, error) {
+	var images []*helmchartValuesImage
+
+	if err := h.valuesImagesRecursive(
+		doc, "", appVersion, &images,
+	); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+func (h *helmchartImageParser) valuesImagesRecursive(
+	doc interface{},
+	key string,
+	appVersion string,
+	images *[]*helmchartValuesImage,
+) error {
+	switch doc := doc.(type) {
+	case yaml.MapSlice:
+		imageLine, ok, err := h.repositoryImageLine(doc, appVersion)
+		if err != nil {
+			return fmt.Errorf("'%s' %v", key, err)
+		}
+
+		if ok {
+			*images = append(*images, &helmchartValuesImage{
+				key:       key,
+				imageLine: imageLine,
+			})
+
+			return nil
+		}
+
+		for _, item := range doc {
+			itemKey := fmt.Sprint(item.Key)
+			if key != "" {
+				itemKey = fmt.Sprintf("%s.%s", key, itemKey)
+			}
+
+			if item.Key == "image" {
+				if imageLine, ok := item.Value.(string); ok {
+					if imageLine != "" {
+						*images = append(*images, &helmchartValuesImage{
+							key:       itemKey,
+							imageLine: imageLine,
+						})
+					}
+
+					continue
+				}
+			}
+
+			if err := h.valuesImagesRecursive(
+				item.Value, itemKey, appVersion, images,
+			); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, doc := range doc {
+			itemKey := fmt.Sprint(i)
+			if key != "" {
+				itemKey = fmt.Sprintf("%s.%s", key, itemKey)
+			}
+
+			if err := h.valuesImagesRecursive(
+				doc, itemKey, appVersion, images,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// repositoryImageLine returns an image line from a map with a
+// "repository" key, such as:
+//
+//	image:
+//	  registry: docker.io
+//	  repository: bitnami/redis
+//	  tag: 6.2.5
+//	  digest: sha256:...
+//
+// ok is false if the map does not have a "repository" string.
+//
+// An error is returned if the "tag" is not a string, such as an unquoted
+// "3.10", which is decoded as the number 3.1 and would lock the wrong tag.
+func (h *helmchartImageParser) repositoryImageLine(
+	doc yaml.MapSlice,
+	appVersion string,
+) (imageLine string, ok bool, err error) {
+	var (
+		registry, repository, tag, digest string
+		tagValue                          interface{}
+	)
+
+	for _, item := range doc {
+		if item.Value == nil {
+			continue
+		}
+
+		switch item.Key {
+		case "registry":
+			registry, _ = item.Value.(string)
+		case "repository":
+			repository, ok = item.Value.(string)
+		case "tag":
+			tagValue = item.Value
+		case "digest":
+			digest, _ = item.Value.(string)
+		}
+	}
+
+	if !ok || repository == "" {
+		return "", false, nil
+	}
+
+	if tagValue != nil {
+		if tag, ok = tagValue.(string); !ok {
+			return "", false, fmt.Errorf(
+				"tag '%v' is not a string, quote it so that it is not "+
+					"read as a number", tagValue,
+			)
+		}
+	}
+
+	imageLine = repository
+	if registry != "" {
+		imageLine = fmt.Sprintf("%s/%s", registry, imageLine)
+	}
+
+	if tag == "" {
+		tag = appVersion
+	}
+
+	if tag != "" {
+		imageLine = fmt.Sprintf("%s:%s", imageLine, tag)
+	}
+
+	if digest != "" && !strings.Contains(imageLine, "@") {
+		if !strings.HasPrefix(digest, "sha256:") {
+			digest = fmt.Sprintf("sha256:%s", digest)
+		}
+
+		imageLine = fmt.Sprintf("%s@%s", imageLine, digest)
+	}
+
+	return imageLine, true, nil
+}
@@ -0,0 +1,294 @@
+package parse_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/collect"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+const helmchartImageParserTestDir = "helmchartParser-tests"
+
+func TestHelmchartImageParser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name              string
+		HelmchartPaths    []string
+		HelmchartContents [][]byte
+		PathsToParse      []string
+		Expected          []parse.IImage
+		ShouldFail        bool
+	}{
+		{
+			Name:           "Values Repository And Tag",
+			HelmchartPaths: []string{"values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`replicaCount: 1
+image:
+  repository: busybox
+  tag: "1.33"
+  pullPolicy: IfNotPresent
+redis:
+  image:
+    registry: docker.io
+    repository: bitnami/redis
+    tag: 6.2.5
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "busybox", "1.33", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 0,
+						"key":      "image",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Helmchart, "docker.io/bitnami/redis", "6.2.5", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 1,
+						"key":      "redis.image",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:           "Values Image Line",
+			HelmchartPaths: []string{"values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`sidecars:
+- name: proxy
+  image: envoyproxy/envoy:v1.18.3
+- name: logger
+  image: busybox
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "envoyproxy/envoy", "v1.18.3", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 0,
+						"key":      "sidecars.0.image",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Helmchart, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 1,
+						"key":      "sidecars.1.image",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:           "Values Digest",
+			HelmchartPaths: []string{"values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`image:
+  repository: busybox
+  tag: latest
+  digest: sha256:busybox
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "busybox", "latest", "busybox",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 0,
+						"key":      "image",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:           "Values Tag Defaults To App Version",
+			HelmchartPaths: []string{"Chart.yaml", "values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`apiVersion: v2
+name: test
+version: 0.1.0
+appVersion: "1.16"
+`),
+				[]byte(`image:
+  repository: golang
+  tag: ""
+`),
+			},
+			PathsToParse: []string{"values.yaml"},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "golang", "1.16", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 0,
+						"key":      "image",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:           "Values Unquoted App Version",
+			HelmchartPaths: []string{"Chart.yaml", "values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`apiVersion: v2
+name: test
+version: 0.1.0
+appVersion: 3.10
+`),
+				[]byte(`image:
+  repository: python
+`),
+			},
+			PathsToParse: []string{"values.yaml"},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "python", "3.10", "",
+					map[string]interface{}{
+						"path":     "values.yaml",
+						"position": 0,
+						"key":      "image",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:           "Values Unquoted Tag",
+			HelmchartPaths: []string{"values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`image:
+  repository: python
+  tag: 3.10
+`),
+			},
+			ShouldFail: true,
+		},
+		{
+			Name:           "Template",
+			HelmchartPaths: []string{filepath.Join("templates", "pod.yaml")},
+			HelmchartContents: [][]byte{
+				[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ include "test.fullname" . }}
+spec:
+  containers:
+  - name: {{ .Chart.Name }}
+    image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+  - name: sidecar
+    image: "redis:6.2"
+  - image: busybox # comment
+    name: busybox
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Helmchart, "redis", "6.2", "",
+					map[string]interface{}{
+						"path":     filepath.Join("templates", "pod.yaml"),
+						"position": 0,
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Helmchart, "busybox", "latest", "",
+					map[string]interface{}{
+						"path":     filepath.Join("templates", "pod.yaml"),
+						"position": 1,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:           "Invalid Values",
+			HelmchartPaths: []string{"values.yaml"},
+			HelmchartContents: [][]byte{
+				[]byte(`image: [busybox
+`),
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, helmchartImageParserTestDir)
+			defer os.RemoveAll(tempDir)
+
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, test.HelmchartPaths,
+			)
+			pathsToParse := testutils.WriteFilesToTempDir(
+				t, tempDir, test.HelmchartPaths, test.HelmchartContents,
+			)
+
+			if len(test.PathsToParse) != 0 {
+				pathsToParse = nil
+
+				for _, path := range test.PathsToParse {
+					pathsToParse = append(
+						pathsToParse, filepath.Join(tempDir, path),
+					)
+				}
+			}
+
+			pathsToParseCh := make(chan collect.IPath, len(pathsToParse))
+			for _, path := range pathsToParse {
+				pathsToParseCh <- collect.NewPath(kind.Helmchart, path, nil)
+			}
+			close(pathsToParseCh)
+
+			done := make(chan struct{})
+			defer close(done)
+
+			parser := parse.NewHelmchartImageParser()
+			images := parser.ParseFiles(pathsToParseCh, done)
+
+			var got []parse.IImage
+
+			for image := range images {
+				if test.ShouldFail {
+					if image.Err() == nil {
+						t.Fatal("expected error but did not get one")
+					}
+
+					return
+				}
+
+				if image.Err() != nil {
+					t.Fatal(image.Err())
+				}
+
+				got = append(got, image)
+			}
+
+			if test.ShouldFail {
+				t.Fatal("expected error but did not get one")
+			}
+
+			for _, image := range test.Expected {
+				metadata := image.Metadata()
+				metadata["path"] = filepath.Join(
+					tempDir, metadata["path"].(string),
+				)
+				image.SetMetadata(metadata)
+			}
+
+			testutils.SortHelmchartImages(t, got)
+
+			testutils.AssertImagesEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/collect"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"gopkg.in/yaml.v2"
+)
+
+type kustomizationImageParser struct {
+	kind                      kind.Kind
+	kubernetesfileImageParser IKubernetesfileImageParser
+}
+
+// kustomization is the subset of a kustomization file that references
+// images.
+type kustomization struct {
+	Resources  []string              `yaml:"resources"`
+	Bases      []string              `yaml:"bases"`
+	Components []string              `yaml:"components"`
+	Images     []*kustomizationImage `yaml:"images"`
+}
+
+// kustomizationImage is an entry in the "images" transformer of a
+// kustomization file.
+type kustomizationImage struct {
+	Name    string `yaml:"name"`
+	NewName string `yaml:"newName"`
+	NewTag  string `yaml:"newTag"`
+	Digest  string `yaml:"digest"`
+}
+
+// kustomizationManifest is a manifest referenced by a kustomization file,
+// either directly or through a base, along with the "images" transformers
+// that apply to it, from the innermost base outward.
+type kustomizationManifest struct {
+	path   string
+	images []*kustomizationImage
+}
+
+// NewKustomizationImageParser returns an IImageParser for Kustomizations.
+// kubernetesfileImageParser cannot be nil as it is responsible for parsing
+// the manifests referenced by Kustomizations.
+func NewKustomizationImageParser(
+	kubernetesfileImageParser IKubernetesfileImageParser,
+) (IKustomizationImageParser, error) {
+	if kubernetesfileImageParser == nil ||
+		reflect.ValueOf(kubernetesfileImageParser).IsNil() {
+		return nil, errors.New("'kubernetesfileImageParser' cannot be nil")
+	}
+
+	return &kustomizationImageParser{
+		kind:                      kind.Kustomization,
+		kubernetesfileImageParser: kubernetesfileImageParser,
+	}, nil
+}
+
+// Kind is a getter for the kind.
+func (k *kustomizationImageParser) Kind() kind.Kind {
+	return k.kind
+}
+
+// ParseFiles parses IImages from Kustomizations.
+func (k *kustomizationImageParser) ParseFiles(
+	paths <-chan collect.IPath,
+	done <-chan struct{},
+) <-chan IImage {
+	if paths == nil {
+		return nil
+	}
+
+	var (
+		waitGroup           sync.WaitGroup
+		kustomizationImages = make(chan IImage)
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for path := range paths {
+			waitGroup.Add(1)
+
+			go k.ParseFile(
+				path, kustomizationImages, done, &waitGroup,
+			)
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(kustomizationImages)
+	}()
+
+	return kustomizationImages
+}
+
+// ParseFile parses IImages from a Kustomization. The "resources", "bases",
+// and "components" of the Kustomization are resolved to the manifests they
+// reference, and the images in those manifests are parsed as
+// Kubernetesfiles. The "images" transformers of the Kustomization and its
+// bases are then applied, so that the images are those that kustomize would
+// build. Remote resources are skipped.
+func (k *kustomizationImageParser) ParseFile(
+	path collect.IPath,
+	kustomizationImages chan<- IImage,
+	done <-chan struct{},
+	waitGroup *sync.WaitGroup,
+) {
+	defer waitGroup.Done()
+
+	if path == nil || reflect.ValueOf(path).IsNil() ||
+		kustomizationImages == nil {
+		return
+	}
+
+	if path.Err() != nil {
+		select {
+		case <-done:
+		case kustomizationImages <- NewImage(
+			k.kind, "", "", "", nil, path.Err(),
+		):
+		}
+
+		return
+	}
+
+	manifests, err := k.manifests(path.Val(), map[string]bool{})
+	if err != nil {
+		select {
+		case <-done:
+		case kustomizationImages <- NewImage(k.kind, "", "", "", nil, err):
+		}
+
+		return
+	}
+
+	for _, manifest := range manifests {
+		waitGroup.Add(1)
+
+		go k.parseManifest(
+			path, manifest, kustomizationImages, waitGroup, done,
+		)
+	}
+}
+
+// manifests returns the manifests referenced by a kustomization file.
+// visited holds the kustomization files that have already been resolved,
+// to detect cycles between bases.
+func (k *kustomizationImageParser) manifests(
+	path string,
+	visited map[string]bool,
+) ([]*kustomizationManifest, error) {
+	visited[filepath.Clean(path)] = true
+
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kustomization kustomization
+	if err = yaml.Unmarshal(byt, &kustomization); err != nil {
+		return nil, fmt.Errorf(
+			"'%s' failed to parse with err: %v", path, err,
+		)
+	}
+
+	var (
+		manifests []*kustomizationManifest
+		resources []string
+	)
+
+	resources = append(resources, kustomization.Bases...)
+	resources = append(resources, kustomization.Resources...)
+	resources = append(resources, kustomization.Components...)
+
+	for _, resource := range resources {
+		if k.isRemoteResource(resource) {
+			fmt.Printf("warning: '%s' references the remote resource '%s' "+
+				"- skipping because only local resources are supported\n",
+				path, resource,
+			)
+
+			continue
+		}
+
+		resourcePath := filepath.Join(filepath.Dir(path), resource)
+
+		fileInfo, err := os.Stat(resourcePath)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"'%s' references '%s' which failed with err: %v",
+				path, resource, err,
+			)
+		}
+
+		if !fileInfo.Mode().IsDir() {
+			manifests = append(manifests, &kustomizationManifest{
+				path: resourcePath,
+			})
+
+			continue
+		}
+
+		basePath, err := k.kustomizationPath(resourcePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if visited[basePath] {
+			return nil, fmt.Errorf(
+				"'%s' references '%s' which forms a cycle", path, resource,
+			)
+		}
+
+		baseManifests, err := k.manifests(basePath, visited)
+		if err != nil {
+			return nil, err
+		}
+
+		manifests = append(manifests, baseManifests...)
+	}
+
+	for _, manifest := range manifests {
+		manifest.images = append(manifest.images, kustomization.Images...)
+	}
+
+	return manifests, nil
+}
+
+// kustomizationPath returns the path to the kustomization file in a
+// directory.
+func (k *kustomizationImageParser) kustomizationPath(
+	dir string,
+) (string, error) {
+	for _, name := range []string{
+		"kustomization.yaml", "kustomization.yml", "Kustomization",
+	} {
+		path := filepath.Join(dir, name)
+
+		if _, err := os.Stat(path); err == nil {
+			return filepath.Clean(path), nil
+		}
+	}
+
+	return "", fmt.Errorf("'%s' does not contain a kustomization file", dir)
+}
+
+// isRemoteResource reports whether a resource refers to a git repository
+// or url instead of a local file or directory.
+func (k *kustomizationImageParser) isRemoteResource(resource string) bool {
+	return strings.Contains(resource, "://") ||
+		strings.Contains(resource, "?ref=") ||
+		strings.HasPrefix(resource, "git@") ||
+		strings.HasPrefix(resource, "github.com/")
+}
+
+func (k *kustomizationImageParser) parseManifest(
+	path collect.IPath,
+	manifest *kustomizationManifest,
+	kustomizationImages chan<- IImage,
+	waitGroup *sync.WaitGroup,
+	done <-chan struct{},
+) {
+	defer waitGroup.Done()
+
+	var (
+		kubernetesfileImageWaitGroup sync.WaitGroup
+		kubernetesfileImages         = make(chan IImage)
+	)
+
+	kubernetesfileImageWaitGroup.Add(1)
+
+	go k.kubernetesfileImageParser.ParseFile(
+		collect.NewPath(k.kind, manifest.path, nil), kubernetesfileImages,
+		done, &kubernetesfileImageWaitGroup,
+	)
+
+	go func() {
+		kubernetesfileImageWaitGroup.Wait()
+		close(kubernetesfileImages)
+	}()
+
+	for kubernetesfileImage := range kubernetesfileImages {
+		kubernetesfileImage.SetKind(k.kind)
+
+		if kubernetesfileImage.Err() != nil {
+			select {
+			case <-done:
+			case kustomizationImages <- kubernetesfileImage:
+			}
+
+			return
+		}
+
+		kubernetesfileImageMetadata := kubernetesfileImage.Metadata()
+		if kubernetesfileImageMetadata == nil {
+			select {
+			case <-done:
+			case kustomizationImages <- NewImage(
+				kubernetesfileImage.Kind(), "", "", "", nil,
+				errors.New("'metadata' cannot be nil"),
+			):
+			}
+
+			return
+		}
+
+		for _, transformer := range manifest.images {
+			k.transformImage(kubernetesfileImage, transformer)
+		}
+
+		kubernetesfileImage.SetMetadata(map[string]interface{}{
+			"manifestPath":  manifest.path,
+			"containerName": kubernetesfileImageMetadata["containerName"],
+			"docPosition":   kubernetesfileImageMetadata["docPosition"],
+			"imagePosition": kubernetesfileImageMetadata["imagePosition"],
+			"path":          path.Val(),
+		})
+
+		select {
+		case <-done:
+			return
+		case kustomizationImages <- kubernetesfileImage:
+		}
+	}
+}
+
+// transformImage applies an "images" transformer to an image in the same
+// way as kustomize. If both "newTag" and "digest" are set, the image has
+// both. If only one is set, it replaces both the original tag and digest.
+func (k *kustomizationImageParser) transformImage(
+	image IImage,
+	transformer *kustomizationImage,
+) {
+	if transformer == nil || transformer.Name != image.Name() {
+		return
+	}
+
+	if transformer.NewName != "" {
+		image.SetName(transformer.NewName)
+	}
+
+	digest := strings.TrimPrefix(transformer.Digest, "sha256:")
+
+	switch {
+	case transformer.NewTag != "" && digest != "":
+		image.SetTag(transformer.NewTag)
+		image.SetDigest(digest)
+	case transformer.NewTag != "":
+		image.SetTag(transformer.NewTag)
+		image.SetDigest("")
+	case digest != "":
+		image.SetTag("")
+		image.SetDigest(digest)
+	}
+}
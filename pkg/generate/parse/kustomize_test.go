@@ -0,0 +1,317 @@
+package parse_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/collect"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+const kustomizationImageParserTestDir = "kustomizationParser-tests"
+
+func TestKustomizationImageParser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                   string
+		KubernetesfilePaths    []string
+		KubernetesfileContents [][]byte
+		KustomizationPaths     []string
+		KustomizationContents  [][]byte
+		PathsToParse           []string
+		Expected               []parse.IImage
+		ShouldFail             bool
+	}{
+		{
+			Name:                "Resources",
+			KubernetesfilePaths: []string{"pod.yaml"},
+			KubernetesfileContents: [][]byte{
+				[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+  - name: busybox
+    image: busybox
+  - name: golang
+    image: golang:1.16
+`),
+			},
+			KustomizationPaths: []string{"kustomization.yaml"},
+			KustomizationContents: [][]byte{
+				[]byte(`resources:
+- pod.yaml
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Kustomization, "busybox", "latest", "",
+					map[string]interface{}{
+						"manifestPath":  "pod.yaml",
+						"containerName": "busybox",
+						"docPosition":   0,
+						"imagePosition": 0,
+						"path":          "kustomization.yaml",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Kustomization, "golang", "1.16", "",
+					map[string]interface{}{
+						"manifestPath":  "pod.yaml",
+						"containerName": "golang",
+						"docPosition":   0,
+						"imagePosition": 1,
+						"path":          "kustomization.yaml",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name: "Overlay Images Transformer",
+			KubernetesfilePaths: []string{
+				filepath.Join("base", "pod.yaml"),
+			},
+			KubernetesfileContents: [][]byte{
+				[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+  - name: busybox
+    image: busybox
+  - name: redis
+    image: redis:6.0
+  - name: golang
+    image: golang:1.15
+`),
+			},
+			KustomizationPaths: []string{
+				filepath.Join("base", "kustomization.yaml"),
+				filepath.Join("overlays", "prod", "kustomization.yaml"),
+			},
+			KustomizationContents: [][]byte{
+				[]byte(`resources:
+- pod.yaml
+images:
+- name: busybox
+  newName: myregistry/busybox
+`),
+				[]byte(`bases:
+- ../../base
+images:
+- name: myregistry/busybox
+  newTag: "1.33"
+- name: redis
+  digest: sha256:redis
+- name: golang
+  newTag: "1.16"
+  digest: sha256:golang
+`),
+			},
+			PathsToParse: []string{
+				filepath.Join("overlays", "prod", "kustomization.yaml"),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Kustomization, "myregistry/busybox", "1.33", "",
+					map[string]interface{}{
+						"manifestPath":  filepath.Join("base", "pod.yaml"),
+						"containerName": "busybox",
+						"docPosition":   0,
+						"imagePosition": 0,
+						"path": filepath.Join(
+							"overlays", "prod", "kustomization.yaml",
+						),
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Kustomization, "redis", "", "redis",
+					map[string]interface{}{
+						"manifestPath":  filepath.Join("base", "pod.yaml"),
+						"containerName": "redis",
+						"docPosition":   0,
+						"imagePosition": 1,
+						"path": filepath.Join(
+							"overlays", "prod", "kustomization.yaml",
+						),
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Kustomization, "golang", "1.16", "golang",
+					map[string]interface{}{
+						"manifestPath":  filepath.Join("base", "pod.yaml"),
+						"containerName": "golang",
+						"docPosition":   0,
+						"imagePosition": 2,
+						"path": filepath.Join(
+							"overlays", "prod", "kustomization.yaml",
+						),
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:                "Remote Resource",
+			KubernetesfilePaths: []string{"pod.yaml"},
+			KubernetesfileContents: [][]byte{
+				[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+  - name: busybox
+    image: busybox
+`),
+			},
+			KustomizationPaths: []string{"kustomization.yaml"},
+			KustomizationContents: [][]byte{
+				[]byte(`resources:
+- github.com/kubernetes-sigs/kustomize/examples/multibases?ref=v1.0.6
+- pod.yaml
+`),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Kustomization, "busybox", "latest", "",
+					map[string]interface{}{
+						"manifestPath":  "pod.yaml",
+						"containerName": "busybox",
+						"docPosition":   0,
+						"imagePosition": 0,
+						"path":          "kustomization.yaml",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name:               "Missing Resource",
+			KustomizationPaths: []string{"kustomization.yaml"},
+			KustomizationContents: [][]byte{
+				[]byte(`resources:
+- pod.yaml
+`),
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Cycle",
+			KustomizationPaths: []string{
+				filepath.Join("a", "kustomization.yaml"),
+				filepath.Join("b", "kustomization.yaml"),
+			},
+			KustomizationContents: [][]byte{
+				[]byte(`resources:
+- ../b
+`),
+				[]byte(`resources:
+- ../a
+`),
+			},
+			PathsToParse: []string{filepath.Join("a", "kustomization.yaml")},
+			ShouldFail:   true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(
+				t, kustomizationImageParserTestDir,
+			)
+			defer os.RemoveAll(tempDir)
+
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, test.KubernetesfilePaths,
+			)
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, test.KustomizationPaths,
+			)
+
+			_ = testutils.WriteFilesToTempDir(
+				t, tempDir, test.KubernetesfilePaths,
+				test.KubernetesfileContents,
+			)
+			pathsToParse := testutils.WriteFilesToTempDir(
+				t, tempDir, test.KustomizationPaths,
+				test.KustomizationContents,
+			)
+
+			if len(test.PathsToParse) != 0 {
+				pathsToParse = nil
+
+				for _, path := range test.PathsToParse {
+					pathsToParse = append(
+						pathsToParse, filepath.Join(tempDir, path),
+					)
+				}
+			}
+
+			pathsToParseCh := make(chan collect.IPath, len(pathsToParse))
+			for _, path := range pathsToParse {
+				pathsToParseCh <- collect.NewPath(
+					kind.Kustomization, path, nil,
+				)
+			}
+			close(pathsToParseCh)
+
+			done := make(chan struct{})
+			defer close(done)
+
+			parser, err := parse.NewKustomizationImageParser(
+				parse.NewKubernetesfileImageParser(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			images := parser.ParseFiles(pathsToParseCh, done)
+
+			var got []parse.IImage
+
+			for image := range images {
+				if test.ShouldFail {
+					if image.Err() == nil {
+						t.Fatal("expected error but did not get one")
+					}
+
+					return
+				}
+
+				if image.Err() != nil {
+					t.Fatal(image.Err())
+				}
+
+				got = append(got, image)
+			}
+
+			if test.ShouldFail {
+				t.Fatal("expected error but did not get one")
+			}
+
+			for _, image := range test.Expected {
+				metadata := image.Metadata()
+				metadata["path"] = filepath.Join(
+					tempDir, metadata["path"].(string),
+				)
+				metadata["manifestPath"] = filepath.Join(
+					tempDir, metadata["manifestPath"].(string),
+				)
+				image.SetMetadata(metadata)
+			}
+
+			testutils.SortKustomizationImages(t, got)
+
+			testutils.AssertImagesEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package parse
+
+import "github.com/safe-waters/docker-lock/pkg/lockfile"
+
+// PlatformDigest is the digest of an image for a single platform, such as
+// "linux/arm64/v8", in a multi-architecture manifest list.
+type PlatformDigest = lockfile.PlatformDigest
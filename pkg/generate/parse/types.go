@@ -69,3 +69,36 @@ type IKubernetesfileImageParser interface {
 		waitGroup *sync.WaitGroup,
 	)
 }
+
+// IHelmchartImageParser is an IImageParser for Helm charts.
+type IHelmchartImageParser interface {
+	IImageParser
+	ParseFile(
+		path collect.IPath,
+		helmchartImages chan<- IImage,
+		done <-chan struct{},
+		waitGroup *sync.WaitGroup,
+	)
+}
+
+// IKustomizationImageParser is an IImageParser for Kustomizations.
+type IKustomizationImageParser interface {
+	IImageParser
+	ParseFile(
+		path collect.IPath,
+		kustomizationImages chan<- IImage,
+		done <-chan struct{},
+		waitGroup *sync.WaitGroup,
+	)
+}
+
+// IBakefileImageParser is an IImageParser for Bakefiles.
+type IBakefileImageParser interface {
+	IImageParser
+	ParseFile(
+		path collect.IPath,
+		bakefileImages chan<- IImage,
+		done <-chan struct{},
+		waitGroup *sync.WaitGroup,
+	)
+}
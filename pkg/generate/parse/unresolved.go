@@ -0,0 +1,49 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnresolvedVariable is a variable without a value in the reference to an
+// image, such as "VERSION" in "FROM golang:${VERSION}" if the ARG has no
+// default and no build arg. It expands to an empty string, so the image
+// may not be the one that is built.
+type UnresolvedVariable struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Name string `json:"name"`
+}
+
+// String returns the path, line, and name of the variable.
+func (u *UnresolvedVariable) String() string {
+	return fmt.Sprintf(
+		"'%s' line %d: variable '%s' is not set", u.Path, u.Line, u.Name,
+	)
+}
+
+// UnresolvedVariables returns the variables without values in the
+// reference to the image, from its "unresolvedVariables" metadata.
+func UnresolvedVariables(image IImage) []*UnresolvedVariable {
+	if image == nil || reflect.ValueOf(image).IsNil() {
+		return nil
+	}
+
+	unresolved, _ := image.Metadata()["unresolvedVariables"].([]*UnresolvedVariable) // nolint: lll
+
+	return unresolved
+}
+
+func newUnresolvedVariables(
+	path string,
+	line int,
+	names []string,
+) []*UnresolvedVariable {
+	unresolved := make([]*UnresolvedVariable, len(names))
+
+	for i, name := range names {
+		unresolved[i] = &UnresolvedVariable{Path: path, Line: line, Name: name}
+	}
+
+	return unresolved
+}
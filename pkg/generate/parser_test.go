@@ -144,7 +144,7 @@ spec:
 
 			close(paths)
 
-			dockerfileImageParser := parse.NewDockerfileImageParser()
+			dockerfileImageParser := parse.NewDockerfileImageParser(nil)
 			composefileImageParser, err := parse.NewComposefileImageParser(
 				dockerfileImageParser,
 			)
@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/safe-waters/docker-lock/internal/semver"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+type imagePolicyChecker struct {
+	forbidLatest      bool
+	requireSemver     bool
+	semverRanges      []*semverRange
+	allowedRegistries []string
+	deniedImages      []string
+}
+
+// semverRange is a range that the tags of images whose names match pattern
+// must satisfy.
+type semverRange struct {
+	pattern    string
+	constraint *semver.Constraint
+}
+
+// NewImagePolicyChecker returns an IImagePolicyChecker after validating the
+// globs and ranges of the Rules.
+func NewImagePolicyChecker(rules *Rules) (IImagePolicyChecker, error) {
+	if rules == nil {
+		return nil, errors.New("'rules' cannot be nil")
+	}
+
+	for _, pattern := range append(
+		append([]string{}, rules.AllowedRegistries...),
+		rules.DeniedImages...,
+	) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid glob", pattern)
+		}
+	}
+
+	semverRanges := make([]*semverRange, 0, len(rules.SemverRanges))
+
+	for pattern, constraint := range rules.SemverRanges {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid glob", pattern)
+		}
+
+		parsedConstraint, err := semver.ParseConstraint(constraint)
+		if err != nil {
+			return nil, err
+		}
+
+		semverRanges = append(semverRanges, &semverRange{
+			pattern:    pattern,
+			constraint: parsedConstraint,
+		})
+	}
+
+	sort.Slice(semverRanges, func(i, j int) bool {
+		return semverRanges[i].pattern < semverRanges[j].pattern
+	})
+
+	allowedRegistries := make([]string, len(rules.AllowedRegistries))
+	for i, registry := range rules.AllowedRegistries {
+		allowedRegistries[i] = update.NormalizeRegistry(registry)
+	}
+
+	return &imagePolicyChecker{
+		forbidLatest:      rules.ForbidLatest,
+		requireSemver:     rules.RequireSemver,
+		semverRanges:      semverRanges,
+		allowedRegistries: allowedRegistries,
+		deniedImages:      rules.DeniedImages,
+	}, nil
+}
+
+// CheckImage returns a Violation for every Rule the image does not satisfy.
+// The image "scratch" is not a real image, so it satisfies every Rule.
+func (i *imagePolicyChecker) CheckImage(image parse.IImage) []*Violation {
+	if image.Name() == "scratch" {
+		return nil
+	}
+
+	var violations []*Violation
+
+	violation := func(rule Rule, format string, args ...interface{}) {
+		violations = append(
+			violations, newViolation(image, rule, fmt.Sprintf(format, args...)),
+		)
+	}
+
+	nameTag := image.Name()
+	if image.Tag() != "" {
+		nameTag = fmt.Sprintf("%s:%s", image.Name(), image.Tag())
+	}
+
+	for _, pattern := range i.deniedImages {
+		if matches(pattern, image.Name()) || matches(pattern, nameTag) {
+			violation(DeniedImages, "the image matches '%s'", pattern)
+			break
+		}
+	}
+
+	if len(i.allowedRegistries) != 0 {
+		registry, err := update.ImageRegistry(image.Name())
+
+		switch {
+		case err != nil:
+			violation(
+				AllowedRegistries,
+				"the registry could not be determined with err: %v", err,
+			)
+		case !matchesAny(i.allowedRegistries, registry):
+			violation(
+				AllowedRegistries, "the registry '%s' is not allowed", registry,
+			)
+		}
+	}
+
+	if i.forbidLatest && image.Tag() == "latest" {
+		violation(ForbidLatest, "the tag 'latest' is forbidden")
+	}
+
+	if image.Tag() == "" {
+		return violations
+	}
+
+	version, err := semver.Parse(image.Tag())
+
+	if i.requireSemver && err != nil {
+		violation(
+			RequireSemver, "the tag '%s' is not a semantic version",
+			image.Tag(),
+		)
+	}
+
+	for _, semverRange := range i.semverRanges {
+		if !matches(semverRange.pattern, image.Name()) {
+			continue
+		}
+
+		if err != nil || !semverRange.constraint.Check(version) {
+			violation(
+				SemverRanges, "the tag '%s' does not satisfy '%s'",
+				image.Tag(), semverRange.constraint,
+			)
+		}
+	}
+
+	return violations
+}
+
+func newViolation(image parse.IImage, rule Rule, message string) *Violation {
+	metadata := image.Metadata()
+
+	filePath, _ := metadata["path"].(string)
+	serviceName, _ := metadata["serviceName"].(string)
+	containerName, _ := metadata["containerName"].(string)
+	targetName, _ := metadata["targetName"].(string)
+
+	return &Violation{
+		Rule:          rule,
+		Kind:          image.Kind(),
+		Path:          filePath,
+		ServiceName:   serviceName,
+		ContainerName: containerName,
+		TargetName:    targetName,
+		Image:         image.ImageLine(),
+		Message:       message,
+	}
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matches(pattern, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matches(pattern string, value string) bool {
+	// patterns were validated in NewImagePolicyChecker
+	matched, _ := path.Match(pattern, value)
+
+	return matched
+}
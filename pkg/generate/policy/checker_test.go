@@ -0,0 +1,282 @@
+package policy_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/policy"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+func TestImagePolicyChecker(t *testing.T) {
+	t.Parallel()
+
+	composefileImage := func(name string, tag string) parse.IImage {
+		return parse.NewImage(
+			kind.Composefile, name, tag, "",
+			map[string]interface{}{
+				"path":        "docker-compose.yml",
+				"serviceName": "web",
+			}, nil,
+		)
+	}
+
+	kubernetesfileImage := func(name string, tag string) parse.IImage {
+		return parse.NewImage(
+			kind.Kubernetesfile, name, tag, "",
+			map[string]interface{}{
+				"path":          "pod.yml",
+				"containerName": "app",
+			}, nil,
+		)
+	}
+
+	tests := []struct {
+		Name        string
+		Rules       *policy.Rules
+		Image       parse.IImage
+		Expected    []*policy.Violation
+		ShouldError bool
+	}{
+		{
+			Name:  "No Rules",
+			Rules: &policy.Rules{},
+			Image: composefileImage("redis", "latest"),
+		},
+		{
+			Name:  "Forbid Latest",
+			Rules: &policy.Rules{ForbidLatest: true},
+			Image: composefileImage("redis", "latest"),
+			Expected: []*policy.Violation{
+				{
+					Rule:        policy.ForbidLatest,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "redis:latest",
+					Message:     "the tag 'latest' is forbidden",
+				},
+			},
+		},
+		{
+			Name:  "Require Semver",
+			Rules: &policy.Rules{RequireSemver: true},
+			Image: kubernetesfileImage("python", "slim-buster"),
+			Expected: []*policy.Violation{
+				{
+					Rule:          policy.RequireSemver,
+					Kind:          kind.Kubernetesfile,
+					Path:          "pod.yml",
+					ContainerName: "app",
+					Image:         "python:slim-buster",
+					Message: "the tag 'slim-buster' is not a " +
+						"semantic version",
+				},
+			},
+		},
+		{
+			Name:  "Require Semver Allows Digests",
+			Rules: &policy.Rules{RequireSemver: true},
+			Image: parse.NewImage(
+				kind.Dockerfile, "python", "", "sha", nil, nil,
+			),
+		},
+		{
+			Name: "Semver Ranges",
+			Rules: &policy.Rules{
+				SemverRanges: map[string]string{
+					"golang":  ">=1.16, <2",
+					"python":  "~3.9",
+					"golang*": "<1.17",
+				},
+			},
+			Image: composefileImage("golang", "1.17-alpine"),
+			Expected: []*policy.Violation{
+				{
+					Rule:        policy.SemverRanges,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "golang:1.17-alpine",
+					Message: "the tag '1.17-alpine' does not satisfy " +
+						"'<1.17'",
+				},
+			},
+		},
+		{
+			Name: "Semver Ranges Reject Other Tags",
+			Rules: &policy.Rules{
+				SemverRanges: map[string]string{"golang": ">=1.16"},
+			},
+			Image: composefileImage("golang", "latest"),
+			Expected: []*policy.Violation{
+				{
+					Rule:        policy.SemverRanges,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "golang:latest",
+					Message: "the tag 'latest' does not satisfy " +
+						"'>=1.16'",
+				},
+			},
+		},
+		{
+			Name: "Allowed Registries",
+			Rules: &policy.Rules{
+				AllowedRegistries: []string{"index.docker.io", "*.corp.io"},
+			},
+			Image: kubernetesfileImage("ghcr.io/org/app", "1.0.0"),
+			Expected: []*policy.Violation{
+				{
+					Rule:          policy.AllowedRegistries,
+					Kind:          kind.Kubernetesfile,
+					Path:          "pod.yml",
+					ContainerName: "app",
+					Image:         "ghcr.io/org/app:1.0.0",
+					Message:       "the registry 'ghcr.io' is not allowed",
+				},
+			},
+		},
+		{
+			Name: "Allowed Registries Normalizes Docker Hub",
+			Rules: &policy.Rules{
+				AllowedRegistries: []string{"index.docker.io", "*.corp.io"},
+			},
+			Image: kubernetesfileImage("redis", "6.2"),
+		},
+		{
+			Name: "Denied Images",
+			Rules: &policy.Rules{
+				DeniedImages: []string{"python:2*", "ubuntu"},
+			},
+			Image: composefileImage("python", "2.7-slim"),
+			Expected: []*policy.Violation{
+				{
+					Rule:        policy.DeniedImages,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "python:2.7-slim",
+					Message:     "the image matches 'python:2*'",
+				},
+			},
+		},
+		{
+			Name: "Multiple Violations",
+			Rules: &policy.Rules{
+				ForbidLatest:  true,
+				RequireSemver: true,
+				DeniedImages:  []string{"redis"},
+			},
+			Image: composefileImage("redis", "latest"),
+			Expected: []*policy.Violation{
+				{
+					Rule:        policy.DeniedImages,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "redis:latest",
+					Message:     "the image matches 'redis'",
+				},
+				{
+					Rule:        policy.ForbidLatest,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "redis:latest",
+					Message:     "the tag 'latest' is forbidden",
+				},
+				{
+					Rule:        policy.RequireSemver,
+					Kind:        kind.Composefile,
+					Path:        "docker-compose.yml",
+					ServiceName: "web",
+					Image:       "redis:latest",
+					Message:     "the tag 'latest' is not a semantic version",
+				},
+			},
+		},
+		{
+			Name: "Scratch",
+			Rules: &policy.Rules{
+				RequireSemver:     true,
+				AllowedRegistries: []string{"ghcr.io"},
+			},
+			Image: parse.NewImage(kind.Dockerfile, "scratch", "", "", nil, nil),
+		},
+		{
+			Name: "Invalid Glob",
+			Rules: &policy.Rules{
+				DeniedImages: []string{"["},
+			},
+			ShouldError: true,
+		},
+		{
+			Name: "Invalid Range",
+			Rules: &policy.Rules{
+				SemverRanges: map[string]string{"golang": ">=latest"},
+			},
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			checker, err := policy.NewImagePolicyChecker(test.Rules)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := checker.CheckImage(test.Image)
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != string(gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	t.Parallel()
+
+	violation := &policy.Violation{
+		Rule:        policy.ForbidLatest,
+		Kind:        kind.Composefile,
+		Path:        "docker-compose.yml",
+		ServiceName: "web",
+		Image:       "redis:latest",
+		Message:     "the tag 'latest' is forbidden",
+	}
+
+	expected := "on path 'docker-compose.yml' of kind 'composefiles', " +
+		"service 'web', image 'redis:latest' violates rule " +
+		"'forbid-latest': the tag 'latest' is forbidden"
+
+	if got := violation.String(); got != expected {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+}
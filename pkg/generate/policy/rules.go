@@ -0,0 +1,39 @@
+package policy
+
+// Rule is the name of a rule in a policy.
+type Rule string
+
+// Rules of a policy.
+const (
+	ForbidLatest      Rule = "forbid-latest"
+	RequireSemver     Rule = "require-semver"
+	SemverRanges      Rule = "semver-ranges"
+	AllowedRegistries Rule = "allowed-registries"
+	DeniedImages      Rule = "denied-images"
+)
+
+// Rules configure the policy that images are checked against. The zero value
+// allows every image.
+//
+// ForbidLatest forbids the tag "latest".
+//
+// RequireSemver requires tags to be semantic versions, such as "1.16" or
+// "3.9.7-alpine". Images pinned only by digest are allowed.
+//
+// SemverRanges maps globs, matched against image names, to ranges that
+// their tags must satisfy, such as ">=1.16, <2". The syntax of the ranges
+// is described in semver.Constraint.
+//
+// AllowedRegistries are globs, such as "docker.io" or "*.corp.example.com",
+// matched against the registries of images. If it is empty, every registry
+// is allowed.
+//
+// DeniedImages are globs matched against image names, and against image
+// names with their tags, such as "redis" or "python:2*".
+type Rules struct {
+	ForbidLatest      bool              `mapstructure:"forbid-latest"`
+	RequireSemver     bool              `mapstructure:"require-semver"`
+	SemverRanges      map[string]string `mapstructure:"semver-ranges"`
+	AllowedRegistries []string          `mapstructure:"allowed-registries"`
+	DeniedImages      []string          `mapstructure:"denied-images"`
+}
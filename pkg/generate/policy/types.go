@@ -0,0 +1,11 @@
+// Package policy provides functionality to check images against the rules
+// of a policy.
+package policy
+
+import "github.com/safe-waters/docker-lock/pkg/generate/parse"
+
+// IImagePolicyChecker provides an interface for ImagePolicyCheckers, which
+// check images against the rules of a policy.
+type IImagePolicyChecker interface {
+	CheckImage(image parse.IImage) []*Violation
+}
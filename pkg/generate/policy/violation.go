@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// Violation is an image that does not satisfy a Rule. ServiceName,
+// ContainerName, and TargetName locate the image in its file for
+// Composefiles, Kubernetesfiles, Kustomizations, and Bakefiles.
+type Violation struct {
+	Rule          Rule      `json:"rule"`
+	Kind          kind.Kind `json:"kind"`
+	Path          string    `json:"path"`
+	ServiceName   string    `json:"serviceName,omitempty"`
+	ContainerName string    `json:"containerName,omitempty"`
+	TargetName    string    `json:"targetName,omitempty"`
+	Image         string    `json:"image"`
+	Message       string    `json:"message"`
+}
+
+// String returns a human readable description of the Violation.
+func (v *Violation) String() string {
+	location := fmt.Sprintf("on path '%s' of kind '%s'", v.Path, v.Kind)
+
+	if v.ServiceName != "" {
+		location = fmt.Sprintf("%s, service '%s'", location, v.ServiceName)
+	}
+
+	if v.ContainerName != "" {
+		location = fmt.Sprintf(
+			"%s, container '%s'", location, v.ContainerName,
+		)
+	}
+
+	if v.TargetName != "" {
+		location = fmt.Sprintf("%s, target '%s'", location, v.TargetName)
+	}
+
+	return fmt.Sprintf(
+		"%s, image '%s' violates rule '%s': %s",
+		location, v.Image, v.Rule, v.Message,
+	)
+}
@@ -6,7 +6,7 @@ import (
 
 	"github.com/safe-waters/docker-lock/pkg/generate/collect"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
-	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 // IGenerator provides an interface for Generators, which are responsible
@@ -30,6 +30,16 @@ type IImageParser interface {
 	) <-chan parse.IImage
 }
 
+// IImagePolicyChecker provides an interface for ImagePolicyCheckers, which
+// are responsible for checking images against the rules of a policy before
+// registries are queried for their digests.
+type IImagePolicyChecker interface {
+	CheckImages(
+		images <-chan parse.IImage,
+		done <-chan struct{},
+	) <-chan parse.IImage
+}
+
 // IImageDigestUpdater provides an interface for ImageDigestUpdaters, which
 // are responsible for querying registries for digests and updating images
 // with them.
@@ -46,5 +56,5 @@ type IImageFormatter interface {
 	FormatImages(
 		images <-chan parse.IImage,
 		done <-chan struct{},
-	) (map[kind.Kind]map[string][]interface{}, error)
+	) (*lockfile.Lockfile, error)
 }
@@ -0,0 +1,333 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
+
+type cachedDigestRequester struct {
+	capabilities
+	digestRequester IDigestRequester
+	digestCache     IDigestCache
+}
+
+type digestCache struct {
+	path    string
+	ttl     time.Duration
+	refresh bool
+	saved   *digestCacheFile
+	queried *digestCacheFile
+	mutex   sync.Mutex
+}
+
+// digestCacheFile is the format of the cache file. Digests are keyed by
+// "name:tag" and platform digests are keyed by image line.
+type digestCacheFile struct {
+	Digests         map[string]*cachedDigest `json:"digests"`
+	PlatformDigests map[string]*cachedDigest `json:"platformDigests"`
+}
+
+type cachedDigest struct {
+	Digest    string                  `json:"digest"`
+	Platforms []*parse.PlatformDigest `json:"platforms,omitempty"`
+	CreatedAt time.Time               `json:"createdAt"`
+}
+
+// NewCachedDigestRequester returns an IDigestRequester that serves digests
+// and platform digests from digestCache, querying digestRequester for those
+// that are not in it and storing the results.
+//
+// The returned IDigestRequester only has the capabilities of
+// digestRequester, such as being an IPlatformDigestRequester. Creation
+// times and artifacts are not cached.
+func NewCachedDigestRequester(
+	digestRequester IDigestRequester,
+	digestCache IDigestCache,
+) (IDigestRequester, error) {
+	if digestRequester == nil || reflect.ValueOf(digestRequester).IsNil() {
+		return nil, errors.New("'digestRequester' cannot be nil")
+	}
+
+	if digestCache == nil || reflect.ValueOf(digestCache).IsNil() {
+		return nil, errors.New("'digestCache' cannot be nil")
+	}
+
+	cachedDigestRequester := &cachedDigestRequester{
+		capabilities:    newCapabilities(digestRequester),
+		digestRequester: digestRequester,
+		digestCache:     digestCache,
+	}
+
+	return cachedDigestRequester.expose(cachedDigestRequester), nil
+}
+
+// Digest returns the cached digest for a name and tag, querying the
+// registry if it is not in the cache.
+func (c *cachedDigestRequester) Digest(
+	name string,
+	tag string,
+) (string, error) {
+	key := fmt.Sprintf("%s:%s", name, tag)
+
+	if digest, ok := c.digestCache.Digest(key); ok {
+		return digest, nil
+	}
+
+	digest, err := c.digestRequester.Digest(name, tag)
+	if err != nil {
+		return "", err
+	}
+
+	c.digestCache.StoreDigest(key, digest)
+
+	return digest, nil
+}
+
+// PlatformDigests returns the cached digest and platform digests for an
+// image line, querying the registry if they are not in the cache.
+func (c *cachedDigestRequester) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	if digest, platformDigests, ok := c.digestCache.PlatformDigests(
+		imageLine,
+	); ok {
+		return digest, platformDigests, nil
+	}
+
+	digest, platformDigests, err := c.platformDigestRequester.PlatformDigests(
+		imageLine,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.digestCache.StorePlatformDigests(imageLine, digest, platformDigests)
+
+	return digest, platformDigests, nil
+}
+
+// NewDigestCache returns an IDigestCache that reads the results saved in the
+// file at path and saves new results to it when flushed. Results are only
+// used until they are older than ttl.
+//
+// If refresh is true, the results saved in the file are not used, so every
+// digest is queried again. Results that are not queried again are still
+// kept in the file.
+func NewDigestCache(
+	path string,
+	ttl time.Duration,
+	refresh bool,
+) (IDigestCache, error) {
+	if path == "" {
+		return nil, errors.New("'path' cannot be empty")
+	}
+
+	if ttl < 0 {
+		return nil, errors.New("'ttl' cannot be negative")
+	}
+
+	saved, err := loadDigestCacheFile(path, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &digestCache{
+		path:    path,
+		ttl:     ttl,
+		refresh: refresh,
+		saved:   saved,
+		queried: newDigestCacheFile(),
+	}, nil
+}
+
+// Digest returns the digest stored under a key, such as "busybox:latest".
+func (d *digestCache) Digest(key string) (string, bool) {
+	cached := d.lookup(func(file *digestCacheFile) *cachedDigest {
+		return file.Digests[key]
+	})
+	if cached == nil {
+		return "", false
+	}
+
+	return cached.Digest, true
+}
+
+// StoreDigest stores a digest under a key, such as "busybox:latest".
+func (d *digestCache) StoreDigest(key string, digest string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.queried.Digests[key] = &cachedDigest{
+		Digest:    digest,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// PlatformDigests returns the digest and platform digests stored under an
+// image line.
+func (d *digestCache) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, bool) {
+	cached := d.lookup(func(file *digestCacheFile) *cachedDigest {
+		return file.PlatformDigests[imageLine]
+	})
+	if cached == nil {
+		return "", nil, false
+	}
+
+	return cached.Digest, cached.Platforms, true
+}
+
+// StorePlatformDigests stores a digest and platform digests under an image
+// line.
+func (d *digestCache) StorePlatformDigests(
+	imageLine string,
+	digest string,
+	platformDigests []*parse.PlatformDigest,
+) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.queried.PlatformDigests[imageLine] = &cachedDigest{
+		Digest:    digest,
+		Platforms: platformDigests,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Flush saves the results stored since the cache was created, along with
+// the unexpired results already in the file, to the file. If no results
+// were stored, the file is not written.
+func (d *digestCache) Flush() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.queried.Digests) == 0 && len(d.queried.PlatformDigests) == 0 {
+		return nil
+	}
+
+	for key, cached := range d.queried.Digests {
+		d.saved.Digests[key] = cached
+	}
+
+	for key, cached := range d.queried.PlatformDigests {
+		d.saved.PlatformDigests[key] = cached
+	}
+
+	d.queried = newDigestCacheFile()
+
+	byt, err := json.MarshalIndent(d.saved, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.path), 0700); err != nil { // nolint: gomnd
+		return err
+	}
+
+	// Write to a temporary file and rename it so that other processes
+	// never read a partially written cache.
+	tempFile, err := ioutil.TempFile(
+		filepath.Dir(d.path), fmt.Sprintf("%s-*", filepath.Base(d.path)),
+	)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(byt); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), d.path)
+}
+
+// lookup returns the unexpired result that get finds in the results stored
+// since the cache was created, or otherwise in the results already in the
+// file, unless the cache is refreshing.
+func (d *digestCache) lookup(
+	get func(file *digestCacheFile) *cachedDigest,
+) *cachedDigest {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	files := []*digestCacheFile{d.queried}
+	if !d.refresh {
+		files = append(files, d.saved)
+	}
+
+	for _, file := range files {
+		if cached := get(file); cached != nil && !isExpired(cached, d.ttl) {
+			return cached
+		}
+	}
+
+	return nil
+}
+
+func newDigestCacheFile() *digestCacheFile {
+	return &digestCacheFile{
+		Digests:         map[string]*cachedDigest{},
+		PlatformDigests: map[string]*cachedDigest{},
+	}
+}
+
+// loadDigestCacheFile reads the cache file at path, dropping expired
+// results. If the file does not exist, the cache is empty.
+func loadDigestCacheFile(
+	path string,
+	ttl time.Duration,
+) (*digestCacheFile, error) {
+	file := &digestCacheFile{}
+
+	byt, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(byt, file); err != nil {
+			return nil, fmt.Errorf(
+				"'%s' failed to parse with err: %v", path, err,
+			)
+		}
+	}
+
+	for _, cachedDigests := range []map[string]*cachedDigest{
+		file.Digests, file.PlatformDigests,
+	} {
+		for key, cached := range cachedDigests {
+			if cached == nil || isExpired(cached, ttl) {
+				delete(cachedDigests, key)
+			}
+		}
+	}
+
+	if file.Digests == nil {
+		file.Digests = map[string]*cachedDigest{}
+	}
+
+	if file.PlatformDigests == nil {
+		file.PlatformDigests = map[string]*cachedDigest{}
+	}
+
+	return file, nil
+}
+
+func isExpired(cached *cachedDigest, ttl time.Duration) bool {
+	return time.Since(cached.CreatedAt) >= ttl
+}
@@ -0,0 +1,342 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+const cachedDigestRequesterTestDir = "cachedDigestRequester-tests"
+
+func TestCachedDigestRequester(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                    string
+		CacheContents           []byte
+		TTL                     time.Duration
+		Refresh                 bool
+		ExpectedDigest          string
+		ExpectedNumNetworkCalls uint64
+		ShouldFail              bool
+	}{
+		{
+			Name:                    "Empty Cache",
+			TTL:                     time.Hour,
+			ExpectedDigest:          testutils.BusyboxLatestSHA,
+			ExpectedNumNetworkCalls: 1,
+		},
+		{
+			Name: "Cached Digest",
+			CacheContents: []byte(`{
+	"digests": {
+		"busybox:latest": {
+			"digest": "cached",
+			"createdAt": "` + time.Now().UTC().Format(time.RFC3339) + `"
+		}
+	}
+}`),
+			TTL:                     time.Hour,
+			ExpectedDigest:          "cached",
+			ExpectedNumNetworkCalls: 0,
+		},
+		{
+			Name: "Expired Digest",
+			CacheContents: []byte(`{
+	"digests": {
+		"busybox:latest": {
+			"digest": "cached",
+			"createdAt": "2020-01-01T00:00:00Z"
+		}
+	}
+}`),
+			TTL:                     time.Hour,
+			ExpectedDigest:          testutils.BusyboxLatestSHA,
+			ExpectedNumNetworkCalls: 1,
+		},
+		{
+			Name: "Refresh",
+			CacheContents: []byte(`{
+	"digests": {
+		"busybox:latest": {
+			"digest": "cached",
+			"createdAt": "` + time.Now().UTC().Format(time.RFC3339) + `"
+		}
+	}
+}`),
+			TTL:                     time.Hour,
+			Refresh:                 true,
+			ExpectedDigest:          testutils.BusyboxLatestSHA,
+			ExpectedNumNetworkCalls: 1,
+		},
+		{
+			Name:          "Invalid Cache",
+			CacheContents: []byte(`{`),
+			TTL:           time.Hour,
+			ShouldFail:    true,
+		},
+		{
+			Name:       "Negative TTL",
+			TTL:        -time.Hour,
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, cachedDigestRequesterTestDir)
+			defer os.RemoveAll(tempDir)
+
+			cachePath := filepath.Join(tempDir, "cache", "digests.json")
+
+			if test.CacheContents != nil {
+				if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := ioutil.WriteFile(
+					cachePath, test.CacheContents, 0600,
+				); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			digestCache, err := update.NewDigestCache(
+				cachePath, test.TTL, test.Refresh,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotNumNetworkCalls uint64
+
+			digestRequester, err := update.NewCachedDigestRequester(
+				testutils.NewMockDigestRequester(t, &gotNumNetworkCalls),
+				digestCache,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The second query should always be served from the cache.
+			for i := 0; i < 2; i++ {
+				got, err := digestRequester.Digest("busybox", "latest")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if test.ExpectedDigest != got {
+					t.Fatalf(
+						"expected digest %s, got %s", test.ExpectedDigest, got,
+					)
+				}
+			}
+
+			testutils.AssertNumNetworkCallsEqual(
+				t, test.ExpectedNumNetworkCalls, gotNumNetworkCalls,
+			)
+
+			if test.CacheContents == nil {
+				if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+					t.Fatal("expected the cache to be written by Flush")
+				}
+			}
+
+			if err := digestCache.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			// A new cache should read the results saved by the first.
+			digestCache, err = update.NewDigestCache(cachePath, test.TTL, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotNumNetworkCallsAfterReload uint64
+
+			digestRequester, err = update.NewCachedDigestRequester(
+				testutils.NewMockDigestRequester(
+					t, &gotNumNetworkCallsAfterReload,
+				),
+				digestCache,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := digestRequester.Digest("busybox", "latest")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.ExpectedDigest != got {
+				t.Fatalf(
+					"expected digest %s, got %s", test.ExpectedDigest, got,
+				)
+			}
+
+			testutils.AssertNumNetworkCallsEqual(
+				t, 0, gotNumNetworkCallsAfterReload,
+			)
+		})
+	}
+}
+
+func TestCachedDigestRequesterPlatformDigests(t *testing.T) {
+	t.Parallel()
+
+	tempDir := testutils.MakeTempDir(t, cachedDigestRequesterTestDir)
+	defer os.RemoveAll(tempDir)
+
+	digestCache, err := update.NewDigestCache(
+		filepath.Join(tempDir, "digests.json"), time.Hour, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotNumNetworkCalls uint64
+
+	digestRequester, err := update.NewCachedDigestRequester(
+		testutils.NewMockDigestRequester(t, &gotNumNetworkCalls),
+		digestCache,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requester, ok := digestRequester.(update.IPlatformDigestRequester)
+	if !ok {
+		t.Fatal("expected the cache to query platform digests")
+	}
+
+	for i := 0; i < 2; i++ {
+		digest, platformDigests, err := requester.PlatformDigests(
+			"busybox:latest",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if digest != testutils.BusyboxLatestSHA {
+			t.Fatalf(
+				"expected digest %s, got %s",
+				testutils.BusyboxLatestSHA, digest,
+			)
+		}
+
+		const expectedNumPlatforms = 2
+		if len(platformDigests) != expectedNumPlatforms {
+			t.Fatalf(
+				"expected %d platforms, got %d",
+				expectedNumPlatforms, len(platformDigests),
+			)
+		}
+	}
+
+	testutils.AssertNumNetworkCallsEqual(t, 1, gotNumNetworkCalls)
+}
+
+func TestCachedDigestRequesterCapabilities(t *testing.T) {
+	t.Parallel()
+
+	tempDir := testutils.MakeTempDir(t, cachedDigestRequesterTestDir)
+	defer os.RemoveAll(tempDir)
+
+	digestCache, err := update.NewDigestCache(
+		filepath.Join(tempDir, "digests.json"), time.Hour, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotNumNetworkCalls uint64
+
+	digestRequester, err := update.NewCachedDigestRequester(
+		testutils.NewMockDigestRequester(t, &gotNumNetworkCalls),
+		digestCache,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := digestRequester.(update.ICreatedRequester); !ok {
+		t.Fatal("expected the cache to query creation times")
+	}
+
+	if _, ok := digestRequester.(update.IArtifactRequester); ok {
+		t.Fatal("expected the cache not to query artifacts")
+	}
+}
+
+func TestDigestCacheRefreshKeepsResults(t *testing.T) {
+	t.Parallel()
+
+	tempDir := testutils.MakeTempDir(t, cachedDigestRequesterTestDir)
+	defer os.RemoveAll(tempDir)
+
+	cachePath := filepath.Join(tempDir, "digests.json")
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+
+	if err := ioutil.WriteFile(cachePath, []byte(`{
+	"digests": {
+		"busybox:latest": {
+			"digest": "cached",
+			"createdAt": "`+createdAt+`"
+		},
+		"golang:latest": {
+			"digest": "cached",
+			"createdAt": "`+createdAt+`"
+		}
+	}
+}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	digestCache, err := update.NewDigestCache(cachePath, time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := digestCache.Digest("busybox:latest"); ok {
+		t.Fatal("expected refresh to ignore the saved results")
+	}
+
+	digestCache.StoreDigest("busybox:latest", testutils.BusyboxLatestSHA)
+
+	if err := digestCache.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	digestCache, err = update.NewDigestCache(cachePath, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, expected := range map[string]string{
+		"busybox:latest": testutils.BusyboxLatestSHA,
+		"golang:latest":  "cached",
+	} {
+		got, ok := digestCache.Digest(key)
+		if !ok || got != expected {
+			t.Fatalf("expected digest %s for %s, got %s", expected, key, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package update
+
+import (
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
+
+// capabilities holds the capabilities beyond IDigestRequester of the
+// requester that a decorator wraps. A capability is nil if the wrapped
+// requester does not have it.
+//
+// Decorators embed capabilities to forward the queries that they do not
+// change to the wrapped requester, and return the result of expose, so
+// that they only have the capabilities of the wrapped requester.
+type capabilities struct {
+	platformDigestRequester IPlatformDigestRequester
+	createdRequester        ICreatedRequester
+	artifactRequester       IArtifactRequester
+}
+
+// decoratedRequester is implemented by decorators that embed capabilities.
+type decoratedRequester interface {
+	IPlatformDigestRequester
+	ICreatedRequester
+	IArtifactRequester
+}
+
+func newCapabilities(digestRequester IDigestRequester) capabilities {
+	platformDigestRequester, _ := digestRequester.(IPlatformDigestRequester)
+	createdRequester, _ := digestRequester.(ICreatedRequester)
+	artifactRequester, _ := digestRequester.(IArtifactRequester)
+
+	return capabilities{
+		platformDigestRequester: platformDigestRequester,
+		createdRequester:        createdRequester,
+		artifactRequester:       artifactRequester,
+	}
+}
+
+// PlatformDigests forwards the query to the wrapped requester.
+func (c capabilities) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	return c.platformDigestRequester.PlatformDigests(imageLine)
+}
+
+// Created forwards the query to the wrapped requester.
+func (c capabilities) Created(
+	imageName string,
+	digest string,
+) (time.Time, error) {
+	return c.createdRequester.Created(imageName, digest)
+}
+
+// ArtifactLayers forwards the query to the wrapped requester.
+func (c capabilities) ArtifactLayers(
+	imageName string,
+	tag string,
+) ([]*ArtifactLayer, error) {
+	return c.artifactRequester.ArtifactLayers(imageName, tag)
+}
+
+// expose returns decorator as an IDigestRequester that is only an
+// IPlatformDigestRequester, ICreatedRequester, or IArtifactRequester if the
+// wrapped requester is as well, so that callers can detect the capabilities
+// of the wrapped requester with type assertions.
+func (c capabilities) expose(decorator decoratedRequester) IDigestRequester {
+	var (
+		platform = c.platformDigestRequester != nil
+		created  = c.createdRequester != nil
+		artifact = c.artifactRequester != nil
+	)
+
+	switch {
+	case platform && created && artifact:
+		return &struct {
+			IPlatformDigestRequester
+			ICreatedRequester
+			IArtifactRequester
+		}{decorator, decorator, decorator}
+	case platform && created:
+		return &struct {
+			IPlatformDigestRequester
+			ICreatedRequester
+		}{decorator, decorator}
+	case platform && artifact:
+		return &struct {
+			IPlatformDigestRequester
+			IArtifactRequester
+		}{decorator, decorator}
+	case platform:
+		return &struct{ IPlatformDigestRequester }{decorator}
+	case created && artifact:
+		return &struct {
+			IDigestRequester
+			ICreatedRequester
+			IArtifactRequester
+		}{decorator, decorator, decorator}
+	case created:
+		return &struct {
+			IDigestRequester
+			ICreatedRequester
+		}{decorator, decorator}
+	case artifact:
+		return &struct {
+			IDigestRequester
+			IArtifactRequester
+		}{decorator, decorator}
+	default:
+		return &struct{ IDigestRequester }{decorator}
+	}
+}
@@ -0,0 +1,146 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
+
+type imageCreatedUpdater struct {
+	imageDigestUpdater IImageDigestUpdater
+	createdRequester   ICreatedRequester
+	maxConcurrency     int
+}
+
+// NewImageCreatedUpdater returns an IImageDigestUpdater that updates images
+// with imageDigestUpdater, and then records the time that each image with a
+// digest was created in its metadata under the key "created". Neither
+// imageDigestUpdater nor createdRequester can be nil.
+//
+// maxConcurrency limits the number of images whose creation times are
+// queried at the same time. If maxConcurrency is 0, there is no limit.
+func NewImageCreatedUpdater(
+	imageDigestUpdater IImageDigestUpdater,
+	createdRequester ICreatedRequester,
+	maxConcurrency int,
+) (IImageDigestUpdater, error) {
+	if imageDigestUpdater == nil ||
+		reflect.ValueOf(imageDigestUpdater).IsNil() {
+		return nil, errors.New("'imageDigestUpdater' cannot be nil")
+	}
+
+	if createdRequester == nil || reflect.ValueOf(createdRequester).IsNil() {
+		return nil, errors.New("'createdRequester' cannot be nil")
+	}
+
+	if maxConcurrency < 0 {
+		return nil, errors.New("'maxConcurrency' cannot be negative")
+	}
+
+	return &imageCreatedUpdater{
+		imageDigestUpdater: imageDigestUpdater,
+		createdRequester:   createdRequester,
+		maxConcurrency:     maxConcurrency,
+	}, nil
+}
+
+// UpdateDigests updates the digests of images, and records the time that
+// each image with a digest was created.
+func (i *imageCreatedUpdater) UpdateDigests(
+	images <-chan parse.IImage,
+	done <-chan struct{},
+) <-chan parse.IImage {
+	updatedImages := i.imageDigestUpdater.UpdateDigests(images, done)
+	if updatedImages == nil {
+		return nil
+	}
+
+	var (
+		waitGroup     sync.WaitGroup
+		createdImages = make(chan parse.IImage)
+		workers       chan struct{} // nil if there is no limit
+	)
+
+	if i.maxConcurrency > 0 {
+		workers = make(chan struct{}, i.maxConcurrency)
+	}
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for image := range updatedImages {
+			image := image
+
+			waitGroup.Add(1)
+
+			go func() {
+				defer waitGroup.Done()
+
+				if image.Err() != nil || image.Digest() == "" {
+					select {
+					case <-done:
+					case createdImages <- image:
+					}
+
+					return
+				}
+
+				if workers != nil {
+					select {
+					case <-done:
+						return
+					case workers <- struct{}{}:
+					}
+				}
+
+				created, err := i.createdRequester.Created(
+					image.Name(), image.Digest(),
+				)
+
+				if workers != nil {
+					<-workers
+				}
+
+				if err != nil {
+					errMsg := fmt.Errorf(
+						"failed to update image with err: %v", err,
+					)
+
+					metadata := image.Metadata()
+					if path, ok := metadata["path"]; ok {
+						errMsg = fmt.Errorf("on '%s': %v", path, errMsg)
+					}
+
+					image = parse.NewImage(
+						image.Kind(), "", "", "", nil, errMsg,
+					)
+				} else {
+					metadata := image.Metadata()
+					if metadata == nil {
+						metadata = map[string]interface{}{}
+					}
+
+					metadata["created"] = created
+					image.SetMetadata(metadata)
+				}
+
+				select {
+				case <-done:
+				case createdImages <- image:
+				}
+			}()
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(createdImages)
+	}()
+
+	return createdImages
+}
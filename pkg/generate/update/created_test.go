@@ -0,0 +1,219 @@
+package update_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+func TestImageCreatedUpdater(t *testing.T) {
+	t.Parallel()
+
+	created, err := time.Parse(time.RFC3339, testutils.LatestCreated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name                    string
+		Images                  []parse.IImage
+		ExpectedNumNetworkCalls uint64
+		ExpectedImages          []parse.IImage
+		ShouldFail              bool
+	}{
+		{
+			Name: "Image Without Digest",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{"path": "Dockerfile"}, nil,
+				),
+			},
+			ExpectedNumNetworkCalls: 2,
+			ExpectedImages: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest",
+					testutils.BusyboxLatestSHA,
+					map[string]interface{}{
+						"path":    "Dockerfile",
+						"created": created,
+					}, nil,
+				),
+			},
+		},
+		{
+			Name: "Image With Digest",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "redis", "latest",
+					testutils.RedisLatestSHA, nil, nil,
+				),
+			},
+			ExpectedNumNetworkCalls: 1,
+			ExpectedImages: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "redis", "latest",
+					testutils.RedisLatestSHA,
+					map[string]interface{}{"created": created}, nil,
+				),
+			},
+		},
+		{
+			Name: "Scratch",
+			Images: []parse.IImage{
+				parse.NewImage(kind.Dockerfile, "scratch", "", "", nil, nil),
+			},
+			ExpectedNumNetworkCalls: 0,
+			ExpectedImages: []parse.IImage{
+				parse.NewImage(kind.Dockerfile, "scratch", "", "", nil, nil),
+			},
+		},
+		{
+			Name: "Missing Image",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "1.33", "missing", nil, nil,
+				),
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotNumNetworkCalls uint64
+
+			digestRequester := testutils.NewMockDigestRequester(
+				t, &gotNumNetworkCalls,
+			)
+
+			digestUpdater, err := update.NewImageDigestUpdater(
+				digestRequester, false, false, false, 0,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			updater, err := update.NewImageCreatedUpdater(
+				digestUpdater,
+				digestRequester.(update.ICreatedRequester), 1,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			defer close(done)
+
+			images := make(chan parse.IImage, len(test.Images))
+
+			for _, image := range test.Images {
+				images <- image
+			}
+			close(images)
+
+			var got []parse.IImage
+
+			for image := range updater.UpdateDigests(images, done) {
+				if test.ShouldFail {
+					if image.Err() == nil {
+						t.Fatal("expected error but did not get one")
+					}
+
+					return
+				}
+
+				if image.Err() != nil {
+					t.Fatal(image.Err())
+				}
+
+				got = append(got, image)
+			}
+
+			testutils.AssertImagesEqual(t, test.ExpectedImages, got)
+
+			testutils.AssertNumNetworkCallsEqual(
+				t, test.ExpectedNumNetworkCalls, gotNumNetworkCalls,
+			)
+		})
+	}
+}
+
+func TestDigestRequesterCreated(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	imageName := fmt.Sprintf(
+		"%s/org/app", strings.TrimPrefix(server.URL, "http://"),
+	)
+
+	expected := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	img, err := random.Image(64, 1) // nolint: gomnd
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err = mutate.CreatedAt(img, v1.Time{Time: expected})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := name.ParseReference(fmt.Sprintf("%s:1.0.0", imageName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digestRequester := update.NewDigestRequester(
+		nil, authn.NewMultiKeychain(),
+	)
+
+	createdRequester, ok := digestRequester.(update.ICreatedRequester)
+	if !ok {
+		t.Fatal("expected digest requester to query creation times")
+	}
+
+	got, err := createdRequester.Created(imageName, digest.Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !expected.Equal(got) {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+
+	if _, err := createdRequester.Created(
+		imageName, strings.Repeat("0", 64), // nolint: gomnd
+	); err == nil {
+		t.Fatal("expected error but did not get one")
+	}
+}
@@ -0,0 +1,257 @@
+package update
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+// Environment variables that hold the credentials of a registry. "%s" is the
+// registry, upper cased, with every character other than a letter or digit
+// replaced by an underscore, such as "GHCR_IO" for "ghcr.io" or "DOCKER_IO"
+// for Docker Hub.
+const (
+	usernameEnvFormat = "DOCKER_LOCK_%s_USERNAME"
+	passwordEnvFormat = "DOCKER_LOCK_%s_PASSWORD"
+)
+
+// credentialHelperTokenUsername is the username that docker credential
+// helpers return when the secret is an identity token.
+const credentialHelperTokenUsername = "<token>"
+
+// dockerHubServerURL is the server that docker credential helpers store
+// Docker Hub's credentials under.
+const dockerHubServerURL = "https://index.docker.io/v1/"
+
+type envKeychain struct {
+	getenv func(string) string
+}
+
+type fileKeychain struct {
+	credentials map[string]authn.AuthConfig
+}
+
+type credentialHelperKeychain struct {
+	helpers map[string]string
+}
+
+// credentialsFile is the format of a credentials file.
+type credentialsFile struct {
+	Registries map[string]authn.AuthConfig `json:"registries"`
+}
+
+// credentialHelperOutput is the output of a docker credential helper's "get"
+// command.
+type credentialHelperOutput struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+// NewEnvKeychain returns an authn.Keychain that reads the username and
+// password of a registry from the environment variables
+// "DOCKER_LOCK_<REGISTRY>_USERNAME" and "DOCKER_LOCK_<REGISTRY>_PASSWORD",
+// where "<REGISTRY>" is the registry, upper cased, with every character other
+// than a letter or digit replaced by an underscore. For instance, the
+// credentials of "ghcr.io" are in "DOCKER_LOCK_GHCR_IO_USERNAME" and
+// "DOCKER_LOCK_GHCR_IO_PASSWORD", and those of Docker Hub are in
+// "DOCKER_LOCK_DOCKER_IO_USERNAME" and "DOCKER_LOCK_DOCKER_IO_PASSWORD".
+//
+// Environment variables are read with getenv. If getenv is nil, os.Getenv is
+// used.
+func NewEnvKeychain(getenv func(string) string) authn.Keychain {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	return &envKeychain{getenv: getenv}
+}
+
+// Resolve returns the credentials of a registry from the environment, or
+// authn.Anonymous if they are not set.
+func (e *envKeychain) Resolve(
+	target authn.Resource,
+) (authn.Authenticator, error) {
+	registry := envRegistry(NormalizeRegistry(target.RegistryStr()))
+
+	var (
+		usernameEnv = fmt.Sprintf(usernameEnvFormat, registry)
+		passwordEnv = fmt.Sprintf(passwordEnvFormat, registry)
+		username    = e.getenv(usernameEnv)
+		password    = e.getenv(passwordEnv)
+	)
+
+	switch {
+	case username == "" && password == "":
+		return authn.Anonymous, nil
+	case username == "":
+		return nil, fmt.Errorf(
+			"'%s' is set but '%s' is not", passwordEnv, usernameEnv,
+		)
+	case password == "":
+		return nil, fmt.Errorf(
+			"'%s' is set but '%s' is not", usernameEnv, passwordEnv,
+		)
+	}
+
+	return authn.FromConfig(
+		authn.AuthConfig{Username: username, Password: password},
+	), nil
+}
+
+// NewFileKeychain returns an authn.Keychain that reads credentials from a
+// JSON file at path that maps registries to credentials, such as:
+//
+//	{
+//		"registries": {
+//			"ghcr.io": {"username": "user", "password": "pass"},
+//			"myregistry.azurecr.io": {"identitytoken": "token"}
+//		}
+//	}
+//
+// Each registry may have a "username" and "password", a base64 encoded
+// "username:password" in "auth", an "identitytoken", or a "registrytoken",
+// as in docker's config file.
+func NewFileKeychain(path string) (authn.Keychain, error) {
+	if path == "" {
+		return nil, errors.New("'path' cannot be empty")
+	}
+
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file credentialsFile
+	if err := json.Unmarshal(byt, &file); err != nil {
+		return nil, fmt.Errorf("'%s' failed to parse with err: %v", path, err)
+	}
+
+	credentials := map[string]authn.AuthConfig{}
+
+	for registry, authConfig := range file.Registries {
+		credentials[NormalizeRegistry(registry)] = authConfig
+	}
+
+	return &fileKeychain{credentials: credentials}, nil
+}
+
+// Resolve returns the credentials of a registry from the credentials file,
+// or authn.Anonymous if the file does not have them.
+func (f *fileKeychain) Resolve(
+	target authn.Resource,
+) (authn.Authenticator, error) {
+	authConfig, ok := f.credentials[NormalizeRegistry(target.RegistryStr())]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(authConfig), nil
+}
+
+// NewCredentialHelperKeychain returns an authn.Keychain that gets
+// credentials from docker credential helpers. helpers maps a registry, such
+// as "123456789.dkr.ecr.us-east-1.amazonaws.com", to the suffix of the
+// helper's executable, such as "ecr-login" for
+// "docker-credential-ecr-login", as in the "credHelpers" of docker's config
+// file. The executable must be in the PATH.
+func NewCredentialHelperKeychain(
+	helpers map[string]string,
+) (authn.Keychain, error) {
+	normalizedHelpers := map[string]string{}
+
+	for registry, helper := range helpers {
+		if registry == "" {
+			return nil, errors.New(
+				"registry of credential helper cannot be empty",
+			)
+		}
+
+		if helper == "" {
+			return nil, fmt.Errorf(
+				"credential helper of '%s' cannot be empty", registry,
+			)
+		}
+
+		normalizedHelpers[NormalizeRegistry(registry)] = helper
+	}
+
+	return &credentialHelperKeychain{helpers: normalizedHelpers}, nil
+}
+
+// Resolve returns the credentials of a registry from its credential helper,
+// or authn.Anonymous if the registry does not have a helper or the helper
+// does not have credentials for it.
+func (c *credentialHelperKeychain) Resolve(
+	target authn.Resource,
+) (authn.Authenticator, error) {
+	registry := NormalizeRegistry(target.RegistryStr())
+
+	helper, ok := c.helpers[registry]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+
+	serverURL := registry
+	if registry == dockerHubRegistry {
+		serverURL = dockerHubServerURL
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(fmt.Sprintf("docker-credential-%s", helper), "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Credential helpers report missing credentials on stdout.
+		if strings.Contains(stdout.String(), "credentials not found") {
+			return authn.Anonymous, nil
+		}
+
+		return nil, fmt.Errorf(
+			"credential helper '%s' failed for '%s' with err: %v %s",
+			helper, registry, err, strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	var output credentialHelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf(
+			"output of credential helper '%s' failed to parse with err: %v",
+			helper, err,
+		)
+	}
+
+	if output.Username == credentialHelperTokenUsername {
+		return authn.FromConfig(
+			authn.AuthConfig{IdentityToken: output.Secret},
+		), nil
+	}
+
+	return authn.FromConfig(
+		authn.AuthConfig{Username: output.Username, Password: output.Secret},
+	), nil
+}
+
+// envRegistry returns the form of a registry used in environment variable
+// names.
+func envRegistry(registry string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		}
+
+		return '_'
+	}, registry)
+}
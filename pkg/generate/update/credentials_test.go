@@ -0,0 +1,313 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+const credentialsTestDir = "credentials-tests"
+
+func TestEnvKeychain(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"DOCKER_LOCK_GHCR_IO_USERNAME":               "ghcr-user",
+		"DOCKER_LOCK_GHCR_IO_PASSWORD":               "ghcr-pass",
+		"DOCKER_LOCK_DOCKER_IO_USERNAME":             "hub-user",
+		"DOCKER_LOCK_DOCKER_IO_PASSWORD":             "hub-pass",
+		"DOCKER_LOCK_MIRROR_INTERNAL_5000_USERNAME":  "mirror-user",
+		"DOCKER_LOCK_QUAY_IO_PASSWORD":               "quay-pass",
+		"DOCKER_LOCK_UNRELATED_EXAMPLE_COM_PASSWORD": "",
+	}
+
+	tests := []struct {
+		Name       string
+		Registry   string
+		Expected   *authn.AuthConfig
+		ShouldFail bool
+	}{
+		{
+			Name:     "Registry",
+			Registry: "ghcr.io",
+			Expected: &authn.AuthConfig{
+				Username: "ghcr-user", Password: "ghcr-pass",
+			},
+		},
+		{
+			Name:     "Docker Hub",
+			Registry: name.DefaultRegistry,
+			Expected: &authn.AuthConfig{
+				Username: "hub-user", Password: "hub-pass",
+			},
+		},
+		{
+			Name:     "Missing Credentials",
+			Registry: "example.com",
+			Expected: &authn.AuthConfig{},
+		},
+		{
+			Name:       "Missing Password",
+			Registry:   "mirror.internal:5000",
+			ShouldFail: true,
+		},
+		{
+			Name:       "Missing Username",
+			Registry:   "quay.io",
+			ShouldFail: true,
+		},
+	}
+
+	keychain := update.NewEnvKeychain(func(key string) string {
+		return env[key]
+	})
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assertResolvedAuthConfig(
+				t, keychain, test.Registry, test.Expected, test.ShouldFail,
+			)
+		})
+	}
+}
+
+func TestFileKeychain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                    string
+		Contents                []byte
+		Registry                string
+		Expected                *authn.AuthConfig
+		ShouldFail              bool
+		ExpectedConstructorFail bool
+	}{
+		{
+			Name: "Username And Password",
+			Contents: []byte(`{
+	"registries": {
+		"ghcr.io": {"username": "user", "password": "pass"}
+	}
+}`),
+			Registry: "ghcr.io",
+			Expected: &authn.AuthConfig{Username: "user", Password: "pass"},
+		},
+		{
+			Name: "Identity Token",
+			Contents: []byte(`{
+	"registries": {
+		"myregistry.azurecr.io": {"identitytoken": "token"}
+	}
+}`),
+			Registry: "myregistry.azurecr.io",
+			Expected: &authn.AuthConfig{IdentityToken: "token"},
+		},
+		{
+			Name: "Docker Hub",
+			Contents: []byte(`{
+	"registries": {
+		"docker.io": {"username": "user", "password": "pass"}
+	}
+}`),
+			Registry: name.DefaultRegistry,
+			Expected: &authn.AuthConfig{Username: "user", Password: "pass"},
+		},
+		{
+			Name:     "Missing Registry",
+			Contents: []byte(`{"registries": {}}`),
+			Registry: "ghcr.io",
+			Expected: &authn.AuthConfig{},
+		},
+		{
+			Name:                    "Invalid File",
+			Contents:                []byte(`{`),
+			ExpectedConstructorFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, credentialsTestDir)
+			defer os.RemoveAll(tempDir)
+
+			path := filepath.Join(tempDir, "credentials.json")
+
+			if err := ioutil.WriteFile(
+				path, test.Contents, 0600, // nolint: gomnd
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			keychain, err := update.NewFileKeychain(path)
+			if test.ExpectedConstructorFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertResolvedAuthConfig(
+				t, keychain, test.Registry, test.Expected, test.ShouldFail,
+			)
+		})
+	}
+}
+
+// TestCredentialHelperKeychain modifies the PATH, so it cannot run in
+// parallel with other tests.
+func TestCredentialHelperKeychain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper scripts require a unix shell")
+	}
+
+	tempDir := testutils.MakeTempDir(t, credentialsTestDir)
+	defer os.RemoveAll(tempDir)
+
+	helpers := map[string]string{
+		"docker-credential-basic": `#!/bin/sh
+read server
+echo "{\"Username\": \"user\", \"Secret\": \"$server\"}"
+`,
+		"docker-credential-token": `#!/bin/sh
+echo '{"Username": "<token>", "Secret": "token"}'
+`,
+		"docker-credential-missing": `#!/bin/sh
+echo "credentials not found in native keychain"
+exit 1
+`,
+		"docker-credential-broken": `#!/bin/sh
+echo "helper is broken" >&2
+exit 1
+`,
+	}
+
+	for helper, script := range helpers {
+		if err := ioutil.WriteFile(
+			filepath.Join(tempDir, helper), []byte(script), 0700, // nolint: gomnd
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+
+	os.Setenv("PATH", tempDir+string(os.PathListSeparator)+path)
+
+	keychain, err := update.NewCredentialHelperKeychain(map[string]string{
+		"ghcr.io":   "basic",
+		"docker.io": "basic",
+		"quay.io":   "token",
+		"gcr.io":    "missing",
+		"ecr.aws":   "broken",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name       string
+		Registry   string
+		Expected   *authn.AuthConfig
+		ShouldFail bool
+	}{
+		{
+			Name:     "Username And Secret",
+			Registry: "ghcr.io",
+			Expected: &authn.AuthConfig{Username: "user", Password: "ghcr.io"},
+		},
+		{
+			Name:     "Docker Hub",
+			Registry: name.DefaultRegistry,
+			Expected: &authn.AuthConfig{
+				Username: "user", Password: "https://index.docker.io/v1/",
+			},
+		},
+		{
+			Name:     "Identity Token",
+			Registry: "quay.io",
+			Expected: &authn.AuthConfig{IdentityToken: "token"},
+		},
+		{
+			Name:     "Credentials Not Found",
+			Registry: "gcr.io",
+			Expected: &authn.AuthConfig{},
+		},
+		{
+			Name:     "No Helper",
+			Registry: "example.com",
+			Expected: &authn.AuthConfig{},
+		},
+		{
+			Name:       "Broken Helper",
+			Registry:   "ecr.aws",
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assertResolvedAuthConfig(
+				t, keychain, test.Registry, test.Expected, test.ShouldFail,
+			)
+		})
+	}
+}
+
+func assertResolvedAuthConfig(
+	t *testing.T,
+	keychain authn.Keychain,
+	registry string,
+	expected *authn.AuthConfig,
+	shouldFail bool,
+) {
+	t.Helper()
+
+	resource, err := name.NewRegistry(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := keychain.Resolve(resource)
+	if shouldFail {
+		if err == nil {
+			t.Fatal("expected error but did not get one")
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := authenticator.Authorization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
@@ -1,18 +1,56 @@
 package update
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 )
 
-type digestRequester struct{}
+type digestRequester struct {
+	transport http.RoundTripper
+	keychain  authn.Keychain
+}
+
+// ArtifactLayer is a layer of an OCI artifact, such as a signature or an
+// attestation, along with the media type and annotations from the
+// artifact's manifest.
+type ArtifactLayer struct {
+	MediaType   string
+	Annotations map[string]string
+	Content     []byte
+}
 
 // NewDigestRequester returns a digest requester based on the library "crane".
-func NewDigestRequester() IDigestRequester {
-	return &digestRequester{}
+// Requests to registries are made with transport and authenticated with
+// credentials from keychain. If transport is nil, http.DefaultTransport is
+// used. If keychain is nil, authn.DefaultKeychain, which reads docker's
+// config file, is used. The digest requester is also an ICreatedRequester and
+// an IArtifactRequester.
+func NewDigestRequester(
+	transport http.RoundTripper,
+	keychain authn.Keychain,
+) IPlatformDigestRequester {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+
+	return &digestRequester{transport: transport, keychain: keychain}
 }
 
 // Digest queries a registry for a sha256 digest given a name and tag.
@@ -27,7 +65,10 @@ func (d *digestRequester) Digest(name string, tag string) (string, error) {
 
 	nameTag := fmt.Sprintf("%s:%s", name, tag)
 
-	digest, err := crane.Digest(nameTag)
+	digest, err := crane.Digest(
+		nameTag, crane.WithTransport(d.transport),
+		crane.WithAuthFromKeychain(d.keychain),
+	)
 	if err != nil {
 		return "", fmt.Errorf(
 			"failed to find digest for '%s' with err: %v", nameTag, err,
@@ -36,3 +77,203 @@ func (d *digestRequester) Digest(name string, tag string) (string, error) {
 
 	return strings.TrimPrefix(digest, "sha256:"), nil
 }
+
+// PlatformDigests queries a registry for the sha256 digest of an image line,
+// such as "busybox:latest" or "busybox@sha256:...". If the image line refers
+// to a manifest list, the digest of each platform in the list is returned as
+// well. Otherwise, there are no platform digests.
+func (d *digestRequester) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	if imageLine == "" {
+		return "", nil, errors.New("'imageLine' cannot be empty")
+	}
+
+	ref, err := name.ParseReference(imageLine)
+	if err != nil {
+		return "", nil, err
+	}
+
+	desc, err := remote.Get(
+		ref, remote.WithAuthFromKeychain(d.keychain),
+		remote.WithTransport(d.transport),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf(
+			"failed to find digest for '%s' with err: %v", imageLine, err,
+		)
+	}
+
+	digest := desc.Digest.Hex
+
+	if !desc.MediaType.IsIndex() {
+		return digest, nil, nil
+	}
+
+	indexManifest, err := v1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return "", nil, fmt.Errorf(
+			"failed to parse manifest list for '%s' with err: %v",
+			imageLine, err,
+		)
+	}
+
+	return digest, platformDigestsFromIndex(indexManifest), nil
+}
+
+// Created queries a registry for the time that the image with a name and
+// digest was created. If the digest is of a manifest list, the creation
+// time of its linux/amd64 image is returned.
+func (d *digestRequester) Created(
+	imageName string,
+	digest string,
+) (time.Time, error) {
+	if imageName == "" {
+		return time.Time{}, errors.New("image 'name' cannot be empty")
+	}
+
+	if digest == "" {
+		return time.Time{}, errors.New("image 'digest' cannot be empty")
+	}
+
+	imageLine := fmt.Sprintf("%s@sha256:%s", imageName, digest)
+
+	ref, err := name.ParseReference(imageLine)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	img, err := remote.Image(
+		ref, remote.WithAuthFromKeychain(d.keychain),
+		remote.WithTransport(d.transport),
+	)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"failed to find image '%s' with err: %v", imageLine, err,
+		)
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"failed to read the config of '%s' with err: %v", imageLine, err,
+		)
+	}
+
+	if configFile.Created.IsZero() {
+		return time.Time{}, fmt.Errorf(
+			"the config of '%s' does not have a creation time", imageLine,
+		)
+	}
+
+	return configFile.Created.UTC(), nil
+}
+
+// ArtifactLayers queries a registry for the layers of the artifact with a
+// name and tag, such as "sha256-<digest>.sig" for a cosign signature. If the
+// tag does not exist, there are no layers.
+func (d *digestRequester) ArtifactLayers(
+	imageName string,
+	tag string,
+) ([]*ArtifactLayer, error) {
+	if imageName == "" {
+		return nil, errors.New("image 'name' cannot be empty")
+	}
+
+	if tag == "" {
+		return nil, errors.New("image 'tag' cannot be empty")
+	}
+
+	imageLine := fmt.Sprintf("%s:%s", imageName, tag)
+
+	ref, err := name.ParseReference(imageLine)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := remote.Image(
+		ref, remote.WithAuthFromKeychain(d.keychain),
+		remote.WithTransport(d.transport),
+	)
+	if err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) &&
+			transportErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"failed to find artifact '%s' with err: %v", imageLine, err,
+		)
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read the manifest of '%s' with err: %v", imageLine, err,
+		)
+	}
+
+	artifactLayers := make([]*ArtifactLayer, 0, len(manifest.Layers))
+
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := readLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to read layer '%s' of '%s' with err: %v",
+				desc.Digest, imageLine, err,
+			)
+		}
+
+		artifactLayers = append(artifactLayers, &ArtifactLayer{
+			MediaType:   string(desc.MediaType),
+			Annotations: desc.Annotations,
+			Content:     content,
+		})
+	}
+
+	return artifactLayers, nil
+}
+
+// readLayer reads the content of a layer as it is stored in the registry.
+// Artifact layers, such as signature payloads, are not compressed.
+func readLayer(layer v1.Layer) ([]byte, error) {
+	reader, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+// platformDigestsFromIndex returns the digest of each platform in a manifest
+// list.
+func platformDigestsFromIndex(
+	indexManifest *v1.IndexManifest,
+) []*parse.PlatformDigest {
+	var platformDigests []*parse.PlatformDigest
+
+	for _, manifest := range indexManifest.Manifests {
+		// Manifest lists may contain entries that are not images,
+		// such as attestations, with an "unknown" platform.
+		if manifest.Platform == nil ||
+			manifest.Platform.OS == "" || manifest.Platform.OS == "unknown" {
+			continue
+		}
+
+		platformDigests = append(platformDigests, &parse.PlatformDigest{
+			OS:           manifest.Platform.OS,
+			Architecture: manifest.Platform.Architecture,
+			Variant:      manifest.Platform.Variant,
+			Digest:       manifest.Digest.Hex,
+		})
+	}
+
+	return platformDigests
+}
@@ -0,0 +1,197 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
+
+// dockerHubRegistry is the name that mirror prefixes use for Docker Hub.
+const dockerHubRegistry = "docker.io"
+
+type mirroredDigestRequester struct {
+	capabilities
+	digestRequester IDigestRequester
+	mirrors         map[string]string
+}
+
+// NewMirroredDigestRequester returns an IDigestRequester that queries
+// mirrors instead of the registries they mirror. Images keep their
+// canonical names, so Lockfiles do not depend on which mirror was queried.
+//
+// mirrors maps a registry, optionally followed by a repository prefix, such
+// as "docker.io" or "docker.io/library", to the mirror that serves its
+// images, such as "mirror.internal:5000". See MirrorName for how names are
+// mapped.
+//
+// The returned IDigestRequester only has the capabilities of
+// digestRequester, such as being an IPlatformDigestRequester.
+func NewMirroredDigestRequester(
+	digestRequester IDigestRequester,
+	mirrors map[string]string,
+) (IDigestRequester, error) {
+	if digestRequester == nil || reflect.ValueOf(digestRequester).IsNil() {
+		return nil, errors.New("'digestRequester' cannot be nil")
+	}
+
+	if err := ValidateMirrors(mirrors); err != nil {
+		return nil, err
+	}
+
+	mirroredDigestRequester := &mirroredDigestRequester{
+		capabilities:    newCapabilities(digestRequester),
+		digestRequester: digestRequester,
+		mirrors:         mirrors,
+	}
+
+	return mirroredDigestRequester.expose(mirroredDigestRequester), nil
+}
+
+// Digest queries the mirror of an image for its digest.
+func (m *mirroredDigestRequester) Digest(
+	name string,
+	tag string,
+) (string, error) {
+	return m.digestRequester.Digest(MirrorName(name, m.mirrors), tag)
+}
+
+// PlatformDigests queries the mirror of an image line for its digest and
+// platform digests.
+func (m *mirroredDigestRequester) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	ref, err := name.ParseReference(imageLine)
+	if err != nil {
+		return "", nil, err
+	}
+
+	separator := ":"
+	if _, ok := ref.(name.Digest); ok {
+		separator = "@"
+	}
+
+	return m.platformDigestRequester.PlatformDigests(
+		fmt.Sprintf(
+			"%s%s%s",
+			MirrorName(ref.Context().Name(), m.mirrors), separator,
+			ref.Identifier(),
+		),
+	)
+}
+
+// Created queries the mirror of an image for the time that the image with
+// a digest was created.
+func (m *mirroredDigestRequester) Created(
+	imageName string,
+	digest string,
+) (time.Time, error) {
+	return m.createdRequester.Created(MirrorName(imageName, m.mirrors), digest)
+}
+
+// ArtifactLayers queries the mirror of an image for the layers of the
+// artifact with a tag.
+func (m *mirroredDigestRequester) ArtifactLayers(
+	imageName string,
+	tag string,
+) ([]*ArtifactLayer, error) {
+	return m.artifactRequester.ArtifactLayers(
+		MirrorName(imageName, m.mirrors), tag,
+	)
+}
+
+// ValidateMirrors returns an error if a registry prefix or mirror in mirrors
+// is empty.
+func ValidateMirrors(mirrors map[string]string) error {
+	for prefix, mirror := range mirrors {
+		if strings.Trim(prefix, "/") == "" {
+			return errors.New("registry prefix of mirror cannot be empty")
+		}
+
+		if strings.Trim(mirror, "/") == "" {
+			return fmt.Errorf("mirror of '%s' cannot be empty", prefix)
+		}
+	}
+
+	return nil
+}
+
+// MirrorName returns the name of an image, such as "busybox" or
+// "docker.io/library/busybox", in the mirror with the longest registry
+// prefix that matches it. For instance, if "docker.io" is mirrored by
+// "mirror.internal:5000", "busybox" becomes
+// "mirror.internal:5000/library/busybox".
+//
+// If no prefix matches or the name is invalid, the name is returned
+// unchanged.
+func MirrorName(imageName string, mirrors map[string]string) string {
+	if len(mirrors) == 0 {
+		return imageName
+	}
+
+	repository, err := name.NewRepository(imageName)
+	if err != nil {
+		return imageName
+	}
+
+	fullName := path.Join(
+		NormalizeRegistry(repository.RegistryStr()),
+		repository.RepositoryStr(),
+	)
+
+	var matchedPrefix, mirror string
+
+	for prefix, prefixMirror := range mirrors {
+		prefix = strings.Trim(prefix, "/")
+
+		registry, repositoryPrefix := prefix, ""
+		if i := strings.Index(prefix, "/"); i != -1 {
+			registry, repositoryPrefix = prefix[:i], prefix[i:]
+		}
+
+		prefix = NormalizeRegistry(registry) + repositoryPrefix
+
+		if fullName != prefix && !strings.HasPrefix(fullName, prefix+"/") {
+			continue
+		}
+
+		if len(prefix) > len(matchedPrefix) {
+			matchedPrefix, mirror = prefix, prefixMirror
+		}
+	}
+
+	if matchedPrefix == "" {
+		return imageName
+	}
+
+	return strings.TrimSuffix(mirror, "/") +
+		strings.TrimPrefix(fullName, matchedPrefix)
+}
+
+// ImageRegistry returns the registry of an image, such as "ghcr.io" for
+// "ghcr.io/org/app". Images on Docker Hub, such as "busybox", return
+// "docker.io".
+func ImageRegistry(imageName string) (string, error) {
+	repository, err := name.NewRepository(imageName)
+	if err != nil {
+		return "", err
+	}
+
+	return NormalizeRegistry(repository.RegistryStr()), nil
+}
+
+// NormalizeRegistry returns "docker.io" for any of Docker Hub's registry
+// names, so that prefixes and image names refer to Docker Hub the same way.
+func NormalizeRegistry(registry string) string {
+	switch registry {
+	case name.DefaultRegistry, "registry-1.docker.io":
+		return dockerHubRegistry
+	}
+
+	return registry
+}
@@ -0,0 +1,211 @@
+package update_test
+
+import (
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+type recordingDigestRequester struct {
+	imageLines []string
+}
+
+func (r *recordingDigestRequester) Digest(
+	name string,
+	tag string,
+) (string, error) {
+	r.imageLines = append(r.imageLines, name+":"+tag)
+
+	return "digest", nil
+}
+
+func (r *recordingDigestRequester) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	r.imageLines = append(r.imageLines, imageLine)
+
+	return "digest", nil, nil
+}
+
+func TestMirrorName(t *testing.T) {
+	t.Parallel()
+
+	mirrors := map[string]string{
+		"docker.io":          "mirror.internal:5000",
+		"docker.io/myorg":    "mirror.internal:5000/myorg-cache/",
+		"ghcr.io":            "ghcr-mirror.internal",
+		"index.docker.io/ns": "ns-mirror.internal",
+	}
+
+	tests := []struct {
+		Name     string
+		Image    string
+		Expected string
+	}{
+		{
+			Name:     "Official Image",
+			Image:    "busybox",
+			Expected: "mirror.internal:5000/library/busybox",
+		},
+		{
+			Name:     "Docker Hub Registry",
+			Image:    "docker.io/library/busybox",
+			Expected: "mirror.internal:5000/library/busybox",
+		},
+		{
+			Name:     "Longest Prefix",
+			Image:    "myorg/app",
+			Expected: "mirror.internal:5000/myorg-cache/app",
+		},
+		{
+			Name:     "Repository Prefix Boundary",
+			Image:    "myorganization/app",
+			Expected: "mirror.internal:5000/myorganization/app",
+		},
+		{
+			Name:     "Normalized Prefix",
+			Image:    "ns/app",
+			Expected: "ns-mirror.internal/app",
+		},
+		{
+			Name:     "Other Registry",
+			Image:    "ghcr.io/owner/app",
+			Expected: "ghcr-mirror.internal/owner/app",
+		},
+		{
+			Name:     "Unmirrored Registry",
+			Image:    "quay.io/owner/app",
+			Expected: "quay.io/owner/app",
+		},
+		{
+			Name:     "Invalid Name",
+			Image:    "Busybox",
+			Expected: "Busybox",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got := update.MirrorName(test.Image, mirrors)
+			if test.Expected != got {
+				t.Fatalf("expected %s, got %s", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestMirroredDigestRequester(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Mirrors    map[string]string
+		Query      func(update.IDigestRequester) error
+		Expected   string
+		ShouldFail bool
+	}{
+		{
+			Name:    "Digest",
+			Mirrors: map[string]string{"docker.io": "mirror.internal:5000"},
+			Query: func(r update.IDigestRequester) error {
+				_, err := r.Digest("busybox", "latest")
+				return err
+			},
+			Expected: "mirror.internal:5000/library/busybox:latest",
+		},
+		{
+			Name:    "Platform Digests Tag",
+			Mirrors: map[string]string{"docker.io": "mirror.internal:5000"},
+			Query: func(r update.IDigestRequester) error {
+				_, _, err := r.(update.IPlatformDigestRequester).PlatformDigests(
+					"busybox:latest",
+				)
+				return err
+			},
+			Expected: "mirror.internal:5000/library/busybox:latest",
+		},
+		{
+			Name:    "Platform Digests Digest",
+			Mirrors: map[string]string{"docker.io": "mirror.internal:5000"},
+			Query: func(r update.IDigestRequester) error {
+				_, _, err := r.(update.IPlatformDigestRequester).PlatformDigests(
+					"busybox@sha256:" + offlineBusyboxSHA,
+				)
+				return err
+			},
+			Expected: "mirror.internal:5000/library/busybox@sha256:" +
+				offlineBusyboxSHA,
+		},
+		{
+			Name:       "Empty Mirror",
+			Mirrors:    map[string]string{"docker.io": ""},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := &recordingDigestRequester{}
+
+			digestRequester, err := update.NewMirroredDigestRequester(
+				recorder, test.Mirrors,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := test.Query(digestRequester); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(recorder.imageLines) != 1 ||
+				recorder.imageLines[0] != test.Expected {
+				t.Fatalf(
+					"expected query for %s, got %v",
+					test.Expected, recorder.imageLines,
+				)
+			}
+		})
+	}
+}
+
+func TestMirroredDigestRequesterCapabilities(t *testing.T) {
+	t.Parallel()
+
+	digestRequester, err := update.NewMirroredDigestRequester(
+		&recordingDigestRequester{},
+		map[string]string{"docker.io": "mirror.internal:5000"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := digestRequester.(update.IPlatformDigestRequester); !ok {
+		t.Fatal("expected the mirror to query platform digests")
+	}
+
+	if _, ok := digestRequester.(update.ICreatedRequester); ok {
+		t.Fatal("expected the mirror not to query creation times")
+	}
+
+	if _, ok := digestRequester.(update.IArtifactRequester); ok {
+		t.Fatal("expected the mirror not to query artifacts")
+	}
+}
@@ -0,0 +1,325 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
+
+// Annotations that record the reference of an image in an OCI image layout.
+// "org.opencontainers.image.ref.name" may be a full reference or only a tag,
+// in which case "io.containerd.image.name" holds the full reference, as in
+// tarballs from "docker save".
+const (
+	ociRefNameAnnotation        = "org.opencontainers.image.ref.name"
+	containerdRefNameAnnotation = "io.containerd.image.name"
+)
+
+type offlineDigestRequester struct {
+	images []*offlineImage
+}
+
+// offlineImage is an image in an offline source.
+type offlineImage struct {
+	refNames        []string
+	digest          string
+	platformDigests []*parse.PlatformDigest
+}
+
+// NewOfflineDigestRequester returns an IPlatformDigestRequester that
+// resolves digests from local sources instead of registries. Each source
+// must be the path to an OCI image layout directory, or to a tarball of one,
+// such as those made by "docker save" in Docker 25 and later.
+//
+// Images are found by the references in the
+// "org.opencontainers.image.ref.name" and "io.containerd.image.name"
+// annotations of the layout's "index.json". References that are only a tag
+// are ignored, because they do not name an image.
+func NewOfflineDigestRequester(
+	sources []string,
+) (IPlatformDigestRequester, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("'sources' cannot be empty")
+	}
+
+	offlineDigestRequester := &offlineDigestRequester{}
+
+	for _, source := range sources {
+		images, err := loadOfflineImages(source)
+		if err != nil {
+			return nil, err
+		}
+
+		offlineDigestRequester.images = append(
+			offlineDigestRequester.images, images...,
+		)
+	}
+
+	return offlineDigestRequester, nil
+}
+
+// Digest returns the sha256 digest of an image in the offline sources given
+// a name and tag.
+func (o *offlineDigestRequester) Digest(
+	name string,
+	tag string,
+) (string, error) {
+	if name == "" {
+		return "", errors.New("image 'name' cannot be empty")
+	}
+
+	if tag == "" {
+		return "", errors.New("image 'tag' cannot be empty")
+	}
+
+	digest, _, err := o.PlatformDigests(fmt.Sprintf("%s:%s", name, tag))
+
+	return digest, err
+}
+
+// PlatformDigests returns the sha256 digest of an image line, such as
+// "busybox:latest" or "busybox@sha256:...", in the offline sources. If the
+// image is a manifest list, the digest of each platform is returned as well.
+func (o *offlineDigestRequester) PlatformDigests(
+	imageLine string,
+) (string, []*parse.PlatformDigest, error) {
+	if imageLine == "" {
+		return "", nil, errors.New("'imageLine' cannot be empty")
+	}
+
+	ref, err := name.ParseReference(imageLine)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if digestRef, ok := ref.(name.Digest); ok {
+		digest := strings.TrimPrefix(digestRef.DigestStr(), "sha256:")
+
+		for _, image := range o.images {
+			if image.digest == digest {
+				return image.digest, image.platformDigests, nil
+			}
+		}
+
+		return "", nil, fmt.Errorf(
+			"failed to find digest for '%s' in offline sources", imageLine,
+		)
+	}
+
+	for _, image := range o.images {
+		for _, refName := range image.refNames {
+			if refName == ref.Name() {
+				return image.digest, image.platformDigests, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf(
+		"failed to find digest for '%s' in offline sources", imageLine,
+	)
+}
+
+// loadOfflineImages reads the images in an OCI image layout directory or
+// tarball.
+func loadOfflineImages(source string) ([]*offlineImage, error) {
+	fileInfo, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	readFiles := func(paths []string) (map[string][]byte, error) {
+		return readTarFiles(source, paths)
+	}
+
+	if fileInfo.IsDir() {
+		readFiles = func(paths []string) (map[string][]byte, error) {
+			return readDirFiles(source, paths)
+		}
+	}
+
+	files, err := readFiles([]string{"index.json"})
+	if err != nil {
+		return nil, err
+	}
+
+	indexByt, ok := files["index.json"]
+	if !ok {
+		return nil, fmt.Errorf(
+			"'%s' is not an OCI image layout because it does not have an "+
+				"'index.json' - tarballs from 'docker save' before Docker 25 "+
+				"are not supported because they do not record digests",
+			source,
+		)
+	}
+
+	indexManifest, err := v1.ParseIndexManifest(bytes.NewReader(indexByt))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"'%s' failed to parse with err: %v", source, err,
+		)
+	}
+
+	// Manifest lists are read to find the digest of each platform.
+	var blobPaths []string
+
+	for _, manifest := range indexManifest.Manifests {
+		if manifest.MediaType.IsIndex() {
+			blobPaths = append(blobPaths, blobPath(manifest.Digest))
+		}
+	}
+
+	blobs, err := readFiles(blobPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*offlineImage, 0, len(indexManifest.Manifests))
+
+	for _, manifest := range indexManifest.Manifests {
+		image := &offlineImage{
+			refNames: refNames(manifest.Annotations),
+			digest:   manifest.Digest.Hex,
+		}
+
+		if manifest.MediaType.IsIndex() {
+			blob, ok := blobs[blobPath(manifest.Digest)]
+			if !ok {
+				return nil, fmt.Errorf(
+					"'%s' does not contain the manifest list '%s'",
+					source, manifest.Digest,
+				)
+			}
+
+			childIndexManifest, err := v1.ParseIndexManifest(
+				bytes.NewReader(blob),
+			)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"manifest list '%s' in '%s' failed to parse with err: %v",
+					manifest.Digest, source, err,
+				)
+			}
+
+			image.platformDigests = platformDigestsFromIndex(
+				childIndexManifest,
+			)
+		}
+
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// refNames returns the normalized references of an image from the
+// annotations of its descriptor in an OCI image layout.
+func refNames(annotations map[string]string) []string {
+	var refNames []string
+
+	for _, annotation := range []string{
+		containerdRefNameAnnotation, ociRefNameAnnotation,
+	} {
+		refName := annotations[annotation]
+
+		// A tag, such as "latest", does not name an image.
+		if refName == "" || !strings.ContainsAny(refName, "/:@") {
+			continue
+		}
+
+		ref, err := name.ParseReference(refName)
+		if err != nil {
+			continue
+		}
+
+		refNames = append(refNames, ref.Name())
+	}
+
+	return refNames
+}
+
+func blobPath(digest v1.Hash) string {
+	return path.Join("blobs", digest.Algorithm, digest.Hex)
+}
+
+func readDirFiles(dir string, paths []string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	for _, p := range paths {
+		byt, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		files[p] = byt
+	}
+
+	return files, nil
+}
+
+// readTarFiles reads the files at paths from a tarball without reading the
+// rest of the tarball, which contains image layers, into memory.
+func readTarFiles(
+	tarballPath string,
+	paths []string,
+) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	if len(paths) == 0 {
+		return files, nil
+	}
+
+	wanted := map[string]bool{}
+	for _, p := range paths {
+		wanted[p] = true
+	}
+
+	tarball, err := os.Open(tarballPath)
+	if err != nil {
+		return nil, err
+	}
+	defer tarball.Close()
+
+	tarReader := tar.NewReader(tarball)
+
+	for len(files) < len(wanted) {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf(
+				"'%s' failed to read with err: %v", tarballPath, err,
+			)
+		}
+
+		p := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if !wanted[p] {
+			continue
+		}
+
+		byt, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
+		}
+
+		files[p] = byt
+	}
+
+	return files, nil
+}
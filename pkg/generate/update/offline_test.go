@@ -0,0 +1,249 @@
+package update_test
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+const offlineDigestRequesterTestDir = "offlineDigestRequester-tests"
+
+// nolint: lll
+const (
+	offlineBusyboxSHA      = "1111111111111111111111111111111111111111111111111111111111111111"
+	offlineBusyboxAMD64SHA = "2222222222222222222222222222222222222222222222222222222222222222"
+	offlineBusyboxARM64SHA = "3333333333333333333333333333333333333333333333333333333333333333"
+	offlineGolangSHA       = "4444444444444444444444444444444444444444444444444444444444444444"
+)
+
+// offlineLayoutFiles are the files of an OCI image layout with a
+// multi-architecture busybox image, named as in tarballs from "docker save",
+// and a single architecture golang image.
+func offlineLayoutFiles() map[string][]byte {
+	return map[string][]byte{
+		"oci-layout": []byte(`{"imageLayoutVersion": "1.0.0"}`),
+		"index.json": []byte(`{
+	"schemaVersion": 2,
+	"manifests": [
+		{
+			"mediaType": "application/vnd.oci.image.index.v1+json",
+			"digest": "sha256:` + offlineBusyboxSHA + `",
+			"size": 1,
+			"annotations": {
+				"io.containerd.image.name": "docker.io/library/busybox:latest",
+				"org.opencontainers.image.ref.name": "latest"
+			}
+		},
+		{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest": "sha256:` + offlineGolangSHA + `",
+			"size": 1,
+			"annotations": {
+				"org.opencontainers.image.ref.name": "golang:1.16"
+			}
+		}
+	]
+}`),
+		filepath.ToSlash(
+			filepath.Join("blobs", "sha256", offlineBusyboxSHA),
+		): []byte(`{
+	"schemaVersion": 2,
+	"manifests": [
+		{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest": "sha256:` + offlineBusyboxAMD64SHA + `",
+			"size": 1,
+			"platform": {"os": "linux", "architecture": "amd64"}
+		},
+		{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest": "sha256:` + offlineBusyboxARM64SHA + `",
+			"size": 1,
+			"platform": {
+				"os": "linux", "architecture": "arm64", "variant": "v8"
+			}
+		}
+	]
+}`),
+	}
+}
+
+func TestOfflineDigestRequester(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                    string
+		Tarball                 bool
+		ImageLine               string
+		ExpectedDigest          string
+		ExpectedNumPlatforms    int
+		ShouldFail              bool
+		WithoutIndex            bool
+		ExpectedConstructorFail bool
+	}{
+		{
+			Name:                 "Layout Manifest List",
+			ImageLine:            "busybox:latest",
+			ExpectedDigest:       offlineBusyboxSHA,
+			ExpectedNumPlatforms: 2,
+		},
+		{
+			Name:           "Layout Manifest",
+			ImageLine:      "golang:1.16",
+			ExpectedDigest: offlineGolangSHA,
+		},
+		{
+			Name:                 "Layout Digest",
+			ImageLine:            "busybox@sha256:" + offlineBusyboxSHA,
+			ExpectedDigest:       offlineBusyboxSHA,
+			ExpectedNumPlatforms: 2,
+		},
+		{
+			Name:                 "Tarball Manifest List",
+			Tarball:              true,
+			ImageLine:            "docker.io/library/busybox:latest",
+			ExpectedDigest:       offlineBusyboxSHA,
+			ExpectedNumPlatforms: 2,
+		},
+		{
+			Name:           "Tarball Manifest",
+			Tarball:        true,
+			ImageLine:      "golang:1.16",
+			ExpectedDigest: offlineGolangSHA,
+		},
+		{
+			Name:       "Missing Image",
+			ImageLine:  "redis:latest",
+			ShouldFail: true,
+		},
+		{
+			Name:       "Missing Tag",
+			ImageLine:  "golang:1.15",
+			ShouldFail: true,
+		},
+		{
+			Name:                    "Missing Index",
+			Tarball:                 true,
+			WithoutIndex:            true,
+			ExpectedConstructorFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDir(t, offlineDigestRequesterTestDir)
+			defer os.RemoveAll(tempDir)
+
+			files := offlineLayoutFiles()
+			if test.WithoutIndex {
+				delete(files, "index.json")
+			}
+
+			source := filepath.Join(tempDir, "layout")
+			if test.Tarball {
+				source = filepath.Join(tempDir, "images.tar")
+				writeTarball(t, source, files)
+			} else {
+				writeLayout(t, source, files)
+			}
+
+			digestRequester, err := update.NewOfflineDigestRequester(
+				[]string{source},
+			)
+			if test.ExpectedConstructorFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			digest, platformDigests, err := digestRequester.PlatformDigests(
+				test.ImageLine,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.ExpectedDigest != digest {
+				t.Fatalf(
+					"expected digest %s, got %s", test.ExpectedDigest, digest,
+				)
+			}
+
+			if test.ExpectedNumPlatforms != len(platformDigests) {
+				t.Fatalf(
+					"expected %d platforms, got %d",
+					test.ExpectedNumPlatforms, len(platformDigests),
+				)
+			}
+		})
+	}
+}
+
+func writeLayout(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+
+	for path, contents := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil { // nolint: gomnd
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, contents, 0777); err != nil { // nolint: gomnd
+			t.Fatal(err)
+		}
+	}
+}
+
+func writeTarball(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+
+	tarball, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tarball.Close()
+
+	tarWriter := tar.NewWriter(tarball)
+
+	for name, contents := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0600,
+			Size: int64(len(contents)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tarWriter.Write(contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+)
+
+type tagLister struct {
+	transport http.RoundTripper
+	keychain  authn.Keychain
+}
+
+type mirroredTagLister struct {
+	tagLister ITagLister
+	mirrors   map[string]string
+}
+
+// NewTagLister returns an ITagLister based on the library "crane". Requests
+// to registries are made with transport and authenticated with credentials
+// from keychain. If transport is nil, http.DefaultTransport is used. If
+// keychain is nil, authn.DefaultKeychain, which reads docker's config file,
+// is used.
+func NewTagLister(
+	transport http.RoundTripper,
+	keychain authn.Keychain,
+) ITagLister {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+
+	return &tagLister{transport: transport, keychain: keychain}
+}
+
+// ListTags queries a registry for the tags of an image, such as "busybox" or
+// "ghcr.io/org/app".
+func (t *tagLister) ListTags(name string) ([]string, error) {
+	if name == "" {
+		return nil, errors.New("image 'name' cannot be empty")
+	}
+
+	tags, err := crane.ListTags(
+		name, crane.WithTransport(t.transport),
+		crane.WithAuthFromKeychain(t.keychain),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to list tags for '%s' with err: %v", name, err,
+		)
+	}
+
+	return tags, nil
+}
+
+// NewMirroredTagLister returns an ITagLister that queries mirrors instead of
+// the registries they mirror. mirrors are described in
+// NewMirroredDigestRequester.
+func NewMirroredTagLister(
+	tagLister ITagLister,
+	mirrors map[string]string,
+) (ITagLister, error) {
+	if tagLister == nil || reflect.ValueOf(tagLister).IsNil() {
+		return nil, errors.New("'tagLister' cannot be nil")
+	}
+
+	if err := ValidateMirrors(mirrors); err != nil {
+		return nil, err
+	}
+
+	return &mirroredTagLister{tagLister: tagLister, mirrors: mirrors}, nil
+}
+
+// ListTags queries the mirror of an image for its tags.
+func (m *mirroredTagLister) ListTags(name string) ([]string, error) {
+	return m.tagLister.ListTags(MirrorName(name, m.mirrors))
+}
@@ -0,0 +1,90 @@
+package update_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+type recordingTagLister struct {
+	names []string
+}
+
+func (r *recordingTagLister) ListTags(name string) ([]string, error) {
+	r.names = append(r.names, name)
+
+	return nil, nil
+}
+
+func TestTagLister(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v2/":
+			case "/v2/org/app/tags/list":
+				fmt.Fprint(
+					w, `{"name":"org/app","tags":["1.0.0","1.1.0","latest"]}`,
+				)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	tagLister := update.NewTagLister(nil, authn.NewMultiKeychain())
+
+	got, err := tagLister.ListTags(fmt.Sprintf("%s/org/app", registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"1.0.0", "1.1.0", "latest"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	if _, err := tagLister.ListTags(
+		fmt.Sprintf("%s/org/missing", registry),
+	); err == nil {
+		t.Fatal("expected error but did not get one")
+	}
+}
+
+func TestMirroredTagLister(t *testing.T) {
+	t.Parallel()
+
+	recorder := &recordingTagLister{}
+
+	tagLister, err := update.NewMirroredTagLister(
+		recorder, map[string]string{"docker.io": "mirror.internal:5000"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tagLister.ListTags("busybox"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"mirror.internal:5000/library/busybox"}
+	if !reflect.DeepEqual(expected, recorder.names) {
+		t.Fatalf("expected %v, got %v", expected, recorder.names)
+	}
+
+	if _, err := update.NewMirroredTagLister(
+		recorder, map[string]string{"docker.io": ""},
+	); err == nil {
+		t.Fatal("expected error but did not get one")
+	}
+}
@@ -0,0 +1,211 @@
+package update
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxBackoff is the longest time to wait between retries, unless a registry
+// asks for a longer wait with the "Retry-After" header.
+const maxBackoff = 30 * time.Second
+
+type registryTransport struct {
+	transport         http.RoundTripper
+	requestsPerSecond float64
+	maxRetries        int
+	initialBackoff    time.Duration
+	buckets           map[string]*tokenBucket
+	mutex             sync.Mutex
+}
+
+// tokenBucket limits the rate of requests to a registry. It holds up to one
+// second's worth of requests, so short bursts are not delayed.
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+	mutex    sync.Mutex
+}
+
+// NewRegistryTransport returns an http.RoundTripper that wraps transport to
+// limit the rate of requests to each registry and to retry requests that
+// fail because the registry is throttling or unavailable.
+//
+// Requests to each host are limited to requestsPerSecond. If
+// requestsPerSecond is not positive, requests are not limited.
+//
+// Responses with a status of 429 or 5xx are retried up to maxRetries times.
+// The wait between retries starts at initialBackoff and doubles after each
+// retry, unless the registry specifies a wait with the "Retry-After" header.
+//
+// If transport is nil, http.DefaultTransport is used.
+func NewRegistryTransport(
+	transport http.RoundTripper,
+	requestsPerSecond float64,
+	maxRetries int,
+	initialBackoff time.Duration,
+) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &registryTransport{
+		transport:         transport,
+		requestsPerSecond: requestsPerSecond,
+		maxRetries:        maxRetries,
+		initialBackoff:    initialBackoff,
+		buckets:           map[string]*tokenBucket{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *registryTransport) RoundTrip(
+	req *http.Request,
+) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := r.wait(req); err != nil {
+			return nil, err
+		}
+
+		resp, err := r.transport.RoundTrip(req)
+		if err != nil || !r.shouldRetry(resp) || attempt >= r.maxRetries {
+			return resp, err
+		}
+
+		// Requests with a body can only be retried if the body can be read
+		// again.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		delay := r.backoff(attempt, resp)
+
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// wait blocks until the rate limit of the request's host allows another
+// request.
+func (r *registryTransport) wait(req *http.Request) error {
+	if r.requestsPerSecond <= 0 {
+		return nil
+	}
+
+	r.mutex.Lock()
+
+	bucket, ok := r.buckets[req.URL.Host]
+	if !ok {
+		capacity := math.Max(1, r.requestsPerSecond)
+
+		bucket = &tokenBucket{
+			rate:     r.requestsPerSecond,
+			capacity: capacity,
+			tokens:   capacity,
+			last:     time.Now(),
+		}
+		r.buckets[req.URL.Host] = bucket
+	}
+
+	r.mutex.Unlock()
+
+	return bucket.take(req.Context())
+}
+
+func (r *registryTransport) shouldRetry(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the time to wait before retrying. The "Retry-After" header
+// may be a number of seconds or an HTTP date.
+func (r *registryTransport) backoff(
+	attempt int,
+	resp *http.Response,
+) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			if delay := time.Until(date); delay > 0 {
+				return delay
+			}
+
+			return 0
+		}
+	}
+
+	delay := r.initialBackoff * time.Duration(
+		math.Pow(2, float64(attempt)), // nolint: gomnd
+	)
+	if delay > maxBackoff || delay < 0 {
+		return maxBackoff
+	}
+
+	return delay
+}
+
+// take blocks until a token is available and removes it from the bucket.
+func (t *tokenBucket) take(ctx context.Context) error {
+	for {
+		t.mutex.Lock()
+
+		now := time.Now()
+
+		t.tokens = math.Min(
+			t.capacity, t.tokens+now.Sub(t.last).Seconds()*t.rate,
+		)
+		t.last = now
+
+		if t.tokens >= 1 {
+			t.tokens--
+			t.mutex.Unlock()
+
+			return nil
+		}
+
+		delay := time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+
+		t.mutex.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
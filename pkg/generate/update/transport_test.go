@@ -0,0 +1,158 @@
+package update_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+func TestRegistryTransport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name                string
+		Statuses            []int
+		RetryAfter          string
+		MaxRetries          int
+		ExpectedStatus      int
+		ExpectedNumRequests int64
+	}{
+		{
+			Name:                "Success",
+			Statuses:            []int{http.StatusOK},
+			MaxRetries:          3,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedNumRequests: 1,
+		},
+		{
+			Name: "Retry Too Many Requests",
+			Statuses: []int{
+				http.StatusTooManyRequests, http.StatusTooManyRequests,
+				http.StatusOK,
+			},
+			RetryAfter:          "0",
+			MaxRetries:          3,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedNumRequests: 3,
+		},
+		{
+			Name: "Retry Server Error",
+			Statuses: []int{
+				http.StatusServiceUnavailable, http.StatusOK,
+			},
+			MaxRetries:          3,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedNumRequests: 2,
+		},
+		{
+			Name: "Max Retries",
+			Statuses: []int{
+				http.StatusBadGateway, http.StatusBadGateway,
+				http.StatusBadGateway, http.StatusOK,
+			},
+			MaxRetries:          2,
+			ExpectedStatus:      http.StatusBadGateway,
+			ExpectedNumRequests: 3,
+		},
+		{
+			Name:                "Client Error",
+			Statuses:            []int{http.StatusNotFound, http.StatusOK},
+			MaxRetries:          3,
+			ExpectedStatus:      http.StatusNotFound,
+			ExpectedNumRequests: 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var numRequests int64
+
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					i := atomic.AddInt64(&numRequests, 1) - 1
+
+					if test.RetryAfter != "" {
+						w.Header().Set("Retry-After", test.RetryAfter)
+					}
+
+					w.WriteHeader(test.Statuses[i])
+				},
+			))
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: update.NewRegistryTransport(
+					nil, 0, test.MaxRetries, time.Millisecond,
+				),
+			}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if test.ExpectedStatus != resp.StatusCode {
+				t.Fatalf(
+					"expected status %d, got %d",
+					test.ExpectedStatus, resp.StatusCode,
+				)
+			}
+
+			if test.ExpectedNumRequests != numRequests {
+				t.Fatalf(
+					"expected %d requests, got %d",
+					test.ExpectedNumRequests, numRequests,
+				)
+			}
+		})
+	}
+}
+
+func TestRegistryTransportRateLimit(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {},
+	))
+	defer server.Close()
+
+	const (
+		requestsPerSecond = 10
+		numRequests       = 15
+	)
+
+	client := &http.Client{
+		Transport: update.NewRegistryTransport(
+			nil, requestsPerSecond, 0, time.Millisecond,
+		),
+	}
+
+	start := time.Now()
+
+	for i := 0; i < numRequests; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// The first requestsPerSecond requests are allowed immediately, and the
+	// rest must wait for tokens.
+	const minElapsed = 400 * time.Millisecond
+	if elapsed := time.Since(start); elapsed < minElapsed {
+		t.Fatalf(
+			"expected %d requests to take at least %s, took %s",
+			numRequests, minElapsed, elapsed,
+		)
+	}
+}
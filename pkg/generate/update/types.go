@@ -1,7 +1,11 @@
 // Package update provides functionality to update images with digests.
 package update
 
-import "github.com/safe-waters/docker-lock/pkg/generate/parse"
+import (
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+)
 
 // IImageDigestUpdater provides an interface for ImageDigestUpdaters, which
 // update images with their digests.
@@ -18,3 +22,50 @@ type IImageDigestUpdater interface {
 type IDigestRequester interface {
 	Digest(name string, tag string) (string, error)
 }
+
+// IPlatformDigestRequester provides an interface for DigestRequesters that
+// can also query the digest of each platform in a multi-architecture
+// manifest list.
+type IPlatformDigestRequester interface {
+	IDigestRequester
+	PlatformDigests(
+		imageLine string,
+	) (digest string, platformDigests []*parse.PlatformDigest, err error)
+}
+
+// ICreatedRequester provides an interface for DigestRequesters that can
+// also query the time that the image with a digest was created, from the
+// "created" field of its config.
+type ICreatedRequester interface {
+	Created(name string, digest string) (time.Time, error)
+}
+
+// IArtifactRequester provides an interface for DigestRequesters that can
+// also query the layers of an OCI artifact stored under a tag in an image's
+// repository, such as a cosign signature.
+type IArtifactRequester interface {
+	ArtifactLayers(name string, tag string) ([]*ArtifactLayer, error)
+}
+
+// ITagLister provides an interface for TagListers, which are responsible for
+// listing the tags of an image's repository in its registry.
+type ITagLister interface {
+	ListTags(name string) ([]string, error)
+}
+
+// IDigestCache provides an interface for DigestCaches, which hold the
+// results of registry queries in memory so that they can be reused, and
+// save them to disk when flushed.
+type IDigestCache interface {
+	Digest(key string) (digest string, ok bool)
+	StoreDigest(key string, digest string)
+	PlatformDigests(
+		imageLine string,
+	) (digest string, platformDigests []*parse.PlatformDigest, ok bool)
+	StorePlatformDigests(
+		imageLine string,
+		digest string,
+		platformDigests []*parse.PlatformDigest,
+	)
+	Flush() error
+}
@@ -10,27 +10,56 @@ import (
 )
 
 type imageDigestUpdater struct {
-	digestRequester       IDigestRequester
-	ignoreMissingDigests  bool
-	updateExistingDigests bool
+	digestRequester         IDigestRequester
+	platformDigestRequester IPlatformDigestRequester
+	ignoreMissingDigests    bool
+	updateExistingDigests   bool
+	platformDigests         bool
+	maxConcurrency          int
 }
 
 // NewImageDigestUpdater returns an IImageDigestUpdater after validating its
 // fields. digestRequester cannot be nil as it is responsible for querying
 // registries for digests.
+//
+// If platformDigests is true, digestRequester must be an
+// IPlatformDigestRequester, and the digest of each platform in
+// multi-architecture images is recorded in the image's metadata under the
+// key "platforms". Images with a "platform" in their metadata, such as those
+// from "FROM --platform" instructions, also require an
+// IPlatformDigestRequester, so that the digest of that platform can be used.
+//
+// maxConcurrency limits the number of images whose digests are updated at
+// the same time. If maxConcurrency is 0, there is no limit.
 func NewImageDigestUpdater(
 	digestRequester IDigestRequester,
 	ignoreMissingDigests bool,
 	updateExistingDigests bool,
+	platformDigests bool,
+	maxConcurrency int,
 ) (IImageDigestUpdater, error) {
 	if digestRequester == nil || reflect.ValueOf(digestRequester).IsNil() {
 		return nil, errors.New("'digestRequester' cannot be nil")
 	}
 
+	if maxConcurrency < 0 {
+		return nil, errors.New("'maxConcurrency' cannot be negative")
+	}
+
+	platformDigestRequester, ok := digestRequester.(IPlatformDigestRequester)
+	if platformDigests && !ok {
+		return nil, errors.New(
+			"'digestRequester' cannot query platform digests",
+		)
+	}
+
 	return &imageDigestUpdater{
-		digestRequester:       digestRequester,
-		ignoreMissingDigests:  ignoreMissingDigests,
-		updateExistingDigests: updateExistingDigests,
+		digestRequester:         digestRequester,
+		platformDigestRequester: platformDigestRequester,
+		ignoreMissingDigests:    ignoreMissingDigests,
+		updateExistingDigests:   updateExistingDigests,
+		platformDigests:         platformDigests,
+		maxConcurrency:          maxConcurrency,
 	}, nil
 }
 
@@ -49,8 +78,13 @@ func (i *imageDigestUpdater) UpdateDigests(
 		waitGroup     sync.WaitGroup
 		doOnce        sync.Once
 		updatedImages = make(chan parse.IImage)
+		workers       chan struct{} // nil if there is no limit
 	)
 
+	if i.maxConcurrency > 0 {
+		workers = make(chan struct{}, i.maxConcurrency)
+	}
+
 	waitGroup.Add(1)
 
 	go func() {
@@ -82,28 +116,22 @@ func (i *imageDigestUpdater) UpdateDigests(
 								"found, will use existing digest",
 						)
 					})
+				}
 
+				if workers != nil {
 					select {
 					case <-done:
-					case updatedImages <- image:
+						return
+					case workers <- struct{}{}:
 					}
-
-					return
 				}
 
-				if (image.Digest() != "" && !i.updateExistingDigests) ||
-					image.Tag() == "" {
-					select {
-					case <-done:
-					case updatedImages <- image:
-					}
+				updatedImage, err := i.updateDigest(image)
 
-					return
+				if workers != nil {
+					<-workers
 				}
 
-				digest, err := i.digestRequester.Digest(
-					image.Name(), image.Tag(),
-				)
 				if err != nil && !i.ignoreMissingDigests {
 					errMsg := fmt.Errorf(
 						"failed to update image with err: %v", err,
@@ -127,10 +155,7 @@ func (i *imageDigestUpdater) UpdateDigests(
 				select {
 				case <-done:
 					return
-				case updatedImages <- parse.NewImage(
-					image.Kind(), image.Name(), image.Tag(),
-					digest, image.Metadata(), nil,
-				):
+				case updatedImages <- updatedImage:
 				}
 			}()
 		}
@@ -143,3 +168,103 @@ func (i *imageDigestUpdater) UpdateDigests(
 
 	return updatedImages
 }
+
+// updateDigest returns the image with its digest, querying the registry
+// if the image does not already have a digest or existing digests should be
+// updated. If platform digests are recorded, the registry is also queried for
+// images that already have a digest, to find the digest of each platform.
+//
+// If the image has a platform, its digest is that of the platform instead of
+// the manifest list, and the digests of the other platforms are not recorded.
+func (i *imageDigestUpdater) updateDigest(
+	image parse.IImage,
+) (parse.IImage, error) {
+	platform, _ := image.Metadata()["platform"].(string)
+
+	if (image.Digest() != "" && !i.updateExistingDigests) ||
+		image.Tag() == "" {
+		if !i.platformDigests || platform != "" || image.Digest() == "" {
+			return image, nil
+		}
+
+		_, platformDigests, err := i.platformDigestRequester.PlatformDigests(
+			fmt.Sprintf("%s@sha256:%s", image.Name(), image.Digest()),
+		)
+
+		return i.newImage(image, image.Digest(), platformDigests), err
+	}
+
+	if platform != "" {
+		digest, err := i.platformDigest(image, platform)
+
+		return i.newImage(image, digest, nil), err
+	}
+
+	if !i.platformDigests {
+		digest, err := i.digestRequester.Digest(image.Name(), image.Tag())
+
+		return i.newImage(image, digest, nil), err
+	}
+
+	digest, platformDigests, err := i.platformDigestRequester.PlatformDigests(
+		fmt.Sprintf("%s:%s", image.Name(), image.Tag()),
+	)
+
+	return i.newImage(image, digest, platformDigests), err
+}
+
+// platformDigest returns the digest of an image for a platform. If the
+// image is not a multi-architecture image, its only digest is returned.
+func (i *imageDigestUpdater) platformDigest(
+	image parse.IImage,
+	platform string,
+) (string, error) {
+	if i.platformDigestRequester == nil {
+		return "", fmt.Errorf(
+			"'digestRequester' cannot query the digest of platform '%s'",
+			platform,
+		)
+	}
+
+	digest, platformDigests, err := i.platformDigestRequester.PlatformDigests(
+		fmt.Sprintf("%s:%s", image.Name(), image.Tag()),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if len(platformDigests) == 0 {
+		return digest, nil
+	}
+
+	for _, platformDigest := range platformDigests {
+		if platformDigest.Matches(platform) {
+			return platformDigest.Digest, nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"image '%s' does not have a digest for platform '%s'",
+		image.ImageLine(), platform,
+	)
+}
+
+func (i *imageDigestUpdater) newImage(
+	image parse.IImage,
+	digest string,
+	platformDigests []*parse.PlatformDigest,
+) parse.IImage {
+	metadata := image.Metadata()
+
+	if len(platformDigests) != 0 {
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+
+		metadata["platforms"] = platformDigests
+	}
+
+	return parse.NewImage(
+		image.Kind(), image.Name(), image.Tag(), digest, metadata, nil,
+	)
+}
@@ -1,7 +1,11 @@
 package update_test
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/safe-waters/docker-lock/internal/testutils"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
@@ -16,8 +20,10 @@ func TestImageDigestUpdater(t *testing.T) {
 		Name                    string
 		Images                  []parse.IImage
 		UpdateExistingDigests   bool
+		PlatformDigests         bool
 		ExpectedNumNetworkCalls uint64
 		ExpectedImages          []parse.IImage
+		ShouldFail              bool
 	}{
 		{
 			Name: "Image Without Digest",
@@ -67,6 +73,131 @@ func TestImageDigestUpdater(t *testing.T) {
 				),
 			},
 		},
+		{
+			Name: "Platform Digests",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{"position": 0}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "redis", "latest", "",
+					map[string]interface{}{"position": 1}, nil,
+				),
+			},
+			PlatformDigests:         true,
+			ExpectedNumNetworkCalls: 2,
+			ExpectedImages: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest",
+					testutils.BusyboxLatestSHA,
+					map[string]interface{}{
+						"position": 0,
+						"platforms": []*parse.PlatformDigest{
+							{
+								OS:           "linux",
+								Architecture: "amd64",
+								Digest:       testutils.BusyboxLatestAMD64SHA,
+							},
+							{
+								OS:           "linux",
+								Architecture: "arm64",
+								Variant:      "v8",
+								Digest:       testutils.BusyboxLatestARM64SHA,
+							},
+						},
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "redis", "latest",
+					testutils.RedisLatestSHA,
+					map[string]interface{}{"position": 1}, nil,
+				),
+			},
+		},
+		{
+			Name: "Platform Digests For Image With Digest",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "",
+					testutils.BusyboxLatestSHA, nil, nil,
+				),
+			},
+			PlatformDigests:         true,
+			ExpectedNumNetworkCalls: 1,
+			ExpectedImages: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "",
+					testutils.BusyboxLatestSHA,
+					map[string]interface{}{
+						"platforms": []*parse.PlatformDigest{
+							{
+								OS:           "linux",
+								Architecture: "amd64",
+								Digest:       testutils.BusyboxLatestAMD64SHA,
+							},
+							{
+								OS:           "linux",
+								Architecture: "arm64",
+								Variant:      "v8",
+								Digest:       testutils.BusyboxLatestARM64SHA,
+							},
+						},
+					}, nil,
+				),
+			},
+		},
+		{
+			Name: "Platform",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"position": 0,
+						"platform": "linux/arm64",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "redis", "latest", "",
+					map[string]interface{}{
+						"position": 1,
+						"platform": "linux/amd64",
+					}, nil,
+				),
+			},
+			ExpectedNumNetworkCalls: 2,
+			ExpectedImages: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest",
+					testutils.BusyboxLatestARM64SHA,
+					map[string]interface{}{
+						"position": 0,
+						"platform": "linux/arm64",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "redis", "latest",
+					testutils.RedisLatestSHA,
+					map[string]interface{}{
+						"position": 1,
+						"platform": "linux/amd64",
+					}, nil,
+				),
+			},
+		},
+		{
+			Name: "Missing Platform",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"position": 0,
+						"platform": "windows/amd64",
+					}, nil,
+				),
+			},
+			ShouldFail: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -82,6 +213,7 @@ func TestImageDigestUpdater(t *testing.T) {
 			)
 			updater, err := update.NewImageDigestUpdater(
 				digestRequester, false, test.UpdateExistingDigests,
+				test.PlatformDigests, 0,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -102,12 +234,22 @@ func TestImageDigestUpdater(t *testing.T) {
 			var got []parse.IImage
 
 			for image := range updatedImages {
+				if test.ShouldFail {
+					if image.Err() == nil {
+						t.Fatal("expected error but did not get one")
+					}
+
+					return
+				}
+
 				if image.Err() != nil {
 					t.Fatal(image.Err())
 				}
 				got = append(got, image)
 			}
 
+			testutils.SortDockerfileImages(t, got)
+
 			testutils.AssertImagesEqual(
 				t, test.ExpectedImages, got,
 			)
@@ -118,3 +260,78 @@ func TestImageDigestUpdater(t *testing.T) {
 		})
 	}
 }
+
+type concurrencyDigestRequester struct {
+	numConcurrentCalls    int64
+	maxNumConcurrentCalls int64
+	mutex                 sync.Mutex
+}
+
+func (c *concurrencyDigestRequester) Digest(
+	name string,
+	tag string,
+) (string, error) {
+	numConcurrentCalls := atomic.AddInt64(&c.numConcurrentCalls, 1)
+	defer atomic.AddInt64(&c.numConcurrentCalls, -1)
+
+	c.mutex.Lock()
+	if numConcurrentCalls > c.maxNumConcurrentCalls {
+		c.maxNumConcurrentCalls = numConcurrentCalls
+	}
+	c.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	return fmt.Sprintf("%s-%s", name, tag), nil
+}
+
+func TestImageDigestUpdaterMaxConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numImages      = 20
+		maxConcurrency = 3
+	)
+
+	digestRequester := &concurrencyDigestRequester{}
+
+	updater, err := update.NewImageDigestUpdater(
+		digestRequester, false, false, false, maxConcurrency,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	images := make(chan parse.IImage, numImages)
+
+	for i := 0; i < numImages; i++ {
+		images <- parse.NewImage(
+			kind.Dockerfile, "busybox", fmt.Sprintf("%d", i), "", nil, nil,
+		)
+	}
+	close(images)
+
+	var numUpdatedImages int
+
+	for image := range updater.UpdateDigests(images, done) {
+		if image.Err() != nil {
+			t.Fatal(image.Err())
+		}
+
+		numUpdatedImages++
+	}
+
+	if numUpdatedImages != numImages {
+		t.Fatalf("expected %d images, got %d", numImages, numUpdatedImages)
+	}
+
+	if digestRequester.maxNumConcurrentCalls > maxConcurrency {
+		t.Fatalf(
+			"expected at most %d concurrent calls, got %d",
+			maxConcurrency, digestRequester.maxNumConcurrentCalls,
+		)
+	}
+}
@@ -2,6 +2,7 @@ package generate
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 
@@ -65,7 +66,7 @@ func (i *imageDigestUpdater) UpdateDigests(
 					return
 				}
 
-				key := image.ImageLine()
+				key := updateKey(image)
 				if _, ok := imageLineCache[key]; !ok {
 					metadata := image.Metadata()
 					if metadata == nil {
@@ -151,8 +152,17 @@ func (i *imageDigestUpdater) UpdateDigests(
 				return
 			}
 
+			delete(metadata, "__updateKey")
+
+			queriedMetadata := imageLineCache[key][0].Metadata()
+
 			for _, image := range imageLineCache[key] {
 				image.SetDigest(updatedImage.Digest())
+				image.SetMetadata(
+					updatedMetadata(
+						image.Metadata(), queriedMetadata, metadata,
+					),
+				)
 
 				select {
 				case <-done:
@@ -170,3 +180,42 @@ func (i *imageDigestUpdater) UpdateDigests(
 
 	return updatedImages
 }
+
+// updateKey returns the key of the images that are queried once. Images
+// with a platform, such as "FROM --platform=linux/arm64 alpine:3", are
+// queried separately from those with the same image line and a different
+// platform or none, as their digests differ.
+func updateKey(image parse.IImage) string {
+	key := image.ImageLine()
+
+	if platform, _ := image.Metadata()["platform"].(string); platform != "" {
+		key = fmt.Sprintf("%s --platform=%s", key, platform)
+	}
+
+	return key
+}
+
+// updatedMetadata returns the metadata of an image with the fields that the
+// updater added to, or changed in, the metadata of the image that was
+// queried, such as "platforms" or "created", so that every image with the
+// same image line has them.
+func updatedMetadata(
+	metadata map[string]interface{},
+	queriedMetadata map[string]interface{},
+	updatedMetadata map[string]interface{},
+) map[string]interface{} {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	for k, v := range updatedMetadata {
+		if queriedValue, ok := queriedMetadata[k]; ok &&
+			reflect.DeepEqual(queriedValue, v) {
+			continue
+		}
+
+		metadata[k] = v
+	}
+
+	return metadata
+}
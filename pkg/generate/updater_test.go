@@ -16,6 +16,7 @@ func TestImageDigestUpdater(t *testing.T) {
 	tests := []struct {
 		Name                    string
 		Images                  []parse.IImage
+		PlatformDigests         bool
 		ExpectedNumNetworkCalls uint64
 		Expected                []parse.IImage
 	}{
@@ -137,6 +138,85 @@ func TestImageDigestUpdater(t *testing.T) {
 			},
 			ExpectedNumNetworkCalls: 3,
 		},
+		{
+			Name: "Platform Digests Of Images With The Same Image Line",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"position": 0,
+						"path":     "Dockerfile",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Composefile, "busybox", "latest", "",
+					map[string]interface{}{
+						"position":    0,
+						"path":        "docker-compose.yml",
+						"serviceName": "svc",
+					}, nil,
+				),
+			},
+			PlatformDigests: true,
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest",
+					testutils.BusyboxLatestSHA, map[string]interface{}{
+						"position":  0,
+						"path":      "Dockerfile",
+						"platforms": busyboxPlatformDigests(),
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Composefile, "busybox", "latest",
+					testutils.BusyboxLatestSHA, map[string]interface{}{
+						"position":    0,
+						"path":        "docker-compose.yml",
+						"serviceName": "svc",
+						"platforms":   busyboxPlatformDigests(),
+					}, nil,
+				),
+			},
+			ExpectedNumNetworkCalls: 1,
+		},
+		{
+			Name: "Images With The Same Image Line And Different Platforms",
+			Images: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"position": 0,
+						"path":     "Dockerfile",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest", "",
+					map[string]interface{}{
+						"position": 1,
+						"path":     "Dockerfile",
+						"platform": "linux/arm64",
+					}, nil,
+				),
+			},
+			Expected: []parse.IImage{
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest",
+					testutils.BusyboxLatestSHA, map[string]interface{}{
+						"position": 0,
+						"path":     "Dockerfile",
+					}, nil,
+				),
+				parse.NewImage(
+					kind.Dockerfile, "busybox", "latest",
+					testutils.BusyboxLatestARM64SHA, map[string]interface{}{
+						"position": 1,
+						"path":     "Dockerfile",
+						"platform": "linux/arm64",
+					}, nil,
+				),
+			},
+			ExpectedNumNetworkCalls: 2,
+		},
 	}
 
 	for _, test := range tests {
@@ -151,7 +231,7 @@ func TestImageDigestUpdater(t *testing.T) {
 				t, &gotNumNetworkCalls,
 			)
 			innerUpdater, err := update.NewImageDigestUpdater(
-				digestRequester, false, false,
+				digestRequester, false, false, test.PlatformDigests, 0,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -194,3 +274,19 @@ func TestImageDigestUpdater(t *testing.T) {
 		})
 	}
 }
+
+func busyboxPlatformDigests() []*parse.PlatformDigest {
+	return []*parse.PlatformDigest{
+		{
+			OS:           "linux",
+			Architecture: "amd64",
+			Digest:       testutils.BusyboxLatestAMD64SHA,
+		},
+		{
+			OS:           "linux",
+			Architecture: "arm64",
+			Variant:      "v8",
+			Digest:       testutils.BusyboxLatestARM64SHA,
+		},
+	}
+}
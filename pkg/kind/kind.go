@@ -8,4 +8,7 @@ const (
 	Dockerfile     Kind = "dockerfiles"
 	Composefile    Kind = "composefiles"
 	Kubernetesfile Kind = "kubernetesfiles"
+	Helmchart      Kind = "helmcharts"
+	Kustomization  Kind = "kustomizations"
+	Bakefile       Kind = "bakefiles"
 )
@@ -0,0 +1,141 @@
+package lockfile
+
+import (
+	"strings"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// Instructions of images in Dockerfiles that are not in FROM instructions.
+const (
+	// CopyInstruction is an image in "COPY --from=<image>".
+	CopyInstruction = "copy"
+	// RunInstruction is an image in "RUN --mount=from=<image>".
+	RunInstruction = "run"
+	// SyntaxInstruction is the frontend image in the "# syntax=<image>"
+	// parser directive.
+	SyntaxInstruction = "syntax"
+)
+
+// DockerfileImage is an image in a Dockerfile. Instruction is empty for
+// images in FROM instructions, or else CopyInstruction, RunInstruction, or
+// SyntaxInstruction.
+type DockerfileImage struct {
+	Name        string            `json:"name"`
+	Tag         string            `json:"tag"`
+	Digest      string            `json:"digest"`
+	Created     *time.Time        `json:"created,omitempty"`
+	Instruction string            `json:"instruction,omitempty"`
+	Platform    string            `json:"platform,omitempty"`
+	Platforms   []*PlatformDigest `json:"platforms,omitempty"`
+}
+
+// ComposefileImage is an image of a service in a Composefile. If the
+// service builds the image from a Dockerfile, DockerfilePath is the path
+// to the Dockerfile, and Instruction is as in DockerfileImage.
+type ComposefileImage struct {
+	Name           string            `json:"name"`
+	Tag            string            `json:"tag"`
+	Digest         string            `json:"digest"`
+	Created        *time.Time        `json:"created,omitempty"`
+	DockerfilePath string            `json:"dockerfile,omitempty"`
+	Instruction    string            `json:"instruction,omitempty"`
+	ServiceName    string            `json:"service"`
+	Platform       string            `json:"platform,omitempty"`
+	Platforms      []*PlatformDigest `json:"platforms,omitempty"`
+}
+
+// KubernetesfileImage is an image of a container in a Kubernetesfile.
+type KubernetesfileImage struct {
+	Name          string            `json:"name"`
+	Tag           string            `json:"tag"`
+	Digest        string            `json:"digest"`
+	Created       *time.Time        `json:"created,omitempty"`
+	ContainerName string            `json:"container"`
+	Platforms     []*PlatformDigest `json:"platforms,omitempty"`
+}
+
+// HelmchartImage is an image in a Helm chart. Key is the dotted path to
+// the image in the chart's values, such as "redis.image".
+type HelmchartImage struct {
+	Name      string            `json:"name"`
+	Tag       string            `json:"tag"`
+	Digest    string            `json:"digest"`
+	Created   *time.Time        `json:"created,omitempty"`
+	Key       string            `json:"key,omitempty"`
+	Platforms []*PlatformDigest `json:"platforms,omitempty"`
+}
+
+// KustomizationImage is an image of a container in a manifest referenced
+// by a Kustomization.
+type KustomizationImage struct {
+	Name          string            `json:"name"`
+	Tag           string            `json:"tag"`
+	Digest        string            `json:"digest"`
+	Created       *time.Time        `json:"created,omitempty"`
+	ManifestPath  string            `json:"manifest"`
+	ContainerName string            `json:"container"`
+	Platforms     []*PlatformDigest `json:"platforms,omitempty"`
+}
+
+// BakefileImage is an image of a target in a Bakefile. If the image is a
+// "docker-image://" named context of the target, ContextName is the name of
+// the context. Otherwise, DockerfilePath is the path to the Dockerfile of the
+// target, and Instruction is as in DockerfileImage.
+type BakefileImage struct {
+	Name           string            `json:"name"`
+	Tag            string            `json:"tag"`
+	Digest         string            `json:"digest"`
+	Created        *time.Time        `json:"created,omitempty"`
+	DockerfilePath string            `json:"dockerfile,omitempty"`
+	Instruction    string            `json:"instruction,omitempty"`
+	ContextName    string            `json:"context,omitempty"`
+	TargetName     string            `json:"target"`
+	Platform       string            `json:"platform,omitempty"`
+	Platforms      []*PlatformDigest `json:"platforms,omitempty"`
+}
+
+// IndexedImage is an image of any kind in a Lockfile, with the kind and
+// path it is in and its index in the path. Image is the image itself, such as
+// a *DockerfileImage. The other fields point to the fields that every kind
+// of image has, so that changing them changes the Lockfile. Platform is empty
+// for kinds whose images do not have a platform.
+type IndexedImage struct {
+	Kind      kind.Kind
+	Path      string
+	Index     int
+	Image     interface{}
+	Name      *string
+	Tag       *string
+	Digest    *string
+	Created   **time.Time
+	Platform  string
+	Platforms *[]*PlatformDigest
+}
+
+// PlatformDigest is the digest of an image for a single platform, such as
+// "linux/arm64/v8", in a multi-architecture manifest list.
+type PlatformDigest struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	Digest       string `json:"digest"`
+}
+
+// Matches reports whether the PlatformDigest is for a platform in the form
+// "os/architecture[/variant]". If the platform does not specify a variant,
+// any variant matches.
+func (p *PlatformDigest) Matches(platform string) bool {
+	fields := strings.Split(platform, "/")
+
+	switch len(fields) {
+	case 2: // nolint: gomnd
+		return p.OS == fields[0] && p.Architecture == fields[1]
+	case 3: // nolint: gomnd
+		return p.OS == fields[0] && p.Architecture == fields[1] &&
+			p.Variant == fields[2]
+	default:
+		return false
+	}
+}
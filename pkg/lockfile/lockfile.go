@@ -0,0 +1,496 @@
+// Package lockfile provides the format of Lockfiles.
+package lockfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// SchemaVersion is the version of the Lockfile format written by this
+// version of docker-lock. Lockfiles without a "schemaVersion" field were
+// written before the format was versioned, and are version 0.
+const SchemaVersion = 4
+
+// migrations upgrade a Lockfile from the version they are keyed by to the
+// next version.
+var migrations = map[int]func(*Lockfile) error{ // nolint: gochecknoglobals
+	// Version 1 added "schemaVersion" without changing the images.
+	0: func(lockfile *Lockfile) error { return nil },
+	// Version 2 added "instruction" to Dockerfile and Composefile images,
+	// so that older versions of docker-lock, which would rewrite images in
+	// COPY and RUN instructions as if they were in FROM instructions, refuse
+	// to read them. Existing images are all in FROM instructions.
+	1: func(lockfile *Lockfile) error { return nil },
+	// Version 3 added the "syntax" instruction, for the frontend image in
+	// the "# syntax" parser directive of Dockerfiles.
+	2: func(lockfile *Lockfile) error { return nil },
+	// Version 4 added Bakefiles, so that older versions of docker-lock,
+	// which would ignore their images, refuse to read them.
+	3: func(lockfile *Lockfile) error { return nil },
+}
+
+// Lockfile is the images in a Lockfile, keyed by kind and path, and the
+// version of the Lockfile's format. The "Created" field of an image, if it
+// is not nil, is the time that the image with its digest was created.
+type Lockfile struct {
+	SchemaVersion   int                               `json:"schemaVersion"`
+	Dockerfiles     map[string][]*DockerfileImage     `json:"dockerfiles,omitempty"`     // nolint: lll
+	Composefiles    map[string][]*ComposefileImage    `json:"composefiles,omitempty"`    // nolint: lll
+	Kubernetesfiles map[string][]*KubernetesfileImage `json:"kubernetesfiles,omitempty"` // nolint: lll
+	Helmcharts      map[string][]*HelmchartImage      `json:"helmcharts,omitempty"`      // nolint: lll
+	Kustomizations  map[string][]*KustomizationImage  `json:"kustomizations,omitempty"`  // nolint: lll
+	Bakefiles       map[string][]*BakefileImage       `json:"bakefiles,omitempty"`       // nolint: lll
+}
+
+// New returns a Lockfile with the current SchemaVersion and no images.
+func New() *Lockfile {
+	return &Lockfile{
+		SchemaVersion:   SchemaVersion,
+		Dockerfiles:     map[string][]*DockerfileImage{},
+		Composefiles:    map[string][]*ComposefileImage{},
+		Kubernetesfiles: map[string][]*KubernetesfileImage{},
+		Helmcharts:      map[string][]*HelmchartImage{},
+		Kustomizations:  map[string][]*KustomizationImage{},
+		Bakefiles:       map[string][]*BakefileImage{},
+	}
+}
+
+// Read decodes a Lockfile, migrating it to the current SchemaVersion if it
+// is older, and validates it. An error is returned if the Lockfile is newer
+// than the current SchemaVersion, because its format may not be understood.
+func Read(reader io.Reader) (*Lockfile, error) {
+	if reader == nil || reflect.ValueOf(reader).IsNil() {
+		return nil, errors.New("'reader' cannot be nil")
+	}
+
+	var lockfile Lockfile
+	if err := json.NewDecoder(reader).Decode(&lockfile); err != nil {
+		return nil, fmt.Errorf("lockfile failed to parse with err: %v", err)
+	}
+
+	if err := lockfile.Migrate(); err != nil {
+		return nil, err
+	}
+
+	if err := lockfile.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &lockfile, nil
+}
+
+// Write validates the Lockfile and encodes it as indented JSON.
+func (l *Lockfile) Write(writer io.Writer) error {
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	byt, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(byt)
+
+	return err
+}
+
+// Migrate upgrades the Lockfile to the current SchemaVersion. An error is
+// returned if the Lockfile is newer than the current SchemaVersion.
+func (l *Lockfile) Migrate() error {
+	if l.SchemaVersion > SchemaVersion {
+		return fmt.Errorf(
+			"lockfile has schemaVersion '%d', but this version of "+
+				"docker-lock only supports up to '%d' - upgrade docker-lock",
+			l.SchemaVersion, SchemaVersion,
+		)
+	}
+
+	if l.SchemaVersion < 0 {
+		return fmt.Errorf(
+			"lockfile has invalid schemaVersion '%d'", l.SchemaVersion,
+		)
+	}
+
+	for ; l.SchemaVersion < SchemaVersion; l.SchemaVersion++ {
+		if err := migrations[l.SchemaVersion](l); err != nil {
+			return fmt.Errorf(
+				"failed to migrate lockfile from schemaVersion '%d' "+
+					"with err: %v",
+				l.SchemaVersion, err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Validate reports the first problem that would stop the Lockfile from
+// being verified or rewritten, such as an image without a name or a
+// Composefile image without a service.
+func (l *Lockfile) Validate() error {
+	if l.SchemaVersion != SchemaVersion {
+		return fmt.Errorf(
+			"lockfile has schemaVersion '%d', but expected '%d'",
+			l.SchemaVersion, SchemaVersion,
+		)
+	}
+
+	for path, images := range l.Dockerfiles {
+		for i, image := range images {
+			if image == nil {
+				return nilImageError(kind.Dockerfile, path, i)
+			}
+
+			if err := validateImage(
+				kind.Dockerfile, path, i, image.Name, image.Platforms,
+			); err != nil {
+				return err
+			}
+
+			if err := validateInstruction(
+				kind.Dockerfile, path, i, image.Instruction,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	for path, images := range l.Composefiles {
+		for i, image := range images {
+			if image == nil {
+				return nilImageError(kind.Composefile, path, i)
+			}
+
+			if err := validateImage(
+				kind.Composefile, path, i, image.Name, image.Platforms,
+			); err != nil {
+				return err
+			}
+
+			if err := validateInstruction(
+				kind.Composefile, path, i, image.Instruction,
+			); err != nil {
+				return err
+			}
+
+			if image.ServiceName == "" {
+				return missingFieldError(kind.Composefile, path, i, "service")
+			}
+		}
+	}
+
+	for path, images := range l.Kubernetesfiles {
+		for i, image := range images {
+			if image == nil {
+				return nilImageError(kind.Kubernetesfile, path, i)
+			}
+
+			if err := validateImage(
+				kind.Kubernetesfile, path, i, image.Name, image.Platforms,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	for path, images := range l.Helmcharts {
+		for i, image := range images {
+			if image == nil {
+				return nilImageError(kind.Helmchart, path, i)
+			}
+
+			if err := validateImage(
+				kind.Helmchart, path, i, image.Name, image.Platforms,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	for path, images := range l.Kustomizations {
+		for i, image := range images {
+			if image == nil {
+				return nilImageError(kind.Kustomization, path, i)
+			}
+
+			if err := validateImage(
+				kind.Kustomization, path, i, image.Name, image.Platforms,
+			); err != nil {
+				return err
+			}
+
+			if image.ManifestPath == "" {
+				return missingFieldError(
+					kind.Kustomization, path, i, "manifest",
+				)
+			}
+		}
+	}
+
+	for path, images := range l.Bakefiles {
+		for i, image := range images {
+			if image == nil {
+				return nilImageError(kind.Bakefile, path, i)
+			}
+
+			if err := validateImage(
+				kind.Bakefile, path, i, image.Name, image.Platforms,
+			); err != nil {
+				return err
+			}
+
+			if err := validateInstruction(
+				kind.Bakefile, path, i, image.Instruction,
+			); err != nil {
+				return err
+			}
+
+			if image.TargetName == "" {
+				return missingFieldError(kind.Bakefile, path, i, "target")
+			}
+
+			if (image.DockerfilePath == "") == (image.ContextName == "") {
+				return fmt.Errorf(
+					"image '%d' in '%s' of kind '%s' must have either "+
+						"'dockerfile' or 'context'",
+					i, path, kind.Bakefile,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Kinds returns the kinds that have at least one path in the Lockfile,
+// sorted.
+func (l *Lockfile) Kinds() []kind.Kind {
+	var kinds []kind.Kind
+
+	for k, numPaths := range map[kind.Kind]int{
+		kind.Dockerfile:     len(l.Dockerfiles),
+		kind.Composefile:    len(l.Composefiles),
+		kind.Kubernetesfile: len(l.Kubernetesfiles),
+		kind.Helmchart:      len(l.Helmcharts),
+		kind.Kustomization:  len(l.Kustomizations),
+		kind.Bakefile:       len(l.Bakefiles),
+	} {
+		if numPaths != 0 {
+			kinds = append(kinds, k)
+		}
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	return kinds
+}
+
+// Paths returns the paths of a kind in the Lockfile, sorted.
+func (l *Lockfile) Paths(k kind.Kind) []string {
+	var paths []string
+
+	switch k {
+	case kind.Dockerfile:
+		for path := range l.Dockerfiles {
+			paths = append(paths, path)
+		}
+	case kind.Composefile:
+		for path := range l.Composefiles {
+			paths = append(paths, path)
+		}
+	case kind.Kubernetesfile:
+		for path := range l.Kubernetesfiles {
+			paths = append(paths, path)
+		}
+	case kind.Helmchart:
+		for path := range l.Helmcharts {
+			paths = append(paths, path)
+		}
+	case kind.Kustomization:
+		for path := range l.Kustomizations {
+			paths = append(paths, path)
+		}
+	case kind.Bakefile:
+		for path := range l.Bakefiles {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// DeletePath removes a path of a kind, and its images, from the Lockfile.
+func (l *Lockfile) DeletePath(k kind.Kind, path string) {
+	switch k {
+	case kind.Dockerfile:
+		delete(l.Dockerfiles, path)
+	case kind.Composefile:
+		delete(l.Composefiles, path)
+	case kind.Kubernetesfile:
+		delete(l.Kubernetesfiles, path)
+	case kind.Helmchart:
+		delete(l.Helmcharts, path)
+	case kind.Kustomization:
+		delete(l.Kustomizations, path)
+	case kind.Bakefile:
+		delete(l.Bakefiles, path)
+	}
+}
+
+// Images returns the images of every kind in the Lockfile, sorted by kind,
+// path, and index.
+func (l *Lockfile) Images() []*IndexedImage {
+	var images []*IndexedImage
+
+	for path, pathImages := range l.Dockerfiles {
+		for i, image := range pathImages {
+			images = append(images, &IndexedImage{
+				Kind: kind.Dockerfile, Path: path, Index: i, Image: image,
+				Name: &image.Name, Tag: &image.Tag, Digest: &image.Digest,
+				Created: &image.Created, Platform: image.Platform,
+				Platforms: &image.Platforms,
+			})
+		}
+	}
+
+	for path, pathImages := range l.Composefiles {
+		for i, image := range pathImages {
+			images = append(images, &IndexedImage{
+				Kind: kind.Composefile, Path: path, Index: i, Image: image,
+				Name: &image.Name, Tag: &image.Tag, Digest: &image.Digest,
+				Created: &image.Created, Platform: image.Platform,
+				Platforms: &image.Platforms,
+			})
+		}
+	}
+
+	for path, pathImages := range l.Kubernetesfiles {
+		for i, image := range pathImages {
+			images = append(images, &IndexedImage{
+				Kind: kind.Kubernetesfile, Path: path, Index: i, Image: image,
+				Name: &image.Name, Tag: &image.Tag, Digest: &image.Digest,
+				Created: &image.Created, Platforms: &image.Platforms,
+			})
+		}
+	}
+
+	for path, pathImages := range l.Helmcharts {
+		for i, image := range pathImages {
+			images = append(images, &IndexedImage{
+				Kind: kind.Helmchart, Path: path, Index: i, Image: image,
+				Name: &image.Name, Tag: &image.Tag, Digest: &image.Digest,
+				Created: &image.Created, Platforms: &image.Platforms,
+			})
+		}
+	}
+
+	for path, pathImages := range l.Kustomizations {
+		for i, image := range pathImages {
+			images = append(images, &IndexedImage{
+				Kind: kind.Kustomization, Path: path, Index: i, Image: image,
+				Name: &image.Name, Tag: &image.Tag, Digest: &image.Digest,
+				Created: &image.Created, Platforms: &image.Platforms,
+			})
+		}
+	}
+
+	for path, pathImages := range l.Bakefiles {
+		for i, image := range pathImages {
+			images = append(images, &IndexedImage{
+				Kind: kind.Bakefile, Path: path, Index: i, Image: image,
+				Name: &image.Name, Tag: &image.Tag, Digest: &image.Digest,
+				Created: &image.Created, Platform: image.Platform,
+				Platforms: &image.Platforms,
+			})
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		switch {
+		case images[i].Kind != images[j].Kind:
+			return images[i].Kind < images[j].Kind
+		case images[i].Path != images[j].Path:
+			return images[i].Path < images[j].Path
+		default:
+			return images[i].Index < images[j].Index
+		}
+	})
+
+	return images
+}
+
+func validateImage(
+	k kind.Kind,
+	path string,
+	index int,
+	name string,
+	platforms []*PlatformDigest,
+) error {
+	if path == "" {
+		return fmt.Errorf("kind '%s' has an empty path", k)
+	}
+
+	if name == "" {
+		return missingFieldError(k, path, index, "name")
+	}
+
+	for _, platform := range platforms {
+		if platform == nil || platform.OS == "" ||
+			platform.Architecture == "" || platform.Digest == "" {
+			return fmt.Errorf(
+				"image '%d' in '%s' of kind '%s' has a malformed platform",
+				index, path, k,
+			)
+		}
+	}
+
+	return nil
+}
+
+func validateInstruction(
+	k kind.Kind,
+	path string,
+	index int,
+	instruction string,
+) error {
+	switch instruction {
+	case "", CopyInstruction, RunInstruction, SyntaxInstruction:
+		return nil
+	default:
+		return fmt.Errorf(
+			"image '%d' in '%s' of kind '%s' has unknown instruction '%s'",
+			index, path, k, instruction,
+		)
+	}
+}
+
+func nilImageError(k kind.Kind, path string, index int) error {
+	return fmt.Errorf(
+		"image '%d' in '%s' of kind '%s' cannot be null", index, path, k,
+	)
+}
+
+func missingFieldError(
+	k kind.Kind,
+	path string,
+	index int,
+	field string,
+) error {
+	return fmt.Errorf(
+		"image '%d' in '%s' of kind '%s' is missing '%s'",
+		index, path, k, field,
+	)
+}
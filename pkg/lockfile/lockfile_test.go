@@ -0,0 +1,476 @@
+package lockfile_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Contents   []byte
+		Expected   *lockfile.Lockfile
+		ShouldFail bool
+	}{
+		{
+			Name: "Current Schema Version",
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			}
+		]
+	}
+}`),
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "busybox", Tag: "latest", Digest: "busybox"},
+					},
+				},
+			},
+		},
+		{
+			Name: "Unversioned",
+			Contents: []byte(`{
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			}
+		]
+	}
+}`),
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "busybox", Tag: "latest", Digest: "busybox"},
+					},
+				},
+			},
+		},
+		{
+			Name: "Previous Schema Version",
+			Contents: []byte(`{
+	"schemaVersion": 1,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			}
+		]
+	}
+}`),
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "busybox", Tag: "latest", Digest: "busybox"},
+					},
+				},
+			},
+		},
+		{
+			Name:       "Future Schema Version",
+			Contents:   []byte(`{"schemaVersion": 1000}`),
+			ShouldFail: true,
+		},
+		{
+			Name:       "Negative Schema Version",
+			Contents:   []byte(`{"schemaVersion": -1}`),
+			ShouldFail: true,
+		},
+		{
+			Name:       "Malformed Schema Version",
+			Contents:   []byte(`{"schemaVersion": "1"}`),
+			ShouldFail: true,
+		},
+		{
+			Name:       "Malformed Kind",
+			Contents:   []byte(`{"dockerfiles": []}`),
+			ShouldFail: true,
+		},
+		{
+			Name: "Invalid Image",
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"composefiles": {
+		"docker-compose.yml": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			}
+		]
+	}
+}`),
+			ShouldFail: true,
+		},
+		{
+			Name: "Unknown Instruction",
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox",
+				"instruction": "add"
+			}
+		]
+	}
+}`),
+			ShouldFail: true,
+		},
+		{
+			Name: "Bakefile Image Without Dockerfile Or Context",
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"bakefiles": {
+		"docker-bake.hcl": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox",
+				"target": "app"
+			}
+		]
+	}
+}`),
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := lockfile.Read(bytes.NewReader(test.Contents))
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(test.Expected, got) {
+				t.Fatalf("expected %+v, got %+v", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Lockfile   *lockfile.Lockfile
+		Expected   []byte
+		ShouldFail bool
+	}{
+		{
+			Name: "Images",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "busybox", Tag: "latest", Digest: "busybox"},
+					},
+				},
+			},
+			Expected: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			}
+		]
+	}
+}`),
+		},
+		{
+			Name:     "No Images",
+			Lockfile: lockfile.New(),
+			Expected: []byte(`{
+	"schemaVersion": 4
+}`),
+		},
+		{
+			Name: "Invalid Image",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {{Tag: "latest", Digest: "busybox"}},
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var got bytes.Buffer
+
+			err := test.Lockfile.Write(&got)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(test.Expected, got.Bytes()) {
+				t.Fatalf(
+					"expected:\n%s\ngot:\n%s", test.Expected, got.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Lockfile   *lockfile.Lockfile
+		ShouldFail bool
+	}{
+		{
+			Name: "Valid",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Composefiles: map[string][]*lockfile.ComposefileImage{
+					"docker-compose.yml": {
+						{
+							Name:        "busybox",
+							Tag:         "latest",
+							Digest:      "busybox",
+							ServiceName: "svc",
+							Platforms: []*lockfile.PlatformDigest{
+								{
+									OS:           "linux",
+									Architecture: "amd64",
+									Digest:       "amd64",
+								},
+							},
+						},
+					},
+				},
+				Kustomizations: map[string][]*lockfile.KustomizationImage{
+					"kustomization.yaml": {
+						{
+							Name:          "busybox",
+							ManifestPath:  "deployment.yaml",
+							ContainerName: "busybox",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:       "Unmigrated Schema Version",
+			Lockfile:   &lockfile.Lockfile{},
+			ShouldFail: true,
+		},
+		{
+			Name: "Null Image",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+					"pod.yaml": {nil},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Empty Path",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Helmcharts: map[string][]*lockfile.HelmchartImage{
+					"": {{Name: "busybox"}},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Missing Service",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Composefiles: map[string][]*lockfile.ComposefileImage{
+					"docker-compose.yml": {{Name: "busybox"}},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Missing Manifest",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Kustomizations: map[string][]*lockfile.KustomizationImage{
+					"kustomization.yaml": {{Name: "busybox"}},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Malformed Platform",
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{
+							Name: "busybox",
+							Platforms: []*lockfile.PlatformDigest{
+								{OS: "linux", Digest: "amd64"},
+							},
+						},
+					},
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.Lockfile.Validate()
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestKindsAndPaths(t *testing.T) {
+	t.Parallel()
+
+	l := lockfile.New()
+	l.Kubernetesfiles["pod.yaml"] = nil
+	l.Dockerfiles["b/Dockerfile"] = nil
+	l.Dockerfiles["a/Dockerfile"] = nil
+
+	expectedKinds := []kind.Kind{kind.Dockerfile, kind.Kubernetesfile}
+	if got := l.Kinds(); !reflect.DeepEqual(expectedKinds, got) {
+		t.Fatalf("expected %v, got %v", expectedKinds, got)
+	}
+
+	expectedPaths := []string{"a/Dockerfile", "b/Dockerfile"}
+	if got := l.Paths(kind.Dockerfile); !reflect.DeepEqual(expectedPaths, got) {
+		t.Fatalf("expected %v, got %v", expectedPaths, got)
+	}
+
+	if got := l.Paths(kind.Helmchart); got != nil {
+		t.Fatalf("expected no paths, got %v", got)
+	}
+}
+
+func TestImages(t *testing.T) {
+	t.Parallel()
+
+	l := lockfile.New()
+	l.Kubernetesfiles["pod.yaml"] = []*lockfile.KubernetesfileImage{
+		{Name: "redis", Tag: "latest", ContainerName: "redis"},
+	}
+	l.Dockerfiles["b/Dockerfile"] = []*lockfile.DockerfileImage{
+		{Name: "busybox", Tag: "latest", Platform: "linux/arm64"},
+	}
+	l.Dockerfiles["a/Dockerfile"] = []*lockfile.DockerfileImage{
+		{Name: "golang", Tag: "1.16"},
+		{Name: "busybox", Tag: "latest"},
+	}
+
+	images := l.Images()
+
+	type location struct {
+		kind     kind.Kind
+		path     string
+		index    int
+		name     string
+		platform string
+	}
+
+	expected := []location{
+		{kind: kind.Dockerfile, path: "a/Dockerfile", index: 0, name: "golang"},
+		{kind: kind.Dockerfile, path: "a/Dockerfile", index: 1, name: "busybox"},
+		{
+			kind: kind.Dockerfile, path: "b/Dockerfile", index: 0,
+			name: "busybox", platform: "linux/arm64",
+		},
+		{kind: kind.Kubernetesfile, path: "pod.yaml", index: 0, name: "redis"},
+	}
+
+	got := make([]location, 0, len(images))
+	for _, image := range images {
+		got = append(got, location{
+			kind:     image.Kind,
+			path:     image.Path,
+			index:    image.Index,
+			name:     *image.Name,
+			platform: image.Platform,
+		})
+	}
+
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+
+	*images[0].Digest = "golang"
+
+	if l.Dockerfiles["a/Dockerfile"][0].Digest != "golang" {
+		t.Fatalf("expected setting the digest to change the lockfile")
+	}
+
+	if images[3].Image != l.Kubernetesfiles["pod.yaml"][0] {
+		t.Fatalf("expected the image to be the image in the lockfile")
+	}
+}
@@ -0,0 +1,193 @@
+package outdated
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+type bumper struct {
+	digestRequester update.IDigestRequester
+	maxConcurrency  int
+}
+
+// bumpedImage locates an image in a Lockfile.
+type bumpedImage struct {
+	kind  kind.Kind
+	path  string
+	index int
+}
+
+// bumpedSelector selects the images in a Lockfile that were bumped.
+type bumpedSelector struct {
+	images map[bumpedImage]struct{}
+}
+
+// NewBumper returns an IBumper after validating its fields. digestRequester
+// cannot be nil as it is responsible for querying registries for the
+// digests of the bumped tags.
+//
+// maxConcurrency limits the number of images whose digests are queried at
+// the same time. If maxConcurrency is 0, there is no limit.
+func NewBumper(
+	digestRequester update.IDigestRequester,
+	maxConcurrency int,
+) (IBumper, error) {
+	if digestRequester == nil || reflect.ValueOf(digestRequester).IsNil() {
+		return nil, errors.New("'digestRequester' cannot be nil")
+	}
+
+	if maxConcurrency < 0 {
+		return nil, errors.New("'maxConcurrency' cannot be negative")
+	}
+
+	return &bumper{
+		digestRequester: digestRequester,
+		maxConcurrency:  maxConcurrency,
+	}, nil
+}
+
+// BumpLockfile reads an existing Lockfile, changes the tag of each image in
+// the Report to its Suggestion at the Level, and writes the Lockfile with
+// the digests of the new tags. The other images are written unchanged.
+//
+// An error is returned if an image in the Report no longer matches the
+// Lockfile, such as if the Lockfile was regenerated after the Report.
+func (b *bumper) BumpLockfile(
+	lockfileReader io.Reader,
+	lockfileWriter io.Writer,
+	report *Report,
+	level Level,
+) error {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return errors.New("'lockfileReader' cannot be nil")
+	}
+
+	if lockfileWriter == nil || reflect.ValueOf(lockfileWriter).IsNil() {
+		return errors.New("'lockfileWriter' cannot be nil")
+	}
+
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if err := ValidateLevel(level); err != nil {
+		return err
+	}
+
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
+		return err
+	}
+
+	images := map[bumpedImage]*lockfileImage{}
+	for _, image := range lockfileImages(existingLockfile) {
+		images[bumpedImage{
+			kind: image.kind, path: image.path, index: image.index,
+		}] = image
+	}
+
+	selector := &bumpedSelector{images: map[bumpedImage]struct{}{}}
+
+	for _, reportImage := range report.Images {
+		suggestion := reportImage.Suggestion(level)
+		if suggestion == "" {
+			continue
+		}
+
+		location := bumpedImage{
+			kind:  reportImage.Kind,
+			path:  reportImage.Path,
+			index: reportImage.Index,
+		}
+
+		image, ok := images[location]
+		if !ok || image.name != reportImage.Name ||
+			*image.tag != reportImage.Tag {
+			return fmt.Errorf(
+				"image '%d' in '%s' of kind '%s', '%s:%s', is not in "+
+					"the lockfile",
+				reportImage.Index, reportImage.Path, reportImage.Kind,
+				reportImage.Name, reportImage.Tag,
+			)
+		}
+
+		*image.tag = suggestion
+		selector.images[location] = struct{}{}
+	}
+
+	var bumpedLockfile bytes.Buffer
+	if err := existingLockfile.Write(&bumpedLockfile); err != nil {
+		return err
+	}
+
+	refresher, err := refresh.NewRefresher(
+		b.digestRequester, selector, b.maxConcurrency,
+	)
+	if err != nil {
+		return err
+	}
+
+	return refresher.RefreshLockfile(&bumpedLockfile, lockfileWriter)
+}
+
+// SelectBumpedPaths removes the paths from the Lockfile that do not have an
+// image in the Report with a Suggestion at the Level, so that rewriting the
+// Lockfile leaves the files without bumped images unchanged.
+func SelectBumpedPaths(
+	bumpedLockfile *lockfile.Lockfile,
+	report *Report,
+	level Level,
+) error {
+	if bumpedLockfile == nil {
+		return errors.New("'bumpedLockfile' cannot be nil")
+	}
+
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if err := ValidateLevel(level); err != nil {
+		return err
+	}
+
+	bumpedPaths := map[kind.Kind]map[string]struct{}{}
+
+	for _, reportImage := range report.Images {
+		if reportImage.Suggestion(level) == "" {
+			continue
+		}
+
+		if bumpedPaths[reportImage.Kind] == nil {
+			bumpedPaths[reportImage.Kind] = map[string]struct{}{}
+		}
+
+		bumpedPaths[reportImage.Kind][reportImage.Path] = struct{}{}
+	}
+
+	for _, k := range bumpedLockfile.Kinds() {
+		for _, path := range bumpedLockfile.Paths(k) {
+			if _, ok := bumpedPaths[k][path]; !ok {
+				bumpedLockfile.DeletePath(k, path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SelectsImage reports whether the image was bumped.
+func (b *bumpedSelector) SelectsImage(image *refresh.Image) bool {
+	_, ok := b.images[bumpedImage{
+		kind: image.Kind, path: image.Path, index: image.Index,
+	}]
+
+	return ok
+}
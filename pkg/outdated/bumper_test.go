@@ -0,0 +1,346 @@
+package outdated_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/outdated"
+
+	cmd_rewrite "github.com/safe-waters/docker-lock/cmd/rewrite"
+)
+
+type nameTagDigestRequester struct{}
+
+func (n *nameTagDigestRequester) Digest(
+	name string,
+	tag string,
+) (string, error) {
+	return fmt.Sprintf("%s-%s", name, tag), nil
+}
+
+func TestBumper(t *testing.T) {
+	t.Parallel()
+
+	existingLockfile := func() *lockfile.Lockfile {
+		return &lockfile.Lockfile{
+			SchemaVersion: lockfile.SchemaVersion,
+			Dockerfiles: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{Name: "golang", Tag: "1.16", Digest: "golang"},
+					{Name: "golang", Tag: "1.16", Digest: "golang"},
+				},
+			},
+			Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+				"pod.yml": {
+					{Name: "redis", Tag: "6.0", Digest: "redis"},
+				},
+			},
+		}
+	}
+
+	report := &outdated.Report{
+		Images: []*outdated.Image{
+			{
+				Kind:   kind.Dockerfile,
+				Path:   "Dockerfile",
+				Index:  1,
+				Name:   "golang",
+				Tag:    "1.16",
+				Digest: "golang",
+				Minor:  "1.18",
+				Major:  "2.0",
+			},
+			{
+				Kind:   kind.Kubernetesfile,
+				Path:   "pod.yml",
+				Index:  0,
+				Name:   "redis",
+				Tag:    "6.0",
+				Digest: "redis",
+				Patch:  "6.0.16",
+				Major:  "7.0",
+			},
+		},
+	}
+
+	tests := []struct {
+		Name        string
+		Level       outdated.Level
+		Lockfile    *lockfile.Lockfile
+		Expected    *lockfile.Lockfile
+		ShouldError bool
+	}{
+		{
+			Name:     "Patch",
+			Level:    outdated.Patch,
+			Lockfile: existingLockfile(),
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "golang", Tag: "1.16", Digest: "golang"},
+						{Name: "golang", Tag: "1.16", Digest: "golang"},
+					},
+				},
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+					"pod.yml": {
+						{Name: "redis", Tag: "6.0.16", Digest: "redis-6.0.16"},
+					},
+				},
+			},
+		},
+		{
+			Name:     "Minor",
+			Level:    outdated.Minor,
+			Lockfile: existingLockfile(),
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "golang", Tag: "1.16", Digest: "golang"},
+						{Name: "golang", Tag: "1.18", Digest: "golang-1.18"},
+					},
+				},
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+					"pod.yml": {
+						{Name: "redis", Tag: "6.0.16", Digest: "redis-6.0.16"},
+					},
+				},
+			},
+		},
+		{
+			Name:     "Major",
+			Level:    outdated.Major,
+			Lockfile: existingLockfile(),
+			Expected: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					"Dockerfile": {
+						{Name: "golang", Tag: "1.16", Digest: "golang"},
+						{Name: "golang", Tag: "2.0", Digest: "golang-2.0"},
+					},
+				},
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+					"pod.yml": {
+						{Name: "redis", Tag: "7.0", Digest: "redis-7.0"},
+					},
+				},
+			},
+		},
+		{
+			Name:  "Lockfile Changed",
+			Level: outdated.Major,
+			Lockfile: &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+					"pod.yml": {
+						{Name: "redis", Tag: "6.2", Digest: "redis"},
+					},
+				},
+			},
+			ShouldError: true,
+		},
+		{
+			Name:        "Invalid Level",
+			Level:       "latest",
+			Lockfile:    existingLockfile(),
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			bumper, err := outdated.NewBumper(&nameTagDigestRequester{}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := test.Lockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotByt bytes.Buffer
+
+			err = bumper.BumpLockfile(
+				&lockfileByt, &gotByt, report, test.Level,
+			)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != gotByt.String() {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt.String())
+			}
+		})
+	}
+}
+
+func TestSelectBumpedPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Level    outdated.Level
+		Expected map[string]string
+	}{
+		{
+			Name:  "Patch",
+			Level: outdated.Patch,
+			Expected: map[string]string{
+				"Dockerfile-golang": "FROM golang:1.16.5@sha256:golang-1.16.5\n",
+				"Dockerfile-redis":  "FROM redis:6.0\n",
+			},
+		},
+		{
+			Name:  "Major",
+			Level: outdated.Major,
+			Expected: map[string]string{
+				"Dockerfile-golang": "FROM golang:1.16.5@sha256:golang-1.16.5\n",
+				"Dockerfile-redis":  "FROM redis:7.0@sha256:redis-7.0\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDirInCurrentDir(t)
+			defer os.RemoveAll(tempDir)
+
+			var (
+				golangPath = filepath.Join(tempDir, "Dockerfile-golang")
+				redisPath  = filepath.Join(tempDir, "Dockerfile-redis")
+			)
+
+			testutils.WriteFilesToTempDir(
+				t, tempDir, []string{"Dockerfile-golang", "Dockerfile-redis"},
+				[][]byte{[]byte("FROM golang:1.16\n"), []byte("FROM redis:6.0\n")},
+			)
+
+			existingLockfile := &lockfile.Lockfile{
+				SchemaVersion: lockfile.SchemaVersion,
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
+					golangPath: {
+						{Name: "golang", Tag: "1.16", Digest: "golang"},
+					},
+					redisPath: {
+						{Name: "redis", Tag: "6.0", Digest: "redis"},
+					},
+				},
+			}
+
+			report := &outdated.Report{
+				Images: []*outdated.Image{
+					{
+						Kind:   kind.Dockerfile,
+						Path:   golangPath,
+						Name:   "golang",
+						Tag:    "1.16",
+						Digest: "golang",
+						Patch:  "1.16.5",
+					},
+					{
+						Kind:   kind.Dockerfile,
+						Path:   redisPath,
+						Name:   "redis",
+						Tag:    "6.0",
+						Digest: "redis",
+						Major:  "7.0",
+					},
+				},
+			}
+
+			bumper, err := outdated.NewBumper(&nameTagDigestRequester{}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt, bumpedByt bytes.Buffer
+			if err := existingLockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := bumper.BumpLockfile(
+				&lockfileByt, &bumpedByt, report, test.Level,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			bumpedLockfile, err := lockfile.Read(&bumpedByt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := outdated.SelectBumpedPaths(
+				bumpedLockfile, report, test.Level,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			var bumpedPathsByt bytes.Buffer
+			if err := bumpedLockfile.Write(&bumpedPathsByt); err != nil {
+				t.Fatal(err)
+			}
+
+			flags, err := cmd_rewrite.NewFlags(
+				filepath.Base("bumper_test.go"), tempDir, false, "", nil, nil,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rewriter, err := cmd_rewrite.SetupRewriter(flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := rewriter.RewriteLockfile(
+				&bumpedPathsByt, tempDir,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, expected := range test.Expected {
+				got, err := ioutil.ReadFile(filepath.Join(tempDir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if expected != string(got) {
+					t.Fatalf(
+						"expected '%s' to be %q, got %q", name, expected, got,
+					)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package outdated
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/internal/semver"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+type checker struct {
+	tagLister      update.ITagLister
+	selector       refresh.ISelector
+	maxConcurrency int
+}
+
+// NewChecker returns an IChecker after validating its fields. tagLister
+// cannot be nil as it is responsible for listing the tags of images.
+// selector cannot be nil, and selects the images that are checked.
+//
+// maxConcurrency limits the number of repositories whose tags are listed at
+// the same time. If maxConcurrency is 0, there is no limit.
+func NewChecker(
+	tagLister update.ITagLister,
+	selector refresh.ISelector,
+	maxConcurrency int,
+) (IChecker, error) {
+	if tagLister == nil || reflect.ValueOf(tagLister).IsNil() {
+		return nil, errors.New("'tagLister' cannot be nil")
+	}
+
+	if selector == nil || reflect.ValueOf(selector).IsNil() {
+		return nil, errors.New("'selector' cannot be nil")
+	}
+
+	if maxConcurrency < 0 {
+		return nil, errors.New("'maxConcurrency' cannot be negative")
+	}
+
+	return &checker{
+		tagLister:      tagLister,
+		selector:       selector,
+		maxConcurrency: maxConcurrency,
+	}, nil
+}
+
+// CheckLockfile reads an existing Lockfile, lists the tags of the selected
+// images whose tags are semantic versions, and reports the images that have
+// newer tags. Images pinned only by digest are not checked.
+func (c *checker) CheckLockfile(lockfileReader io.Reader) (*Report, error) {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return nil, errors.New("'lockfileReader' cannot be nil")
+	}
+
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		images []*lockfileImage
+		names  = map[string]struct{}{}
+	)
+
+	for _, image := range lockfileImages(existingLockfile) {
+		if !c.selector.SelectsImage(&refresh.Image{
+			Kind:  image.kind,
+			Path:  image.path,
+			Index: image.index,
+			Name:  image.name,
+		}) {
+			continue
+		}
+
+		if _, err := semver.Parse(*image.tag); err != nil {
+			continue
+		}
+
+		images = append(images, image)
+		names[image.name] = struct{}{}
+	}
+
+	tags, err := c.listTags(names)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Images: []*Image{}}
+
+	for _, image := range images {
+		patch, minor, major := suggestions(*image.tag, tags[image.name])
+		if patch == "" && minor == "" && major == "" {
+			continue
+		}
+
+		report.Images = append(report.Images, &Image{
+			Kind:   image.kind,
+			Path:   image.path,
+			Index:  image.index,
+			Name:   image.name,
+			Tag:    *image.tag,
+			Digest: image.digest,
+			Patch:  patch,
+			Minor:  minor,
+			Major:  major,
+		})
+	}
+
+	return report, nil
+}
+
+// listTags lists the tags of each name, at most maxConcurrency at a time.
+// If listing any tags fails, the first error is returned.
+func (c *checker) listTags(
+	names map[string]struct{},
+) (map[string][]string, error) {
+	var (
+		waitGroup sync.WaitGroup
+		mutex     sync.Mutex
+		firstErr  error
+		tags      = map[string][]string{}
+		semaphore chan struct{}
+	)
+
+	if c.maxConcurrency > 0 {
+		semaphore = make(chan struct{}, c.maxConcurrency)
+	}
+
+	for name := range names {
+		name := name
+
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			if semaphore != nil {
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+			}
+
+			nameTags, err := c.tagLister.ListTags(name)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				return
+			}
+
+			tags[name] = nameTags
+		}()
+	}
+
+	waitGroup.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,196 @@
+package outdated_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/outdated"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+type fixedTagLister struct {
+	tags map[string][]string
+}
+
+func (f *fixedTagLister) ListTags(name string) ([]string, error) {
+	tags, ok := f.tags[name]
+	if !ok {
+		return nil, errors.New("repository not found")
+	}
+
+	return tags, nil
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	tagLister := &fixedTagLister{
+		tags: map[string][]string{
+			"golang": {
+				"1.15", "1.16", "1.16.3", "1.17", "1.17-alpine", "1.18",
+				"2.0", "latest",
+			},
+			"python": {
+				"3.8-slim", "3.8.9-slim", "3.9-slim", "3.10-alpine",
+				"v3.11-slim",
+			},
+			"redis": {"6.0", "6.2", "7.0"},
+		},
+	}
+
+	existingLockfile := &lockfile.Lockfile{
+		SchemaVersion: lockfile.SchemaVersion,
+		Dockerfiles: map[string][]*lockfile.DockerfileImage{
+			"Dockerfile": {
+				{Name: "golang", Tag: "1.16", Digest: "golang"},
+				{Name: "busybox", Tag: "latest", Digest: "busybox"},
+				{Name: "golang", Tag: "2.0", Digest: "golang"},
+			},
+		},
+		Composefiles: map[string][]*lockfile.ComposefileImage{
+			"docker-compose.yml": {
+				{
+					Name: "python", Tag: "3.8-slim", Digest: "python",
+					ServiceName: "web",
+				},
+			},
+		},
+		Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+			"pod.yml": {
+				{Name: "redis", Tag: "6.0", Digest: "redis"},
+			},
+		},
+	}
+
+	tests := []struct {
+		Name        string
+		Names       []string
+		Paths       []string
+		TagLister   *fixedTagLister
+		Expected    *outdated.Report
+		ShouldError bool
+	}{
+		{
+			Name:      "All Images",
+			TagLister: tagLister,
+			Expected: &outdated.Report{
+				Images: []*outdated.Image{
+					{
+						Kind:   kind.Composefile,
+						Path:   "docker-compose.yml",
+						Index:  0,
+						Name:   "python",
+						Tag:    "3.8-slim",
+						Digest: "python",
+						Minor:  "3.9-slim",
+					},
+					{
+						Kind:   kind.Dockerfile,
+						Path:   "Dockerfile",
+						Index:  0,
+						Name:   "golang",
+						Tag:    "1.16",
+						Digest: "golang",
+						Minor:  "1.18",
+						Major:  "2.0",
+					},
+					{
+						Kind:   kind.Kubernetesfile,
+						Path:   "pod.yml",
+						Index:  0,
+						Name:   "redis",
+						Tag:    "6.0",
+						Digest: "redis",
+						Minor:  "6.2",
+						Major:  "7.0",
+					},
+				},
+			},
+		},
+		{
+			Name:      "Selected Images",
+			Names:     []string{"redis"},
+			TagLister: tagLister,
+			Expected: &outdated.Report{
+				Images: []*outdated.Image{
+					{
+						Kind:   kind.Kubernetesfile,
+						Path:   "pod.yml",
+						Index:  0,
+						Name:   "redis",
+						Tag:    "6.0",
+						Digest: "redis",
+						Minor:  "6.2",
+						Major:  "7.0",
+					},
+				},
+			},
+		},
+		{
+			Name:      "Up To Date",
+			Paths:     []string{"Dockerfile"},
+			Names:     []string{"busybox"},
+			TagLister: tagLister,
+			Expected:  &outdated.Report{Images: []*outdated.Image{}},
+		},
+		{
+			Name:        "Missing Repository",
+			TagLister:   &fixedTagLister{},
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := refresh.NewSelector(test.Names, nil, test.Paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checker, err := outdated.NewChecker(test.TagLister, selector, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := existingLockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := checker.CheckLockfile(&lockfileByt)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != string(gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package outdated
+
+import (
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+// lockfileImage is an image of any kind in a Lockfile. tag points to the
+// image's field, so that bumping it updates the Lockfile.
+type lockfileImage struct {
+	kind   kind.Kind
+	path   string
+	index  int
+	name   string
+	tag    *string
+	digest string
+}
+
+// lockfileImages returns the images of every kind in the Lockfile, sorted by
+// kind, path, and index.
+func lockfileImages(l *lockfile.Lockfile) []*lockfileImage {
+	images := l.Images()
+	allImages := make([]*lockfileImage, 0, len(images))
+
+	for _, image := range images {
+		allImages = append(allImages, &lockfileImage{
+			kind:   image.Kind,
+			path:   image.Path,
+			index:  image.Index,
+			name:   *image.Name,
+			tag:    image.Tag,
+			digest: *image.Digest,
+		})
+	}
+
+	return allImages
+}
@@ -0,0 +1,127 @@
+package outdated
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+type textReportWriter struct{}
+
+type jsonReportWriter struct{}
+
+// NewTextReportWriter returns an IReportWriter that writes a human readable
+// list of the images with newer tags, grouped by kind and path, such as:
+//
+//	dockerfiles
+//	  Dockerfile
+//	    image 0 golang:1.16@sha256:...: patch 1.16.15, minor 1.17
+func NewTextReportWriter() IReportWriter {
+	return &textReportWriter{}
+}
+
+// NewJSONReportWriter returns an IReportWriter that writes the Report as
+// indented JSON.
+func NewJSONReportWriter() IReportWriter {
+	return &jsonReportWriter{}
+}
+
+// WriteReport writes the Report as text.
+func (t *textReportWriter) WriteReport(
+	report *Report,
+	writer io.Writer,
+) error {
+	if err := ensureReportWriterArgsNotNil(report, writer); err != nil {
+		return err
+	}
+
+	if len(report.Images) == 0 {
+		_, err := fmt.Fprintln(writer, "all images are up-to-date")
+		return err
+	}
+
+	var (
+		builder     strings.Builder
+		currentKind kind.Kind
+		currentPath string
+	)
+
+	for i, image := range report.Images {
+		if i == 0 || image.Kind != currentKind {
+			currentKind, currentPath = image.Kind, ""
+			fmt.Fprintln(&builder, image.Kind)
+		}
+
+		if image.Path != currentPath {
+			currentPath = image.Path
+			fmt.Fprintf(&builder, "  %s\n", image.Path)
+		}
+
+		var bumps []string
+
+		for _, bump := range []struct {
+			level Level
+			tag   string
+		}{
+			{level: Patch, tag: image.Patch},
+			{level: Minor, tag: image.Minor},
+			{level: Major, tag: image.Major},
+		} {
+			if bump.tag != "" {
+				bumps = append(
+					bumps, fmt.Sprintf("%s %s", bump.level, bump.tag),
+				)
+			}
+		}
+
+		reference := fmt.Sprintf("%s:%s", image.Name, image.Tag)
+		if image.Digest != "" {
+			reference = fmt.Sprintf("%s@sha256:%s", reference, image.Digest)
+		}
+
+		fmt.Fprintf(
+			&builder, "    image %d %s: %s\n",
+			image.Index, reference, strings.Join(bumps, ", "),
+		)
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+
+	return err
+}
+
+// WriteReport writes the Report as indented JSON.
+func (j *jsonReportWriter) WriteReport(
+	report *Report,
+	writer io.Writer,
+) error {
+	if err := ensureReportWriterArgsNotNil(report, writer); err != nil {
+		return err
+	}
+
+	byt, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(writer, string(byt))
+
+	return err
+}
+
+func ensureReportWriterArgsNotNil(report *Report, writer io.Writer) error {
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	return nil
+}
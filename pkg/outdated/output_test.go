@@ -0,0 +1,87 @@
+package outdated_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/outdated"
+)
+
+func TestTextReportWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Report   *outdated.Report
+		Expected string
+	}{
+		{
+			Name: "Outdated Images",
+			Report: &outdated.Report{
+				Images: []*outdated.Image{
+					{
+						Kind:   kind.Dockerfile,
+						Path:   "Dockerfile",
+						Index:  0,
+						Name:   "golang",
+						Tag:    "1.16",
+						Digest: "golang",
+						Minor:  "1.18",
+						Major:  "2.0",
+					},
+					{
+						Kind:  kind.Dockerfile,
+						Path:  "Dockerfile",
+						Index: 2,
+						Name:  "redis",
+						Tag:   "6.0",
+						Patch: "6.0.16",
+					},
+					{
+						Kind:   kind.Kubernetesfile,
+						Path:   "pod.yml",
+						Index:  0,
+						Name:   "redis",
+						Tag:    "6.0",
+						Digest: "redis",
+						Major:  "7.0",
+					},
+				},
+			},
+			Expected: `dockerfiles
+  Dockerfile
+    image 0 golang:1.16@sha256:golang: minor 1.18, major 2.0
+    image 2 redis:6.0: patch 6.0.16
+kubernetesfiles
+  pod.yml
+    image 0 redis:6.0@sha256:redis: major 7.0
+`,
+		},
+		{
+			Name:     "Up To Date",
+			Report:   &outdated.Report{},
+			Expected: "all images are up-to-date\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var got bytes.Buffer
+
+			if err := outdated.NewTextReportWriter().WriteReport(
+				test.Report, &got,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.Expected != got.String() {
+				t.Fatalf("expected %s, got %s", test.Expected, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package outdated
+
+import (
+	"fmt"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// Level is how far an image may be bumped, from its current tag to a newer
+// tag.
+type Level string
+
+// Levels of bumps. Each Level includes the Levels before it, so a Minor bump
+// is to the newest minor or patch version.
+const (
+	Patch Level = "patch"
+	Minor Level = "minor"
+	Major Level = "major"
+)
+
+// Report is the images in a Lockfile that have newer tags, sorted by kind,
+// path, and index.
+type Report struct {
+	Images []*Image `json:"images"`
+}
+
+// Image is an image in a Lockfile, located by its kind, path, and index, and
+// the newest tags of its repository that bump its tag's patch, minor, or
+// major version. A tag is empty if there is no newer tag at that Level.
+type Image struct {
+	Kind   kind.Kind `json:"kind"`
+	Path   string    `json:"path"`
+	Index  int       `json:"index"`
+	Name   string    `json:"name"`
+	Tag    string    `json:"tag"`
+	Digest string    `json:"digest"`
+	Patch  string    `json:"patch,omitempty"`
+	Minor  string    `json:"minor,omitempty"`
+	Major  string    `json:"major,omitempty"`
+}
+
+// ValidateLevel returns an error if level is not Patch, Minor, or Major.
+func ValidateLevel(level Level) error {
+	switch level {
+	case Patch, Minor, Major:
+		return nil
+	default:
+		return fmt.Errorf(
+			"'%s' level must be one of '%s', '%s', or '%s'",
+			level, Patch, Minor, Major,
+		)
+	}
+}
+
+// Suggestion returns the newest tag that the image can be bumped to at the
+// Level, or an empty string if there is none.
+func (i *Image) Suggestion(level Level) string {
+	var candidates []string
+
+	switch level {
+	case Patch:
+		candidates = []string{i.Patch}
+	case Minor:
+		candidates = []string{i.Minor, i.Patch}
+	case Major:
+		candidates = []string{i.Major, i.Minor, i.Patch}
+	}
+
+	for _, candidate := range candidates {
+		if candidate != "" {
+			return candidate
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,62 @@
+package outdated
+
+import (
+	"strings"
+
+	"github.com/safe-waters/docker-lock/internal/semver"
+)
+
+// suggestions returns the newest tags that bump the patch, minor, and major
+// version of tag. Only tags in the same style as tag are considered: tags
+// must have the same number of components, the same suffix, such as
+// "alpine", and the same "v" prefix, so that "3.9-alpine" is bumped to
+// "3.10-alpine", but not to "3.10" or "3.10.1-alpine".
+//
+// If tag is not a semantic version, there are no suggestions.
+func suggestions(
+	tag string,
+	tags []string,
+) (patch string, minor string, major string) {
+	current, err := semver.Parse(tag)
+	if err != nil {
+		return "", "", ""
+	}
+
+	var patchVersion, minorVersion, majorVersion *semver.Version
+
+	newest := func(
+		candidate string,
+		version *semver.Version,
+		newestTag *string,
+		newestVersion **semver.Version,
+	) {
+		if *newestVersion == nil || version.Compare(*newestVersion) > 0 {
+			*newestTag, *newestVersion = candidate, version
+		}
+	}
+
+	for _, candidate := range tags {
+		if strings.HasPrefix(candidate, "v") != strings.HasPrefix(tag, "v") {
+			continue
+		}
+
+		version, err := semver.Parse(candidate)
+		if err != nil ||
+			version.Precision != current.Precision ||
+			version.Suffix != current.Suffix ||
+			version.Compare(current) <= 0 {
+			continue
+		}
+
+		switch {
+		case version.Major != current.Major:
+			newest(candidate, version, &major, &majorVersion)
+		case version.Minor != current.Minor:
+			newest(candidate, version, &minor, &minorVersion)
+		default:
+			newest(candidate, version, &patch, &patchVersion)
+		}
+	}
+
+	return patch, minor, major
+}
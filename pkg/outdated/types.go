@@ -0,0 +1,29 @@
+// Package outdated provides functionality to find newer tags of the images
+// in a Lockfile and to bump the images to them.
+package outdated
+
+import "io"
+
+// IChecker provides an interface for Checkers, which are responsible for
+// listing the tags of the images in a Lockfile and reporting newer ones.
+type IChecker interface {
+	CheckLockfile(lockfileReader io.Reader) (*Report, error)
+}
+
+// IBumper provides an interface for Bumpers, which are responsible for
+// changing the tags of the images in a Lockfile to the newer tags in a
+// Report, and updating their digests.
+type IBumper interface {
+	BumpLockfile(
+		lockfileReader io.Reader,
+		lockfileWriter io.Writer,
+		report *Report,
+		level Level,
+	) error
+}
+
+// IReportWriter provides an interface for ReportWriters, which are
+// responsible for writing Reports in a format.
+type IReportWriter interface {
+	WriteReport(report *Report, writer io.Writer) error
+}
@@ -0,0 +1,501 @@
+package refresh
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type refresher struct {
+	imageDigestUpdater         update.IImageDigestUpdater
+	platformImageDigestUpdater update.IImageDigestUpdater
+	createdRequester           update.ICreatedRequester
+	selector                   ISelector
+	maxConcurrency             int
+}
+
+// target is a selected image in the Lockfile. digest, created, and platforms
+// point to the fields of image, so that refreshing them updates the
+// Lockfile. encoded is the JSON encoding of image before it was refreshed.
+type target struct {
+	*Image
+	tag       string
+	platform  string
+	digest    *string
+	created   **time.Time
+	platforms *[]*lockfile.PlatformDigest
+	image     interface{}
+	encoded   []byte
+}
+
+// location is the kind, path, and index of an image in a Lockfile.
+type location struct {
+	kind  kind.Kind
+	path  string
+	index int
+}
+
+// span is the start and end offsets of an image in the bytes of a Lockfile.
+type span struct {
+	start int64
+	end   int64
+}
+
+// targetGroup is the fields that targets refreshed by the same
+// IImageDigestUpdater have recorded.
+type targetGroup struct {
+	platforms bool
+	created   bool
+}
+
+// NewRefresher returns an IRefresher after validating its fields.
+// digestRequester cannot be nil as it is responsible for querying registries
+// for digests. selector cannot be nil.
+//
+// Images that recorded the digest of each platform are refreshed with the
+// digests of each platform, which requires an IPlatformDigestRequester.
+// Images that recorded the time they were created are refreshed with the
+// time that the image with the new digest was created, which requires an
+// update.ICreatedRequester.
+//
+// maxConcurrency limits the number of images whose digests are refreshed at
+// the same time. If maxConcurrency is 0, there is no limit.
+func NewRefresher(
+	digestRequester update.IDigestRequester,
+	selector ISelector,
+	maxConcurrency int,
+) (IRefresher, error) {
+	if digestRequester == nil || reflect.ValueOf(digestRequester).IsNil() {
+		return nil, errors.New("'digestRequester' cannot be nil")
+	}
+
+	if selector == nil || reflect.ValueOf(selector).IsNil() {
+		return nil, errors.New("'selector' cannot be nil")
+	}
+
+	imageDigestUpdater, err := update.NewImageDigestUpdater(
+		digestRequester, false, true, false, maxConcurrency,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var platformImageDigestUpdater update.IImageDigestUpdater
+
+	if _, ok := digestRequester.(update.IPlatformDigestRequester); ok {
+		platformImageDigestUpdater, err = update.NewImageDigestUpdater(
+			digestRequester, false, true, true, maxConcurrency,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	createdRequester, _ := digestRequester.(update.ICreatedRequester)
+
+	return &refresher{
+		imageDigestUpdater:         imageDigestUpdater,
+		platformImageDigestUpdater: platformImageDigestUpdater,
+		createdRequester:           createdRequester,
+		selector:                   selector,
+		maxConcurrency:             maxConcurrency,
+	}, nil
+}
+
+// RefreshLockfile reads an existing Lockfile, queries registries for the
+// digests of the selected images, and writes the Lockfile with them. Only
+// the selected images whose digests changed are encoded again. The rest of
+// the Lockfile, including its schemaVersion, is written byte for byte, so
+// older Lockfiles are not migrated.
+func (r *refresher) RefreshLockfile(
+	lockfileReader io.Reader,
+	lockfileWriter io.Writer,
+) error {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return errors.New("'lockfileReader' cannot be nil")
+	}
+
+	if lockfileWriter == nil || reflect.ValueOf(lockfileWriter).IsNil() {
+		return errors.New("'lockfileWriter' cannot be nil")
+	}
+
+	lockfileByt, err := ioutil.ReadAll(lockfileReader)
+	if err != nil {
+		return err
+	}
+
+	existingLockfile, err := lockfile.Read(bytes.NewReader(lockfileByt))
+	if err != nil {
+		return err
+	}
+
+	targets, err := r.targets(existingLockfile)
+	if err != nil {
+		return err
+	}
+
+	groupTargets := map[targetGroup][]*target{}
+
+	for _, target := range targets {
+		group := targetGroup{
+			platforms: len(*target.platforms) != 0,
+			created:   *target.created != nil,
+		}
+		groupTargets[group] = append(groupTargets[group], target)
+	}
+
+	for _, group := range []targetGroup{
+		{},
+		{platforms: true},
+		{created: true},
+		{platforms: true, created: true},
+	} {
+		targets := groupTargets[group]
+		if len(targets) == 0 {
+			continue
+		}
+
+		imageDigestUpdater, err := r.groupImageDigestUpdater(group)
+		if err != nil {
+			return err
+		}
+
+		if err := refreshTargets(imageDigestUpdater, targets); err != nil {
+			return err
+		}
+	}
+
+	refreshedByt, err := replaceImages(lockfileByt, existingLockfile, targets)
+	if err != nil {
+		return err
+	}
+
+	_, err = lockfileWriter.Write(refreshedByt)
+
+	return err
+}
+
+// groupImageDigestUpdater returns the IImageDigestUpdater that refreshes the
+// fields recorded by targets in the group.
+func (r *refresher) groupImageDigestUpdater(
+	group targetGroup,
+) (update.IImageDigestUpdater, error) {
+	imageDigestUpdater := r.imageDigestUpdater
+
+	if group.platforms {
+		if r.platformImageDigestUpdater == nil {
+			return nil, errors.New(
+				"'digestRequester' cannot query platform digests",
+			)
+		}
+
+		imageDigestUpdater = r.platformImageDigestUpdater
+	}
+
+	if !group.created {
+		return imageDigestUpdater, nil
+	}
+
+	if r.createdRequester == nil {
+		return nil, errors.New(
+			"'digestRequester' cannot query creation times",
+		)
+	}
+
+	return update.NewImageCreatedUpdater(
+		imageDigestUpdater, r.createdRequester, r.maxConcurrency,
+	)
+}
+
+// targets returns the selected images of every kind in the Lockfile.
+func (r *refresher) targets(l *lockfile.Lockfile) ([]*target, error) {
+	var targets []*target
+
+	for _, image := range l.Images() {
+		refreshImage := &Image{
+			Kind:  image.Kind,
+			Path:  image.Path,
+			Index: image.Index,
+			Name:  *image.Name,
+		}
+
+		if !r.selector.SelectsImage(refreshImage) {
+			continue
+		}
+
+		encoded, err := json.Marshal(image.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, &target{
+			Image:     refreshImage,
+			tag:       *image.Tag,
+			platform:  image.Platform,
+			digest:    image.Digest,
+			created:   image.Created,
+			platforms: image.Platforms,
+			image:     image.Image,
+			encoded:   encoded,
+		})
+	}
+
+	return targets, nil
+}
+
+func (t *target) location() location {
+	return location{kind: t.Kind, path: t.Path, index: t.Index}
+}
+
+// replaceImages returns the bytes of the Lockfile with the targets whose
+// encoding changed encoded again, in the indentation of the images they
+// replace. The other bytes are unchanged.
+func replaceImages(
+	lockfileByt []byte,
+	l *lockfile.Lockfile,
+	targets []*target,
+) ([]byte, error) {
+	spans, err := imageSpans(lockfileByt, l)
+	if err != nil {
+		return nil, err
+	}
+
+	var replacedTargets []*target
+
+	for _, target := range targets {
+		if _, ok := spans[target.location()]; !ok {
+			return nil, fmt.Errorf(
+				"image '%d' in '%s' of kind '%s' not found in the lockfile",
+				target.Index, target.Path, target.Kind,
+			)
+		}
+
+		encoded, err := json.Marshal(target.image)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(encoded, target.encoded) {
+			replacedTargets = append(replacedTargets, target)
+		}
+	}
+
+	sort.Slice(replacedTargets, func(i, j int) bool {
+		return spans[replacedTargets[i].location()].start <
+			spans[replacedTargets[j].location()].start
+	})
+
+	var (
+		replacedByt bytes.Buffer
+		offset      int64
+	)
+
+	for _, target := range replacedTargets {
+		imageSpan := spans[target.location()]
+
+		encoded, err := encodeImage(target.image, lockfileByt, imageSpan)
+		if err != nil {
+			return nil, err
+		}
+
+		replacedByt.Write(lockfileByt[offset:imageSpan.start])
+		replacedByt.Write(encoded)
+
+		offset = imageSpan.end
+	}
+
+	replacedByt.Write(lockfileByt[offset:])
+
+	return replacedByt.Bytes(), nil
+}
+
+// imageSpans returns the span of each image in the bytes of the Lockfile.
+func imageSpans(
+	lockfileByt []byte,
+	l *lockfile.Lockfile,
+) (map[location]span, error) {
+	kinds := map[kind.Kind]struct{}{}
+	for _, k := range l.Kinds() {
+		kinds[k] = struct{}{}
+	}
+
+	var (
+		decoder = json.NewDecoder(bytes.NewReader(lockfileByt))
+		spans   = map[location]span{}
+	)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		k := kind.Kind(fmt.Sprint(key))
+
+		if _, ok := kinds[k]; !ok {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err := expectDelim(decoder, '{'); err != nil {
+			return nil, err
+		}
+
+		for decoder.More() {
+			path, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := expectDelim(decoder, '['); err != nil {
+				return nil, err
+			}
+
+			for index := 0; decoder.More(); index++ {
+				var image json.RawMessage
+				if err := decoder.Decode(&image); err != nil {
+					return nil, err
+				}
+
+				end := decoder.InputOffset()
+
+				spans[location{kind: k, path: fmt.Sprint(path), index: index}] =
+					span{start: end - int64(len(image)), end: end}
+			}
+
+			if err := expectDelim(decoder, ']'); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := expectDelim(decoder, '}'); err != nil {
+			return nil, err
+		}
+	}
+
+	return spans, nil
+}
+
+// encodeImage encodes the image with the indentation of the image in the
+// span of the Lockfile's bytes. If that image is on one line, the image is
+// encoded on one line.
+func encodeImage(
+	image interface{},
+	lockfileByt []byte,
+	imageSpan span,
+) ([]byte, error) {
+	existingImage := lockfileByt[imageSpan.start:imageSpan.end]
+
+	newline := bytes.IndexByte(existingImage, '\n')
+	if newline == -1 {
+		return json.Marshal(image)
+	}
+
+	lineStart := bytes.LastIndexByte(lockfileByt[:imageSpan.start], '\n') + 1
+
+	var (
+		prefix = leadingWhitespace(lockfileByt[lineStart:imageSpan.start])
+		indent = strings.TrimPrefix(
+			leadingWhitespace(existingImage[newline+1:]), prefix,
+		)
+	)
+
+	return json.MarshalIndent(image, prefix, indent)
+}
+
+func leadingWhitespace(byt []byte) string {
+	return string(byt[:len(byt)-len(bytes.TrimLeft(byt, " \t"))])
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("lockfile has '%v' instead of '%v'", token, delim)
+	}
+
+	return nil
+}
+
+// refreshTargets queries the digests of the targets with the
+// imageDigestUpdater, and sets them on the targets. Images without a tag
+// keep their digest, as there is nothing to query.
+func refreshTargets(
+	imageDigestUpdater update.IImageDigestUpdater,
+	targets []*target,
+) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var (
+		images = make(chan parse.IImage)
+		done   = make(chan struct{})
+	)
+
+	defer close(done)
+
+	go func() {
+		defer close(images)
+
+		for i, target := range targets {
+			metadata := map[string]interface{}{"path": target.Path, "index": i}
+			if target.platform != "" {
+				metadata["platform"] = target.platform
+			}
+
+			select {
+			case <-done:
+				return
+			case images <- parse.NewImage(
+				target.Kind, target.Name, target.tag, *target.digest,
+				metadata, nil,
+			):
+			}
+		}
+	}()
+
+	for image := range imageDigestUpdater.UpdateDigests(images, done) {
+		if image.Err() != nil {
+			return image.Err()
+		}
+
+		metadata := image.Metadata()
+		target := targets[metadata["index"].(int)]
+
+		*target.digest = image.Digest()
+
+		if created, ok := metadata["created"].(time.Time); ok {
+			*target.created = &created
+		}
+
+		if platforms, ok := metadata["platforms"].([]*parse.PlatformDigest); ok {
+			*target.platforms = platforms
+		}
+	}
+
+	return nil
+}
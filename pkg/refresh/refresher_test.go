@@ -0,0 +1,288 @@
+package refresh_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+func TestRefresher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Names      []string
+		Paths      []string
+		Contents   []byte
+		Expected   []byte
+		ShouldFail bool
+	}{
+		{
+			Name:  "Selected Name",
+			Names: []string{"busybox"},
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "outdated"
+			},
+			{
+				"name": "golang",
+				"tag": "latest",
+				"digest": "outdated"
+			}
+		]
+	},
+	"kubernetesfiles": {
+		"pod.yaml": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "outdated",
+				"container": "busybox"
+			}
+		]
+	}
+}`),
+			Expected: []byte(fmt.Sprintf(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "%s"
+			},
+			{
+				"name": "golang",
+				"tag": "latest",
+				"digest": "outdated"
+			}
+		]
+	},
+	"kubernetesfiles": {
+		"pod.yaml": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "%s",
+				"container": "busybox"
+			}
+		]
+	}
+}`, testutils.BusyboxLatestSHA, testutils.BusyboxLatestSHA)),
+		},
+		{
+			Name:  "Selected Path With Platforms",
+			Paths: []string{"services/*/Dockerfile"},
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "outdated"
+			}
+		],
+		"services/app/Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "outdated",
+				"platforms": [
+					{
+						"os": "linux",
+						"architecture": "amd64",
+						"digest": "outdated"
+					}
+				]
+			}
+		]
+	}
+}`),
+			Expected: []byte(fmt.Sprintf(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "outdated"
+			}
+		],
+		"services/app/Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "%s",
+				"platforms": [
+					{
+						"os": "linux",
+						"architecture": "amd64",
+						"digest": "%s"
+					},
+					{
+						"os": "linux",
+						"architecture": "arm64",
+						"variant": "v8",
+						"digest": "%s"
+					}
+				]
+			}
+		]
+	}
+}`, testutils.BusyboxLatestSHA, testutils.BusyboxLatestAMD64SHA,
+				testutils.BusyboxLatestARM64SHA,
+			)),
+		},
+		{
+			Name:  "Selected Name With Created",
+			Names: []string{"redis"},
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "outdated",
+				"created": "2020-01-01T00:00:00Z"
+			},
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "outdated"
+			}
+		]
+	}
+}`),
+			Expected: []byte(fmt.Sprintf(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "%s",
+				"created": "%s"
+			},
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "%s"
+			}
+		]
+	}
+}`, testutils.RedisLatestSHA, testutils.LatestCreated,
+				testutils.RedisLatestSHA,
+			)),
+		},
+		{
+			Name:  "Older Format",
+			Names: []string{"busybox"},
+			Contents: []byte(`{
+  "kubernetesfiles": {
+    "pod.yaml": [
+      {"name": "golang", "tag": "1", "digest": "outdated", "container": "go"}
+    ]
+  },
+  "dockerfiles": {
+    "Dockerfile": [
+      { "name": "golang", "tag": "latest", "digest": "outdated" },
+      {
+        "name": "busybox",
+        "tag": "latest",
+        "digest": "outdated"
+      }
+    ]
+  }
+}
+`),
+			Expected: []byte(fmt.Sprintf(`{
+  "kubernetesfiles": {
+    "pod.yaml": [
+      {"name": "golang", "tag": "1", "digest": "outdated", "container": "go"}
+    ]
+  },
+  "dockerfiles": {
+    "Dockerfile": [
+      { "name": "golang", "tag": "latest", "digest": "outdated" },
+      {
+        "name": "busybox",
+        "tag": "latest",
+        "digest": "%s"
+      }
+    ]
+  }
+}
+`, testutils.BusyboxLatestSHA)),
+		},
+		{
+			Name:  "Missing Digest",
+			Names: []string{"unknown"},
+			Contents: []byte(`{
+	"schemaVersion": 4,
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "unknown",
+				"tag": "latest",
+				"digest": "outdated"
+			}
+		]
+	}
+}`),
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := refresh.NewSelector(test.Names, nil, test.Paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			refresher, err := refresh.NewRefresher(
+				testutils.NewMockDigestRequester(t, nil), selector, 0,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+
+			err = refresher.RefreshLockfile(
+				bytes.NewReader(test.Contents), &got,
+			)
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(test.Expected, got.Bytes()) {
+				t.Fatalf(
+					"expected:\n%s\ngot:\n%s", test.Expected, got.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package refresh
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+)
+
+// Selector selects the images in a Lockfile whose digests are refreshed.
+type Selector struct {
+	names      []string
+	registries []string
+	paths      []string
+}
+
+// NewSelector returns a Selector after validating its patterns.
+//
+// names are globs, such as "redis" or "ghcr.io/org/*", matched against
+// image names. registries, such as "ghcr.io" or "docker.io", are matched
+// against the registries of images. paths are globs, such as
+// "services/*/Dockerfile", matched against the paths in the Lockfile.
+//
+// An image is selected if it matches any of the names, any of the
+// registries, and any of the paths. If names, registries, or paths are
+// empty, they match every image.
+func NewSelector(
+	names []string,
+	registries []string,
+	paths []string,
+) (*Selector, error) {
+	for _, pattern := range append(append([]string{}, names...), paths...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid glob", pattern)
+		}
+	}
+
+	normalizedRegistries := make([]string, len(registries))
+	for i, registry := range registries {
+		normalizedRegistries[i] = update.NormalizeRegistry(registry)
+	}
+
+	return &Selector{
+		names:      names,
+		registries: normalizedRegistries,
+		paths:      paths,
+	}, nil
+}
+
+// SelectsImage reports whether the image is selected by its name and path.
+func (s *Selector) SelectsImage(image *Image) bool {
+	if !matchesAny(s.names, image.Name) || !matchesAny(s.paths, image.Path) {
+		return false
+	}
+
+	if len(s.registries) == 0 {
+		return true
+	}
+
+	registry, err := update.ImageRegistry(image.Name)
+	if err != nil {
+		return false
+	}
+
+	for _, selectedRegistry := range s.registries {
+		if registry == selectedRegistry {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package refresh_test
+
+import (
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/refresh"
+)
+
+func TestSelector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name       string
+		Names      []string
+		Registries []string
+		Paths      []string
+		Path       string
+		ImageName  string
+		Expected   bool
+	}{
+		{
+			Name:      "Everything",
+			Path:      "Dockerfile",
+			ImageName: "busybox",
+			Expected:  true,
+		},
+		{
+			Name:      "Name Glob",
+			Names:     []string{"redis", "ghcr.io/org/*"},
+			Path:      "Dockerfile",
+			ImageName: "ghcr.io/org/app",
+			Expected:  true,
+		},
+		{
+			Name:      "Unselected Name",
+			Names:     []string{"redis"},
+			Path:      "Dockerfile",
+			ImageName: "busybox",
+		},
+		{
+			Name:       "Docker Hub Registry",
+			Registries: []string{"index.docker.io"},
+			Path:       "Dockerfile",
+			ImageName:  "busybox",
+			Expected:   true,
+		},
+		{
+			Name:       "Unselected Registry",
+			Registries: []string{"ghcr.io"},
+			Path:       "Dockerfile",
+			ImageName:  "quay.io/org/app",
+		},
+		{
+			Name:      "Path Glob",
+			Paths:     []string{"services/*/Dockerfile"},
+			Path:      "services/app/Dockerfile",
+			ImageName: "busybox",
+			Expected:  true,
+		},
+		{
+			Name:       "Name And Unselected Path",
+			Names:      []string{"busybox"},
+			Registries: []string{"docker.io"},
+			Paths:      []string{"services/*/Dockerfile"},
+			Path:       "Dockerfile",
+			ImageName:  "busybox",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := refresh.NewSelector(
+				test.Names, test.Registries, test.Paths,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := selector.SelectsImage(&refresh.Image{
+				Path: test.Path,
+				Name: test.ImageName,
+			}); got != test.Expected {
+				t.Fatalf("expected %t, got %t", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestSelectorInvalidGlob(t *testing.T) {
+	t.Parallel()
+
+	if _, err := refresh.NewSelector(
+		[]string{"["}, nil, nil,
+	); err == nil {
+		t.Fatal("expected error but did not get one")
+	}
+}
@@ -0,0 +1,31 @@
+// Package refresh provides functionality to refresh the digests of selected
+// images in an existing Lockfile.
+package refresh
+
+import (
+	"io"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// IRefresher provides an interface for Refreshers, which are responsible for
+// querying registries for new digests of the selected images in a Lockfile,
+// leaving the other images unchanged.
+type IRefresher interface {
+	RefreshLockfile(lockfileReader io.Reader, lockfileWriter io.Writer) error
+}
+
+// Image is an image in a Lockfile that an ISelector may select. Index is the
+// position of the image in the file at Path in the Lockfile.
+type Image struct {
+	Kind  kind.Kind
+	Path  string
+	Index int
+	Name  string
+}
+
+// ISelector provides an interface for Selectors, which select the images in
+// a Lockfile whose digests are refreshed.
+type ISelector interface {
+	SelectsImage(image *Image) bool
+}
@@ -0,0 +1,68 @@
+package preprocess
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type bakefilePreprocessor struct {
+	kind kind.Kind
+}
+
+// NewBakefilePreprocessor returns an IPreprocessor for Bakefiles.
+func NewBakefilePreprocessor() IPreprocessor {
+	return &bakefilePreprocessor{
+		kind: kind.Bakefile,
+	}
+}
+
+// Kind is a getter for the kind.
+func (b *bakefilePreprocessor) Kind() kind.Kind {
+	return b.kind
+}
+
+// PreprocessLockfile removes Dockerfiles from the Lockfile if they are already
+// referenced by Bakefiles.
+func (b *bakefilePreprocessor) PreprocessLockfile(
+	lockfile *lockfile.Lockfile,
+) (*lockfile.Lockfile, error) {
+	if lockfile == nil {
+		return nil, errors.New("'lockfile' cannot be nil")
+	}
+
+	if len(lockfile.Bakefiles) == 0 || len(lockfile.Dockerfiles) == 0 {
+		return lockfile, nil
+	}
+
+	dockerfilePathsCache := map[string]struct{}{}
+
+	for _, images := range lockfile.Bakefiles {
+		for _, image := range images {
+			if image.DockerfilePath == "" {
+				continue
+			}
+
+			dockerfilePath := image.DockerfilePath
+
+			if filepath.IsAbs(dockerfilePath) {
+				var err error
+
+				dockerfilePath, err = convertAbsToRelPath(dockerfilePath)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			dockerfilePathsCache[dockerfilePath] = struct{}{}
+		}
+	}
+
+	for path := range dockerfilePathsCache {
+		delete(lockfile.Dockerfiles, path)
+	}
+
+	return lockfile, nil
+}
@@ -4,20 +4,15 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
-	"sync"
 
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type composefilePreprocessor struct {
 	kind kind.Kind
 }
 
-type deduplicatedPath struct {
-	val string
-	err error
-}
-
 // NewComposefilePreprocessor returns an IPreprocessor for Composefiles.
 func NewComposefilePreprocessor() IPreprocessor {
 	return &composefilePreprocessor{
@@ -33,124 +28,47 @@ func (c *composefilePreprocessor) Kind() kind.Kind {
 // PreprocessLockfile removes Dockerfiles from the Lockfile if they are already
 // referenced by Composefiles.
 func (c *composefilePreprocessor) PreprocessLockfile(
-	lockfile map[kind.Kind]map[string][]interface{},
-) (map[kind.Kind]map[string][]interface{}, error) {
+	lockfile *lockfile.Lockfile,
+) (*lockfile.Lockfile, error) {
 	if lockfile == nil {
 		return nil, errors.New("'lockfile' cannot be nil")
 	}
 
-	if len(lockfile[kind.Composefile]) == 0 ||
-		len(lockfile[kind.Dockerfile]) == 0 {
+	if len(lockfile.Composefiles) == 0 || len(lockfile.Dockerfiles) == 0 {
 		return lockfile, nil
 	}
 
-	var (
-		waitGroup                   sync.WaitGroup
-		deduplicatedDockerfilePaths = make(chan *deduplicatedPath)
-		done                        = make(chan struct{})
-	)
-
-	defer close(done)
-
-	for _, images := range lockfile[kind.Composefile] {
-		images := images
+	dockerfilePathsCache := map[string]struct{}{}
 
-		waitGroup.Add(1)
+	for _, images := range lockfile.Composefiles {
+		for _, image := range images {
+			if image.DockerfilePath == "" {
+				continue
+			}
 
-		go func() {
-			defer waitGroup.Done()
+			dockerfilePath := image.DockerfilePath
 
-			for _, image := range images {
-				image, ok := image.(map[string]interface{})
-				if !ok {
-					select {
-					case <-done:
-					case deduplicatedDockerfilePaths <- &deduplicatedPath{
-						err: errors.New("malformed image"),
-					}:
-					}
+			if filepath.IsAbs(dockerfilePath) {
+				var err error
 
-					return
-				}
-
-				if image["dockerfile"] != nil {
-					dockerfilePath, ok := image["dockerfile"].(string)
-					if !ok {
-						select {
-						case <-done:
-						case deduplicatedDockerfilePaths <- &deduplicatedPath{
-							err: errors.New("malformed 'dockerfile' in image"),
-						}:
-						}
-
-						return
-					}
-
-					if filepath.IsAbs(dockerfilePath) {
-						var err error
-
-						dockerfilePath, err = c.convertAbsToRelPath(
-							dockerfilePath,
-						)
-						if err != nil {
-							select {
-							case <-done:
-							case deduplicatedDockerfilePaths <- &deduplicatedPath{ // nolint: lll
-								err: err,
-							}:
-							}
-
-							return
-						}
-					}
-
-					select {
-					case <-done:
-						return
-					case deduplicatedDockerfilePaths <- &deduplicatedPath{
-						val: dockerfilePath,
-					}:
-					}
+				dockerfilePath, err = convertAbsToRelPath(dockerfilePath)
+				if err != nil {
+					return nil, err
 				}
 			}
-		}()
-	}
 
-	go func() {
-		waitGroup.Wait()
-		close(deduplicatedDockerfilePaths)
-	}()
-
-	dockerfilePathsCache := map[string]struct{}{}
-
-	for deduplicatedDockerfilePath := range deduplicatedDockerfilePaths {
-		if deduplicatedDockerfilePath.err != nil {
-			return nil, deduplicatedDockerfilePath.err
+			dockerfilePathsCache[dockerfilePath] = struct{}{}
 		}
-
-		dockerfilePathsCache[deduplicatedDockerfilePath.val] = struct{}{}
-	}
-
-	if len(dockerfilePathsCache) == 0 {
-		return lockfile, nil
 	}
 
-	dockerfilePathImages := map[string][]interface{}{}
-
-	for path, images := range lockfile[kind.Dockerfile] {
-		if _, ok := dockerfilePathsCache[path]; !ok {
-			dockerfilePathImages[path] = images
-		}
+	for path := range dockerfilePathsCache {
+		delete(lockfile.Dockerfiles, path)
 	}
 
-	lockfile[kind.Dockerfile] = dockerfilePathImages
-
 	return lockfile, nil
 }
 
-func (c *composefilePreprocessor) convertAbsToRelPath(
-	path string,
-) (string, error) {
+func convertAbsToRelPath(path string) (string, error) {
 	currentWorkingDirectory, err := os.Getwd()
 	if err != nil {
 		return "", err
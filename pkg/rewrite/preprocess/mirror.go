@@ -0,0 +1,64 @@
+package preprocess
+
+import (
+	"errors"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type mirrorPreprocessor struct {
+	kind    kind.Kind
+	mirrors map[string]string
+}
+
+// NewMirrorPreprocessor returns an IPreprocessor that replaces the names of
+// images in the Lockfile with their names in registry mirrors, so that
+// rewritten files pull from the mirrors. mirrors maps registry prefixes to
+// mirrors, as described in update.MirrorName.
+//
+// Images in kustomizations keep their names, because kustomize matches
+// images by the names in the resources they transform.
+//
+// The preprocessor applies to images of every other kind, so its kind is
+// empty.
+func NewMirrorPreprocessor(
+	mirrors map[string]string,
+) (IPreprocessor, error) {
+	if len(mirrors) == 0 {
+		return nil, errors.New("'mirrors' cannot be empty")
+	}
+
+	if err := update.ValidateMirrors(mirrors); err != nil {
+		return nil, err
+	}
+
+	return &mirrorPreprocessor{mirrors: mirrors}, nil
+}
+
+// Kind is a getter for the kind.
+func (m *mirrorPreprocessor) Kind() kind.Kind {
+	return m.kind
+}
+
+// PreprocessLockfile replaces the name of each image with its name in the
+// mirror of its registry. Images whose registries are not mirrored keep
+// their names.
+func (m *mirrorPreprocessor) PreprocessLockfile(
+	lockfile *lockfile.Lockfile,
+) (*lockfile.Lockfile, error) {
+	if lockfile == nil {
+		return nil, errors.New("'lockfile' cannot be nil")
+	}
+
+	for _, image := range lockfile.Images() {
+		if image.Kind == kind.Kustomization {
+			continue
+		}
+
+		*image.Name = update.MirrorName(*image.Name, m.mirrors)
+	}
+
+	return lockfile, nil
+}
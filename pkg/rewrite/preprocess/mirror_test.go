@@ -0,0 +1,120 @@
+package preprocess_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/rewrite/preprocess"
+)
+
+func TestMirrorPreprocessor(t *testing.T) {
+	t.Parallel()
+
+	lockfileByt := []byte(`{
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			},
+			{
+				"name": "quay.io/owner/app",
+				"tag": "latest",
+				"digest": "app"
+			}
+		]
+	},
+	"kustomizations": {
+		"kustomization.yaml": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			}
+		]
+	}
+}`)
+
+	tests := []struct {
+		Name                      string
+		Mirrors                   map[string]string
+		ExpectedDockerfileNames   []string
+		ExpectedKustomizationName string
+		ShouldFail                bool
+	}{
+		{
+			Name:    "Mirrors",
+			Mirrors: map[string]string{"docker.io": "mirror.internal:5000"},
+			ExpectedDockerfileNames: []string{
+				"mirror.internal:5000/library/busybox", "quay.io/owner/app",
+			},
+			ExpectedKustomizationName: "busybox",
+		},
+		{
+			Name:       "No Mirrors",
+			ShouldFail: true,
+		},
+		{
+			Name:       "Empty Mirror",
+			Mirrors:    map[string]string{"docker.io": ""},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			existingLockfile := &lockfile.Lockfile{}
+			if err := json.Unmarshal(lockfileByt, existingLockfile); err != nil {
+				t.Fatal(err)
+			}
+
+			preprocessor, err := preprocess.NewMirrorPreprocessor(
+				test.Mirrors,
+			)
+			if err == nil {
+				existingLockfile, err = preprocessor.PreprocessLockfile(
+					existingLockfile,
+				)
+			}
+
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+
+			for _, image := range existingLockfile.Dockerfiles["Dockerfile"] {
+				got = append(got, image.Name)
+			}
+
+			if !reflect.DeepEqual(test.ExpectedDockerfileNames, got) {
+				t.Fatalf(
+					"expected %v, got %v", test.ExpectedDockerfileNames, got,
+				)
+			}
+
+			gotKustomizationName := existingLockfile.Kustomizations["kustomization.yaml"][0].Name // nolint: lll
+			if test.ExpectedKustomizationName != gotKustomizationName {
+				t.Fatalf(
+					"expected %s, got %s",
+					test.ExpectedKustomizationName, gotKustomizationName,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type platformPreprocessor struct {
+	kind         kind.Kind
+	os           string
+	architecture string
+	variant      string
+}
+
+// NewPlatformPreprocessor returns an IPreprocessor that selects the digest of
+// a platform, such as "linux/arm64/v8", for multi-architecture images in the
+// Lockfile. The platform must be in the form "os/architecture[/variant]".
+//
+// The preprocessor applies to images of every kind, so its kind is empty.
+func NewPlatformPreprocessor(platform string) (IPreprocessor, error) {
+	// os/architecture[/variant]
+	fields := strings.Split(platform, "/")
+
+	const minNumFields, maxNumFields = 2, 3
+	if (len(fields) != minNumFields && len(fields) != maxNumFields) ||
+		fields[0] == "" || fields[1] == "" ||
+		(len(fields) == maxNumFields && fields[2] == "") {
+		return nil, fmt.Errorf(
+			"'%s' platform must be in the form 'os/architecture[/variant]'",
+			platform,
+		)
+	}
+
+	var variant string
+	if len(fields) == maxNumFields {
+		variant = fields[2]
+	}
+
+	return &platformPreprocessor{
+		os:           fields[0],
+		architecture: fields[1],
+		variant:      variant,
+	}, nil
+}
+
+// Kind is a getter for the kind.
+func (p *platformPreprocessor) Kind() kind.Kind {
+	return p.kind
+}
+
+// PreprocessLockfile replaces the digest of each image that has platform
+// digests with the digest of the platform. If the platform does not specify
+// a variant, the first digest with a matching os and architecture is used.
+// Images without platform digests keep their digest.
+func (p *platformPreprocessor) PreprocessLockfile(
+	lockfile *lockfile.Lockfile,
+) (*lockfile.Lockfile, error) {
+	if lockfile == nil {
+		return nil, errors.New("'lockfile' cannot be nil")
+	}
+
+	for _, image := range lockfile.Images() {
+		digest, err := p.platformDigest(
+			*image.Name, *image.Digest, *image.Platforms,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		*image.Digest = digest
+	}
+
+	return lockfile, nil
+}
+
+// platformDigest returns the digest of the platform from an image's platform
+// digests, or digest if the image does not have platform digests.
+func (p *platformPreprocessor) platformDigest(
+	name string,
+	digest string,
+	platforms []*lockfile.PlatformDigest,
+) (string, error) {
+	if platforms == nil {
+		return digest, nil
+	}
+
+	for _, platform := range platforms {
+		if platform.OS != p.os || platform.Architecture != p.architecture ||
+			(p.variant != "" && platform.Variant != p.variant) {
+			continue
+		}
+
+		return platform.Digest, nil
+	}
+
+	return "", fmt.Errorf(
+		"image '%s' does not have a digest for platform '%s'",
+		name, p.platform(),
+	)
+}
+
+func (p *platformPreprocessor) platform() string {
+	if p.variant == "" {
+		return fmt.Sprintf("%s/%s", p.os, p.architecture)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", p.os, p.architecture, p.variant)
+}
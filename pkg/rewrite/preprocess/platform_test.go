@@ -0,0 +1,121 @@
+package preprocess_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/rewrite/preprocess"
+)
+
+func TestPlatformPreprocessor(t *testing.T) {
+	t.Parallel()
+
+	lockfileByt := []byte(`{
+	"dockerfiles": {
+		"Dockerfile": [
+			{
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox",
+				"platforms": [
+					{
+						"os": "linux",
+						"architecture": "amd64",
+						"digest": "busybox-amd64"
+					},
+					{
+						"os": "linux",
+						"architecture": "arm",
+						"variant": "v7",
+						"digest": "busybox-armv7"
+					}
+				]
+			},
+			{
+				"name": "golang",
+				"tag": "latest",
+				"digest": "golang"
+			}
+		]
+	}
+}`)
+
+	tests := []struct {
+		Name            string
+		Platform        string
+		ExpectedDigests []string
+		ShouldFail      bool
+	}{
+		{
+			Name:            "Platform",
+			Platform:        "linux/amd64",
+			ExpectedDigests: []string{"busybox-amd64", "golang"},
+		},
+		{
+			Name:            "Platform With Variant",
+			Platform:        "linux/arm/v7",
+			ExpectedDigests: []string{"busybox-armv7", "golang"},
+		},
+		{
+			Name:            "Platform Without Variant",
+			Platform:        "linux/arm",
+			ExpectedDigests: []string{"busybox-armv7", "golang"},
+		},
+		{
+			Name:       "Missing Platform",
+			Platform:   "windows/amd64",
+			ShouldFail: true,
+		},
+		{
+			Name:       "Invalid Platform",
+			Platform:   "linux",
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			existingLockfile := &lockfile.Lockfile{}
+			if err := json.Unmarshal(lockfileByt, existingLockfile); err != nil {
+				t.Fatal(err)
+			}
+
+			preprocessor, err := preprocess.NewPlatformPreprocessor(
+				test.Platform,
+			)
+			if err == nil {
+				existingLockfile, err = preprocessor.PreprocessLockfile(
+					existingLockfile,
+				)
+			}
+
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+
+			for _, image := range existingLockfile.Dockerfiles["Dockerfile"] {
+				got = append(got, image.Digest)
+			}
+
+			if !reflect.DeepEqual(test.ExpectedDigests, got) {
+				t.Fatalf("expected %v, got %v", test.ExpectedDigests, got)
+			}
+		})
+	}
+}
@@ -2,13 +2,14 @@
 // before rewriting.
 package preprocess
 
-import "github.com/safe-waters/docker-lock/pkg/kind"
+import (
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
 
 // IPreprocessor provides an interface for Preprocessors, which can modify
 // a Lockfile before rewriting.
 type IPreprocessor interface {
 	Kind() kind.Kind
-	PreprocessLockfile(
-		lockfile map[kind.Kind]map[string][]interface{},
-	) (map[kind.Kind]map[string][]interface{}, error)
+	PreprocessLockfile(lockfile *lockfile.Lockfile) (*lockfile.Lockfile, error)
 }
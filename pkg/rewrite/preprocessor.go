@@ -4,7 +4,7 @@ import (
 	"errors"
 	"reflect"
 
-	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/preprocess"
 )
 
@@ -35,8 +35,8 @@ func NewPreprocessor(
 
 // PreprocessLockfile preprocesses a Lockfile before rewriting occurs.
 func (p *preprocessor) PreprocessLockfile(
-	lockfile map[kind.Kind]map[string][]interface{},
-) (map[kind.Kind]map[string][]interface{}, error) {
+	lockfile *lockfile.Lockfile,
+) (*lockfile.Lockfile, error) {
 	if lockfile == nil {
 		return nil, errors.New("'lockfile' cannot be nil")
 	}
@@ -1,14 +1,13 @@
 package rewrite
 
 import (
-	"encoding/json"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"reflect"
 
-	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type rewriter struct {
@@ -59,15 +58,15 @@ func (r *rewriter) RewriteLockfile(
 
 	defer os.RemoveAll(tempDir)
 
-	var lockfile map[kind.Kind]map[string][]interface{}
-	if err := json.NewDecoder(lockfileReader).Decode(&lockfile); err != nil {
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
 		return err
 	}
 
 	if r.preprocessor != nil && !reflect.ValueOf(r.preprocessor).IsNil() {
-		var err error
-
-		lockfile, err = r.preprocessor.PreprocessLockfile(lockfile)
+		existingLockfile, err = r.preprocessor.PreprocessLockfile(
+			existingLockfile,
+		)
 		if err != nil {
 			return err
 		}
@@ -76,7 +75,7 @@ func (r *rewriter) RewriteLockfile(
 	done := make(chan struct{})
 	defer close(done)
 
-	writtenPaths := r.writer.WriteFiles(lockfile, tempDir, done)
+	writtenPaths := r.writer.WriteFiles(existingLockfile, tempDir, done)
 
 	return r.renamer.RenameFiles(writtenPaths)
 }
@@ -9,7 +9,7 @@ import (
 	"testing"
 
 	"github.com/safe-waters/docker-lock/internal/testutils"
-	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 
 	cmd_rewrite "github.com/safe-waters/docker-lock/cmd/rewrite"
 )
@@ -396,24 +396,23 @@ services:
 			tempDir := testutils.MakeTempDirInCurrentDir(t)
 			defer os.RemoveAll(tempDir)
 
-			var lockfile map[kind.Kind]map[string][]interface{}
+			var existingLockfile lockfile.Lockfile
 			if err := json.Unmarshal(
-				test.Contents[len(test.Contents)-1], &lockfile,
+				test.Contents[len(test.Contents)-1], &existingLockfile,
 			); err != nil {
 				t.Fatal(err)
 			}
 
 			uniquePathsToWrite := map[string]struct{}{}
 
-			composefileImagesWithTempDir := map[string][]interface{}{}
+			composefileImagesWithTempDir := map[string][]*lockfile.ComposefileImage{} // nolint: lll
 
-			for composefilePath, images := range lockfile[kind.Composefile] {
+			for composefilePath, images := range existingLockfile.Composefiles {
 				for _, image := range images {
-					image := image.(map[string]interface{})
-					if image["dockerfile"] != nil {
-						dockerfilePath := image["dockerfile"].(string)
+					if image.DockerfilePath != "" {
+						dockerfilePath := image.DockerfilePath
 						uniquePathsToWrite[dockerfilePath] = struct{}{}
-						image["dockerfile"] = filepath.Join(
+						image.DockerfilePath = filepath.Join(
 							tempDir, dockerfilePath,
 						)
 					}
@@ -424,18 +423,18 @@ services:
 				composefileImagesWithTempDir[composefilePath] = images
 			}
 
-			dockerfileImagesWithTempDir := map[string][]interface{}{}
+			dockerfileImagesWithTempDir := map[string][]*lockfile.DockerfileImage{}
 
-			for dockerfilePath, images := range lockfile[kind.Dockerfile] {
+			for dockerfilePath, images := range existingLockfile.Dockerfiles {
 				uniquePathsToWrite[dockerfilePath] = struct{}{}
 
 				dockerfilePath = filepath.Join(tempDir, dockerfilePath)
 				dockerfileImagesWithTempDir[dockerfilePath] = images
 			}
 
-			kubernetesfileImagesWithTempDir := map[string][]interface{}{}
+			kubernetesfileImagesWithTempDir := map[string][]*lockfile.KubernetesfileImage{} // nolint: lll
 
-			for kubernetesfilePath, images := range lockfile[kind.Kubernetesfile] { // nolint: lll
+			for kubernetesfilePath, images := range existingLockfile.Kubernetesfiles { // nolint: lll
 				uniquePathsToWrite[kubernetesfilePath] = struct{}{}
 
 				kubernetesfilePath = filepath.Join(tempDir, kubernetesfilePath)
@@ -455,7 +454,9 @@ services:
 
 			noopFile := filepath.Base("rewriter_test.go")
 
-			flags, err := cmd_rewrite.NewFlags(noopFile, tempDir, false)
+			flags, err := cmd_rewrite.NewFlags(
+				noopFile, tempDir, false, "", nil, nil,
+			)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -465,10 +466,11 @@ services:
 				t.Fatal(err)
 			}
 
-			lockfileWithTempDir := map[kind.Kind]map[string][]interface{}{
-				kind.Dockerfile:     dockerfileImagesWithTempDir,
-				kind.Composefile:    composefileImagesWithTempDir,
-				kind.Kubernetesfile: kubernetesfileImagesWithTempDir,
+			lockfileWithTempDir := &lockfile.Lockfile{
+				SchemaVersion:   lockfile.SchemaVersion,
+				Dockerfiles:     dockerfileImagesWithTempDir,
+				Composefiles:    composefileImagesWithTempDir,
+				Kubernetesfiles: kubernetesfileImagesWithTempDir,
 			}
 
 			lockfileByt, err := json.Marshal(lockfileWithTempDir)
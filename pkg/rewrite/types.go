@@ -4,16 +4,14 @@ package rewrite
 import (
 	"io"
 
-	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
 )
 
 // IPreprocessor provides an interface for Preprocessors, which are responsible
 // for modifying a Lockfile, if need be, before rewriting.
 type IPreprocessor interface {
-	PreprocessLockfile(
-		lockfile map[kind.Kind]map[string][]interface{},
-	) (map[kind.Kind]map[string][]interface{}, error)
+	PreprocessLockfile(lockfile *lockfile.Lockfile) (*lockfile.Lockfile, error)
 }
 
 // IWriter provides an interface for Writers, which are responsible for
@@ -21,7 +19,7 @@ type IPreprocessor interface {
 // Lockfile.
 type IWriter interface {
 	WriteFiles(
-		lockfile map[kind.Kind]map[string][]interface{},
+		lockfile *lockfile.Lockfile,
 		tempDir string,
 		done <-chan struct{},
 	) <-chan write.IWrittenPath
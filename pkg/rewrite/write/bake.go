@@ -0,0 +1,450 @@
+package write
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type bakefileWriter struct {
+	kind             kind.Kind
+	dockerfileWriter *dockerfileWriter
+	excludeTags      bool
+}
+
+// targetDockerfile is a Dockerfile referenced by a target.
+type targetDockerfile struct {
+	targetName     string
+	dockerfilePath string
+}
+
+// bakefileDockerfile is a Dockerfile referenced by targets in Bakefiles,
+// with the images to write, the build args, and the names of the targets'
+// named contexts.
+type bakefileDockerfile struct {
+	images        []*lockfile.DockerfileImage
+	buildArgs     map[string]string
+	namedContexts []string
+}
+
+// contextReplacement replaces the value of a named context in a Bakefile,
+// from the start to the end byte offset.
+type contextReplacement struct {
+	start int
+	end   int
+	value string
+}
+
+// NewBakefileWriter returns an IWriter for Bakefiles. Dockerfiles referenced
+// by Bakefiles are written as well, except for images that are replaced by
+// the named contexts of their targets.
+func NewBakefileWriter(excludeTags bool) IWriter {
+	return &bakefileWriter{
+		kind: kind.Bakefile,
+		dockerfileWriter: &dockerfileWriter{
+			kind:        kind.Dockerfile,
+			excludeTags: excludeTags,
+		},
+		excludeTags: excludeTags,
+	}
+}
+
+// Kind is a getter for the kind.
+func (b *bakefileWriter) Kind() kind.Kind {
+	return b.kind
+}
+
+// WriteFiles writes new Bakefiles and Dockerfiles referenced by the
+// Bakefiles given the paths of the original Bakefiles and new images that
+// should replace the existing ones. In Bakefiles, only the values of
+// "docker-image://" named contexts are replaced.
+func (b *bakefileWriter) WriteFiles(
+	lockfile *lockfile.Lockfile,
+	outputDir string,
+	done <-chan struct{},
+) <-chan IWrittenPath {
+	var (
+		waitGroup    sync.WaitGroup
+		writtenPaths = make(chan IWrittenPath)
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		dockerfiles, err := b.filterDockerfilePathImages(lockfile.Bakefiles)
+		if err != nil {
+			select {
+			case <-done:
+			case writtenPaths <- NewWrittenPath("", "", err):
+			}
+
+			return
+		}
+
+		for path, dockerfile := range dockerfiles {
+			path := path
+			dockerfile := dockerfile
+
+			waitGroup.Add(1)
+
+			go func() {
+				defer waitGroup.Done()
+
+				writtenPath, err := b.dockerfileWriter.writeFile(
+					path, dockerfile.images, dockerfile.buildArgs,
+					dockerfile.namedContexts, outputDir,
+				)
+				if err != nil {
+					select {
+					case <-done:
+					case writtenPaths <- NewWrittenPath("", "", err):
+					}
+
+					return
+				}
+
+				select {
+				case <-done:
+					return
+				case writtenPaths <- NewWrittenPath(path, writtenPath, nil):
+				}
+			}()
+		}
+	}()
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for path, images := range lockfile.Bakefiles {
+			path := path
+			images := images
+
+			waitGroup.Add(1)
+
+			go func() {
+				defer waitGroup.Done()
+
+				writtenPath, err := b.writeFile(path, images, outputDir)
+				if err != nil {
+					select {
+					case <-done:
+					case writtenPaths <- NewWrittenPath("", "", err):
+					}
+
+					return
+				}
+
+				if writtenPath != "" {
+					select {
+					case <-done:
+						return
+					case writtenPaths <- NewWrittenPath(path, writtenPath, nil):
+					}
+				}
+			}()
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(writtenPaths)
+	}()
+
+	return writtenPaths
+}
+
+// writeFile writes a new Bakefile with the images of its "docker-image://"
+// named contexts. If the Bakefile has no such contexts, nothing is written
+// and the returned path is empty.
+func (b *bakefileWriter) writeFile(
+	path string,
+	images []*lockfile.BakefileImage,
+	outputDir string,
+) (string, error) {
+	targets, err := parse.LoadBakefile(path)
+	if err != nil {
+		return "", fmt.Errorf("'%s' failed to parse with err: %v", path, err)
+	}
+
+	pathByt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	targetContexts := map[string]map[string]*parse.BakeContext{}
+
+	for _, target := range targets {
+		targetContexts[target.Name] = target.Contexts
+	}
+
+	var (
+		replacements      = map[int]*contextReplacement{}
+		numTargetContexts = map[string]int{}
+	)
+
+	for _, image := range images {
+		if image.ContextName == "" {
+			continue
+		}
+
+		contexts, ok := targetContexts[image.TargetName]
+		if !ok {
+			return "", fmt.Errorf(
+				"in '%s', '%s' target does not exist", path, image.TargetName,
+			)
+		}
+
+		context, ok := contexts[image.ContextName]
+		if !ok {
+			return "", fmt.Errorf(
+				"in '%s', '%s' target does not have a '%s' context",
+				path, image.TargetName, image.ContextName,
+			)
+		}
+
+		if _, ok := context.ImageLine(); !ok {
+			return "", fmt.Errorf(
+				"in '%s', '%s' context of '%s' target is not an image",
+				path, image.ContextName, image.TargetName,
+			)
+		}
+
+		if context.Start < 0 ||
+			string(pathByt[context.Start:context.End]) !=
+				strconv.Quote(context.Value) {
+			return "", fmt.Errorf(
+				"in '%s', '%s' context of '%s' target cannot be rewritten "+
+					"because it is not a string literal",
+				path, image.ContextName, image.TargetName,
+			)
+		}
+
+		tag := image.Tag
+		if b.excludeTags {
+			tag = ""
+		}
+
+		replacement := &contextReplacement{
+			start: context.Start,
+			end:   context.End,
+			value: strconv.Quote(fmt.Sprintf(
+				"%s%s", parse.BakeImageContextPrefix,
+				parse.NewImage(
+					b.kind, image.Name, tag, image.Digest, nil, nil,
+				).ImageLine(),
+			)),
+		}
+
+		// Targets share the context if they inherit it from the same target.
+		if existing, ok := replacements[replacement.start]; ok &&
+			existing.value != replacement.value {
+			return "", fmt.Errorf(
+				"in '%s', multiple targets inherit the '%s' context "+
+					"with different images",
+				path, image.ContextName,
+			)
+		}
+
+		replacements[replacement.start] = replacement
+		numTargetContexts[image.TargetName]++
+	}
+
+	for _, target := range targets {
+		var numImageContexts int
+
+		for _, context := range target.Contexts {
+			if _, ok := context.ImageLine(); ok {
+				numImageContexts++
+			}
+		}
+
+		if numImageContexts != numTargetContexts[target.Name] {
+			return "", fmt.Errorf(
+				"in '%s', '%s' target has '%d' image context(s), "+
+					"yet asked to rewrite '%d'",
+				path, target.Name, numImageContexts,
+				numTargetContexts[target.Name],
+			)
+		}
+	}
+
+	if len(replacements) == 0 {
+		return "", nil
+	}
+
+	sortedReplacements := make([]*contextReplacement, 0, len(replacements))
+	for _, replacement := range replacements {
+		sortedReplacements = append(sortedReplacements, replacement)
+	}
+
+	sort.Slice(sortedReplacements, func(i, j int) bool {
+		return sortedReplacements[i].start < sortedReplacements[j].start
+	})
+
+	var (
+		outputBuffer bytes.Buffer
+		offset       int
+	)
+
+	for _, replacement := range sortedReplacements {
+		outputBuffer.Write(pathByt[offset:replacement.start])
+		outputBuffer.WriteString(replacement.value)
+
+		offset = replacement.end
+	}
+
+	outputBuffer.Write(pathByt[offset:])
+
+	replacer := strings.NewReplacer("/", "-", "\\", "-")
+	outputPath := replacer.Replace(fmt.Sprintf("%s-*", path))
+
+	writtenFile, err := ioutil.TempFile(outputDir, outputPath)
+	if err != nil {
+		return "", err
+	}
+	defer writtenFile.Close()
+
+	if _, err = writtenFile.Write(outputBuffer.Bytes()); err != nil {
+		return "", err
+	}
+
+	return writtenFile.Name(), err
+}
+
+// filterDockerfilePathImages returns the Dockerfiles referenced by targets
+// in Bakefiles. If multiple targets reference the same Dockerfile, they
+// must have the same images and named contexts.
+func (b *bakefileWriter) filterDockerfilePathImages(
+	pathImages map[string][]*lockfile.BakefileImage,
+) (map[string]*bakefileDockerfile, error) {
+	dockerfiles := map[string]*bakefileDockerfile{}
+
+	for path, images := range pathImages {
+		targetDockerfileImages := map[targetDockerfile][]*lockfile.DockerfileImage{} // nolint: lll
+
+		for _, image := range images {
+			if image.DockerfilePath == "" {
+				continue
+			}
+
+			dockerfilePath := image.DockerfilePath
+
+			if filepath.IsAbs(dockerfilePath) {
+				var err error
+
+				dockerfilePath, err = convertAbsToRelPath(dockerfilePath)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			key := targetDockerfile{
+				targetName:     image.TargetName,
+				dockerfilePath: dockerfilePath,
+			}
+
+			targetDockerfileImages[key] = append(
+				targetDockerfileImages[key], &lockfile.DockerfileImage{
+					Name:        image.Name,
+					Tag:         image.Tag,
+					Digest:      image.Digest,
+					Instruction: image.Instruction,
+					Platform:    image.Platform,
+					Platforms:   image.Platforms,
+				},
+			)
+		}
+
+		if len(targetDockerfileImages) == 0 {
+			continue
+		}
+
+		// Lines in Dockerfiles that are replaced by named contexts, such as
+		// "FROM base" with "base = target:base", are not images, whether or
+		// not the context is an image.
+		targets, err := parse.LoadBakefile(path)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"'%s' failed to parse with err: %v", path, err,
+			)
+		}
+
+		namedTargets := map[string]*parse.BakeTarget{}
+
+		for _, target := range targets {
+			namedTargets[target.Name] = target
+		}
+
+		for key, images := range targetDockerfileImages {
+			target, ok := namedTargets[key.targetName]
+			if !ok {
+				return nil, fmt.Errorf(
+					"in '%s', '%s' target does not exist",
+					path, key.targetName,
+				)
+			}
+
+			dockerfile := &bakefileDockerfile{
+				images:        images,
+				buildArgs:     target.Args,
+				namedContexts: target.ContextNames(),
+			}
+
+			existing, ok := dockerfiles[key.dockerfilePath]
+			if !ok {
+				dockerfiles[key.dockerfilePath] = dockerfile
+
+				continue
+			}
+
+			if !b.equalDockerfiles(existing, dockerfile) {
+				return nil, fmt.Errorf(
+					"multiple targets reference the same Dockerfile "+
+						"'%s' with different images or named contexts",
+					key.dockerfilePath,
+				)
+			}
+		}
+	}
+
+	return dockerfiles, nil
+}
+
+func (b *bakefileWriter) equalDockerfiles(
+	dockerfile1 *bakefileDockerfile,
+	dockerfile2 *bakefileDockerfile,
+) bool {
+	if len(dockerfile1.images) != len(dockerfile2.images) ||
+		len(dockerfile1.namedContexts) != len(dockerfile2.namedContexts) {
+		return false
+	}
+
+	for i := range dockerfile1.images {
+		if dockerfile1.images[i].Name != dockerfile2.images[i].Name ||
+			dockerfile1.images[i].Tag != dockerfile2.images[i].Tag ||
+			dockerfile1.images[i].Digest != dockerfile2.images[i].Digest {
+			return false
+		}
+	}
+
+	for i := range dockerfile1.namedContexts {
+		if dockerfile1.namedContexts[i] != dockerfile2.namedContexts[i] {
+			return false
+		}
+	}
+
+	return true
+}
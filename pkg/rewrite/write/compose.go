@@ -17,21 +17,32 @@ import (
 	"github.com/compose-spec/compose-go/types"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type composefileWriter struct {
 	kind             kind.Kind
-	dockerfileWriter IWriter
+	dockerfileWriter dockerfileFileWriter
 	excludeTags      bool
 }
 
-type filteredDockerfilePathImages struct {
-	dockerfilePathImages map[string][]interface{}
-	err                  error
+// serviceDockerfile is a Dockerfile referenced by a service.
+type serviceDockerfile struct {
+	serviceName    string
+	dockerfilePath string
+}
+
+// composefileDockerfile is a Dockerfile referenced by services in
+// Composefiles, with the images to write and the build args of the services.
+type composefileDockerfile struct {
+	images    []*lockfile.DockerfileImage
+	buildArgs map[string]string
 }
 
 // NewComposefileWriter returns an IWriter for Composefiles. dockerfileWriter
 // cannot be nil as it handles writing Dockerfiles referenced by Composefiles.
+// It must be created by NewDockerfileWriter, so that the Dockerfiles are
+// written with the build args of their services.
 func NewComposefileWriter(
 	dockerfileWriter IWriter,
 	excludeTags bool,
@@ -40,9 +51,16 @@ func NewComposefileWriter(
 		return nil, errors.New("dockerfileWriter cannot be nil")
 	}
 
+	fileWriter, ok := dockerfileWriter.(dockerfileFileWriter)
+	if !ok {
+		return nil, errors.New(
+			"dockerfileWriter must be created by NewDockerfileWriter",
+		)
+	}
+
 	return &composefileWriter{
 		kind:             kind.Composefile,
-		dockerfileWriter: dockerfileWriter,
+		dockerfileWriter: fileWriter,
 		excludeTags:      excludeTags,
 	}, nil
 }
@@ -56,7 +74,7 @@ func (c *composefileWriter) Kind() kind.Kind {
 // Composefiles given the paths of the original Composefiles
 // and new images that should replace the exsting ones.
 func (c *composefileWriter) WriteFiles(
-	pathImages map[string][]interface{},
+	lockfile *lockfile.Lockfile,
 	outputDir string,
 	done <-chan struct{},
 ) <-chan IWrittenPath {
@@ -75,8 +93,8 @@ func (c *composefileWriter) WriteFiles(
 		go func() {
 			defer waitGroup.Done()
 
-			dockerfilePathImages, err := c.filterDockerfilePathImages(
-				pathImages,
+			dockerfiles, err := c.filterDockerfilePathImages(
+				lockfile.Composefiles,
 			)
 			if err != nil {
 				select {
@@ -87,14 +105,23 @@ func (c *composefileWriter) WriteFiles(
 				return
 			}
 
-			if len(dockerfilePathImages) != 0 {
-				for writtenPath := range c.dockerfileWriter.WriteFiles(
-					dockerfilePathImages, outputDir, done,
-				) {
-					if writtenPath.Err() != nil {
+			for path, dockerfile := range dockerfiles {
+				path := path
+				dockerfile := dockerfile
+
+				waitGroup.Add(1)
+
+				go func() {
+					defer waitGroup.Done()
+
+					writtenPath, err := c.dockerfileWriter.writeFile(
+						path, dockerfile.images, dockerfile.buildArgs, nil,
+						outputDir,
+					)
+					if err != nil {
 						select {
 						case <-done:
-						case writtenPaths <- writtenPath:
+						case writtenPaths <- NewWrittenPath("", "", err):
 						}
 
 						return
@@ -103,9 +130,11 @@ func (c *composefileWriter) WriteFiles(
 					select {
 					case <-done:
 						return
-					case writtenPaths <- writtenPath:
+					case writtenPaths <- NewWrittenPath(
+						path, writtenPath, nil,
+					):
 					}
-				}
+				}()
 			}
 		}()
 
@@ -115,7 +144,7 @@ func (c *composefileWriter) WriteFiles(
 			defer waitGroup.Done()
 
 			for writtenPath := range c.writeComposefiles(
-				pathImages, outputDir, done,
+				lockfile.Composefiles, outputDir, done,
 			) {
 				if writtenPath.Err() != nil {
 					select {
@@ -144,7 +173,7 @@ func (c *composefileWriter) WriteFiles(
 }
 
 func (c *composefileWriter) writeComposefiles(
-	pathImages map[string][]interface{},
+	pathImages map[string][]*lockfile.ComposefileImage,
 	outputDir string,
 	done <-chan struct{},
 ) <-chan IWrittenPath {
@@ -198,7 +227,7 @@ func (c *composefileWriter) writeComposefiles(
 
 func (c *composefileWriter) writeFile(
 	path string,
-	images []interface{},
+	images []*lockfile.ComposefileImage,
 	outputDir string,
 ) (string, error) {
 	project, err := c.loadNewProject(path)
@@ -314,7 +343,7 @@ func (c *composefileWriter) loadNewProject(
 
 func (c *composefileWriter) filterComposefileServices(
 	project *types.Project,
-	images []interface{},
+	images []*lockfile.ComposefileImage,
 ) (map[string]string, error) {
 	var (
 		uniqueServicesInLockfile = map[string]struct{}{}
@@ -322,15 +351,7 @@ func (c *composefileWriter) filterComposefileServices(
 	)
 
 	for _, image := range images {
-		image, ok := image.(map[string]interface{})
-		if !ok {
-			return nil, errors.New("malformed image")
-		}
-
-		serviceName, ok := image["service"].(string)
-		if !ok {
-			return nil, errors.New("malformed 'service' in image")
-		}
+		serviceName := image.ServiceName
 
 		if _, err := project.GetService(serviceName); err != nil {
 			return nil, fmt.Errorf(
@@ -338,7 +359,7 @@ func (c *composefileWriter) filterComposefileServices(
 			)
 		}
 
-		if image["dockerfile"] == nil {
+		if image.DockerfilePath == "" {
 			if _, ok := serviceImageLines[serviceName]; ok {
 				return nil, fmt.Errorf(
 					"multiple images exist for the same service '%s'",
@@ -346,27 +367,13 @@ func (c *composefileWriter) filterComposefileServices(
 				)
 			}
 
-			tag, ok := image["tag"].(string)
-			if !ok {
-				return nil, errors.New("malformed 'tag' in image")
-			}
-
+			tag := image.Tag
 			if c.excludeTags {
 				tag = ""
 			}
 
-			name, ok := image["name"].(string)
-			if !ok {
-				return nil, errors.New("malformed 'name' in image")
-			}
-
-			digest, ok := image["digest"].(string)
-			if !ok {
-				return nil, errors.New("malformed 'digest' in image")
-			}
-
 			imageLine := parse.NewImage(
-				c.kind, name, tag, digest, nil, nil,
+				c.kind, image.Name, tag, image.Digest, nil, nil,
 			).ImageLine()
 			serviceImageLines[serviceName] = imageLine
 		}
@@ -419,200 +426,120 @@ func (c *composefileWriter) filterComposefileServices(
 	return serviceImageLines, nil
 }
 
+// filterDockerfilePathImages returns the Dockerfiles referenced by services
+// in Composefiles. If multiple services reference the same Dockerfile, they
+// must have the same images.
 func (c *composefileWriter) filterDockerfilePathImages(
-	pathImages map[string][]interface{},
-) (map[string][]interface{}, error) {
-	var (
-		filteredCh = make(chan *filteredDockerfilePathImages)
-		done       = make(chan struct{})
-		waitGroup  sync.WaitGroup
-	)
-
-	defer close(done)
-
-	waitGroup.Add(1)
-
-	go func() {
-		defer waitGroup.Done()
-
-		for _, images := range pathImages {
-			images := images
+	pathImages map[string][]*lockfile.ComposefileImage,
+) (map[string]*composefileDockerfile, error) {
+	dockerfiles := map[string]*composefileDockerfile{}
 
-			waitGroup.Add(1)
+	for composefilePath, images := range pathImages {
+		serviceDockerfileImages := map[serviceDockerfile][]*lockfile.DockerfileImage{} // nolint: lll
 
-			go func() {
-				defer waitGroup.Done()
+		for _, image := range images {
+			if image.DockerfilePath == "" {
+				continue
+			}
 
-				serviceDockerfileImages := map[string][]interface{}{}
+			dockerfilePath := image.DockerfilePath
 
-				for _, image := range images {
-					image, ok := image.(map[string]interface{})
-					if !ok {
-						select {
-						case <-done:
-						case filteredCh <- &filteredDockerfilePathImages{
-							err: errors.New("malformed image"),
-						}:
-						}
+			if filepath.IsAbs(dockerfilePath) {
+				var err error
 
-						return
-					}
+				dockerfilePath, err = convertAbsToRelPath(dockerfilePath)
+				if err != nil {
+					return nil, err
+				}
+			}
 
-					if image["dockerfile"] != nil {
-						dockerfilePath, ok := image["dockerfile"].(string)
-						if !ok {
-							select {
-							case <-done:
-							case filteredCh <- &filteredDockerfilePathImages{
-								err: errors.New(
-									"malformed 'dockerfile' in image",
-								),
-							}:
-							}
-
-							return
-						}
+			key := serviceDockerfile{
+				serviceName:    image.ServiceName,
+				dockerfilePath: dockerfilePath,
+			}
 
-						if filepath.IsAbs(dockerfilePath) {
-							var err error
+			serviceDockerfileImages[key] = append(
+				serviceDockerfileImages[key], &lockfile.DockerfileImage{
+					Name:        image.Name,
+					Tag:         image.Tag,
+					Digest:      image.Digest,
+					Instruction: image.Instruction,
+					Platform:    image.Platform,
+					Platforms:   image.Platforms,
+				},
+			)
+		}
 
-							dockerfilePath, err = c.convertAbsToRelPath(
-								dockerfilePath,
-							)
-							if err != nil {
-								select {
-								case <-done:
-								case filteredCh <- &filteredDockerfilePathImages{ // nolint: lll
-									err: err,
-								}:
-								}
+		if len(serviceDockerfileImages) == 0 {
+			continue
+		}
 
-								return
-							}
+		// The Dockerfiles are written with the build args of their
+		// services, as they were parsed.
+		project, err := c.loadNewProject(composefilePath)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"'%s' failed to parse with err: %v", composefilePath, err,
+			)
+		}
 
-							image["dockerfile"] = dockerfilePath
-						}
+		for key, images := range serviceDockerfileImages {
+			path := key.dockerfilePath
 
-						serviceName, ok := image["service"].(string)
-						if !ok {
-							select {
-							case <-done:
-							case filteredCh <- &filteredDockerfilePathImages{
-								err: errors.New("malformed 'service' in image"),
-							}:
-							}
+			serviceConfig, err := project.GetService(key.serviceName)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"in '%s', '%s' service does not exist",
+					composefilePath, key.serviceName,
+				)
+			}
 
-							return
-						}
+			buildArgs := map[string]string{}
 
-						serviceDockerfileImages[serviceName] = append(
-							serviceDockerfileImages[serviceName], image,
-						)
+			if serviceConfig.Build != nil {
+				for arg, val := range serviceConfig.Build.Args {
+					if val != nil {
+						buildArgs[arg] = *val
 					}
 				}
+			}
 
-				for _, images := range serviceDockerfileImages {
-					dockerfilePathImages := map[string][]interface{}{}
-
-					for _, image := range images {
-						image, ok := image.(map[string]interface{})
-						if !ok {
-							select {
-							case <-done:
-							case filteredCh <- &filteredDockerfilePathImages{
-								err: errors.New("malformed image"),
-							}:
-							}
-
-							return
-						}
-
-						dockerfilePath, ok := image["dockerfile"].(string)
-						if !ok {
-							select {
-							case <-done:
-							case filteredCh <- &filteredDockerfilePathImages{
-								err: errors.New(
-									"malformed 'dockerfile' in image",
-								),
-							}:
-							}
-
-							return
-						}
-
-						dockerfilePathImages[dockerfilePath] = append(
-							dockerfilePathImages[dockerfilePath], image,
-						)
-					}
-
-					select {
-					case <-done:
-						return
-					case filteredCh <- &filteredDockerfilePathImages{
-						dockerfilePathImages: dockerfilePathImages,
-					}:
-					}
+			existing, ok := dockerfiles[path]
+			if !ok {
+				dockerfiles[path] = &composefileDockerfile{
+					images:    images,
+					buildArgs: buildArgs,
 				}
-			}()
-		}
-	}()
-
-	go func() {
-		waitGroup.Wait()
-		close(filteredCh)
-	}()
 
-	dockerfilePathImages := map[string][]interface{}{}
+				continue
+			}
 
-	for filtered := range filteredCh {
-		if filtered.err != nil {
-			return nil, filtered.err
-		}
+			if len(existing.images) != len(images) {
+				return nil, fmt.Errorf(
+					"multiple services reference the same Dockerfile"+
+						"'%s' with different images",
+					path,
+				)
+			}
 
-		for path, images := range filtered.dockerfilePathImages {
-			if existingImages, ok := dockerfilePathImages[path]; ok {
-				if len(existingImages) != len(images) {
+			for i := range existing.images {
+				if existing.images[i].Name != images[i].Name ||
+					existing.images[i].Tag != images[i].Tag ||
+					existing.images[i].Digest != images[i].Digest {
 					return nil, fmt.Errorf(
 						"multiple services reference the same Dockerfile"+
-							"'%s' with different images",
+							" '%s' with different images",
 						path,
 					)
 				}
-
-				for i := 0; i < len(existingImages); i++ {
-					existingImage, ok := existingImages[i].(map[string]interface{}) // nolint: lll
-					if !ok {
-						return nil, errors.New("malformed image")
-					}
-
-					image, ok := images[i].(map[string]interface{})
-					if !ok {
-						return nil, errors.New("malformed image")
-					}
-
-					if existingImage["name"] != image["name"] ||
-						existingImage["tag"] != image["tag"] ||
-						existingImage["digest"] != image["digest"] {
-						return nil, fmt.Errorf(
-							"multiple services reference the same Dockerfile"+
-								" '%s' with different images",
-							path,
-						)
-					}
-				}
-			} else {
-				dockerfilePathImages[path] = images
 			}
 		}
 	}
 
-	return dockerfilePathImages, nil
+	return dockerfiles, nil
 }
 
-func (c *composefileWriter) convertAbsToRelPath(
-	path string,
-) (string, error) {
+func convertAbsToRelPath(path string) (string, error) {
 	currentWorkingDirectory, err := os.Getwd()
 	if err != nil {
 		return "", err
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
 )
 
@@ -17,7 +18,7 @@ func TestComposefileWriter(t *testing.T) {
 		Name        string
 		Contents    [][]byte
 		Expected    [][]byte
-		PathImages  map[string][]interface{}
+		PathImages  map[string][]*lockfile.ComposefileImage
 		EnvVars     map[string]string
 		ExcludeTags bool
 		ShouldFail  bool
@@ -36,19 +37,56 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":       "busybox",
-						"tag":        "latest",
-						"digest":     "busybox",
-						"dockerfile": "Dockerfile",
-						"service":    "svc",
+					{
+						Name:           "busybox",
+						Tag:            "latest",
+						Digest:         "busybox",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc",
 					},
 				},
 			},
 			Expected: [][]byte{
 				[]byte(`FROM busybox:latest@sha256:busybox
+`),
+			},
+		},
+		{
+			Name: "Dockerfile With Build Args",
+			Contents: [][]byte{
+				[]byte(`ARG BUILDER
+FROM busybox AS base
+COPY --from=${BUILDER} /bin /bin
+`),
+				[]byte(`
+version: '3'
+
+services:
+  svc:
+    build:
+      context: .
+      args:
+        BUILDER: base
+`,
+				),
+			},
+			PathImages: map[string][]*lockfile.ComposefileImage{
+				"docker-compose.yml": {
+					{
+						Name:           "busybox",
+						Tag:            "latest",
+						Digest:         "busybox",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`ARG BUILDER
+FROM busybox:latest@sha256:busybox AS base
+COPY --from=${BUILDER} /bin /bin
 `),
 			},
 		},
@@ -64,13 +102,13 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc",
 					},
 				},
 			},
@@ -98,13 +136,13 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc",
 					},
 				},
 			},
@@ -133,13 +171,13 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "scratch",
-						"tag":     "",
-						"digest":  "",
-						"service": "svc",
+					{
+						Name:        "scratch",
+						Tag:         "",
+						Digest:      "",
+						ServiceName: "svc",
 					},
 				},
 			},
@@ -166,13 +204,13 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc",
 					},
 				},
 			},
@@ -204,20 +242,20 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -265,27 +303,27 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile-1",
-						"service":    "svc-docker",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile-2",
-						"service":    "svc-docker-context",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile-1",
+						ServiceName:    "svc-docker",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile-2",
+						ServiceName:    "svc-docker-context",
 					},
 				},
 			},
@@ -333,14 +371,14 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -362,26 +400,26 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-unknown",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-unknown",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -405,27 +443,27 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "busybox",
-						"tag":        "latest",
-						"digest":     "busybox",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-another-docker",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "busybox",
+						Tag:            "latest",
+						Digest:         "busybox",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-another-docker",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -457,35 +495,35 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose-1.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "busybox",
-						"tag":        "latest",
-						"digest":     "busybox",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "busybox",
+						Tag:            "latest",
+						Digest:         "busybox",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 				"docker-compose-2.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -509,27 +547,27 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-another-docker",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-another-docker",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -576,35 +614,35 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose-one.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 				"docker-compose-two.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile",
+						ServiceName:    "svc-docker",
 					},
 				},
 			},
@@ -665,35 +703,35 @@ services:
 `,
 				),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.ComposefileImage{
 				"docker-compose-1.yml": {
-					map[string]interface{}{
-						"name":    "busybox",
-						"tag":     "latest",
-						"digest":  "busybox",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "golang",
-						"tag":        "latest",
-						"digest":     "golang",
-						"dockerfile": "Dockerfile-1",
-						"service":    "svc-docker",
+					{
+						Name:        "busybox",
+						Tag:         "latest",
+						Digest:      "busybox",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "golang",
+						Tag:            "latest",
+						Digest:         "golang",
+						DockerfilePath: "Dockerfile-1",
+						ServiceName:    "svc-docker",
 					},
 				},
 				"docker-compose-2.yml": {
-					map[string]interface{}{
-						"name":    "node",
-						"tag":     "latest",
-						"digest":  "node",
-						"service": "svc-compose",
-					},
-					map[string]interface{}{
-						"name":       "python",
-						"tag":        "latest",
-						"digest":     "python",
-						"dockerfile": "Dockerfile-2",
-						"service":    "svc-another-docker",
+					{
+						Name:        "node",
+						Tag:         "latest",
+						Digest:      "node",
+						ServiceName: "svc-compose",
+					},
+					{
+						Name:           "python",
+						Tag:            "latest",
+						Digest:         "python",
+						DockerfilePath: "Dockerfile-2",
+						ServiceName:    "svc-another-docker",
 					},
 				},
 			},
@@ -744,15 +782,14 @@ services:
 
 			uniquePathsToWrite := map[string]struct{}{}
 
-			tempPathImages := map[string][]interface{}{}
+			tempPathImages := map[string][]*lockfile.ComposefileImage{}
 
 			for composefilePath, images := range test.PathImages {
 				for _, image := range images {
-					image := image.(map[string]interface{})
-					if image["dockerfile"] != nil {
-						dockerfilePath := image["dockerfile"].(string)
+					if image.DockerfilePath != "" {
+						dockerfilePath := image.DockerfilePath
 						uniquePathsToWrite[dockerfilePath] = struct{}{}
-						image["dockerfile"] = filepath.Join(
+						image.DockerfilePath = filepath.Join(
 							tempDir, dockerfilePath,
 						)
 					}
@@ -775,7 +812,9 @@ services:
 				t, tempDir, pathsToWrite, test.Contents,
 			)
 
-			dockerfileWriter := write.NewDockerfileWriter(test.ExcludeTags)
+			dockerfileWriter := write.NewDockerfileWriter(
+				test.ExcludeTags, nil,
+			)
 
 			composefileWriter, err := write.NewComposefileWriter(
 				dockerfileWriter, test.ExcludeTags,
@@ -788,7 +827,8 @@ services:
 			defer close(done)
 
 			writtenPathResults := composefileWriter.WriteFiles(
-				tempPathImages, tempDir, done,
+				&lockfile.Lockfile{Composefiles: tempPathImages},
+				tempDir, done,
 			)
 
 			var got []string
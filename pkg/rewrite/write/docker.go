@@ -3,27 +3,49 @@ package write
 import (
 	"bufio"
 	"bytes"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
 	"sync"
 
+	"github.com/moby/buildkit/frontend/dockerfile/dockerfile2llb"
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type dockerfileWriter struct {
 	kind        kind.Kind
 	excludeTags bool
+	buildArgs   *parse.BuildArgs
 }
 
-// NewDockerfileWriter returns an IWriter for Dockerfiles.
-func NewDockerfileWriter(excludeTags bool) IWriter {
+// dockerfileFileWriter writes a single Dockerfile. Writers of files that
+// reference Dockerfiles, such as Composefiles, use it to write the
+// Dockerfiles with the build args and named contexts of their references.
+type dockerfileFileWriter interface {
+	writeFile(
+		path string,
+		images []*lockfile.DockerfileImage,
+		buildArgs map[string]string,
+		namedContexts []string,
+		outputDir string,
+	) (string, error)
+}
+
+// NewDockerfileWriter returns an IWriter for Dockerfiles. buildArgs should
+// be the same as those the Dockerfiles were parsed with, so that the ARGs in
+// image references expand the same way. If buildArgs is nil, Dockerfiles
+// are written without build args.
+func NewDockerfileWriter(
+	excludeTags bool,
+	buildArgs *parse.BuildArgs,
+) IWriter {
 	return &dockerfileWriter{
 		kind:        kind.Dockerfile,
 		excludeTags: excludeTags,
+		buildArgs:   buildArgs,
 	}
 }
 
@@ -35,7 +57,7 @@ func (d *dockerfileWriter) Kind() kind.Kind {
 // WriteFiles writes new Dockerfiles given the paths of the original Dockerfiles
 // and new images that should replace the exsting ones.
 func (d *dockerfileWriter) WriteFiles( // nolint: dupl
-	pathImages map[string][]interface{},
+	lockfile *lockfile.Lockfile,
 	outputDir string,
 	done <-chan struct{},
 ) <-chan IWrittenPath {
@@ -49,7 +71,7 @@ func (d *dockerfileWriter) WriteFiles( // nolint: dupl
 	go func() {
 		defer waitGroup.Done()
 
-		for path, images := range pathImages {
+		for path, images := range lockfile.Dockerfiles {
 			path := path
 			images := images
 
@@ -58,7 +80,9 @@ func (d *dockerfileWriter) WriteFiles( // nolint: dupl
 			go func() {
 				defer waitGroup.Done()
 
-				writtenPath, err := d.writeFile(path, images, outputDir)
+				writtenPath, err := d.writeFile(
+					path, images, d.buildArgs.ForPath(path), nil, outputDir,
+				)
 				if err != nil {
 					select {
 					case <-done:
@@ -85,9 +109,15 @@ func (d *dockerfileWriter) WriteFiles( // nolint: dupl
 	return writtenPaths
 }
 
+// writeFile writes a new Dockerfile with the images. ARGs in image
+// references are expanded with the build args, as when the Dockerfile was
+// parsed. Images that are replaced by named contexts, such as "alpine" in
+// "FROM alpine" if there is a named context "alpine", are not rewritten.
 func (d *dockerfileWriter) writeFile(
 	path string,
-	images []interface{},
+	images []*lockfile.DockerfileImage,
+	buildArgs map[string]string,
+	namedContexts []string,
 	outputDir string,
 ) (string, error) {
 	pathByt, err := ioutil.ReadFile(path)
@@ -95,26 +125,182 @@ func (d *dockerfileWriter) writeFile(
 		return "", err
 	}
 
-	if _, err = parser.Parse(bytes.NewBuffer(pathByt)); err != nil {
+	loadedDockerfile, err := parser.Parse(bytes.NewBuffer(pathByt))
+	if err != nil {
 		return "", fmt.Errorf(
 			"'%s' failed to parse with err: %v", path, err,
 		)
 	}
 
+	globalArgs, err := parse.DockerfileGlobalArgs(loadedDockerfile.AST)
+	if err != nil {
+		return "", fmt.Errorf("%v in Dockerfile '%s'", err, path)
+	}
+
+	syntax, _, syntaxLocation, hasSyntax := dockerfile2llb.DetectSyntax(
+		bytes.NewReader(pathByt),
+	)
+
 	var (
 		scanner      = bufio.NewScanner(bytes.NewBuffer(pathByt))
 		stageNames   = map[string]bool{}
 		imageIndex   int
+		lineNumber   int
 		outputBuffer bytes.Buffer
 		outputLine   string
+		// flagsInstruction is the COPY or RUN instruction whose flags
+		// continue onto the next line.
+		flagsInstruction string
+		// continued is true if the next line continues an instruction,
+		// rather than starting a new one.
+		continued bool
 	)
 
 	const instructionIndex = 0 // for instance, FROM is an instruction
 
+	replaceImageLine := func(
+		instruction string,
+		imageLine string,
+	) (string, error) {
+		if imageIndex >= len(images) {
+			return "", fmt.Errorf(
+				"more images exist in '%s' than in the Lockfile", path,
+			)
+		}
+
+		image := images[imageIndex]
+
+		if image.Instruction != instruction {
+			return "", fmt.Errorf(
+				"image '%d' in '%s' is in a '%s' instruction, but the "+
+					"Lockfile expects '%s'",
+				imageIndex, path, instructionName(instruction),
+				instructionName(image.Instruction),
+			)
+		}
+
+		tag := image.Tag
+		if d.excludeTags {
+			tag = ""
+		}
+
+		imageIndex++
+
+		return parse.NewImage(
+			kind.Dockerfile, image.Name, tag, image.Digest, nil, nil,
+		).ImageLine(), nil
+	}
+
+	replaceFromFlagValue := func(
+		instruction string,
+		imageLine string,
+	) (string, error) {
+		// COPY --from=<stage>, COPY --from=<stage index>, and
+		// COPY --from=<named context> do not refer to images.
+		expandedImageLine, _, isImage := parse.FromFlagImageLine(
+			imageLine, stageNames, globalArgs, buildArgs,
+		)
+		if !isImage ||
+			parse.IsNamedContext(expandedImageLine, namedContexts) {
+			return imageLine, nil
+		}
+
+		return replaceImageLine(instruction, imageLine)
+	}
+
 	for scanner.Scan() {
+		lineNumber++
+
+		// # syntax=<image>
+		if hasSyntax && lineNumber == syntaxLocation[0].Start.Line {
+			line := scanner.Text()
+			valueStart := strings.Index(line, "=") + 1
+
+			replacementSyntax, err := replaceImageLine(
+				lockfile.SyntaxInstruction, syntax,
+			)
+			if err != nil {
+				return "", err
+			}
+
+			outputBuffer.WriteString(fmt.Sprintf(
+				"%s%s\n", line[:valueStart],
+				strings.Replace(line[valueStart:], syntax, replacementSyntax, 1),
+			))
+
+			continue
+		}
+
+		if flagsInstruction != "" || continued {
+			line := scanner.Text()
+
+			// Comments and empty lines do not end a line continuation.
+			if isCommentOrEmpty(line) {
+				outputBuffer.WriteString(fmt.Sprintf("%s\n", line))
+				continue
+			}
+
+			if flagsInstruction != "" {
+				var (
+					flagsContinued bool
+					err            error
+				)
+
+				line, flagsContinued, err = rewriteFromFlags(
+					flagsInstruction, line, replaceFromFlagValue,
+				)
+				if err != nil {
+					return "", err
+				}
+
+				if !flagsContinued {
+					flagsInstruction = ""
+				}
+			}
+
+			continued = isContinued(line)
+
+			outputBuffer.WriteString(fmt.Sprintf("%s\n", line))
+
+			continue
+		}
+
 		outputLine = fmt.Sprintf("%s%s", outputLine, scanner.Text())
 		fields := strings.Fields(outputLine)
 
+		if len(fields) > 0 {
+			switch instruction := strings.ToLower(
+				fields[instructionIndex],
+			); instruction {
+			case lockfile.CopyInstruction, lockfile.RunInstruction:
+				// Only the flags after the instruction are rewritten.
+				instructionEnd := strings.Index(
+					outputLine, fields[instructionIndex],
+				) + len(fields[instructionIndex])
+
+				line, flagsContinued, err := rewriteFromFlags(
+					instruction, outputLine[instructionEnd:],
+					replaceFromFlagValue,
+				)
+				if err != nil {
+					return "", err
+				}
+
+				if flagsContinued {
+					flagsInstruction = instruction
+				}
+
+				line = fmt.Sprintf("%s%s", outputLine[:instructionEnd], line)
+				continued = isContinued(line)
+
+				outputBuffer.WriteString(fmt.Sprintf("%s\n", line))
+
+				outputLine = ""
+
+				continue
+			}
+		}
+
 		if len(fields) > 1 &&
 			strings.ToLower(fields[instructionIndex]) == "from" {
 			if fields[len(fields)-1] == "\\" {
@@ -145,45 +331,21 @@ func (d *dockerfileWriter) writeFile(
 
 			if len(fields) > imageLineIndex {
 				imageLine := fields[imageLineIndex]
-
-				if !stageNames[imageLine] {
-					if imageIndex >= len(images) {
-						return "", fmt.Errorf(
-							"more images exist in '%s' than in the Lockfile",
-							path,
-						)
-					}
-
-					image, ok := images[imageIndex].(map[string]interface{})
-					if !ok {
-						return "", errors.New("malformed image")
-					}
-
-					tag, ok := image["tag"].(string)
-					if !ok {
-						return "", errors.New("malformed 'tag' in image")
+				expandedImageLine, _ := parse.ExpandDockerfileArgs(
+					imageLine, globalArgs, buildArgs,
+				)
+
+				// Stage names are case insensitive, as in Docker.
+				if !stageNames[strings.ToLower(imageLine)] &&
+					!parse.IsNamedContext(expandedImageLine, namedContexts) {
+					replacementImageLine, err := replaceImageLine(
+						"", imageLine,
+					)
+					if err != nil {
+						return "", err
 					}
 
-					if d.excludeTags {
-						tag = ""
-					}
-
-					name, ok := image["name"].(string)
-					if !ok {
-						return "", errors.New("malformed 'name' in image")
-					}
-
-					digest, ok := image["digest"].(string)
-					if !ok {
-						return "", errors.New("malformed 'digest' in image")
-					}
-
-					replacementImageLine := parse.NewImage(
-						kind.Dockerfile, name, tag, digest, nil, nil,
-					).ImageLine()
-
 					fields[imageLineIndex] = replacementImageLine
-					imageIndex++
 				}
 
 				// Ensure stage is added to the stage name set:
@@ -192,13 +354,15 @@ func (d *dockerfileWriter) writeFile(
 				// Ensure another stage is added to the stage name set:
 				// FROM <stage> AS <another stage>
 				if len(fields) == maxNumFields {
-					stageNames[fields[stageIndex]] = true
+					stageNames[strings.ToLower(fields[stageIndex])] = true
 				}
 			}
 
 			outputLine = strings.Join(fields, " ")
 		}
 
+		continued = isContinued(outputLine)
+
 		outputBuffer.WriteString(fmt.Sprintf("%s\n", outputLine))
 
 		outputLine = ""
@@ -225,3 +389,125 @@ func (d *dockerfileWriter) writeFile(
 
 	return writtenFile.Name(), err
 }
+
+// rewriteFromFlags replaces the values of the flags of a COPY or RUN
+// instruction that refer to an image or a stage, such as "golang" in
+// "--from=golang" or "alpine" in "--mount=type=bind,from=alpine". The rest
+// of the line, including its whitespace, is unchanged.
+//
+// The flags end at the first field that is not a flag. If the line ends
+// before then with a line continuation, flagsContinued is true, and the next
+// line should be rewritten as well.
+func rewriteFromFlags(
+	instruction string,
+	line string,
+	replace func(instruction string, imageLine string) (string, error),
+) (rewrittenLine string, flagsContinued bool, err error) {
+	const (
+		copyFromFlag = "--from="
+		runMountFlag = "--mount="
+		mountFromOpt = "from="
+	)
+
+	var (
+		builder strings.Builder
+		rest    = line
+	)
+
+	for {
+		field := strings.TrimLeft(rest, " \t")
+		builder.WriteString(rest[:len(rest)-len(field)])
+
+		if field == "" {
+			return builder.String(), false, nil
+		}
+
+		if strings.TrimSpace(field) == "\\" {
+			builder.WriteString(field)
+			return builder.String(), true, nil
+		}
+
+		if !strings.HasPrefix(field, "--") {
+			builder.WriteString(field)
+			return builder.String(), false, nil
+		}
+
+		fieldEnd := strings.IndexAny(field, " \t")
+		if fieldEnd == -1 {
+			fieldEnd = len(field)
+		}
+
+		flag := field[:fieldEnd]
+		rest = field[fieldEnd:]
+
+		var lineContinuation string
+		if strings.TrimSpace(rest) == "" && strings.HasSuffix(flag, "\\") {
+			flag = strings.TrimSuffix(flag, "\\")
+			lineContinuation = "\\"
+		}
+
+		switch {
+		case instruction == lockfile.CopyInstruction &&
+			strings.HasPrefix(flag, copyFromFlag):
+			imageLine, err := replace(
+				instruction, strings.TrimPrefix(flag, copyFromFlag),
+			)
+			if err != nil {
+				return "", false, err
+			}
+
+			flag = fmt.Sprintf("%s%s", copyFromFlag, imageLine)
+		case instruction == lockfile.RunInstruction &&
+			strings.HasPrefix(flag, runMountFlag):
+			opts := strings.Split(strings.TrimPrefix(flag, runMountFlag), ",")
+
+			for i, opt := range opts {
+				if strings.HasPrefix(opt, mountFromOpt) {
+					imageLine, err := replace(
+						instruction, strings.TrimPrefix(opt, mountFromOpt),
+					)
+					if err != nil {
+						return "", false, err
+					}
+
+					opts[i] = fmt.Sprintf("%s%s", mountFromOpt, imageLine)
+				}
+			}
+
+			flag = fmt.Sprintf("%s%s", runMountFlag, strings.Join(opts, ","))
+		}
+
+		builder.WriteString(flag)
+
+		if lineContinuation != "" {
+			builder.WriteString(lineContinuation)
+			builder.WriteString(rest)
+
+			return builder.String(), true, nil
+		}
+	}
+}
+
+// isCommentOrEmpty reports whether a line is a comment or only whitespace.
+func isCommentOrEmpty(line string) bool {
+	line = strings.TrimSpace(line)
+
+	return line == "" || strings.HasPrefix(line, "#")
+}
+
+// isContinued reports whether the instruction on a line continues onto the
+// next line.
+func isContinued(line string) bool {
+	return !isCommentOrEmpty(line) &&
+		strings.HasSuffix(strings.TrimSpace(line), "\\")
+}
+
+// instructionName returns the name of the instruction of a DockerfileImage,
+// which is empty for FROM.
+func instructionName(instruction string) string {
+	if instruction == "" {
+		return "from"
+	}
+
+	return instruction
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
 )
 
@@ -17,7 +19,8 @@ func TestDockerfileWriter(t *testing.T) {
 		Name        string
 		Contents    [][]byte
 		Expected    [][]byte
-		PathImages  map[string][]interface{}
+		PathImages  map[string][]*lockfile.DockerfileImage
+		BuildArgs   *parse.BuildArgs
 		ExcludeTags bool
 		ShouldFail  bool
 	}{
@@ -30,22 +33,22 @@ FROM redis:latest
 FROM golang:latest@sha256:12345
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
 					},
-					map[string]interface{}{
-						"name":   "golang",
-						"tag":    "latest",
-						"digest": "golang",
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang",
 					},
 				},
 			},
@@ -73,12 +76,12 @@ RUN echo
 RUN touch foo
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
 				},
 			},
@@ -110,17 +113,17 @@ RUN apt-get update && \
     apt-get intall vim
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
 					},
 				},
 			},
@@ -138,12 +141,12 @@ RUN apt-get update && \
 			Contents: [][]byte{
 				[]byte(`FROM scratch`),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "scratch",
-						"tag":    "",
-						"digest": "",
+					{
+						Name:   "scratch",
+						Tag:    "",
+						Digest: "",
 					},
 				},
 			},
@@ -164,39 +167,39 @@ FROM busybox
 FROM redis
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile-1": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox-1",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox-1",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis-1",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis-1",
 					},
-					map[string]interface{}{
-						"name":   "golang",
-						"tag":    "latest",
-						"digest": "golang-1",
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang-1",
 					},
 				},
 				"Dockerfile-2": {
-					map[string]interface{}{
-						"name":   "golang",
-						"tag":    "latest",
-						"digest": "golang-2",
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang-2",
 					},
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox-2",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox-2",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis-2",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis-2",
 					},
 				},
 			},
@@ -220,22 +223,22 @@ FROM golang
 `),
 			},
 			ExcludeTags: true,
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
 					},
-					map[string]interface{}{
-						"name":   "golang",
-						"tag":    "latest",
-						"digest": "golang",
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang",
 					},
 				},
 			},
@@ -255,22 +258,22 @@ FROM base
 FROM golang
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
 					},
-					map[string]interface{}{
-						"name":   "golang",
-						"tag":    "latest",
-						"digest": "golang",
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang",
 					},
 				},
 			},
@@ -279,6 +282,39 @@ FROM golang
 FROM redis:latest@sha256:redis
 FROM base
 FROM golang:latest@sha256:golang
+`),
+			},
+		},
+		{
+			Name: "Mixed Case Stages",
+			Contents: [][]byte{
+				[]byte(`FROM golang AS Builder
+FROM builder AS Tester
+FROM ubuntu
+COPY --from=builder /bin/app /bin/app
+RUN --mount=from=TESTER,target=/test ls /test
+`),
+			},
+			PathImages: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang",
+					},
+					{
+						Name:   "ubuntu",
+						Tag:    "latest",
+						Digest: "ubuntu",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`FROM golang:latest@sha256:golang AS Builder
+FROM builder AS Tester
+FROM ubuntu:latest@sha256:ubuntu
+COPY --from=builder /bin/app /bin/app
+RUN --mount=from=TESTER,target=/test ls /test
 `),
 			},
 		},
@@ -291,17 +327,17 @@ FROM --platform=$BUILDPLATFORM redis
 FROM --platform=$BUILDPLATFORM base AS anotherbase
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
 					},
 				},
 			},
@@ -313,22 +349,168 @@ FROM --platform=$BUILDPLATFORM base AS anotherbase
 `),
 			},
 		},
+		{
+			Name: "Copy From And Run Mount",
+			Contents: [][]byte{
+				[]byte(`FROM busybox AS base
+COPY --from=golang:1.16 /go/bin /bin
+COPY --from=base /bin /bin
+COPY --from=0 /bin /bin
+RUN --mount=type=cache,target=/root \
+    --mount=type=bind,from=alpine,target=/alpine \
+    echo --from=redis \
+    && echo done
+COPY --chown=1000 \
+  # my comment
+  --from=redis /data /data
+`),
+			},
+			PathImages: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+					{
+						Name:        "golang",
+						Tag:         "1.16",
+						Digest:      "golang",
+						Instruction: lockfile.CopyInstruction,
+					},
+					{
+						Name:        "alpine",
+						Tag:         "latest",
+						Digest:      "alpine",
+						Instruction: lockfile.RunInstruction,
+					},
+					{
+						Name:        "redis",
+						Tag:         "latest",
+						Digest:      "redis",
+						Instruction: lockfile.CopyInstruction,
+					},
+				},
+			},
+			Expected: [][]byte{
+				// nolint: lll
+				[]byte(`FROM busybox:latest@sha256:busybox AS base
+COPY --from=golang:1.16@sha256:golang /go/bin /bin
+COPY --from=base /bin /bin
+COPY --from=0 /bin /bin
+RUN --mount=type=cache,target=/root \
+    --mount=type=bind,from=alpine:latest@sha256:alpine,target=/alpine \
+    echo --from=redis \
+    && echo done
+COPY --chown=1000 \
+  # my comment
+  --from=redis:latest@sha256:redis /data /data
+`),
+			},
+		},
+		{
+			Name: "Copy From Args",
+			Contents: [][]byte{
+				[]byte(`ARG BUILDER=base
+ARG UNSET
+ARG IMAGE
+FROM busybox AS base
+COPY --from=${BUILDER} /bin /bin
+COPY --from=${UNSET} /bin /bin
+COPY --from=${IMAGE} /go/bin /bin
+`),
+			},
+			PathImages: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+					{
+						Name:        "golang",
+						Tag:         "1.16",
+						Digest:      "golang",
+						Instruction: lockfile.CopyInstruction,
+					},
+				},
+			},
+			BuildArgs: &parse.BuildArgs{
+				Global: map[string]string{"IMAGE": "golang:1.16"},
+			},
+			Expected: [][]byte{
+				[]byte(`ARG BUILDER=base
+ARG UNSET
+ARG IMAGE
+FROM busybox:latest@sha256:busybox AS base
+COPY --from=${BUILDER} /bin /bin
+COPY --from=${UNSET} /bin /bin
+COPY --from=golang:1.16@sha256:golang /go/bin /bin
+`),
+			},
+		},
+		{
+			Name: "Syntax Directive",
+			Contents: [][]byte{
+				[]byte(`#  syntax = docker/dockerfile:1.4
+FROM busybox
+# syntax=docker/dockerfile:1.4
+`),
+			},
+			PathImages: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:        "docker/dockerfile",
+						Tag:         "1.4",
+						Digest:      "dockerfile",
+						Instruction: lockfile.SyntaxInstruction,
+					},
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`#  syntax = docker/dockerfile:1.4@sha256:dockerfile
+FROM busybox:latest@sha256:busybox
+# syntax=docker/dockerfile:1.4
+`),
+			},
+		},
+		{
+			Name: "Different Instruction",
+			Contents: [][]byte{
+				[]byte(`COPY --from=busybox /bin /bin`),
+			},
+			PathImages: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+				},
+			},
+			ShouldFail: true,
+		},
 		{
 			Name: "Fewer Images In Dockerfile",
 			Contents: [][]byte{
 				[]byte(`FROM busybox`),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
-					map[string]interface{}{
-						"name":   "redis",
-						"tag":    "latest",
-						"digest": "redis",
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
 					},
 				},
 			},
@@ -341,12 +523,12 @@ FROM --platform=$BUILDPLATFORM base AS anotherbase
 FROM redis
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
 				},
 			},
@@ -357,12 +539,12 @@ FROM redis
 			Contents: [][]byte{
 				[]byte(`FROM`),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
 				},
 			},
@@ -373,12 +555,12 @@ FROM redis
 			Contents: [][]byte{
 				[]byte(`FROM --platform=$BUILDTARGET`),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.DockerfileImage{
 				"Dockerfile": {
-					map[string]interface{}{
-						"name":   "busybox",
-						"tag":    "latest",
-						"digest": "busybox",
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
 					},
 				},
 			},
@@ -397,7 +579,7 @@ FROM redis
 
 			var pathsToWrite []string
 
-			tempPathImages := map[string][]interface{}{}
+			tempPathImages := map[string][]*lockfile.DockerfileImage{}
 
 			for path, images := range test.PathImages {
 				pathsToWrite = append(pathsToWrite, path)
@@ -412,13 +594,16 @@ FROM redis
 				t, tempDir, pathsToWrite, test.Contents,
 			)
 
-			writer := write.NewDockerfileWriter(test.ExcludeTags)
+			writer := write.NewDockerfileWriter(
+				test.ExcludeTags, test.BuildArgs,
+			)
 
 			done := make(chan struct{})
 			defer close(done)
 
 			writtenPathResults := writer.WriteFiles(
-				tempPathImages, tempDir, done,
+				&lockfile.Lockfile{Dockerfiles: tempPathImages},
+				tempDir, done,
 			)
 
 			var got []string
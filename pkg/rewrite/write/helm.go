@@ -0,0 +1,416 @@
+package write
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/parse"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"gopkg.in/yaml.v2"
+)
+
+type helmchartWriter struct {
+	kind        kind.Kind
+	excludeTags bool
+}
+
+// NewHelmchartWriter returns an IWriter for Helm charts.
+func NewHelmchartWriter(excludeTags bool) IWriter {
+	return &helmchartWriter{
+		kind:        kind.Helmchart,
+		excludeTags: excludeTags,
+	}
+}
+
+// Kind is a getter for the kind.
+func (h *helmchartWriter) Kind() kind.Kind {
+	return h.kind
+}
+
+// WriteFiles writes new values files and templates given the paths of the
+// original files and new images that should replace the exsting ones.
+func (h *helmchartWriter) WriteFiles( // nolint: dupl
+	lockfile *lockfile.Lockfile,
+	outputDir string,
+	done <-chan struct{},
+) <-chan IWrittenPath {
+	var (
+		writtenPaths = make(chan IWrittenPath)
+		waitGroup    sync.WaitGroup
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for path, images := range lockfile.Helmcharts {
+			path := path
+			images := images
+
+			waitGroup.Add(1)
+
+			go func() {
+				defer waitGroup.Done()
+
+				writtenPath, err := h.writeFile(path, images, outputDir)
+				if err != nil {
+					select {
+					case <-done:
+					case writtenPaths <- NewWrittenPath("", "", err):
+					}
+
+					return
+				}
+
+				select {
+				case <-done:
+					return
+				case writtenPaths <- NewWrittenPath(path, writtenPath, nil):
+				}
+			}()
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(writtenPaths)
+	}()
+
+	return writtenPaths
+}
+
+func (h *helmchartWriter) writeFile(
+	path string,
+	images []*lockfile.HelmchartImage,
+	outputDir string,
+) (string, error) {
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var outputByt []byte
+
+	if parse.IsHelmValuesFile(path) {
+		outputByt, err = h.writeValuesFile(path, byt, images)
+	} else {
+		outputByt, err = h.writeTemplateFile(path, byt, images)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer("/", "-", "\\", "-")
+	outputPath := replacer.Replace(fmt.Sprintf("%s-*", path))
+
+	writtenFile, err := ioutil.TempFile(outputDir, outputPath)
+	if err != nil {
+		return "", err
+	}
+	defer writtenFile.Close()
+
+	if _, err = writtenFile.Write(outputByt); err != nil {
+		return "", err
+	}
+
+	return writtenFile.Name(), nil
+}
+
+func (h *helmchartWriter) writeTemplateFile(
+	path string,
+	byt []byte,
+	images []*lockfile.HelmchartImage,
+) ([]byte, error) {
+	var (
+		imagePosition int
+		outputBuffer  bytes.Buffer
+		scanner       = bufio.NewScanner(bytes.NewReader(byt))
+	)
+
+	for scanner.Scan() {
+		outputLine := scanner.Text()
+
+		if imageLine, ok := parse.HelmTemplateImageLine(outputLine); ok {
+			if imagePosition >= len(images) {
+				return nil, fmt.Errorf(
+					"more images exist in '%s' than in the Lockfile", path,
+				)
+			}
+
+			replacementImageLine := h.imageLine(images[imagePosition])
+
+			imageIndex := strings.Index(outputLine, "image:") + len("image:")
+			outputLine = fmt.Sprintf(
+				"%s%s", outputLine[:imageIndex], strings.Replace(
+					outputLine[imageIndex:], imageLine,
+					replacementImageLine, 1,
+				),
+			)
+
+			imagePosition++
+		}
+
+		outputBuffer.WriteString(fmt.Sprintf("%s\n", outputLine))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if imagePosition < len(images) {
+		return nil, fmt.Errorf(
+			"fewer images exist in '%s' than asked to rewrite", path,
+		)
+	}
+
+	return outputBuffer.Bytes(), nil
+}
+
+func (h *helmchartWriter) writeValuesFile(
+	path string,
+	byt []byte,
+	images []*lockfile.HelmchartImage,
+) ([]byte, error) {
+	var (
+		encodedDocs   []interface{}
+		imagePosition int
+		dec           = yaml.NewDecoder(bytes.NewReader(byt))
+	)
+
+	for {
+		var doc yaml.MapSlice
+
+		if err := dec.Decode(&doc); err != nil {
+			if err != io.EOF {
+				return nil, fmt.Errorf(
+					"'%s' yaml decoder failed with err: %v", path, err,
+				)
+			}
+
+			break
+		}
+
+		encodedDoc, err := h.encodeValuesDoc(
+			path, doc, "", images, &imagePosition,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedDocs = append(encodedDocs, encodedDoc)
+	}
+
+	if imagePosition < len(images) {
+		return nil, fmt.Errorf(
+			"fewer images exist in '%s' than asked to rewrite", path,
+		)
+	}
+
+	var outputBuffer bytes.Buffer
+
+	enc := yaml.NewEncoder(&outputBuffer)
+
+	for _, encodedDoc := range encodedDocs {
+		if err := enc.Encode(encodedDoc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return outputBuffer.Bytes(), nil
+}
+
+// encodeValuesDoc replaces images in a values document and returns the
+// document. Images under an "image" key are replaced by the image line from
+// the Lockfile. Images defined by a "repository" have their digest written
+// to the "digest" key, if it exists, otherwise the digest is appended to the
+// "tag", since charts conventionally render such images as "repository:tag".
+// If there is neither, the Lockfile's tag is the chart's appVersion, so a
+// "tag" key is added with the appVersion and the digest.
+//
+// If tags are excluded, the digest is written to the "digest" key and the
+// "tag" key is removed. Images without a "digest" key cannot exclude their
+// tags, as charts render them as "repository:tag".
+func (h *helmchartWriter) encodeValuesDoc(
+	path string,
+	doc interface{},
+	key string,
+	images []*lockfile.HelmchartImage,
+	imagePosition *int,
+) (interface{}, error) {
+	switch doc := doc.(type) {
+	case yaml.MapSlice:
+		if h.isRepositoryImage(doc) {
+			image, err := h.nextImage(path, key, images, imagePosition)
+			if err != nil {
+				return nil, err
+			}
+
+			tag, digest := image.Tag, image.Digest
+
+			if digest == "" {
+				return doc, nil
+			}
+
+			digestIndex := -1
+			tagIndex := -1
+			repositoryIndex := -1
+
+			for i, item := range doc {
+				switch item.Key {
+				case "digest":
+					digestIndex = i
+				case "tag":
+					tagIndex = i
+				case "repository":
+					repositoryIndex = i
+				}
+			}
+
+			switch {
+			case h.excludeTags && digestIndex == -1:
+				return nil, fmt.Errorf(
+					"'%s' image '%s' does not have a 'digest' key, so its "+
+						"tag cannot be excluded", path, key,
+				)
+			case h.excludeTags:
+				doc[digestIndex].Value = fmt.Sprintf("sha256:%s", digest)
+
+				if tagIndex != -1 {
+					doc = append(doc[:tagIndex], doc[tagIndex+1:]...)
+				}
+			case digestIndex != -1:
+				doc[digestIndex].Value = fmt.Sprintf("sha256:%s", digest)
+			case tagIndex != -1:
+				doc[tagIndex].Value = fmt.Sprintf("%s@sha256:%s", tag, digest)
+			default:
+				tagItem := yaml.MapItem{
+					Key:   "tag",
+					Value: fmt.Sprintf("%s@sha256:%s", tag, digest),
+				}
+
+				doc = append(doc[:repositoryIndex+1], append(
+					yaml.MapSlice{tagItem}, doc[repositoryIndex+1:]...,
+				)...)
+			}
+
+			return doc, nil
+		}
+
+		for i, item := range doc {
+			itemKey := fmt.Sprint(item.Key)
+			if key != "" {
+				itemKey = fmt.Sprintf("%s.%s", key, itemKey)
+			}
+
+			if item.Key == "image" {
+				if imageLine, ok := item.Value.(string); ok {
+					if imageLine == "" {
+						continue
+					}
+
+					image, err := h.nextImage(
+						path, itemKey, images, imagePosition,
+					)
+					if err != nil {
+						return nil, err
+					}
+
+					doc[i].Value = h.imageLine(image)
+
+					continue
+				}
+			}
+
+			encodedValue, err := h.encodeValuesDoc(
+				path, item.Value, itemKey, images, imagePosition,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			doc[i].Value = encodedValue
+		}
+
+		return doc, nil
+	case []interface{}:
+		for i, item := range doc {
+			itemKey := fmt.Sprint(i)
+			if key != "" {
+				itemKey = fmt.Sprintf("%s.%s", key, itemKey)
+			}
+
+			encodedItem, err := h.encodeValuesDoc(
+				path, item, itemKey, images, imagePosition,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			doc[i] = encodedItem
+		}
+
+		return doc, nil
+	}
+
+	return doc, nil
+}
+
+func (h *helmchartWriter) nextImage(
+	path string,
+	key string,
+	images []*lockfile.HelmchartImage,
+	imagePosition *int,
+) (*lockfile.HelmchartImage, error) {
+	if *imagePosition >= len(images) {
+		return nil, fmt.Errorf(
+			"more images exist in '%s' than in the Lockfile", path,
+		)
+	}
+
+	image := images[*imagePosition]
+
+	if image.Key != "" && image.Key != key {
+		return nil, fmt.Errorf(
+			"in '%s', expected image with key '%s' but found '%s'",
+			path, image.Key, key,
+		)
+	}
+
+	*imagePosition++
+
+	return image, nil
+}
+
+func (h *helmchartWriter) imageLine(image *lockfile.HelmchartImage) string {
+	tag := image.Tag
+	if h.excludeTags {
+		tag = ""
+	}
+
+	return parse.NewImage(
+		h.kind, image.Name, tag, image.Digest, nil, nil,
+	).ImageLine()
+}
+
+func (h *helmchartWriter) isRepositoryImage(doc yaml.MapSlice) bool {
+	for _, item := range doc {
+		if item.Key == "repository" {
+			repository, _ := item.Value.(string)
+			return repository != ""
+		}
+	}
+
+	return false
+}
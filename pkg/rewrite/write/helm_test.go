@@ -0,0 +1,377 @@
+package write_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
+)
+
+func TestHelmchartWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name        string
+		Contents    [][]byte
+		Expected    [][]byte
+		PathImages  map[string][]*lockfile.HelmchartImage
+		ExcludeTags bool
+		ShouldFail  bool
+	}{
+		{
+			Name: "Values Repository And Tag",
+			Contents: [][]byte{
+				[]byte(`replicaCount: 1
+image:
+  repository: busybox
+  tag: latest
+redis:
+  image:
+    repository: redis
+    tag: latest
+    digest: ""
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "image",
+					},
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
+						Key:    "redis.image",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`replicaCount: 1
+image:
+  repository: busybox
+  tag: latest@sha256:busybox
+redis:
+  image:
+    repository: redis
+    tag: latest
+    digest: sha256:redis
+`),
+			},
+		},
+		{
+			Name: "Values Repository With App Version",
+			Contents: [][]byte{
+				[]byte(`image:
+  repository: busybox
+  pullPolicy: IfNotPresent
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "1.33.1",
+						Digest: "busybox",
+						Key:    "image",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`image:
+  repository: busybox
+  tag: 1.33.1@sha256:busybox
+  pullPolicy: IfNotPresent
+`),
+			},
+		},
+		{
+			Name: "Values Image Line",
+			Contents: [][]byte{
+				[]byte(`sidecars:
+- name: busybox
+  image: busybox
+- name: golang
+  image: golang
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "sidecars.0.image",
+					},
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang",
+						Key:    "sidecars.1.image",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`sidecars:
+- name: busybox
+  image: busybox:latest@sha256:busybox
+- name: golang
+  image: golang:latest@sha256:golang
+`),
+			},
+		},
+		{
+			Name: "Exclude Tags",
+			Contents: [][]byte{
+				[]byte(`image: busybox
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "image",
+					},
+				},
+			},
+			ExcludeTags: true,
+			Expected: [][]byte{
+				[]byte(`image: busybox@sha256:busybox
+`),
+			},
+		},
+		{
+			Name: "Exclude Tags Of Values Repository And Tag",
+			Contents: [][]byte{
+				[]byte(`image:
+  repository: busybox
+  tag: latest
+  digest: ""
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "image",
+					},
+				},
+			},
+			ExcludeTags: true,
+			Expected: [][]byte{
+				[]byte(`image:
+  repository: busybox
+  digest: sha256:busybox
+`),
+			},
+		},
+		{
+			Name: "Exclude Tags Of Values Repository Without Digest Key",
+			Contents: [][]byte{
+				[]byte(`image:
+  repository: busybox
+  tag: latest
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "image",
+					},
+				},
+			},
+			ExcludeTags: true,
+			ShouldFail:  true,
+		},
+		{
+			Name: "Template",
+			Contents: [][]byte{
+				[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ include "test.fullname" . }}
+spec:
+  containers:
+  - name: {{ .Chart.Name }}
+    image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+  - name: sidecar
+    image: "redis" # comment
+  - image: busybox
+    name: busybox
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				filepath.Join("templates", "pod.yaml"): {
+					{
+						Name:   "redis",
+						Tag:    "latest",
+						Digest: "redis",
+					},
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ include "test.fullname" . }}
+spec:
+  containers:
+  - name: {{ .Chart.Name }}
+    image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+  - name: sidecar
+    image: "redis:latest@sha256:redis" # comment
+  - image: busybox:latest@sha256:busybox
+    name: busybox
+`),
+			},
+		},
+		{
+			Name: "Different Key",
+			Contents: [][]byte{
+				[]byte(`image: busybox
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "sidecar.image",
+					},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "More Images In Values File",
+			Contents: [][]byte{
+				[]byte(`image: busybox
+sidecar:
+  image: golang
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				"values.yaml": {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+						Key:    "image",
+					},
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Fewer Images In Template",
+			Contents: [][]byte{
+				[]byte(`spec:
+  containers:
+  - image: busybox
+`),
+			},
+			PathImages: map[string][]*lockfile.HelmchartImage{
+				filepath.Join("templates", "pod.yaml"): {
+					{
+						Name:   "busybox",
+						Tag:    "latest",
+						Digest: "busybox",
+					},
+					{
+						Name:   "golang",
+						Tag:    "latest",
+						Digest: "golang",
+					},
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests { // nolint: dupl
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDirInCurrentDir(t)
+			defer os.RemoveAll(tempDir)
+
+			var pathsToWrite []string
+
+			tempPathImages := map[string][]*lockfile.HelmchartImage{}
+
+			for path, images := range test.PathImages {
+				pathsToWrite = append(pathsToWrite, path)
+
+				path = filepath.Join(tempDir, path)
+				tempPathImages[path] = images
+			}
+
+			sort.Strings(pathsToWrite)
+
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, pathsToWrite,
+			)
+			testutils.WriteFilesToTempDir(
+				t, tempDir, pathsToWrite, test.Contents,
+			)
+
+			writer := write.NewHelmchartWriter(test.ExcludeTags)
+
+			done := make(chan struct{})
+			defer close(done)
+
+			writtenPathResults := writer.WriteFiles(
+				&lockfile.Lockfile{Helmcharts: tempPathImages},
+				tempDir, done,
+			)
+
+			var got []string
+
+			var err error
+
+			for writtenPath := range writtenPathResults {
+				if writtenPath.Err() != nil {
+					err = writtenPath.Err()
+				}
+				got = append(got, writtenPath.NewPath())
+			}
+
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Strings(got)
+
+			testutils.AssertWrittenFilesEqual(t, test.Expected, got)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package write
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +10,7 @@ import (
 
 	"github.com/safe-waters/docker-lock/pkg/generate/parse"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"gopkg.in/yaml.v2"
 	"k8s.io/client-go/kubernetes/scheme"
 )
@@ -37,7 +37,7 @@ func (k *kubernetesfileWriter) Kind() kind.Kind {
 // original Kubernetesfiles and new images that should replace
 // the exsting ones.
 func (k *kubernetesfileWriter) WriteFiles( // nolint: dupl
-	pathImages map[string][]interface{},
+	lockfile *lockfile.Lockfile,
 	outputDir string,
 	done <-chan struct{},
 ) <-chan IWrittenPath {
@@ -51,7 +51,7 @@ func (k *kubernetesfileWriter) WriteFiles( // nolint: dupl
 	go func() {
 		defer waitGroup.Done()
 
-		for path, images := range pathImages {
+		for path, images := range lockfile.Kubernetesfiles {
 			path := path
 			images := images
 
@@ -89,7 +89,7 @@ func (k *kubernetesfileWriter) WriteFiles( // nolint: dupl
 
 func (k *kubernetesfileWriter) writeFile(
 	path string,
-	images []interface{},
+	images []*lockfile.KubernetesfileImage,
 	outputDir string,
 ) (string, error) {
 	byt, err := ioutil.ReadFile(path)
@@ -159,7 +159,7 @@ func (k *kubernetesfileWriter) writeFile(
 func (k *kubernetesfileWriter) encodeDoc(
 	path string,
 	doc interface{},
-	images []interface{},
+	images []*lockfile.KubernetesfileImage,
 	imagePosition *int,
 ) error {
 	switch doc := doc.(type) {
@@ -185,32 +185,15 @@ func (k *kubernetesfileWriter) encodeDoc(
 				)
 			}
 
-			image, ok := images[*imagePosition].(map[string]interface{})
-			if !ok {
-				return errors.New("malformed image")
-			}
-
-			tag, ok := image["tag"].(string)
-			if !ok {
-				return errors.New("malformed 'tag' in image")
-			}
+			image := images[*imagePosition]
 
+			tag := image.Tag
 			if k.excludeTags {
 				tag = ""
 			}
 
-			name, ok := image["name"].(string)
-			if !ok {
-				return errors.New("malformed 'name' in image")
-			}
-
-			digest, ok := image["digest"].(string)
-			if !ok {
-				return errors.New("malformed 'digest' in image")
-			}
-
 			imageLine := parse.NewImage(
-				k.kind, name, tag, digest, nil, nil,
+				k.kind, image.Name, tag, image.Digest, nil, nil,
 			).ImageLine()
 			doc[imageLineIndex].Value = imageLine
 
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
 )
 
@@ -17,7 +18,7 @@ func TestKubernetesfileWriter(t *testing.T) {
 		Name        string
 		Contents    [][]byte
 		Expected    [][]byte
-		PathImages  map[string][]interface{}
+		PathImages  map[string][]*lockfile.KubernetesfileImage
 		ExcludeTags bool
 		ShouldFail  bool
 	}{
@@ -42,19 +43,19 @@ spec:
     - containerPort: 88
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.KubernetesfileImage{
 				"pod.yml": {
-					map[string]interface{}{
-						"name":      "busybox",
-						"tag":       "latest",
-						"digest":    "busybox",
-						"container": "busybox",
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ContainerName: "busybox",
 					},
-					map[string]interface{}{
-						"name":      "golang",
-						"tag":       "latest",
-						"digest":    "golang",
-						"container": "golang",
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "golang",
+						ContainerName: "golang",
 					},
 				},
 			},
@@ -132,31 +133,31 @@ spec:
         name: bash
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.KubernetesfileImage{
 				"deployment.yaml": {
-					map[string]interface{}{
-						"name":      "golang",
-						"tag":       "latest",
-						"digest":    "golang",
-						"container": "golang",
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "golang",
+						ContainerName: "golang",
 					},
-					map[string]interface{}{
-						"name":      "python",
-						"tag":       "latest",
-						"digest":    "python",
-						"container": "python",
+					{
+						Name:          "python",
+						Tag:           "latest",
+						Digest:        "python",
+						ContainerName: "python",
 					},
-					map[string]interface{}{
-						"name":      "redis",
-						"tag":       "latest",
-						"digest":    "redis",
-						"container": "redis",
+					{
+						Name:          "redis",
+						Tag:           "latest",
+						Digest:        "redis",
+						ContainerName: "redis",
 					},
-					map[string]interface{}{
-						"name":      "bash",
-						"tag":       "latest",
-						"digest":    "bash",
-						"container": "bash",
+					{
+						Name:          "bash",
+						Tag:           "latest",
+						Digest:        "bash",
+						ContainerName: "bash",
 					},
 				},
 			},
@@ -317,57 +318,57 @@ spec:
         name: ruby
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.KubernetesfileImage{
 				"deployment.yaml": {
-					map[string]interface{}{
-						"name":      "golang",
-						"tag":       "latest",
-						"digest":    "golang",
-						"container": "golang",
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "golang",
+						ContainerName: "golang",
 					},
-					map[string]interface{}{
-						"name":      "python",
-						"tag":       "latest",
-						"digest":    "python",
-						"container": "python",
+					{
+						Name:          "python",
+						Tag:           "latest",
+						Digest:        "python",
+						ContainerName: "python",
 					},
-					map[string]interface{}{
-						"name":      "redis",
-						"tag":       "latest",
-						"digest":    "redis",
-						"container": "redis",
+					{
+						Name:          "redis",
+						Tag:           "latest",
+						Digest:        "redis",
+						ContainerName: "redis",
 					},
-					map[string]interface{}{
-						"name":      "bash",
-						"tag":       "latest",
-						"digest":    "bash",
-						"container": "bash",
+					{
+						Name:          "bash",
+						Tag:           "latest",
+						Digest:        "bash",
+						ContainerName: "bash",
 					},
 				},
 				"deployment1.yaml": {
-					map[string]interface{}{
-						"name":      "busybox",
-						"tag":       "latest",
-						"digest":    "busybox",
-						"container": "busybox",
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ContainerName: "busybox",
 					},
-					map[string]interface{}{
-						"name":      "java",
-						"tag":       "latest",
-						"digest":    "java",
-						"container": "java",
+					{
+						Name:          "java",
+						Tag:           "latest",
+						Digest:        "java",
+						ContainerName: "java",
 					},
-					map[string]interface{}{
-						"name":      "alpine",
-						"tag":       "latest",
-						"digest":    "alpine",
-						"container": "alpine",
+					{
+						Name:          "alpine",
+						Tag:           "latest",
+						Digest:        "alpine",
+						ContainerName: "alpine",
 					},
-					map[string]interface{}{
-						"name":      "ruby",
-						"tag":       "latest",
-						"digest":    "ruby",
-						"container": "ruby",
+					{
+						Name:          "ruby",
+						Tag:           "latest",
+						Digest:        "ruby",
+						ContainerName: "ruby",
 					},
 				},
 			},
@@ -491,13 +492,13 @@ spec:
     - containerPort: 80
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.KubernetesfileImage{
 				"pod.yaml": {
-					map[string]interface{}{
-						"name":      "busybox",
-						"tag":       "latest",
-						"digest":    "busybox",
-						"container": "busybox",
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ContainerName: "busybox",
 					},
 				},
 			},
@@ -539,25 +540,25 @@ spec:
     - containerPort: 88
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.KubernetesfileImage{
 				"pod.yaml": {
-					map[string]interface{}{
-						"name":      "busybox",
-						"tag":       "latest",
-						"digest":    "busybox",
-						"container": "busybox",
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ContainerName: "busybox",
 					},
-					map[string]interface{}{
-						"name":      "golang",
-						"tag":       "latest",
-						"digest":    "golang",
-						"container": "golang",
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "golang",
+						ContainerName: "golang",
 					},
-					map[string]interface{}{
-						"name":      "extra",
-						"tag":       "latest",
-						"digest":    "extra",
-						"container": "extra",
+					{
+						Name:          "extra",
+						Tag:           "latest",
+						Digest:        "extra",
+						ContainerName: "extra",
 					},
 				},
 			},
@@ -584,13 +585,13 @@ spec:
     - containerPort: 88
 `),
 			},
-			PathImages: map[string][]interface{}{
+			PathImages: map[string][]*lockfile.KubernetesfileImage{
 				"pod.yml": {
-					map[string]interface{}{
-						"name":      "busybox",
-						"tag":       "latest",
-						"digest":    "busybox",
-						"container": "busybox",
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ContainerName: "busybox",
 					},
 				},
 			},
@@ -609,7 +610,7 @@ spec:
 
 			var pathsToWrite []string
 
-			tempPathImages := map[string][]interface{}{}
+			tempPathImages := map[string][]*lockfile.KubernetesfileImage{}
 
 			for path, images := range test.PathImages {
 				pathsToWrite = append(pathsToWrite, path)
@@ -630,7 +631,8 @@ spec:
 			defer close(done)
 
 			writtenPathResults := writer.WriteFiles(
-				tempPathImages, tempDir, done,
+				&lockfile.Lockfile{Kubernetesfiles: tempPathImages},
+				tempDir, done,
 			)
 
 			var got []string
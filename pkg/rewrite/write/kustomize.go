@@ -0,0 +1,305 @@
+package write
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"gopkg.in/yaml.v2"
+)
+
+type kustomizationWriter struct {
+	kind        kind.Kind
+	excludeTags bool
+}
+
+// kustomizationPin is the name, tag, and digest that an "images" entry in a
+// kustomization file pins an image to.
+type kustomizationPin struct {
+	name   string
+	tag    string
+	digest string
+}
+
+// NewKustomizationWriter returns an IWriter for Kustomizations.
+func NewKustomizationWriter(excludeTags bool) IWriter {
+	return &kustomizationWriter{
+		kind:        kind.Kustomization,
+		excludeTags: excludeTags,
+	}
+}
+
+// Kind is a getter for the kind.
+func (k *kustomizationWriter) Kind() kind.Kind {
+	return k.kind
+}
+
+// WriteFiles writes new kustomization files given the paths of the
+// original files and new images that should replace the exsting ones.
+//
+// Instead of editing the manifests referenced by a kustomization file,
+// which may be shared by other overlays, images are pinned with entries
+// in the kustomization file's "images" transformer.
+func (k *kustomizationWriter) WriteFiles( // nolint: dupl
+	lockfile *lockfile.Lockfile,
+	outputDir string,
+	done <-chan struct{},
+) <-chan IWrittenPath {
+	var (
+		writtenPaths = make(chan IWrittenPath)
+		waitGroup    sync.WaitGroup
+	)
+
+	waitGroup.Add(1)
+
+	go func() {
+		defer waitGroup.Done()
+
+		for path, images := range lockfile.Kustomizations {
+			path := path
+			images := images
+
+			waitGroup.Add(1)
+
+			go func() {
+				defer waitGroup.Done()
+
+				writtenPath, err := k.writeFile(path, images, outputDir)
+				if err != nil {
+					select {
+					case <-done:
+					case writtenPaths <- NewWrittenPath("", "", err):
+					}
+
+					return
+				}
+
+				select {
+				case <-done:
+					return
+				case writtenPaths <- NewWrittenPath(path, writtenPath, nil):
+				}
+			}()
+		}
+	}()
+
+	go func() {
+		waitGroup.Wait()
+		close(writtenPaths)
+	}()
+
+	return writtenPaths
+}
+
+func (k *kustomizationWriter) writeFile(
+	path string,
+	images []*lockfile.KustomizationImage,
+	outputDir string,
+) (string, error) {
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var doc yaml.MapSlice
+	if err = yaml.Unmarshal(byt, &doc); err != nil {
+		return "", fmt.Errorf(
+			"'%s' failed to parse with err: %v", path, err,
+		)
+	}
+
+	pins, err := k.pins(path, images)
+	if err != nil {
+		return "", err
+	}
+
+	if doc, err = k.encodeDoc(path, doc, pins); err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer("/", "-", "\\", "-")
+	outputPath := replacer.Replace(fmt.Sprintf("%s-*", path))
+
+	writtenFile, err := ioutil.TempFile(outputDir, outputPath)
+	if err != nil {
+		return "", err
+	}
+	defer writtenFile.Close()
+
+	enc := yaml.NewEncoder(writtenFile)
+
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+
+	return writtenFile.Name(), enc.Close()
+}
+
+// pins returns one kustomizationPin per image name in the Lockfile, in the
+// order that the names first appear. Since an "images" entry applies to
+// every image with the same name, it is an error for images with the same
+// name to have different tags or digests.
+func (k *kustomizationWriter) pins(
+	path string,
+	images []*lockfile.KustomizationImage,
+) ([]*kustomizationPin, error) {
+	var (
+		pins       []*kustomizationPin
+		pinsByName = map[string]*kustomizationPin{}
+	)
+
+	for _, image := range images {
+		name, tag, digest := image.Name, image.Tag, image.Digest
+
+		if k.excludeTags {
+			tag = ""
+		}
+
+		if digest == "" {
+			continue
+		}
+
+		if pin, ok := pinsByName[name]; ok {
+			if pin.tag != tag || pin.digest != digest {
+				return nil, fmt.Errorf(
+					"in '%s', image '%s' has different tags or digests "+
+						"that cannot be pinned by a single 'images' entry",
+					path, name,
+				)
+			}
+
+			continue
+		}
+
+		pin := &kustomizationPin{name: name, tag: tag, digest: digest}
+
+		pinsByName[name] = pin
+		pins = append(pins, pin)
+	}
+
+	return pins, nil
+}
+
+// encodeDoc adds the pins to the "images" transformer of a kustomization
+// file. An existing entry is updated if its "newName", or its "name" if it
+// does not have a "newName", matches the name of the pin. Otherwise, a new
+// entry is appended.
+func (k *kustomizationWriter) encodeDoc(
+	path string,
+	doc yaml.MapSlice,
+	pins []*kustomizationPin,
+) (yaml.MapSlice, error) {
+	if len(pins) == 0 {
+		return doc, nil
+	}
+
+	imagesIndex := -1
+
+	for i, item := range doc {
+		if item.Key == "images" {
+			imagesIndex = i
+			break
+		}
+	}
+
+	var entries []interface{}
+
+	if imagesIndex != -1 && doc[imagesIndex].Value != nil {
+		var ok bool
+
+		entries, ok = doc[imagesIndex].Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("malformed 'images' in '%s'", path)
+		}
+	}
+
+	for _, pin := range pins {
+		entryIndex := -1
+
+		for i, entry := range entries {
+			entry, ok := entry.(yaml.MapSlice)
+			if !ok {
+				return nil, fmt.Errorf(
+					"malformed entry in 'images' in '%s'", path,
+				)
+			}
+
+			if k.entryName(entry) == pin.name {
+				entryIndex = i
+				break
+			}
+		}
+
+		if entryIndex == -1 {
+			entries = append(entries, yaml.MapSlice{
+				{Key: "name", Value: pin.name},
+			})
+			entryIndex = len(entries) - 1
+		}
+
+		entry := entries[entryIndex].(yaml.MapSlice)
+		entry = k.setEntryValue(entry, "newTag", pin.tag)
+		entry = k.setEntryValue(
+			entry, "digest", fmt.Sprintf("sha256:%s", pin.digest),
+		)
+		entries[entryIndex] = entry
+	}
+
+	if imagesIndex == -1 {
+		return append(doc, yaml.MapItem{Key: "images", Value: entries}), nil
+	}
+
+	doc[imagesIndex].Value = entries
+
+	return doc, nil
+}
+
+// entryName returns the name of the image that an "images" entry
+// produces.
+func (k *kustomizationWriter) entryName(entry yaml.MapSlice) string {
+	var name, newName string
+
+	for _, item := range entry {
+		switch item.Key {
+		case "name":
+			name, _ = item.Value.(string)
+		case "newName":
+			newName, _ = item.Value.(string)
+		}
+	}
+
+	if newName != "" {
+		return newName
+	}
+
+	return name
+}
+
+// setEntryValue sets a key in an "images" entry, removing the key if the
+// value is empty.
+func (k *kustomizationWriter) setEntryValue(
+	entry yaml.MapSlice,
+	key string,
+	value string,
+) yaml.MapSlice {
+	for i, item := range entry {
+		if item.Key == key {
+			if value == "" {
+				return append(entry[:i], entry[i+1:]...)
+			}
+
+			entry[i].Value = value
+
+			return entry
+		}
+	}
+
+	if value == "" {
+		return entry
+	}
+
+	return append(entry, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,244 @@
+package write_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
+)
+
+func TestKustomizationWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name        string
+		Contents    [][]byte
+		Expected    [][]byte
+		PathImages  map[string][]*lockfile.KustomizationImage
+		ExcludeTags bool
+		ShouldFail  bool
+	}{
+		{
+			Name: "New Images",
+			Contents: [][]byte{
+				[]byte(`resources:
+- ../../base
+namePrefix: prod-
+`),
+			},
+			PathImages: map[string][]*lockfile.KustomizationImage{
+				"kustomization.yaml": {
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "busybox",
+					},
+					{
+						Name:          "golang",
+						Tag:           "latest",
+						Digest:        "golang",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "golang",
+					},
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "sidecar",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`resources:
+- ../../base
+namePrefix: prod-
+images:
+- name: busybox
+  newTag: latest
+  digest: sha256:busybox
+- name: golang
+  newTag: latest
+  digest: sha256:golang
+`),
+			},
+		},
+		{
+			Name: "Existing Images",
+			Contents: [][]byte{
+				[]byte(`resources:
+- ../../base
+images:
+- name: busybox
+  newName: myregistry/busybox
+  newTag: "1.33"
+- name: redis
+  newTag: "6.0"
+`),
+			},
+			PathImages: map[string][]*lockfile.KustomizationImage{
+				"kustomization.yaml": {
+					{
+						Name:          "myregistry/busybox",
+						Tag:           "1.33",
+						Digest:        "busybox",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "busybox",
+					},
+					{
+						Name:          "redis",
+						Tag:           "6.0",
+						Digest:        "redis",
+						ManifestPath:  "base/pod.yaml",
+						ContainerName: "redis",
+					},
+				},
+			},
+			Expected: [][]byte{
+				[]byte(`resources:
+- ../../base
+images:
+- name: busybox
+  newName: myregistry/busybox
+  newTag: "1.33"
+  digest: sha256:busybox
+- name: redis
+  newTag: "6.0"
+  digest: sha256:redis
+`),
+			},
+		},
+		{
+			Name: "Exclude Tags",
+			Contents: [][]byte{
+				[]byte(`resources:
+- pod.yaml
+images:
+- name: busybox
+  newTag: latest
+`),
+			},
+			PathImages: map[string][]*lockfile.KustomizationImage{
+				"kustomization.yaml": {
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ManifestPath:  "pod.yaml",
+						ContainerName: "busybox",
+					},
+				},
+			},
+			ExcludeTags: true,
+			Expected: [][]byte{
+				[]byte(`resources:
+- pod.yaml
+images:
+- name: busybox
+  digest: sha256:busybox
+`),
+			},
+		},
+		{
+			Name: "Conflicting Digests",
+			Contents: [][]byte{
+				[]byte(`resources:
+- pod.yaml
+`),
+			},
+			PathImages: map[string][]*lockfile.KustomizationImage{
+				"kustomization.yaml": {
+					{
+						Name:          "busybox",
+						Tag:           "latest",
+						Digest:        "busybox",
+						ManifestPath:  "pod.yaml",
+						ContainerName: "busybox",
+					},
+					{
+						Name:          "busybox",
+						Tag:           "1.33",
+						Digest:        "busybox1",
+						ManifestPath:  "pod.yaml",
+						ContainerName: "sidecar",
+					},
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for _, test := range tests { // nolint: dupl
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDirInCurrentDir(t)
+			defer os.RemoveAll(tempDir)
+
+			var pathsToWrite []string
+
+			tempPathImages := map[string][]*lockfile.KustomizationImage{}
+
+			for path, images := range test.PathImages {
+				pathsToWrite = append(pathsToWrite, path)
+
+				path = filepath.Join(tempDir, path)
+				tempPathImages[path] = images
+			}
+
+			sort.Strings(pathsToWrite)
+
+			testutils.MakeParentDirsInTempDirFromFilePaths(
+				t, tempDir, pathsToWrite,
+			)
+			testutils.WriteFilesToTempDir(
+				t, tempDir, pathsToWrite, test.Contents,
+			)
+
+			writer := write.NewKustomizationWriter(test.ExcludeTags)
+
+			done := make(chan struct{})
+			defer close(done)
+
+			writtenPathResults := writer.WriteFiles(
+				&lockfile.Lockfile{Kustomizations: tempPathImages},
+				tempDir, done,
+			)
+
+			var got []string
+
+			var err error
+
+			for writtenPath := range writtenPathResults {
+				if writtenPath.Err() != nil {
+					err = writtenPath.Err()
+				}
+				got = append(got, writtenPath.NewPath())
+			}
+
+			if test.ShouldFail {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Strings(got)
+
+			testutils.AssertWrittenFilesEqual(t, test.Expected, got)
+		})
+	}
+}
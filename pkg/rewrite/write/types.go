@@ -1,14 +1,18 @@
 // Package write provides functionality to write files with image digests.
 package write
 
-import "github.com/safe-waters/docker-lock/pkg/kind"
+import (
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
 
 // IWriter provides an interface for Writers, which are responsible for
-// writing files with information from a Lockfile to paths in outputDir.
+// writing files of their kind with information from a Lockfile to paths in
+// outputDir.
 type IWriter interface {
 	Kind() kind.Kind
 	WriteFiles(
-		pathImages map[string][]interface{},
+		lockfile *lockfile.Lockfile,
 		outputDir string,
 		done <-chan struct{},
 	) <-chan IWrittenPath
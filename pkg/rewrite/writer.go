@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
 )
 
@@ -34,7 +35,7 @@ func NewWriter(writers ...write.IWriter) (IWriter, error) {
 
 // WriteFiles writes files with images from a Lockfile.
 func (w *writer) WriteFiles(
-	lockfile map[kind.Kind]map[string][]interface{},
+	lockfile *lockfile.Lockfile,
 	tempDir string,
 	done <-chan struct{},
 ) <-chan write.IWrittenPath {
@@ -52,8 +53,7 @@ func (w *writer) WriteFiles(
 	go func() {
 		defer waitGroup.Done()
 
-		for kind, writer := range w.writers {
-			kind := kind
+		for _, writer := range w.writers {
 			writer := writer
 
 			waitGroup.Add(1)
@@ -62,7 +62,7 @@ func (w *writer) WriteFiles(
 				defer waitGroup.Done()
 
 				for writtenPath := range writer.WriteFiles(
-					lockfile[kind], tempDir, done,
+					lockfile, tempDir, done,
 				) {
 					select {
 					case <-done:
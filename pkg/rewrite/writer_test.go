@@ -7,7 +7,7 @@ import (
 	"testing"
 
 	"github.com/safe-waters/docker-lock/internal/testutils"
-	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/rewrite"
 	"github.com/safe-waters/docker-lock/pkg/rewrite/write"
 )
@@ -17,40 +17,40 @@ func TestWriter(t *testing.T) {
 
 	tests := []struct {
 		Name       string
-		Lockfile   map[kind.Kind]map[string][]interface{}
+		Lockfile   *lockfile.Lockfile
 		Contents   [][]byte
 		Expected   [][]byte
 		ShouldFail bool
 	}{
 		{
 			Name: "Dockerfile, Composefile, And Kubernetesfile",
-			Lockfile: map[kind.Kind]map[string][]interface{}{
-				kind.Dockerfile: {
+			Lockfile: &lockfile.Lockfile{
+				Dockerfiles: map[string][]*lockfile.DockerfileImage{
 					"Dockerfile": {
-						map[string]interface{}{
-							"name":   "golang",
-							"tag":    "latest",
-							"digest": "golang",
+						{
+							Name:   "golang",
+							Tag:    "latest",
+							Digest: "golang",
 						},
 					},
 				},
-				kind.Composefile: {
+				Composefiles: map[string][]*lockfile.ComposefileImage{
 					"docker-compose.yml": {
-						map[string]interface{}{
-							"name":    "busybox",
-							"tag":     "latest",
-							"digest":  "busybox",
-							"service": "svc-compose",
+						{
+							Name:        "busybox",
+							Tag:         "latest",
+							Digest:      "busybox",
+							ServiceName: "svc-compose",
 						},
 					},
 				},
-				kind.Kubernetesfile: {
+				Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
 					"pod.yml": {
-						map[string]interface{}{
-							"name":      "redis",
-							"tag":       "latest",
-							"digest":    "redis",
-							"container": "redis",
+						{
+							Name:          "redis",
+							Tag:           "latest",
+							Digest:        "redis",
+							ContainerName: "redis",
 						},
 					},
 				},
@@ -120,20 +120,14 @@ spec:
 
 			uniquePathsToWrite := map[string]struct{}{}
 
-			lockfileWithTempDir := map[kind.Kind]map[string][]interface{}{}
+			lockfileWithTempDir := lockfile.New()
 
-			lockfileWithTempDir[kind.Dockerfile] = map[string][]interface{}{}
-			lockfileWithTempDir[kind.Kubernetesfile] = map[string][]interface{}{} // nolint: lll
-			lockfileWithTempDir[kind.Composefile] = map[string][]interface{}{}
-
-			for composefilePath, images := range test.Lockfile[kind.Composefile] { // nolint: lll
+			for composefilePath, images := range test.Lockfile.Composefiles {
 				for _, image := range images {
-					image := image.(map[string]interface{})
-					dockerfilePath := image["dockerfile"]
-					if dockerfilePath != nil {
-						dockerfilePath := dockerfilePath.(string)
+					if image.DockerfilePath != "" {
+						dockerfilePath := image.DockerfilePath
 						uniquePathsToWrite[dockerfilePath] = struct{}{}
-						image["dockerfile"] = filepath.Join(
+						image.DockerfilePath = filepath.Join(
 							tempDir, dockerfilePath,
 						)
 					}
@@ -142,21 +136,21 @@ spec:
 				uniquePathsToWrite[composefilePath] = struct{}{}
 
 				composefilePath = filepath.Join(tempDir, composefilePath)
-				lockfileWithTempDir[kind.Composefile][composefilePath] = images
+				lockfileWithTempDir.Composefiles[composefilePath] = images
 			}
 
-			for dockerfilePath, images := range test.Lockfile[kind.Dockerfile] {
+			for dockerfilePath, images := range test.Lockfile.Dockerfiles {
 				uniquePathsToWrite[dockerfilePath] = struct{}{}
 
 				dockerfilePath = filepath.Join(tempDir, dockerfilePath)
-				lockfileWithTempDir[kind.Dockerfile][dockerfilePath] = images
+				lockfileWithTempDir.Dockerfiles[dockerfilePath] = images
 			}
 
-			for kubernetesfilePath, images := range test.Lockfile[kind.Kubernetesfile] { // nolint: lll
+			for kubernetesfilePath, images := range test.Lockfile.Kubernetesfiles { // nolint: lll
 				uniquePathsToWrite[kubernetesfilePath] = struct{}{}
 
 				kubernetesfilePath = filepath.Join(tempDir, kubernetesfilePath)
-				lockfileWithTempDir[kind.Kubernetesfile][kubernetesfilePath] = images // nolint: lll
+				lockfileWithTempDir.Kubernetesfiles[kubernetesfilePath] = images
 			}
 
 			var pathsToWrite []string
@@ -170,7 +164,7 @@ spec:
 				t, tempDir, pathsToWrite, test.Contents,
 			)
 
-			dockerfileWriter := write.NewDockerfileWriter(false)
+			dockerfileWriter := write.NewDockerfileWriter(false, nil)
 			composefileWriter, err := write.NewComposefileWriter(
 				dockerfileWriter, false,
 			)
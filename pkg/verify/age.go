@@ -0,0 +1,136 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type ageVerifier struct {
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// StaleImage is an image in a Lockfile whose digest is older than the
+// maximum age. Created is nil if the Lockfile did not record when the image
+// was created, in which case its age is unknown.
+type StaleImage struct {
+	Kind    kind.Kind     `json:"kind"`
+	Path    string        `json:"path"`
+	Index   int           `json:"index"`
+	Name    string        `json:"name"`
+	Tag     string        `json:"tag"`
+	Digest  string        `json:"digest"`
+	Created *time.Time    `json:"created,omitempty"`
+	Age     time.Duration `json:"age,omitempty"`
+}
+
+// NewAgeVerifier returns an IAgeVerifier after validating its fields.
+// maxAge must be positive. now returns the time that ages are measured
+// from. If now is nil, time.Now is used.
+func NewAgeVerifier(
+	maxAge time.Duration,
+	now func() time.Time,
+) (IAgeVerifier, error) {
+	if maxAge <= 0 {
+		return nil, errors.New("'maxAge' must be positive")
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &ageVerifier{maxAge: maxAge, now: now}, nil
+}
+
+// StaleImages reads an existing Lockfile and returns every image with a
+// digest that was created more than the maximum age ago, or whose creation
+// time was not recorded, sorted by kind, path, and image index.
+func (a *ageVerifier) StaleImages(
+	lockfileReader io.Reader,
+) ([]*StaleImage, error) {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return nil, errors.New("'lockfileReader' cannot be nil")
+	}
+
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	staleImages := []*StaleImage{}
+
+	for _, image := range existingLockfile.Images() {
+		if *image.Digest == "" {
+			continue
+		}
+
+		staleImage := &StaleImage{
+			Kind:    image.Kind,
+			Path:    image.Path,
+			Index:   image.Index,
+			Name:    *image.Name,
+			Tag:     *image.Tag,
+			Digest:  *image.Digest,
+			Created: *image.Created,
+		}
+
+		if staleImage.Created != nil {
+			staleImage.Age = now.Sub(*staleImage.Created)
+			if staleImage.Age <= a.maxAge {
+				continue
+			}
+		}
+
+		staleImages = append(staleImages, staleImage)
+	}
+
+	return staleImages, nil
+}
+
+// String returns a human readable description of the StaleImage.
+func (s *StaleImage) String() string {
+	location := imageLocation(
+		s.Kind, s.Path, s.Index, s.Name, s.Tag, s.Digest,
+	)
+
+	if s.Created == nil {
+		return fmt.Sprintf(
+			"%s, does not have a recorded creation time", location,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s, was created at %s, %d day(s) ago",
+		location, s.Created.UTC().Format(time.RFC3339),
+		int(s.Age.Hours()/24), // nolint: gomnd
+	)
+}
+
+// imageLocation describes where an image with a digest is in a Lockfile,
+// such as "on path 'Dockerfile' of kind 'Dockerfile', image '0',
+// 'golang:1.16@sha256:...'".
+func imageLocation(
+	k kind.Kind,
+	path string,
+	index int,
+	name string,
+	tag string,
+	digest string,
+) string {
+	reference := name
+	if tag != "" {
+		reference = fmt.Sprintf("%s:%s", reference, tag)
+	}
+
+	return fmt.Sprintf(
+		"on path '%s' of kind '%s', image '%d', '%s@sha256:%s'",
+		path, k, index, reference, digest,
+	)
+}
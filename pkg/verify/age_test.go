@@ -0,0 +1,384 @@
+package verify_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
+	"github.com/safe-waters/docker-lock/internal/testutils"
+	"github.com/safe-waters/docker-lock/pkg/generate"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify"
+)
+
+func TestAgeVerifier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now    = time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)
+		recent = now.Add(-24 * time.Hour)
+		old    = now.Add(-60 * 24 * time.Hour)
+	)
+
+	existingLockfile := &lockfile.Lockfile{
+		SchemaVersion: lockfile.SchemaVersion,
+		Dockerfiles: map[string][]*lockfile.DockerfileImage{
+			"Dockerfile": {
+				{Name: "busybox", Tag: "latest", Digest: "busybox"},
+				{
+					Name: "golang", Tag: "1.16", Digest: "golang",
+					Created: &old,
+				},
+				{Name: "scratch"},
+			},
+		},
+		Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+			"pod.yml": {
+				{
+					Name: "redis", Tag: "6.0", Digest: "redis",
+					Created: &recent,
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		Name        string
+		MaxAge      time.Duration
+		Expected    []*verify.StaleImage
+		ShouldError bool
+	}{
+		{
+			Name:   "Stale Images",
+			MaxAge: 30 * 24 * time.Hour,
+			Expected: []*verify.StaleImage{
+				{
+					Kind:   kind.Dockerfile,
+					Path:   "Dockerfile",
+					Index:  0,
+					Name:   "busybox",
+					Tag:    "latest",
+					Digest: "busybox",
+				},
+				{
+					Kind:    kind.Dockerfile,
+					Path:    "Dockerfile",
+					Index:   1,
+					Name:    "golang",
+					Tag:     "1.16",
+					Digest:  "golang",
+					Created: &old,
+					Age:     60 * 24 * time.Hour,
+				},
+			},
+		},
+		{
+			Name:   "Recent Images",
+			MaxAge: 12 * time.Hour,
+			Expected: []*verify.StaleImage{
+				{
+					Kind:   kind.Dockerfile,
+					Path:   "Dockerfile",
+					Index:  0,
+					Name:   "busybox",
+					Tag:    "latest",
+					Digest: "busybox",
+				},
+				{
+					Kind:    kind.Dockerfile,
+					Path:    "Dockerfile",
+					Index:   1,
+					Name:    "golang",
+					Tag:     "1.16",
+					Digest:  "golang",
+					Created: &old,
+					Age:     60 * 24 * time.Hour,
+				},
+				{
+					Kind:    kind.Kubernetesfile,
+					Path:    "pod.yml",
+					Index:   0,
+					Name:    "redis",
+					Tag:     "6.0",
+					Digest:  "redis",
+					Created: &recent,
+					Age:     24 * time.Hour,
+				},
+			},
+		},
+		{
+			Name:        "Non Positive Max Age",
+			MaxAge:      0,
+			ShouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ageVerifier, err := verify.NewAgeVerifier(
+				test.MaxAge, func() time.Time { return now },
+			)
+			if test.ShouldError {
+				if err == nil {
+					t.Fatal("expected error but did not get one")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := existingLockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ageVerifier.StaleImages(&lockfileByt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(expectedByt, gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
+
+func TestStaleImageString(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name       string
+		StaleImage *verify.StaleImage
+		Expected   string
+	}{
+		{
+			Name: "Created",
+			StaleImage: &verify.StaleImage{
+				Kind:    kind.Dockerfile,
+				Path:    "Dockerfile",
+				Index:   1,
+				Name:    "golang",
+				Tag:     "1.16",
+				Digest:  "golang",
+				Created: &created,
+				Age:     45*24*time.Hour + time.Hour,
+			},
+			Expected: "on path 'Dockerfile' of kind 'dockerfiles', image '1', " +
+				"'golang:1.16@sha256:golang', was created at " +
+				"2021-03-01T12:00:00Z, 45 day(s) ago",
+		},
+		{
+			Name: "Not Created",
+			StaleImage: &verify.StaleImage{
+				Kind:   kind.Kubernetesfile,
+				Path:   "pod.yml",
+				Name:   "redis",
+				Tag:    "6.0",
+				Digest: "redis",
+			},
+			Expected: "on path 'pod.yml' of kind 'kubernetesfiles', " +
+				"image '0', 'redis:6.0@sha256:redis', does not have a " +
+				"recorded creation time",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.StaleImage.String(); test.Expected != got {
+				t.Fatalf("expected %s, got %s", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestAgeVerifierWithGeneratedLockfile(t *testing.T) {
+	t.Parallel()
+
+	created, err := time.Parse(time.RFC3339, testutils.LatestCreated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name     string
+		MaxAge   time.Duration
+		Expected int
+	}{
+		{
+			Name:   "Recent Images",
+			MaxAge: 30 * 24 * time.Hour,
+		},
+		{
+			Name:     "Stale Images",
+			MaxAge:   time.Hour,
+			Expected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			tempDir := testutils.MakeTempDirInCurrentDir(t)
+			defer os.RemoveAll(tempDir)
+
+			// busybox is in the Dockerfile twice, so that its creation time
+			// must be recorded for both images although it is queried once.
+			dockerfilePath := testutils.WriteFilesToTempDir(
+				t, tempDir, []string{"Dockerfile"}, [][]byte{
+					[]byte(
+						"FROM busybox:latest\n" +
+							"FROM busybox:latest\n" +
+							"FROM redis:latest\n",
+					),
+				},
+			)[0]
+
+			generator := newCreatedGenerator(t, dockerfilePath)
+
+			var lockfileByt bytes.Buffer
+			if err := generator.GenerateLockfile(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			ageVerifier, err := verify.NewAgeVerifier(
+				test.MaxAge, func() time.Time {
+					return created.Add(24 * time.Hour)
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			staleImages, err := ageVerifier.StaleImages(&lockfileByt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.Expected != len(staleImages) {
+				t.Fatalf(
+					"expected %d stale images, got %d",
+					test.Expected, len(staleImages),
+				)
+			}
+
+			for _, staleImage := range staleImages {
+				if staleImage.Created == nil ||
+					!staleImage.Created.Equal(created) {
+					t.Fatalf(
+						"expected created %s, got %v",
+						created, staleImage.Created,
+					)
+				}
+			}
+		})
+	}
+}
+
+func newCreatedGenerator(
+	t *testing.T,
+	dockerfilePath string,
+) generate.IGenerator {
+	t.Helper()
+
+	generatorFlags, err := cmd_generate.NewFlags(
+		&cmd_generate.FlagsWithSharedValues{
+			BaseDir:       ".",
+			RecordCreated: true,
+		},
+		&cmd_generate.FlagsWithSharedNames{
+			ManualPaths: []string{dockerfilePath},
+		},
+		&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+		&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+		&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+		&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+		&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+		false, nil, nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector, err := cmd_generate.DefaultPathCollector(generatorFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := cmd_generate.DefaultImageParser(generatorFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker, err := cmd_generate.DefaultImagePolicyChecker(generatorFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digestRequester := testutils.NewMockDigestRequester(t, nil)
+
+	imageDigestUpdater, err := update.NewImageDigestUpdater(
+		digestRequester, false, false, false, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imageDigestUpdater, err = update.NewImageCreatedUpdater(
+		imageDigestUpdater,
+		digestRequester.(update.ICreatedRequester), 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updater, err := generate.NewImageDigestUpdater(imageDigestUpdater)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formatter, err := cmd_generate.DefaultImageFormatter(generatorFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := generate.NewGenerator(
+		collector, parser, checker, updater, formatter,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return generator
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"errors"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type bakefileImageDifferentiator struct {
+	imageDifferentiator *imageDifferentiator
+}
+
+// NewBakefileDifferentiator returns an IImageDifferentiator for Bakefiles.
+func NewBakefileDifferentiator(excludeTags bool) IImageDifferentiator {
+	return &bakefileImageDifferentiator{
+		imageDifferentiator: &imageDifferentiator{
+			kind:        kind.Bakefile,
+			excludeTags: excludeTags,
+		},
+	}
+}
+
+// DifferentiateImages reports every difference between Bakefiles in the
+// existing and new Lockfiles. An image whose name, "dockerfile", "context",
+// "target", "platform", or "instruction" differs is reported as removed and
+// added.
+func (b *bakefileImageDifferentiator) DifferentiateImages( // nolint: dupl
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+) ([]*Difference, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
+	}
+
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
+	}
+
+	return b.imageDifferentiator.differentiatePaths(
+		b.comparedPathImages(existingLockfile.Bakefiles),
+		b.comparedPathImages(newLockfile.Bakefiles),
+	), nil
+}
+
+// Kind is a getter for the kind.
+func (b *bakefileImageDifferentiator) Kind() kind.Kind {
+	return b.imageDifferentiator.kind
+}
+
+func (b *bakefileImageDifferentiator) comparedPathImages(
+	pathImages map[string][]*lockfile.BakefileImage,
+) map[string][]*comparedImage {
+	comparedPathImages := make(map[string][]*comparedImage, len(pathImages))
+
+	for path, images := range pathImages {
+		for _, image := range images {
+			comparedPathImages[path] = append(
+				comparedPathImages[path], &comparedImage{
+					image: &Image{
+						Name:   image.Name,
+						Tag:    image.Tag,
+						Digest: image.Digest,
+					},
+					identity: []string{
+						image.Name,
+						image.DockerfilePath,
+						image.ContextName,
+						image.TargetName,
+						image.Platform,
+						image.Instruction,
+					},
+				},
+			)
+		}
+	}
+
+	return comparedPathImages
+}
@@ -0,0 +1,189 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+func TestBakefileDifferentiator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Existing     *lockfile.BakefileImage
+		New          *lockfile.BakefileImage
+		ExcludeTags  bool
+		ShouldDiffer bool
+	}{
+		{
+			Name: "Different Name",
+			Existing: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			New: &lockfile.BakefileImage{
+				Name:       "redis",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Tag",
+			Existing: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			New: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "busybox",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Digest",
+			Existing: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			New: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "unknown",
+				TargetName: "app",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Target",
+			Existing: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			New: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app1",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Dockerfile",
+			Existing: &lockfile.BakefileImage{
+				Name:           "busybox",
+				Tag:            "latest",
+				Digest:         "busybox",
+				TargetName:     "app",
+				DockerfilePath: "Dockerfile",
+			},
+			New: &lockfile.BakefileImage{
+				Name:           "busybox",
+				Tag:            "latest",
+				Digest:         "busybox",
+				TargetName:     "app",
+				DockerfilePath: "Dockerfile1",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Context",
+			Existing: &lockfile.BakefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				TargetName:  "app",
+				ContextName: "busybox",
+			},
+			New: &lockfile.BakefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				TargetName:  "app",
+				ContextName: "base",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Exclude Tags",
+			Existing: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			New: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "unknown",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			ExcludeTags:  true,
+			ShouldDiffer: false,
+		},
+		{
+			Name: "Normal",
+			Existing: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			New: &lockfile.BakefileImage{
+				Name:       "busybox",
+				Tag:        "latest",
+				Digest:     "busybox",
+				TargetName: "app",
+			},
+			ShouldDiffer: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			differentiator := diff.NewBakefileDifferentiator(
+				test.ExcludeTags,
+			)
+			differences, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{
+					Bakefiles: map[string][]*lockfile.BakefileImage{
+						"docker-bake.hcl": {test.Existing},
+					},
+				},
+				&lockfile.Lockfile{
+					Bakefiles: map[string][]*lockfile.BakefileImage{
+						"docker-bake.hcl": {test.New},
+					},
+				},
+			)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.ShouldDiffer != (len(differences) != 0) {
+				t.Fatalf(
+					"expected differences '%t', got '%v'",
+					test.ShouldDiffer, differences,
+				)
+			}
+		})
+	}
+}
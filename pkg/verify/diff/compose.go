@@ -4,11 +4,10 @@ import (
 	"errors"
 
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type composefileImageDifferentiator struct {
-	kind                kind.Kind
-	excludeTags         bool
 	imageDifferentiator *imageDifferentiator
 }
 
@@ -16,40 +15,64 @@ type composefileImageDifferentiator struct {
 // Composefiles.
 func NewComposefileDifferentiator(excludeTags bool) IImageDifferentiator {
 	return &composefileImageDifferentiator{
-		kind:                kind.Composefile,
-		excludeTags:         excludeTags,
-		imageDifferentiator: &imageDifferentiator{},
+		imageDifferentiator: &imageDifferentiator{
+			kind:        kind.Composefile,
+			excludeTags: excludeTags,
+		},
 	}
 }
 
-// DifferentiateImage reports differences between images in the fields
-// "name", "tag", "digest", "dockerfile", and "service".
-func (c *composefileImageDifferentiator) DifferentiateImage(
-	existingImage map[string]interface{},
-	newImage map[string]interface{},
-) error {
-	if existingImage == nil {
-		return errors.New("'existingImage' cannot be nil")
+// DifferentiateImages reports every difference between Composefiles in the
+// existing and new Lockfiles. An image whose name, "dockerfile", "service",
+// "platform", or "instruction" differs is reported as removed and added.
+func (c *composefileImageDifferentiator) DifferentiateImages( // nolint: dupl
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+) ([]*Difference, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
 	}
 
-	if newImage == nil {
-		return errors.New("'newImage' cannot be nil")
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
 	}
 
-	var diffFields = []string{"name", "tag", "digest", "dockerfile", "service"}
-
-	if c.excludeTags {
-		const tagIndex = 1
-
-		diffFields = append(diffFields[:tagIndex], diffFields[tagIndex+1:]...)
-	}
-
-	return c.imageDifferentiator.differentiateImage(
-		existingImage, newImage, diffFields,
-	)
+	return c.imageDifferentiator.differentiatePaths(
+		c.comparedPathImages(existingLockfile.Composefiles),
+		c.comparedPathImages(newLockfile.Composefiles),
+	), nil
 }
 
 // Kind is a getter for the kind.
 func (c *composefileImageDifferentiator) Kind() kind.Kind {
-	return c.kind
+	return c.imageDifferentiator.kind
+}
+
+func (c *composefileImageDifferentiator) comparedPathImages(
+	pathImages map[string][]*lockfile.ComposefileImage,
+) map[string][]*comparedImage {
+	comparedPathImages := make(map[string][]*comparedImage, len(pathImages))
+
+	for path, images := range pathImages {
+		for _, image := range images {
+			comparedPathImages[path] = append(
+				comparedPathImages[path], &comparedImage{
+					image: &Image{
+						Name:   image.Name,
+						Tag:    image.Tag,
+						Digest: image.Digest,
+					},
+					identity: []string{
+						image.Name,
+						image.DockerfilePath,
+						image.ServiceName,
+						image.Platform,
+						image.Instruction,
+					},
+				},
+			)
+		}
+	}
+
+	return comparedPathImages
 }
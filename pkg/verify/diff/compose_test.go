@@ -3,6 +3,7 @@ package diff_test
 import (
 	"testing"
 
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/verify/diff"
 )
 
@@ -10,126 +11,126 @@ func TestComposefileDifferentiator(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		Name        string
-		Existing    map[string]interface{}
-		New         map[string]interface{}
-		ExcludeTags bool
-		ShouldFail  bool
+		Name         string
+		Existing     *lockfile.ComposefileImage
+		New          *lockfile.ComposefileImage
+		ExcludeTags  bool
+		ShouldDiffer bool
 	}{
 		{
 			Name: "Different Name",
-			Existing: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			Existing: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			New: map[string]interface{}{
-				"name":    "redis",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			New: &lockfile.ComposefileImage{
+				Name:        "redis",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Tag",
-			Existing: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			Existing: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			New: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "busybox",
-				"digest":  "busybox",
-				"service": "svc",
+			New: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "busybox",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Digest",
-			Existing: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			Existing: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			New: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "unknown",
-				"service": "svc",
+			New: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "unknown",
+				ServiceName: "svc",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Service",
-			Existing: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			Existing: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			New: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc1",
+			New: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc1",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Dockerfile",
-			Existing: map[string]interface{}{
-				"name":       "busybox",
-				"tag":        "latest",
-				"digest":     "busybox",
-				"service":    "svc",
-				"dockerfile": "Dockerfile",
+			Existing: &lockfile.ComposefileImage{
+				Name:           "busybox",
+				Tag:            "latest",
+				Digest:         "busybox",
+				ServiceName:    "svc",
+				DockerfilePath: "Dockerfile",
 			},
-			New: map[string]interface{}{
-				"name":       "busybox",
-				"tag":        "latest",
-				"digest":     "busybox",
-				"service":    "svc",
-				"dockerfile": "Dockerfile1",
+			New: &lockfile.ComposefileImage{
+				Name:           "busybox",
+				Tag:            "latest",
+				Digest:         "busybox",
+				ServiceName:    "svc",
+				DockerfilePath: "Dockerfile1",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Exclude Tags",
-			Existing: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			Existing: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			New: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "unknown",
-				"digest":  "busybox",
-				"service": "svc",
+			New: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "unknown",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			ExcludeTags: true,
-			ShouldFail:  false,
+			ExcludeTags:  true,
+			ShouldDiffer: false,
 		},
 		{
 			Name: "Normal",
-			Existing: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			Existing: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			New: map[string]interface{}{
-				"name":    "busybox",
-				"tag":     "latest",
-				"digest":  "busybox",
-				"service": "svc",
+			New: &lockfile.ComposefileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				ServiceName: "svc",
 			},
-			ShouldFail: false,
+			ShouldDiffer: false,
 		},
 	}
 
@@ -142,19 +143,29 @@ func TestComposefileDifferentiator(t *testing.T) {
 			differentiator := diff.NewComposefileDifferentiator(
 				test.ExcludeTags,
 			)
-			err := differentiator.DifferentiateImage(test.Existing, test.New)
-
-			if test.ShouldFail {
-				if err == nil {
-					t.Fatal("expected error but did not get one")
-				}
-
-				return
-			}
+			differences, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{
+					Composefiles: map[string][]*lockfile.ComposefileImage{
+						"docker-compose.yml": {test.Existing},
+					},
+				},
+				&lockfile.Lockfile{
+					Composefiles: map[string][]*lockfile.ComposefileImage{
+						"docker-compose.yml": {test.New},
+					},
+				},
+			)
 
 			if err != nil {
 				t.Fatal(err)
 			}
+
+			if test.ShouldDiffer != (len(differences) != 0) {
+				t.Fatalf(
+					"expected differences '%t', got '%v'",
+					test.ShouldDiffer, differences,
+				)
+			}
 		})
 	}
 }
@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+)
+
+// DifferenceType classifies how an image changed between the existing and
+// new Lockfiles.
+type DifferenceType string
+
+// DifferenceTypes of images.
+const (
+	Added         DifferenceType = "added"
+	Removed       DifferenceType = "removed"
+	TagChanged    DifferenceType = "tagChanged"
+	DigestChanged DifferenceType = "digestChanged"
+)
+
+// Image is the name, tag, and digest of an image in a Lockfile.
+type Image struct {
+	Name   string `json:"name"`
+	Tag    string `json:"tag"`
+	Digest string `json:"digest"`
+}
+
+// Difference is a difference between the image at Index of Path in the
+// existing and new Lockfiles. Existing is nil if the image was added, and
+// New is nil if the image was removed.
+type Difference struct {
+	Kind     kind.Kind      `json:"kind"`
+	Path     string         `json:"path"`
+	Index    int            `json:"index"`
+	Type     DifferenceType `json:"type"`
+	Existing *Image         `json:"existing,omitempty"`
+	New      *Image         `json:"new,omitempty"`
+}
+
+// String returns a human readable description of the Difference.
+func (d *Difference) String() string {
+	location := fmt.Sprintf("on path '%s' of kind '%s'", d.Path, d.Kind)
+
+	switch d.Type {
+	case Added:
+		return fmt.Sprintf(
+			"%s, image '%d', '%s', was added", location, d.Index, d.New,
+		)
+	case Removed:
+		return fmt.Sprintf(
+			"%s, image '%d', '%s', was removed",
+			location, d.Index, d.Existing,
+		)
+	case TagChanged:
+		return fmt.Sprintf(
+			"%s, the tag of image '%d', '%s', changed from '%s' to '%s'",
+			location, d.Index, d.Existing.Name, d.Existing.Tag, d.New.Tag,
+		)
+	case DigestChanged:
+		return fmt.Sprintf(
+			"%s, the digest of image '%d', '%s:%s', changed from '%s' to '%s'",
+			location, d.Index, d.Existing.Name, d.Existing.Tag,
+			d.Existing.Digest, d.New.Digest,
+		)
+	default:
+		return fmt.Sprintf(
+			"%s, image '%d' has an unknown difference '%s'",
+			location, d.Index, d.Type,
+		)
+	}
+}
+
+// String returns the image in the form "name:tag@sha256:digest", omitting
+// the tag or digest if they are empty.
+func (i *Image) String() string {
+	reference := i.Name
+
+	if i.Tag != "" {
+		reference = fmt.Sprintf("%s:%s", reference, i.Tag)
+	}
+
+	if i.Digest != "" {
+		reference = fmt.Sprintf("%s@sha256:%s", reference, i.Digest)
+	}
+
+	return reference
+}
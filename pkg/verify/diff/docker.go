@@ -4,11 +4,10 @@ import (
 	"errors"
 
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type dockerfileImageDifferentiator struct {
-	kind                kind.Kind
-	excludeTags         bool
 	imageDifferentiator *imageDifferentiator
 }
 
@@ -16,40 +15,62 @@ type dockerfileImageDifferentiator struct {
 // Dockerfiles.
 func NewDockerfileDifferentiator(excludeTags bool) IImageDifferentiator {
 	return &dockerfileImageDifferentiator{
-		kind:                kind.Dockerfile,
-		excludeTags:         excludeTags,
-		imageDifferentiator: &imageDifferentiator{},
+		imageDifferentiator: &imageDifferentiator{
+			kind:        kind.Dockerfile,
+			excludeTags: excludeTags,
+		},
 	}
 }
 
-// DifferentiateImage reports differences between images in the fields
-// "name", "tag", and "digest".
-func (d *dockerfileImageDifferentiator) DifferentiateImage(
-	existingImage map[string]interface{},
-	newImage map[string]interface{},
-) error {
-	if existingImage == nil {
-		return errors.New("'existingImage' cannot be nil")
+// DifferentiateImages reports every difference between Dockerfiles in the
+// existing and new Lockfiles. An image whose name, "platform", or
+// "instruction" differs is reported as removed and added.
+func (d *dockerfileImageDifferentiator) DifferentiateImages( // nolint: dupl
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+) ([]*Difference, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
 	}
 
-	if newImage == nil {
-		return errors.New("'newImage' cannot be nil")
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
 	}
 
-	var diffFields = []string{"name", "tag", "digest"}
-
-	if d.excludeTags {
-		const tagIndex = 1
-
-		diffFields = append(diffFields[:tagIndex], diffFields[tagIndex+1:]...)
-	}
-
-	return d.imageDifferentiator.differentiateImage(
-		existingImage, newImage, diffFields,
-	)
+	return d.imageDifferentiator.differentiatePaths(
+		d.comparedPathImages(existingLockfile.Dockerfiles),
+		d.comparedPathImages(newLockfile.Dockerfiles),
+	), nil
 }
 
 // Kind is a getter for the kind.
 func (d *dockerfileImageDifferentiator) Kind() kind.Kind {
-	return d.kind
+	return d.imageDifferentiator.kind
+}
+
+func (d *dockerfileImageDifferentiator) comparedPathImages(
+	pathImages map[string][]*lockfile.DockerfileImage,
+) map[string][]*comparedImage {
+	comparedPathImages := make(map[string][]*comparedImage, len(pathImages))
+
+	for path, images := range pathImages {
+		for _, image := range images {
+			comparedPathImages[path] = append(
+				comparedPathImages[path], &comparedImage{
+					image: &Image{
+						Name:   image.Name,
+						Tag:    image.Tag,
+						Digest: image.Digest,
+					},
+					identity: []string{
+						image.Name,
+						image.Platform,
+						image.Instruction,
+					},
+				},
+			)
+		}
+	}
+
+	return comparedPathImages
 }
@@ -3,6 +3,7 @@ package diff_test
 import (
 	"testing"
 
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/verify/diff"
 )
 
@@ -10,82 +11,128 @@ func TestDockerfileDifferentiator(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		Name        string
-		Existing    map[string]interface{}
-		New         map[string]interface{}
-		ExcludeTags bool
-		ShouldFail  bool
+		Name         string
+		Existing     *lockfile.DockerfileImage
+		New          *lockfile.DockerfileImage
+		ExcludeTags  bool
+		ShouldDiffer bool
 	}{
 		{
 			Name: "Different Name",
-			Existing: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "busybox",
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":   "redis",
-				"tag":    "latest",
-				"digest": "busybox",
+			New: &lockfile.DockerfileImage{
+				Name:   "redis",
+				Tag:    "latest",
+				Digest: "busybox",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Tag",
-			Existing: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "busybox",
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "busybox",
-				"digest": "busybox",
+			New: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "busybox",
+				Digest: "busybox",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Digest",
-			Existing: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "busybox",
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "unknown",
+			New: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "unknown",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Platform",
+			Existing: &lockfile.DockerfileImage{
+				Name:     "busybox",
+				Tag:      "latest",
+				Digest:   "busybox",
+				Platform: "linux/amd64",
+			},
+			New: &lockfile.DockerfileImage{
+				Name:     "busybox",
+				Tag:      "latest",
+				Digest:   "busybox",
+				Platform: "linux/arm64",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Missing Platform",
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+			},
+			New: &lockfile.DockerfileImage{
+				Name:     "busybox",
+				Tag:      "latest",
+				Digest:   "busybox",
+				Platform: "linux/amd64",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Instruction",
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+			},
+			New: &lockfile.DockerfileImage{
+				Name:        "busybox",
+				Tag:         "latest",
+				Digest:      "busybox",
+				Instruction: lockfile.CopyInstruction,
+			},
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Exclude Tags",
-			Existing: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "busybox",
-			},
-			New: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "unknown",
-				"digest": "busybox",
-			},
-			ExcludeTags: true,
-			ShouldFail:  false,
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+			},
+			New: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "unknown",
+				Digest: "busybox",
+			},
+			ExcludeTags:  true,
+			ShouldDiffer: false,
 		},
 		{
 			Name: "Normal",
-			Existing: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "busybox",
+			Existing: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":   "busybox",
-				"tag":    "latest",
-				"digest": "busybox",
+			New: &lockfile.DockerfileImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
 			},
-			ShouldFail: false,
+			ShouldDiffer: false,
 		},
 	}
 
@@ -96,19 +143,29 @@ func TestDockerfileDifferentiator(t *testing.T) {
 			t.Parallel()
 
 			differentiator := diff.NewDockerfileDifferentiator(test.ExcludeTags)
-			err := differentiator.DifferentiateImage(test.Existing, test.New)
-
-			if test.ShouldFail {
-				if err == nil {
-					t.Fatal("expected error but did not get one")
-				}
-
-				return
-			}
+			differences, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{
+					Dockerfiles: map[string][]*lockfile.DockerfileImage{
+						"Dockerfile": {test.Existing},
+					},
+				},
+				&lockfile.Lockfile{
+					Dockerfiles: map[string][]*lockfile.DockerfileImage{
+						"Dockerfile": {test.New},
+					},
+				},
+			)
 
 			if err != nil {
 				t.Fatal(err)
 			}
+
+			if test.ShouldDiffer != (len(differences) != 0) {
+				t.Fatalf(
+					"expected differences '%t', got '%v'",
+					test.ShouldDiffer, differences,
+				)
+			}
 		})
 	}
 }
@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"errors"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type helmchartImageDifferentiator struct {
+	imageDifferentiator *imageDifferentiator
+}
+
+// NewHelmchartDifferentiator returns an IImageDifferentiator for
+// Helm charts.
+func NewHelmchartDifferentiator(excludeTags bool) IImageDifferentiator {
+	return &helmchartImageDifferentiator{
+		imageDifferentiator: &imageDifferentiator{
+			kind:        kind.Helmchart,
+			excludeTags: excludeTags,
+		},
+	}
+}
+
+// DifferentiateImages reports every difference between Helm charts in the
+// existing and new Lockfiles. An image whose name or "key" differs is reported
+// as removed and added.
+func (h *helmchartImageDifferentiator) DifferentiateImages( // nolint: dupl
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+) ([]*Difference, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
+	}
+
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
+	}
+
+	return h.imageDifferentiator.differentiatePaths(
+		h.comparedPathImages(existingLockfile.Helmcharts),
+		h.comparedPathImages(newLockfile.Helmcharts),
+	), nil
+}
+
+// Kind is a getter for the kind.
+func (h *helmchartImageDifferentiator) Kind() kind.Kind {
+	return h.imageDifferentiator.kind
+}
+
+func (h *helmchartImageDifferentiator) comparedPathImages(
+	pathImages map[string][]*lockfile.HelmchartImage,
+) map[string][]*comparedImage {
+	comparedPathImages := make(map[string][]*comparedImage, len(pathImages))
+
+	for path, images := range pathImages {
+		for _, image := range images {
+			comparedPathImages[path] = append(
+				comparedPathImages[path], &comparedImage{
+					image: &Image{
+						Name:   image.Name,
+						Tag:    image.Tag,
+						Digest: image.Digest,
+					},
+					identity: []string{
+						image.Name,
+						image.Key,
+					},
+				},
+			)
+		}
+	}
+
+	return comparedPathImages
+}
@@ -0,0 +1,153 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+func TestHelmchartDifferentiator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Existing     *lockfile.HelmchartImage
+		New          *lockfile.HelmchartImage
+		ExcludeTags  bool
+		ShouldDiffer bool
+	}{
+		{
+			Name: "Different Name",
+			Existing: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			New: &lockfile.HelmchartImage{
+				Name:   "redis",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Tag",
+			Existing: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			New: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "busybox",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Digest",
+			Existing: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			New: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "unknown",
+				Key:    "image",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Key",
+			Existing: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			New: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "redis.image",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Exclude Tags",
+			Existing: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			New: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "unknown",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			ExcludeTags:  true,
+			ShouldDiffer: false,
+		},
+		{
+			Name: "Normal",
+			Existing: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			New: &lockfile.HelmchartImage{
+				Name:   "busybox",
+				Tag:    "latest",
+				Digest: "busybox",
+				Key:    "image",
+			},
+			ShouldDiffer: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			differentiator := diff.NewHelmchartDifferentiator(
+				test.ExcludeTags,
+			)
+			differences, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{
+					Helmcharts: map[string][]*lockfile.HelmchartImage{
+						"values.yaml": {test.Existing},
+					},
+				},
+				&lockfile.Lockfile{
+					Helmcharts: map[string][]*lockfile.HelmchartImage{
+						"values.yaml": {test.New},
+					},
+				},
+			)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.ShouldDiffer != (len(differences) != 0) {
+				t.Fatalf(
+					"expected differences '%t', got '%v'",
+					test.ShouldDiffer, differences,
+				)
+			}
+		})
+	}
+}
@@ -1,34 +1,144 @@
 package diff
 
 import (
-	"fmt"
+	"sort"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
 )
 
-type imageDifferentiator struct{}
+type imageDifferentiator struct {
+	kind        kind.Kind
+	excludeTags bool
+}
+
+// comparedImage is an image of any kind. identity holds the name and the
+// kind specific fields, such as "service" or "container", that locate the
+// image in its file. If they differ, the image was replaced rather than
+// updated.
+type comparedImage struct {
+	image    *Image
+	identity []string
+}
+
+// differentiatePaths reports every difference between the images of the kind
+// in the existing and new Lockfiles, sorted by path and image index. Images
+// are compared by their position in their path.
+func (i *imageDifferentiator) differentiatePaths(
+	existingPathImages map[string][]*comparedImage,
+	newPathImages map[string][]*comparedImage,
+) []*Difference {
+	uniquePaths := map[string]struct{}{}
+
+	for path := range existingPathImages {
+		uniquePaths[path] = struct{}{}
+	}
+
+	for path := range newPathImages {
+		uniquePaths[path] = struct{}{}
+	}
 
+	paths := make([]string, 0, len(uniquePaths))
+	for path := range uniquePaths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	var differences []*Difference
+
+	for _, path := range paths {
+		existingImages := existingPathImages[path]
+		newImages := newPathImages[path]
+
+		numImages := len(existingImages)
+		if len(newImages) > numImages {
+			numImages = len(newImages)
+		}
+
+		for index := 0; index < numImages; index++ {
+			switch {
+			case index >= len(newImages):
+				differences = append(differences, i.difference(
+					path, index, Removed, existingImages[index].image, nil,
+				))
+			case index >= len(existingImages):
+				differences = append(differences, i.difference(
+					path, index, Added, nil, newImages[index].image,
+				))
+			default:
+				differences = append(differences, i.differentiateImage(
+					path, index, existingImages[index], newImages[index],
+				)...)
+			}
+		}
+	}
+
+	return differences
+}
+
+// differentiateImage classifies the difference between an image in the
+// existing Lockfile and the image in the same position in the new Lockfile.
+// If their identities differ, the existing image was removed and the new
+// image was added. Otherwise, a changed tag takes precedence over a changed
+// digest, unless tags are excluded.
 func (i *imageDifferentiator) differentiateImage(
-	existingImage map[string]interface{},
-	newImage map[string]interface{},
-	fields []string,
-) error {
-	var diffField string
-
-	for _, field := range fields {
-		if existingImage[field] != newImage[field] {
-			diffField = field
-			break
+	path string,
+	index int,
+	existingImage *comparedImage,
+	newImage *comparedImage,
+) []*Difference {
+	if !equalIdentities(existingImage.identity, newImage.identity) {
+		return []*Difference{
+			i.difference(path, index, Removed, existingImage.image, nil),
+			i.difference(path, index, Added, nil, newImage.image),
 		}
 	}
 
-	if diffField != "" {
-		return fmt.Errorf(
-			"existing image with field '%s' "+
-				"and value '%v' differs from the new "+
-				"image's value '%v'",
-			diffField, existingImage[diffField],
-			newImage[diffField],
-		)
+	var differenceType DifferenceType
+
+	switch {
+	case !i.excludeTags && existingImage.image.Tag != newImage.image.Tag:
+		differenceType = TagChanged
+	case existingImage.image.Digest != newImage.image.Digest:
+		differenceType = DigestChanged
+	default:
+		return nil
+	}
+
+	return []*Difference{
+		i.difference(
+			path, index, differenceType, existingImage.image, newImage.image,
+		),
+	}
+}
+
+func (i *imageDifferentiator) difference(
+	path string,
+	index int,
+	differenceType DifferenceType,
+	existingImage *Image,
+	newImage *Image,
+) *Difference {
+	return &Difference{
+		Kind:     i.kind,
+		Path:     path,
+		Index:    index,
+		Type:     differenceType,
+		Existing: existingImage,
+		New:      newImage,
+	}
+}
+
+func equalIdentities(existingIdentity []string, newIdentity []string) bool {
+	if len(existingIdentity) != len(newIdentity) {
+		return false
+	}
+
+	for i := range existingIdentity {
+		if existingIdentity[i] != newIdentity[i] {
+			return false
+		}
 	}
 
-	return nil
+	return true
 }
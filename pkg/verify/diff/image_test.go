@@ -0,0 +1,206 @@
+package diff_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+func TestDifferentiatePaths(t *testing.T) {
+	t.Parallel()
+
+	var (
+		busybox = &lockfile.DockerfileImage{
+			Name: "busybox", Tag: "latest", Digest: "busybox",
+		}
+		busyboxUpdated = &lockfile.DockerfileImage{
+			Name: "busybox", Tag: "latest", Digest: "updated",
+		}
+		busyboxRetagged = &lockfile.DockerfileImage{
+			Name: "busybox", Tag: "1.33", Digest: "updated",
+		}
+		redis = &lockfile.DockerfileImage{
+			Name: "redis", Tag: "latest", Digest: "redis",
+		}
+	)
+
+	image := func(image *lockfile.DockerfileImage) *diff.Image {
+		return &diff.Image{
+			Name: image.Name, Tag: image.Tag, Digest: image.Digest,
+		}
+	}
+
+	tests := []struct {
+		Name        string
+		Existing    map[string][]*lockfile.DockerfileImage
+		New         map[string][]*lockfile.DockerfileImage
+		ExcludeTags bool
+		Expected    []*diff.Difference
+	}{
+		{
+			Name: "Added And Removed Paths",
+			Existing: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile":  {busybox},
+				"Dockerfile1": {busybox},
+			},
+			New: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile":  {busybox},
+				"Dockerfile2": {redis},
+			},
+			Expected: []*diff.Difference{
+				{
+					Kind:     kind.Dockerfile,
+					Path:     "Dockerfile1",
+					Index:    0,
+					Type:     diff.Removed,
+					Existing: image(busybox),
+				},
+				{
+					Kind:  kind.Dockerfile,
+					Path:  "Dockerfile2",
+					Index: 0,
+					Type:  diff.Added,
+					New:   image(redis),
+				},
+			},
+		},
+		{
+			Name: "Different Number Of Images",
+			Existing: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busybox, redis},
+			},
+			New: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busybox},
+			},
+			Expected: []*diff.Difference{
+				{
+					Kind:     kind.Dockerfile,
+					Path:     "Dockerfile",
+					Index:    1,
+					Type:     diff.Removed,
+					Existing: image(redis),
+				},
+			},
+		},
+		{
+			Name: "Replaced Image",
+			Existing: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busybox},
+			},
+			New: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {redis},
+			},
+			Expected: []*diff.Difference{
+				{
+					Kind:     kind.Dockerfile,
+					Path:     "Dockerfile",
+					Index:    0,
+					Type:     diff.Removed,
+					Existing: image(busybox),
+				},
+				{
+					Kind:  kind.Dockerfile,
+					Path:  "Dockerfile",
+					Index: 0,
+					Type:  diff.Added,
+					New:   image(redis),
+				},
+			},
+		},
+		{
+			Name: "Changed Tag And Digest",
+			Existing: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busybox, busybox},
+			},
+			New: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busyboxRetagged, busyboxUpdated},
+			},
+			Expected: []*diff.Difference{
+				{
+					Kind:     kind.Dockerfile,
+					Path:     "Dockerfile",
+					Index:    0,
+					Type:     diff.TagChanged,
+					Existing: image(busybox),
+					New:      image(busyboxRetagged),
+				},
+				{
+					Kind:     kind.Dockerfile,
+					Path:     "Dockerfile",
+					Index:    1,
+					Type:     diff.DigestChanged,
+					Existing: image(busybox),
+					New:      image(busyboxUpdated),
+				},
+			},
+		},
+		{
+			Name: "Exclude Tags",
+			Existing: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busybox},
+			},
+			New: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile": {busyboxRetagged},
+			},
+			ExcludeTags: true,
+			Expected: []*diff.Difference{
+				{
+					Kind:     kind.Dockerfile,
+					Path:     "Dockerfile",
+					Index:    0,
+					Type:     diff.DigestChanged,
+					Existing: image(busybox),
+					New:      image(busyboxRetagged),
+				},
+			},
+		},
+		{
+			Name: "Normal",
+			Existing: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile":  {busybox},
+				"Dockerfile1": {busybox, redis},
+			},
+			New: map[string][]*lockfile.DockerfileImage{
+				"Dockerfile":  {busybox},
+				"Dockerfile1": {busybox, redis},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			differentiator := diff.NewDockerfileDifferentiator(
+				test.ExcludeTags,
+			)
+
+			got, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{Dockerfiles: test.Existing},
+				&lockfile.Lockfile{Dockerfiles: test.New},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != string(gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
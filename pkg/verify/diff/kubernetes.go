@@ -4,11 +4,10 @@ import (
 	"errors"
 
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 )
 
 type kubernetesfileImageDifferentiator struct {
-	kind                kind.Kind
-	excludeTags         bool
 	imageDifferentiator *imageDifferentiator
 }
 
@@ -16,40 +15,61 @@ type kubernetesfileImageDifferentiator struct {
 // Kubernetesfiles.
 func NewKubernetesfileDifferentiator(excludeTags bool) IImageDifferentiator {
 	return &kubernetesfileImageDifferentiator{
-		kind:                kind.Kubernetesfile,
-		excludeTags:         excludeTags,
-		imageDifferentiator: &imageDifferentiator{},
+		imageDifferentiator: &imageDifferentiator{
+			kind:        kind.Kubernetesfile,
+			excludeTags: excludeTags,
+		},
 	}
 }
 
-// DifferentiateImage reports differences between images in the fields
-// "name", "tag", "digest", and "container".
-func (k *kubernetesfileImageDifferentiator) DifferentiateImage(
-	existingImage map[string]interface{},
-	newImage map[string]interface{},
-) error {
-	if existingImage == nil {
-		return errors.New("'existingImage' cannot be nil")
+// DifferentiateImages reports every difference between Kubernetesfiles in the
+// existing and new Lockfiles. An image whose name or "container" differs is
+// reported as removed and added.
+func (k *kubernetesfileImageDifferentiator) DifferentiateImages( // nolint: dupl
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+) ([]*Difference, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
 	}
 
-	if newImage == nil {
-		return errors.New("'newImage' cannot be nil")
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
 	}
 
-	var diffFields = []string{"name", "tag", "digest", "container"}
-
-	if k.excludeTags {
-		const tagIndex = 1
-
-		diffFields = append(diffFields[:tagIndex], diffFields[tagIndex+1:]...)
-	}
-
-	return k.imageDifferentiator.differentiateImage(
-		existingImage, newImage, diffFields,
-	)
+	return k.imageDifferentiator.differentiatePaths(
+		k.comparedPathImages(existingLockfile.Kubernetesfiles),
+		k.comparedPathImages(newLockfile.Kubernetesfiles),
+	), nil
 }
 
 // Kind is a getter for the kind.
 func (k *kubernetesfileImageDifferentiator) Kind() kind.Kind {
-	return k.kind
+	return k.imageDifferentiator.kind
+}
+
+func (k *kubernetesfileImageDifferentiator) comparedPathImages(
+	pathImages map[string][]*lockfile.KubernetesfileImage,
+) map[string][]*comparedImage {
+	comparedPathImages := make(map[string][]*comparedImage, len(pathImages))
+
+	for path, images := range pathImages {
+		for _, image := range images {
+			comparedPathImages[path] = append(
+				comparedPathImages[path], &comparedImage{
+					image: &Image{
+						Name:   image.Name,
+						Tag:    image.Tag,
+						Digest: image.Digest,
+					},
+					identity: []string{
+						image.Name,
+						image.ContainerName,
+					},
+				},
+			)
+		}
+	}
+
+	return comparedPathImages
 }
@@ -3,6 +3,7 @@ package diff_test
 import (
 	"testing"
 
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/verify/diff"
 )
 
@@ -10,108 +11,108 @@ func TestKubernetesfileDifferentiator(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		Name        string
-		Existing    map[string]interface{}
-		New         map[string]interface{}
-		ExcludeTags bool
-		ShouldFail  bool
+		Name         string
+		Existing     *lockfile.KubernetesfileImage
+		New          *lockfile.KubernetesfileImage
+		ExcludeTags  bool
+		ShouldDiffer bool
 	}{
 		{
 			Name: "Different Name",
-			Existing: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			Existing: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":      "redis",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			New: &lockfile.KubernetesfileImage{
+				Name:          "redis",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Tag",
-			Existing: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			Existing: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "busybox",
-				"digest":    "busybox",
-				"container": "busybox",
+			New: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "busybox",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Digest",
-			Existing: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			Existing: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "unknown",
-				"container": "busybox",
+			New: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "unknown",
+				ContainerName: "busybox",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Different Container",
-			Existing: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			Existing: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox1",
+			New: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox1",
 			},
-			ShouldFail: true,
+			ShouldDiffer: true,
 		},
 		{
 			Name: "Exclude Tags",
-			Existing: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			Existing: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "unknown",
-				"digest":    "busybox",
-				"container": "busybox",
+			New: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "unknown",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			ExcludeTags: true,
-			ShouldFail:  false,
+			ExcludeTags:  true,
+			ShouldDiffer: false,
 		},
 		{
 			Name: "Normal",
-			Existing: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			Existing: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			New: map[string]interface{}{
-				"name":      "busybox",
-				"tag":       "latest",
-				"digest":    "busybox",
-				"container": "busybox",
+			New: &lockfile.KubernetesfileImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ContainerName: "busybox",
 			},
-			ShouldFail: false,
+			ShouldDiffer: false,
 		},
 	}
 
@@ -124,19 +125,29 @@ func TestKubernetesfileDifferentiator(t *testing.T) {
 			differentiator := diff.NewKubernetesfileDifferentiator(
 				test.ExcludeTags,
 			)
-			err := differentiator.DifferentiateImage(test.Existing, test.New)
-
-			if test.ShouldFail {
-				if err == nil {
-					t.Fatal("expected error but did not get one")
-				}
-
-				return
-			}
+			differences, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{
+					Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+						"pod.yaml": {test.Existing},
+					},
+				},
+				&lockfile.Lockfile{
+					Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+						"pod.yaml": {test.New},
+					},
+				},
+			)
 
 			if err != nil {
 				t.Fatal(err)
 			}
+
+			if test.ShouldDiffer != (len(differences) != 0) {
+				t.Fatalf(
+					"expected differences '%t', got '%v'",
+					test.ShouldDiffer, differences,
+				)
+			}
 		})
 	}
 }
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"errors"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+type kustomizationImageDifferentiator struct {
+	imageDifferentiator *imageDifferentiator
+}
+
+// NewKustomizationDifferentiator returns an IImageDifferentiator for
+// Kustomizations.
+func NewKustomizationDifferentiator(excludeTags bool) IImageDifferentiator {
+	return &kustomizationImageDifferentiator{
+		imageDifferentiator: &imageDifferentiator{
+			kind:        kind.Kustomization,
+			excludeTags: excludeTags,
+		},
+	}
+}
+
+// DifferentiateImages reports every difference between Kustomizations in the
+// existing and new Lockfiles. An image whose name, "manifest", or "container"
+// differs is reported as removed and added.
+func (k *kustomizationImageDifferentiator) DifferentiateImages( // nolint: dupl
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+) ([]*Difference, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
+	}
+
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
+	}
+
+	return k.imageDifferentiator.differentiatePaths(
+		k.comparedPathImages(existingLockfile.Kustomizations),
+		k.comparedPathImages(newLockfile.Kustomizations),
+	), nil
+}
+
+// Kind is a getter for the kind.
+func (k *kustomizationImageDifferentiator) Kind() kind.Kind {
+	return k.imageDifferentiator.kind
+}
+
+func (k *kustomizationImageDifferentiator) comparedPathImages(
+	pathImages map[string][]*lockfile.KustomizationImage,
+) map[string][]*comparedImage {
+	comparedPathImages := make(map[string][]*comparedImage, len(pathImages))
+
+	for path, images := range pathImages {
+		for _, image := range images {
+			comparedPathImages[path] = append(
+				comparedPathImages[path], &comparedImage{
+					image: &Image{
+						Name:   image.Name,
+						Tag:    image.Tag,
+						Digest: image.Digest,
+					},
+					identity: []string{
+						image.Name,
+						image.ManifestPath,
+						image.ContainerName,
+					},
+				},
+			)
+		}
+	}
+
+	return comparedPathImages
+}
@@ -0,0 +1,183 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+func TestKustomizationDifferentiator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Existing     *lockfile.KustomizationImage
+		New          *lockfile.KustomizationImage
+		ExcludeTags  bool
+		ShouldDiffer bool
+	}{
+		{
+			Name: "Different Name",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "redis",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Tag",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "busybox",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Digest",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "unknown",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Container",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox1",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Different Manifest",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "deployment.yaml",
+				ContainerName: "busybox",
+			},
+			ShouldDiffer: true,
+		},
+		{
+			Name: "Exclude Tags",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "unknown",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			ExcludeTags:  true,
+			ShouldDiffer: false,
+		},
+		{
+			Name: "Normal",
+			Existing: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			New: &lockfile.KustomizationImage{
+				Name:          "busybox",
+				Tag:           "latest",
+				Digest:        "busybox",
+				ManifestPath:  "pod.yaml",
+				ContainerName: "busybox",
+			},
+			ShouldDiffer: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			differentiator := diff.NewKustomizationDifferentiator(
+				test.ExcludeTags,
+			)
+			differences, err := differentiator.DifferentiateImages(
+				&lockfile.Lockfile{
+					Kustomizations: map[string][]*lockfile.KustomizationImage{
+						"kustomization.yaml": {test.Existing},
+					},
+				},
+				&lockfile.Lockfile{
+					Kustomizations: map[string][]*lockfile.KustomizationImage{
+						"kustomization.yaml": {test.New},
+					},
+				},
+			)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.ShouldDiffer != (len(differences) != 0) {
+				t.Fatalf(
+					"expected differences '%t', got '%v'",
+					test.ShouldDiffer, differences,
+				)
+			}
+		})
+	}
+}
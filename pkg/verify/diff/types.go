@@ -1,15 +1,18 @@
 // Package diff provides functionality to diff an image.
 package diff
 
-import "github.com/safe-waters/docker-lock/pkg/kind"
+import (
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
 
 // IImageDifferentiator provides an interface for ImageDifferentiators, which
-// are responsible for reporting the difference between an image in the existing
-// Lockfile and an image in the newly generated Lockfile.
+// are responsible for reporting the differences between the images of a kind
+// in the existing Lockfile and in the newly generated Lockfile.
 type IImageDifferentiator interface {
-	DifferentiateImage(
-		existingImage map[string]interface{},
-		newImage map[string]interface{},
-	) error
+	DifferentiateImages(
+		existingLockfile *lockfile.Lockfile,
+		newLockfile *lockfile.Lockfile,
+	) ([]*Difference, error)
 	Kind() kind.Kind
 }
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/verify"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+type changelogReportWriter struct{}
+
+// pathDifferences are the differences of a path, in the order of its images.
+type pathDifferences struct {
+	kindPath
+	differences []*diff.Difference
+}
+
+// NewChangelogReportWriter returns an IReportWriter that writes Reports as a
+// human readable changelog, grouped by kind and path, for reviewing changes
+// to a Lockfile without reading its JSON.
+func NewChangelogReportWriter() IReportWriter {
+	return &changelogReportWriter{}
+}
+
+// WriteReport writes a line for each difference in the Report, under its
+// kind and path. Paths whose images were all added or removed are marked as
+// added or removed.
+func (c *changelogReportWriter) WriteReport(
+	report *verify.Report,
+	writer io.Writer,
+) error {
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	if report.Verified() {
+		_, err := fmt.Fprintln(writer, "no differences")
+
+		return err
+	}
+
+	var (
+		changelog strings.Builder
+		groups    []*pathDifferences
+	)
+
+	for _, difference := range report.Differences {
+		key := kindPath{kind: difference.Kind, path: difference.Path}
+
+		if len(groups) == 0 || groups[len(groups)-1].kindPath != key {
+			groups = append(groups, &pathDifferences{kindPath: key})
+		}
+
+		group := groups[len(groups)-1]
+		group.differences = append(group.differences, difference)
+	}
+
+	for i, group := range groups {
+		if i == 0 || groups[i-1].kind != group.kind {
+			fmt.Fprintf(&changelog, "%s\n", group.kind)
+		}
+
+		switch {
+		case allOfType(group.differences, diff.Added):
+			fmt.Fprintf(&changelog, "  %s (added)\n", group.path)
+		case allOfType(group.differences, diff.Removed):
+			fmt.Fprintf(&changelog, "  %s (removed)\n", group.path)
+		default:
+			fmt.Fprintf(&changelog, "  %s\n", group.path)
+		}
+
+		for _, difference := range group.differences {
+			fmt.Fprintf(&changelog, "    %s\n", changelogLine(difference))
+		}
+	}
+
+	_, err := io.WriteString(writer, changelog.String())
+
+	return err
+}
+
+// allOfType reports whether every image of a path has the DifferenceType,
+// meaning the path was added or removed as a whole.
+func allOfType(
+	differences []*diff.Difference,
+	differenceType diff.DifferenceType,
+) bool {
+	for i, difference := range differences {
+		if difference.Type != differenceType || difference.Index != i {
+			return false
+		}
+	}
+
+	return true
+}
+
+func changelogLine(difference *diff.Difference) string {
+	switch difference.Type {
+	case diff.Added:
+		return fmt.Sprintf("+ image %d %s", difference.Index, difference.New)
+	case diff.Removed:
+		return fmt.Sprintf(
+			"- image %d %s", difference.Index, difference.Existing,
+		)
+	case diff.TagChanged:
+		line := fmt.Sprintf(
+			"~ image %d %s tag %s -> %s",
+			difference.Index, difference.Existing.Name,
+			difference.Existing.Tag, difference.New.Tag,
+		)
+
+		if difference.Existing.Digest != difference.New.Digest {
+			line = fmt.Sprintf(
+				"%s, digest %s -> %s",
+				line, difference.Existing.Digest, difference.New.Digest,
+			)
+		}
+
+		return line
+	case diff.DigestChanged:
+		return fmt.Sprintf(
+			"~ image %d %s:%s digest %s -> %s",
+			difference.Index, difference.Existing.Name,
+			difference.Existing.Tag, difference.Existing.Digest,
+			difference.New.Digest,
+		)
+	default:
+		return fmt.Sprintf("? %s", difference)
+	}
+}
@@ -0,0 +1,108 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/verify"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+	"github.com/safe-waters/docker-lock/pkg/verify/output"
+)
+
+func TestChangelogReportWriter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		busybox = &diff.Image{Name: "busybox", Tag: "latest", Digest: "a"}
+		redis6  = &diff.Image{Name: "redis", Tag: "6", Digest: "6"}
+		redis7  = &diff.Image{Name: "redis", Tag: "7", Digest: "7"}
+	)
+
+	tests := []struct {
+		Name     string
+		Report   *verify.Report
+		Expected []byte
+	}{
+		{
+			Name:   "Changed Digest",
+			Report: testReport(),
+			Expected: []byte(`dockerfiles
+  Dockerfile
+    ~ image 0 busybox:latest digest busybox -> updated
+`),
+		},
+		{
+			Name: "Added And Removed Paths",
+			Report: &verify.Report{
+				Differences: []*diff.Difference{
+					{
+						Kind:     kind.Composefile,
+						Path:     "docker-compose.yml",
+						Index:    0,
+						Type:     diff.TagChanged,
+						Existing: redis6,
+						New:      redis7,
+					},
+					{
+						Kind:     kind.Composefile,
+						Path:     "docker-compose.yml",
+						Index:    1,
+						Type:     diff.Removed,
+						Existing: busybox,
+					},
+					{
+						Kind:  kind.Dockerfile,
+						Path:  "new/Dockerfile",
+						Index: 0,
+						Type:  diff.Added,
+						New:   busybox,
+					},
+					{
+						Kind:     kind.Dockerfile,
+						Path:     "old/Dockerfile",
+						Index:    0,
+						Type:     diff.Removed,
+						Existing: redis6,
+					},
+				},
+			},
+			Expected: []byte(`composefiles
+  docker-compose.yml
+    ~ image 0 redis tag 6 -> 7, digest 6 -> 7
+    - image 1 busybox:latest@sha256:a
+dockerfiles
+  new/Dockerfile (added)
+    + image 0 busybox:latest@sha256:a
+  old/Dockerfile (removed)
+    - image 0 redis:6@sha256:6
+`),
+		},
+		{
+			Name:     "No Differences",
+			Report:   &verify.Report{},
+			Expected: []byte("no differences\n"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var got bytes.Buffer
+
+			writer := output.NewChangelogReportWriter()
+			if err := writer.WriteReport(test.Report, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(test.Expected, got.Bytes()) {
+				t.Fatalf(
+					"expected:\n%s\ngot:\n%s", test.Expected, got.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/safe-waters/docker-lock/pkg/verify"
+)
+
+type jsonReportWriter struct{}
+
+// NewJSONReportWriter returns an IReportWriter that writes Reports as
+// indented JSON.
+func NewJSONReportWriter() IReportWriter {
+	return &jsonReportWriter{}
+}
+
+// WriteReport writes the Report as indented JSON.
+func (j *jsonReportWriter) WriteReport(
+	report *verify.Report,
+	writer io.Writer,
+) error {
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	byt, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(append(byt, '\n'))
+
+	return err
+}
@@ -0,0 +1,108 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/verify"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+	"github.com/safe-waters/docker-lock/pkg/verify/output"
+)
+
+func TestJSONReportWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Report   *verify.Report
+		Expected []byte
+	}{
+		{
+			Name:   "Differences",
+			Report: testReport(),
+			Expected: []byte(`{
+	"paths": {
+		"dockerfiles": [
+			"Dockerfile",
+			"Dockerfile1"
+		]
+	},
+	"differences": [
+		{
+			"kind": "dockerfiles",
+			"path": "Dockerfile",
+			"index": 0,
+			"type": "digestChanged",
+			"existing": {
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "busybox"
+			},
+			"new": {
+				"name": "busybox",
+				"tag": "latest",
+				"digest": "updated"
+			}
+		}
+	]
+}
+`),
+		},
+		{
+			Name: "No Differences",
+			Report: &verify.Report{
+				Paths:       map[kind.Kind][]string{},
+				Differences: []*diff.Difference{},
+			},
+			Expected: []byte(`{
+	"paths": {},
+	"differences": []
+}
+`),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var got bytes.Buffer
+
+			writer := output.NewJSONReportWriter()
+			if err := writer.WriteReport(test.Report, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(test.Expected, got.Bytes()) {
+				t.Fatalf(
+					"expected:\n%s\ngot:\n%s", test.Expected, got.String(),
+				)
+			}
+		})
+	}
+}
+
+func testReport() *verify.Report {
+	return &verify.Report{
+		Paths: map[kind.Kind][]string{
+			kind.Dockerfile: {"Dockerfile", "Dockerfile1"},
+		},
+		Differences: []*diff.Difference{
+			{
+				Kind:  kind.Dockerfile,
+				Path:  "Dockerfile",
+				Index: 0,
+				Type:  diff.DigestChanged,
+				Existing: &diff.Image{
+					Name: "busybox", Tag: "latest", Digest: "busybox",
+				},
+				New: &diff.Image{
+					Name: "busybox", Tag: "latest", Digest: "updated",
+				},
+			},
+		},
+	}
+}
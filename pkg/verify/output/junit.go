@@ -0,0 +1,133 @@
+package output
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/verify"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+type junitReportWriter struct{}
+
+type kindPath struct {
+	kind kind.Kind
+	path string
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnitReportWriter returns an IReportWriter that writes Reports as
+// JUnit XML, so that CI systems can show each verified path as a test.
+func NewJUnitReportWriter() IReportWriter {
+	return &junitReportWriter{}
+}
+
+// WriteReport writes a test suite for each kind in the Report, with a test
+// case for each path. A test case fails if its path has any differences.
+func (j *junitReportWriter) WriteReport(
+	report *verify.Report,
+	writer io.Writer,
+) error {
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	pathDifferences := map[kindPath][]*diff.Difference{}
+
+	for _, difference := range report.Differences {
+		key := kindPath{kind: difference.Kind, path: difference.Path}
+		pathDifferences[key] = append(pathDifferences[key], difference)
+	}
+
+	testSuites := &junitTestSuites{Name: "docker-lock verify"}
+
+	for _, kind := range sortedKinds(report) {
+		testSuite := &junitTestSuite{Name: string(kind)}
+
+		for _, path := range report.Paths[kind] {
+			testCase := &junitTestCase{Name: path, ClassName: string(kind)}
+
+			differences := pathDifferences[kindPath{kind: kind, path: path}]
+			if len(differences) != 0 {
+				messages := make([]string, len(differences))
+				for i, difference := range differences {
+					messages[i] = difference.String()
+				}
+
+				testCase.Failure = &junitFailure{
+					Message: fmt.Sprintf(
+						"%d image(s) differ from the Lockfile",
+						len(differences),
+					),
+					Type: "DifferentLockfile",
+					Text: strings.Join(messages, "\n"),
+				}
+
+				testSuite.Failures++
+			}
+
+			testSuite.Tests++
+			testSuite.TestCases = append(testSuite.TestCases, testCase)
+		}
+
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+		testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+	}
+
+	byt, err := xml.MarshalIndent(testSuites, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(writer, "%s%s\n", xml.Header, byt)
+
+	return err
+}
+
+func sortedKinds(report *verify.Report) []kind.Kind {
+	kinds := make([]kind.Kind, 0, len(report.Paths))
+	for kind := range report.Paths {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	return kinds
+}
@@ -0,0 +1,34 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/verify/output"
+)
+
+func TestJUnitReportWriter(t *testing.T) {
+	t.Parallel()
+
+	expected := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="docker-lock verify" tests="2" failures="1">
+	<testsuite name="dockerfiles" tests="2" failures="1">
+		<testcase name="Dockerfile" classname="dockerfiles">
+			<failure message="1 image(s) differ from the Lockfile" type="DifferentLockfile">on path &#39;Dockerfile&#39; of kind &#39;dockerfiles&#39;, the digest of image &#39;0&#39;, &#39;busybox:latest&#39;, changed from &#39;busybox&#39; to &#39;updated&#39;</failure>
+		</testcase>
+		<testcase name="Dockerfile1" classname="dockerfiles"></testcase>
+	</testsuite>
+</testsuites>
+`) // nolint: lll
+
+	var got bytes.Buffer
+
+	writer := output.NewJUnitReportWriter()
+	if err := writer.WriteReport(testReport(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expected, got.Bytes()) {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got.String())
+	}
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/safe-waters/docker-lock/pkg/verify"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "docker-lock"
+	toolURI      = "https://github.com/safe-waters/docker-lock"
+)
+
+type sarifReportWriter struct{}
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    *sarifTool     `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	Level     string           `json:"level"`
+	Message   *sarifMessage    `json:"message"`
+	Locations []*sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRules describes each DifferenceType as a SARIF rule.
+var sarifRules = []*sarifRule{ // nolint: gochecknoglobals
+	{
+		ID: string(diff.Added),
+		ShortDescription: &sarifMessage{
+			Text: "Image is not in the Lockfile",
+		},
+	},
+	{
+		ID: string(diff.Removed),
+		ShortDescription: &sarifMessage{
+			Text: "Image in the Lockfile no longer exists",
+		},
+	},
+	{
+		ID: string(diff.TagChanged),
+		ShortDescription: &sarifMessage{
+			Text: "Image tag differs from the Lockfile",
+		},
+	},
+	{
+		ID: string(diff.DigestChanged),
+		ShortDescription: &sarifMessage{
+			Text: "Image digest differs from the Lockfile",
+		},
+	},
+}
+
+// NewSARIFReportWriter returns an IReportWriter that writes Reports in the
+// Static Analysis Results Interchange Format, so that code hosts can
+// annotate the files whose images differ from the Lockfile.
+func NewSARIFReportWriter() IReportWriter {
+	return &sarifReportWriter{}
+}
+
+// WriteReport writes each difference in the Report as a SARIF result,
+// located in the file of the image.
+func (s *sarifReportWriter) WriteReport(
+	report *verify.Report,
+	writer io.Writer,
+) error {
+	if report == nil {
+		return errors.New("'report' cannot be nil")
+	}
+
+	if writer == nil || reflect.ValueOf(writer).IsNil() {
+		return errors.New("'writer' cannot be nil")
+	}
+
+	results := make([]*sarifResult, len(report.Differences))
+
+	for i, difference := range report.Differences {
+		results[i] = &sarifResult{
+			RuleID:  string(difference.Type),
+			Level:   "error",
+			Message: &sarifMessage{Text: difference.String()},
+			Locations: []*sarifLocation{
+				{
+					PhysicalLocation: &sarifPhysicalLocation{
+						ArtifactLocation: &sarifArtifactLocation{
+							URI: difference.Path,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	log := &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []*sarifRun{
+			{
+				Tool: &sarifTool{
+					Driver: &sarifDriver{
+						Name:           toolName,
+						InformationURI: toolURI,
+						Rules:          sarifRules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	byt, err := json.MarshalIndent(log, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(append(byt, '\n'))
+
+	return err
+}
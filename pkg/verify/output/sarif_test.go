@@ -0,0 +1,70 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/safe-waters/docker-lock/pkg/verify/output"
+)
+
+func TestSARIFReportWriter(t *testing.T) {
+	t.Parallel()
+
+	var got bytes.Buffer
+
+	writer := output.NewSARIFReportWriter()
+	if err := writer.WriteReport(testReport(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+
+	if err := json.Unmarshal(got.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected a single SARIF 2.1.0 run, got %s", got.String())
+	}
+
+	var (
+		expectedRuleIDs = []string{"digestChanged"}
+		expectedURIs    = []string{"Dockerfile"}
+		gotRuleIDs      []string
+		gotURIs         []string
+	)
+
+	for _, result := range log.Runs[0].Results {
+		gotRuleIDs = append(gotRuleIDs, result.RuleID)
+
+		for _, location := range result.Locations {
+			gotURIs = append(
+				gotURIs, location.PhysicalLocation.ArtifactLocation.URI,
+			)
+		}
+	}
+
+	if !reflect.DeepEqual(expectedRuleIDs, gotRuleIDs) {
+		t.Fatalf("expected rule ids %v, got %v", expectedRuleIDs, gotRuleIDs)
+	}
+
+	if !reflect.DeepEqual(expectedURIs, gotURIs) {
+		t.Fatalf("expected uris %v, got %v", expectedURIs, gotURIs)
+	}
+}
@@ -0,0 +1,15 @@
+// Package output provides functionality to write verification Reports in
+// machine-readable formats.
+package output
+
+import (
+	"io"
+
+	"github.com/safe-waters/docker-lock/pkg/verify"
+)
+
+// IReportWriter provides an interface for ReportWriters, which are
+// responsible for writing a verification Report in a format, such as SARIF.
+type IReportWriter interface {
+	WriteReport(report *verify.Report, writer io.Writer) error
+}
@@ -0,0 +1,122 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify/diff"
+)
+
+// Report is the result of verifying a Lockfile. Paths holds the verified
+// paths of each kind, from both the existing and new Lockfiles. Differences
+// holds every difference between the Lockfiles, sorted by kind, path, and
+// image index.
+type Report struct {
+	Paths       map[kind.Kind][]string `json:"paths"`
+	Differences []*diff.Difference     `json:"differences"`
+}
+
+// NewReport returns a Report of the differences between two Lockfiles,
+// such as the Lockfiles of two git revisions. Every kind in the Lockfiles
+// must have a differentiator.
+func NewReport(
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+	differentiators ...diff.IImageDifferentiator,
+) (*Report, error) {
+	if existingLockfile == nil {
+		return nil, errors.New("'existingLockfile' cannot be nil")
+	}
+
+	if newLockfile == nil {
+		return nil, errors.New("'newLockfile' cannot be nil")
+	}
+
+	kindDifferentiator := map[kind.Kind]diff.IImageDifferentiator{}
+
+	for _, differentiator := range differentiators {
+		if differentiator != nil && !reflect.ValueOf(differentiator).IsNil() {
+			kindDifferentiator[differentiator.Kind()] = differentiator
+		}
+	}
+
+	return newReport(existingLockfile, newLockfile, kindDifferentiator)
+}
+
+// Verified reports whether the existing Lockfile is up-to-date.
+func (r *Report) Verified() bool {
+	return len(r.Differences) == 0
+}
+
+// newReport collects the differences of every kind in the existing or new
+// Lockfiles.
+func newReport(
+	existingLockfile *lockfile.Lockfile,
+	newLockfile *lockfile.Lockfile,
+	kindDifferentiator map[kind.Kind]diff.IImageDifferentiator,
+) (*Report, error) {
+	report := &Report{
+		Paths:       map[kind.Kind][]string{},
+		Differences: []*diff.Difference{},
+	}
+
+	uniqueKinds := map[kind.Kind]struct{}{}
+
+	for _, kind := range existingLockfile.Kinds() {
+		uniqueKinds[kind] = struct{}{}
+	}
+
+	for _, kind := range newLockfile.Kinds() {
+		uniqueKinds[kind] = struct{}{}
+	}
+
+	kinds := make([]kind.Kind, 0, len(uniqueKinds))
+	for kind := range uniqueKinds {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	for _, kind := range kinds {
+		differentiator, ok := kindDifferentiator[kind]
+		if !ok {
+			return nil, fmt.Errorf(
+				"kind %s does not have a differentiator defined", kind,
+			)
+		}
+
+		differences, err := differentiator.DifferentiateImages(
+			existingLockfile, newLockfile,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Paths[kind] = uniquePaths(
+			existingLockfile.Paths(kind), newLockfile.Paths(kind),
+		)
+		report.Differences = append(report.Differences, differences...)
+	}
+
+	return report, nil
+}
+
+// uniquePaths merges the sorted existing and new paths, without duplicates.
+func uniquePaths(existingPaths []string, newPaths []string) []string {
+	paths := append([]string{}, existingPaths...)
+
+	for _, path := range newPaths {
+		i := sort.SearchStrings(existingPaths, path)
+		if i == len(existingPaths) || existingPaths[i] != path {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
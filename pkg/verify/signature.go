@@ -0,0 +1,466 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+)
+
+// Media types and annotations of the artifacts that cosign stores next to
+// images in their repositories.
+const (
+	cosignSignatureMediaType  = "application/vnd.dev.cosign.simplesigning.v1+json" // nolint: lll
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	dsseEnvelopeMediaType     = "application/vnd.dsse.envelope.v1+json"
+	inTotoPayloadType         = "application/vnd.in-toto+json"
+)
+
+type signatureVerifier struct {
+	artifactRequester update.IArtifactRequester
+	publicKeys        []crypto.PublicKey
+	maxConcurrency    int
+}
+
+// UnsignedImage is an image in a Lockfile whose digest does not have a
+// signature or attestation that is valid for any of the public keys.
+// Reason describes why, such as if there was no signature at all.
+type UnsignedImage struct {
+	Kind   kind.Kind `json:"kind"`
+	Path   string    `json:"path"`
+	Index  int       `json:"index"`
+	Name   string    `json:"name"`
+	Tag    string    `json:"tag"`
+	Digest string    `json:"digest"`
+	Reason string    `json:"reason"`
+}
+
+// signedImage identifies an image whose signatures are verified once, no
+// matter how many times it appears in a Lockfile.
+type signedImage struct {
+	name   string
+	digest string
+}
+
+// simpleSigningPayload is the payload that a cosign signature signs.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// dsseEnvelope is the envelope of a cosign attestation, as described in
+// https://github.com/secure-systems-lab/dsse.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		Sig string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// inTotoStatement is the payload of an attestation, as described in
+// https://github.com/in-toto/attestation.
+type inTotoStatement struct {
+	Subject []struct {
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// NewSignatureVerifier returns an ISignatureVerifier after validating its
+// fields. artifactRequester cannot be nil as it is responsible for querying
+// registries for the signatures and attestations of images. publicKeys
+// cannot be empty, and each must be an ECDSA, RSA, or Ed25519 key.
+//
+// maxConcurrency limits the number of images whose signatures are queried
+// at the same time. If maxConcurrency is 0, there is no limit.
+func NewSignatureVerifier(
+	artifactRequester update.IArtifactRequester,
+	publicKeys []crypto.PublicKey,
+	maxConcurrency int,
+) (ISignatureVerifier, error) {
+	if artifactRequester == nil ||
+		reflect.ValueOf(artifactRequester).IsNil() {
+		return nil, errors.New("'artifactRequester' cannot be nil")
+	}
+
+	if len(publicKeys) == 0 {
+		return nil, errors.New("'publicKeys' cannot be empty")
+	}
+
+	for _, publicKey := range publicKeys {
+		if err := validatePublicKey(publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if maxConcurrency < 0 {
+		return nil, errors.New("'maxConcurrency' cannot be negative")
+	}
+
+	return &signatureVerifier{
+		artifactRequester: artifactRequester,
+		publicKeys:        publicKeys,
+		maxConcurrency:    maxConcurrency,
+	}, nil
+}
+
+// LoadPublicKeys reads PEM encoded public keys, such as "cosign.pub", from
+// paths.
+func LoadPublicKeys(paths []string) ([]crypto.PublicKey, error) {
+	publicKeys := make([]crypto.PublicKey, 0, len(paths))
+
+	for _, path := range paths {
+		byt, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		block, _ := pem.Decode(byt)
+		if block == nil || block.Type != "PUBLIC KEY" {
+			return nil, fmt.Errorf(
+				"'%s' does not contain a PEM encoded public key", path,
+			)
+		}
+
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to parse public key '%s' with err: %v", path, err,
+			)
+		}
+
+		if err := validatePublicKey(publicKey); err != nil {
+			return nil, fmt.Errorf("public key '%s': %v", path, err)
+		}
+
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return publicKeys, nil
+}
+
+// UnsignedImages reads an existing Lockfile and returns every image with a
+// digest that does not have a valid signature or attestation, sorted by
+// kind, path, and image index.
+//
+// Signatures are read from the tag "sha256-<digest>.sig" in the image's
+// repository, and attestations from "sha256-<digest>.att", as cosign stores
+// them. A signature is valid if it signs the image's digest with one of the
+// public keys. An attestation is valid if its envelope is signed with one of
+// the public keys and its statement has the image's digest as a subject.
+func (s *signatureVerifier) UnsignedImages(
+	lockfileReader io.Reader,
+) ([]*UnsignedImage, error) {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return nil, errors.New("'lockfileReader' cannot be nil")
+	}
+
+	existingLockfile, err := lockfile.Read(lockfileReader)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		images       []*UnsignedImage
+		signedImages = map[signedImage]struct{}{}
+	)
+
+	for _, image := range existingLockfile.Images() {
+		if *image.Digest == "" {
+			continue
+		}
+
+		images = append(images, &UnsignedImage{
+			Kind:   image.Kind,
+			Path:   image.Path,
+			Index:  image.Index,
+			Name:   *image.Name,
+			Tag:    *image.Tag,
+			Digest: *image.Digest,
+		})
+		signedImages[signedImage{
+			name: *image.Name, digest: *image.Digest,
+		}] = struct{}{}
+	}
+
+	reasons, err := s.verifyImages(signedImages)
+	if err != nil {
+		return nil, err
+	}
+
+	unsignedImages := []*UnsignedImage{}
+
+	for _, image := range images {
+		reason := reasons[signedImage{name: image.Name, digest: image.Digest}]
+		if reason == "" {
+			continue
+		}
+
+		image.Reason = reason
+		unsignedImages = append(unsignedImages, image)
+	}
+
+	return unsignedImages, nil
+}
+
+// String returns a human readable description of the UnsignedImage.
+func (u *UnsignedImage) String() string {
+	return fmt.Sprintf(
+		"%s, %s",
+		imageLocation(u.Kind, u.Path, u.Index, u.Name, u.Tag, u.Digest),
+		u.Reason,
+	)
+}
+
+// verifyImages verifies the signatures of each image, at most
+// maxConcurrency at a time, and returns why each image is not signed. Images
+// that are signed do not have a reason. If querying any signatures fails,
+// the first error is returned.
+func (s *signatureVerifier) verifyImages(
+	images map[signedImage]struct{},
+) (map[signedImage]string, error) {
+	var (
+		waitGroup sync.WaitGroup
+		mutex     sync.Mutex
+		firstErr  error
+		reasons   = map[signedImage]string{}
+		semaphore chan struct{}
+	)
+
+	if s.maxConcurrency > 0 {
+		semaphore = make(chan struct{}, s.maxConcurrency)
+	}
+
+	for image := range images {
+		image := image
+
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			if semaphore != nil {
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+			}
+
+			reason, err := s.verifyImage(image)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				return
+			}
+
+			reasons[image] = reason
+		}()
+	}
+
+	waitGroup.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return reasons, nil
+}
+
+// verifyImage returns why the image is not signed, or an empty string if it
+// has a valid signature or attestation.
+func (s *signatureVerifier) verifyImage(image signedImage) (string, error) {
+	signatureLayers, err := s.artifactRequester.ArtifactLayers(
+		image.name, fmt.Sprintf("sha256-%s.sig", image.digest),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	for _, layer := range signatureLayers {
+		if s.verifySignature(layer, image.digest) {
+			return "", nil
+		}
+	}
+
+	attestationLayers, err := s.artifactRequester.ArtifactLayers(
+		image.name, fmt.Sprintf("sha256-%s.att", image.digest),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	for _, layer := range attestationLayers {
+		if s.verifyAttestation(layer, image.digest) {
+			return "", nil
+		}
+	}
+
+	if len(signatureLayers) == 0 && len(attestationLayers) == 0 {
+		return "does not have a signature or attestation", nil
+	}
+
+	return "does not have a signature or attestation that is valid for " +
+		"the public keys", nil
+}
+
+// verifySignature reports whether a layer of a cosign signature signs the
+// digest with one of the public keys.
+func (s *signatureVerifier) verifySignature(
+	layer *update.ArtifactLayer,
+	digest string,
+) bool {
+	if layer.MediaType != cosignSignatureMediaType {
+		return false
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(
+		layer.Annotations[cosignSignatureAnnotation],
+	)
+	if err != nil || len(signature) == 0 {
+		return false
+	}
+
+	var payload simpleSigningPayload
+	if err := json.Unmarshal(layer.Content, &payload); err != nil {
+		return false
+	}
+
+	if payload.Critical.Image.DockerManifestDigest !=
+		fmt.Sprintf("sha256:%s", digest) {
+		return false
+	}
+
+	return s.verifyAnyPublicKey(layer.Content, signature)
+}
+
+// verifyAttestation reports whether a layer of a cosign attestation is
+// signed with one of the public keys and has the digest as a subject.
+func (s *signatureVerifier) verifyAttestation(
+	layer *update.ArtifactLayer,
+	digest string,
+) bool {
+	if layer.MediaType != dsseEnvelopeMediaType {
+		return false
+	}
+
+	var envelope dsseEnvelope
+	if err := json.Unmarshal(layer.Content, &envelope); err != nil {
+		return false
+	}
+
+	if envelope.PayloadType != inTotoPayloadType {
+		return false
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return false
+	}
+
+	var (
+		message  = preAuthenticationEncoding(envelope.PayloadType, payload)
+		verified bool
+	)
+
+	for _, envelopeSignature := range envelope.Signatures {
+		signature, err := base64.StdEncoding.DecodeString(envelopeSignature.Sig)
+		if err != nil {
+			continue
+		}
+
+		if s.verifyAnyPublicKey(message, signature) {
+			verified = true
+			break
+		}
+	}
+
+	if !verified {
+		return false
+	}
+
+	var statement inTotoStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return false
+	}
+
+	for _, subject := range statement.Subject {
+		if subject.Digest["sha256"] == digest {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyAnyPublicKey reports whether the signature of the message was made
+// with the private key of one of the public keys. ECDSA and RSA signatures
+// are of the message's sha256 hash, as cosign signs them.
+func (s *signatureVerifier) verifyAnyPublicKey(
+	message []byte,
+	signature []byte,
+) bool {
+	hash := sha256.Sum256(message)
+
+	for _, publicKey := range s.publicKeys {
+		switch publicKey := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(publicKey, hash[:], signature) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(
+				publicKey, crypto.SHA256, hash[:], signature,
+			) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(publicKey, message, signature) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// preAuthenticationEncoding returns the message that DSSE signatures sign.
+func preAuthenticationEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf(
+		"DSSEv1 %d %s %d %s",
+		len(payloadType), payloadType, len(payload), payload,
+	))
+}
+
+func validatePublicKey(publicKey crypto.PublicKey) error {
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return nil
+	default:
+		return fmt.Errorf(
+			"public key of type '%T' must be an ECDSA, RSA, or Ed25519 key",
+			publicKey,
+		)
+	}
+}
@@ -0,0 +1,341 @@
+package verify_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/safe-waters/docker-lock/pkg/generate/update"
+	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
+	"github.com/safe-waters/docker-lock/pkg/verify"
+)
+
+// rawLayer is an uncompressed layer, such as the payload of a signature.
+type rawLayer struct {
+	content   []byte
+	mediaType types.MediaType
+}
+
+func (r *rawLayer) Digest() (v1.Hash, error) {
+	hash, _, err := v1.SHA256(bytes.NewReader(r.content))
+
+	return hash, err
+}
+
+func (r *rawLayer) DiffID() (v1.Hash, error) {
+	return r.Digest()
+}
+
+func (r *rawLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(r.content)), nil
+}
+
+func (r *rawLayer) Uncompressed() (io.ReadCloser, error) {
+	return r.Compressed()
+}
+
+func (r *rawLayer) Size() (int64, error) {
+	return int64(len(r.content)), nil
+}
+
+func (r *rawLayer) MediaType() (types.MediaType, error) {
+	return r.mediaType, nil
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	registryHost := strings.TrimPrefix(server.URL, "http://")
+
+	trustedKey := generateKey(t)
+	untrustedKey := generateKey(t)
+
+	var (
+		signedName    = fmt.Sprintf("%s/org/signed", registryHost)
+		attestedName  = fmt.Sprintf("%s/org/attested", registryHost)
+		untrustedName = fmt.Sprintf("%s/org/untrusted", registryHost)
+		unsignedName  = fmt.Sprintf("%s/org/unsigned", registryHost)
+	)
+
+	signedDigest := pushImage(t, signedName)
+	pushSignature(t, signedName, signedDigest, trustedKey)
+
+	attestedDigest := pushImage(t, attestedName)
+	pushAttestation(t, attestedName, attestedDigest, trustedKey)
+
+	untrustedDigest := pushImage(t, untrustedName)
+	pushSignature(t, untrustedName, untrustedDigest, untrustedKey)
+
+	unsignedDigest := pushImage(t, unsignedName)
+
+	existingLockfile := &lockfile.Lockfile{
+		SchemaVersion: lockfile.SchemaVersion,
+		Dockerfiles: map[string][]*lockfile.DockerfileImage{
+			"Dockerfile": {
+				{Name: signedName, Tag: "1.0.0", Digest: signedDigest},
+				{Name: attestedName, Tag: "1.0.0", Digest: attestedDigest},
+				{Name: "scratch"},
+			},
+		},
+		Kubernetesfiles: map[string][]*lockfile.KubernetesfileImage{
+			"pod.yml": {
+				{Name: untrustedName, Tag: "1.0.0", Digest: untrustedDigest},
+				{Name: unsignedName, Tag: "1.0.0", Digest: unsignedDigest},
+				{Name: signedName, Tag: "1.0.0", Digest: signedDigest},
+			},
+		},
+	}
+
+	tests := []struct {
+		Name       string
+		PublicKeys []crypto.PublicKey
+		Expected   []*verify.UnsignedImage
+	}{
+		{
+			Name:       "Trusted Key",
+			PublicKeys: []crypto.PublicKey{trustedKey.Public()},
+			Expected: []*verify.UnsignedImage{
+				{
+					Kind:   kind.Kubernetesfile,
+					Path:   "pod.yml",
+					Index:  0,
+					Name:   untrustedName,
+					Tag:    "1.0.0",
+					Digest: untrustedDigest,
+					Reason: "does not have a signature or attestation that " +
+						"is valid for the public keys",
+				},
+				{
+					Kind:   kind.Kubernetesfile,
+					Path:   "pod.yml",
+					Index:  1,
+					Name:   unsignedName,
+					Tag:    "1.0.0",
+					Digest: unsignedDigest,
+					Reason: "does not have a signature or attestation",
+				},
+			},
+		},
+		{
+			Name: "Both Keys",
+			PublicKeys: []crypto.PublicKey{
+				trustedKey.Public(), untrustedKey.Public(),
+			},
+			Expected: []*verify.UnsignedImage{
+				{
+					Kind:   kind.Kubernetesfile,
+					Path:   "pod.yml",
+					Index:  1,
+					Name:   unsignedName,
+					Tag:    "1.0.0",
+					Digest: unsignedDigest,
+					Reason: "does not have a signature or attestation",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		// The subtests share the registry, so they do not run in parallel.
+		t.Run(test.Name, func(t *testing.T) {
+			artifactRequester := update.NewDigestRequester(
+				nil, authn.NewMultiKeychain(),
+			).(update.IArtifactRequester)
+
+			signatureVerifier, err := verify.NewSignatureVerifier(
+				artifactRequester, test.PublicKeys, 2,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var lockfileByt bytes.Buffer
+			if err := existingLockfile.Write(&lockfileByt); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := signatureVerifier.UnsignedImages(&lockfileByt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedByt, err := json.MarshalIndent(test.Expected, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotByt, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(expectedByt) != string(gotByt) {
+				t.Fatalf("expected %s, got %s", expectedByt, gotByt)
+			}
+		})
+	}
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKey
+}
+
+func sign(t *testing.T, privateKey *ecdsa.PrivateKey, message []byte) string {
+	t.Helper()
+
+	hash := sha256.Sum256(message)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, privateKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func pushImage(t *testing.T, imageName string) string {
+	t.Helper()
+
+	img, err := random.Image(64, 1) // nolint: gomnd
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeImage(t, fmt.Sprintf("%s:1.0.0", imageName), img)
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return digest.Hex
+}
+
+func pushSignature(
+	t *testing.T,
+	imageName string,
+	digest string,
+	privateKey *ecdsa.PrivateKey,
+) {
+	t.Helper()
+
+	payload := []byte(fmt.Sprintf(
+		`{"critical":{"identity":{"docker-reference":"%s"},`+
+			`"image":{"docker-manifest-digest":"sha256:%s"},`+
+			`"type":"cosign container image signature"},"optional":null}`,
+		imageName, digest,
+	))
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: &rawLayer{
+			content:   payload,
+			mediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+		},
+		Annotations: map[string]string{
+			"dev.cosignproject.cosign/signature": sign(
+				t, privateKey, payload,
+			),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeImage(t, fmt.Sprintf("%s:sha256-%s.sig", imageName, digest), img)
+}
+
+func pushAttestation(
+	t *testing.T,
+	imageName string,
+	digest string,
+	privateKey *ecdsa.PrivateKey,
+) {
+	t.Helper()
+
+	const payloadType = "application/vnd.in-toto+json"
+
+	statement := []byte(fmt.Sprintf(
+		`{"_type":"https://in-toto.io/Statement/v0.1",`+
+			`"predicateType":"https://slsa.dev/provenance/v0.2",`+
+			`"subject":[{"name":"%s","digest":{"sha256":"%s"}}],`+
+			`"predicate":{}}`,
+		imageName, digest,
+	))
+
+	message := []byte(fmt.Sprintf(
+		"DSSEv1 %d %s %d %s",
+		len(payloadType), payloadType, len(statement), statement,
+	))
+
+	envelope, err := json.Marshal(map[string]interface{}{
+		"payloadType": payloadType,
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"signatures": []map[string]string{
+			{"keyid": "", "sig": sign(t, privateKey, message)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: &rawLayer{
+			content:   envelope,
+			mediaType: "application/vnd.dsse.envelope.v1+json",
+		},
+		Annotations: map[string]string{
+			"dev.cosignproject.cosign/signature": "",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeImage(t, fmt.Sprintf("%s:sha256-%s.att", imageName, digest), img)
+}
+
+func writeImage(t *testing.T, imageLine string, img v1.Image) {
+	t.Helper()
+
+	ref, err := name.ParseReference(imageLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,4 +8,19 @@ import "io"
 // for verifying that a newly generated Lockfile equals the existing Lockfile.
 type IVerifier interface {
 	VerifyLockfile(lockfileReader io.Reader) error
+	ReportLockfile(lockfileReader io.Reader) (*Report, error)
+}
+
+// IAgeVerifier provides an interface for AgeVerifiers, which are responsible
+// for finding the images in an existing Lockfile whose digests are older
+// than a maximum age.
+type IAgeVerifier interface {
+	StaleImages(lockfileReader io.Reader) ([]*StaleImage, error)
+}
+
+// ISignatureVerifier provides an interface for SignatureVerifiers, which are
+// responsible for finding the images in an existing Lockfile whose digests
+// are not signed with trusted keys.
+type ISignatureVerifier interface {
+	UnsignedImages(lockfileReader io.Reader) ([]*UnsignedImage, error)
 }
@@ -2,16 +2,15 @@ package verify
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"reflect"
-	"sync"
+	"strings"
 
 	"github.com/safe-waters/docker-lock/pkg/generate"
 	"github.com/safe-waters/docker-lock/pkg/kind"
+	"github.com/safe-waters/docker-lock/pkg/lockfile"
 	"github.com/safe-waters/docker-lock/pkg/verify/diff"
 )
 
@@ -58,194 +57,77 @@ func (v *verifier) VerifyLockfile(lockfileReader io.Reader) error {
 		return errors.New("'lockfileReader' cannot be nil")
 	}
 
-	existingLockfileByt, err := ioutil.ReadAll(lockfileReader)
+	report, existingLockfileByt, newLockfileByt, err := v.reportLockfile(
+		lockfileReader,
+	)
 	if err != nil {
 		return err
 	}
 
-	var existingLockfile map[kind.Kind]map[string][]interface{}
-	if err := json.Unmarshal(
-		existingLockfileByt, &existingLockfile,
-	); err != nil {
-		return err
+	if report.Verified() {
+		return nil
 	}
 
-	var newLockfileBytBuffer bytes.Buffer
-	if err := v.generator.GenerateLockfile(&newLockfileBytBuffer); err != nil {
-		return err
+	differences := make([]string, len(report.Differences))
+	for i, difference := range report.Differences {
+		differences[i] = difference.String()
 	}
 
-	newLockfileByt := newLockfileBytBuffer.Bytes()
+	return &differentLockfileError{
+		existingLockfile: existingLockfileByt,
+		newLockfile:      newLockfileByt,
+		err:              errors.New(strings.Join(differences, "\n")),
+	}
+}
 
-	var newLockfile map[kind.Kind]map[string][]interface{}
-	if err := json.Unmarshal(newLockfileByt, &newLockfile); err != nil {
-		return err
+// ReportLockfile reads an existing Lockfile and generates a new one for the
+// specified paths, returning a Report of every difference between them.
+// Differences are not returned as an error.
+func (v *verifier) ReportLockfile(lockfileReader io.Reader) (*Report, error) {
+	if lockfileReader == nil || reflect.ValueOf(lockfileReader).IsNil() {
+		return nil, errors.New("'lockfileReader' cannot be nil")
 	}
 
-	if len(existingLockfile) != len(newLockfile) {
-		return &differentLockfileError{
-			existingLockfile: existingLockfileByt,
-			newLockfile:      newLockfileByt,
-			err: fmt.Errorf(
-				"existing has '%d' kind(s), but new has '%d'",
-				len(existingLockfile), len(newLockfile),
-			),
-		}
+	report, _, _, err := v.reportLockfile(lockfileReader)
+
+	return report, err
+}
+
+// reportLockfile returns the Report, as well as the existing and new
+// Lockfiles' bytes.
+func (v *verifier) reportLockfile(
+	lockfileReader io.Reader,
+) (*Report, []byte, []byte, error) {
+	existingLockfileByt, err := ioutil.ReadAll(lockfileReader)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	var (
-		waitGroup sync.WaitGroup
-		errCh     = make(chan error)
-		done      = make(chan struct{})
+	existingLockfile, err := lockfile.Read(
+		bytes.NewReader(existingLockfileByt),
 	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	defer close(done)
-
-	for kind := range existingLockfile {
-		kind := kind
-
-		waitGroup.Add(1)
-
-		go func() {
-			defer waitGroup.Done()
-
-			if _, ok := newLockfile[kind]; !ok {
-				select {
-				case <-done:
-				case errCh <- fmt.Errorf(
-					"existing kind '%s' does not exist in new", kind,
-				):
-				}
-
-				return
-			}
-
-			if len(existingLockfile[kind]) != len(newLockfile[kind]) {
-				select {
-				case <-done:
-				case errCh <- fmt.Errorf(
-					"existing kind '%s' has '%d' paths, but new has '%d'",
-					kind, len(existingLockfile[kind]), len(newLockfile[kind]),
-				):
-				}
-
-				return
-			}
-
-			for path, existingImages := range existingLockfile[kind] {
-				path := path
-				existingImages := existingImages
-
-				waitGroup.Add(1)
-
-				go func() {
-					defer waitGroup.Done()
-
-					newImages, ok := newLockfile[kind][path]
-					if !ok {
-						select {
-						case <-done:
-						case errCh <- fmt.Errorf(
-							"existing path '%s' does not exist in new", path,
-						):
-						}
-
-						return
-					}
-
-					if len(existingImages) != len(newImages) {
-						select {
-						case <-done:
-						case errCh <- fmt.Errorf(
-							"existing path '%s' has '%d' images but "+
-								"new has '%d'",
-							path, len(existingImages), len(newImages),
-						):
-						}
-
-						return
-					}
-
-					for i := range existingImages {
-						i := i
-
-						waitGroup.Add(1)
-
-						go func() {
-							defer waitGroup.Done()
-
-							existingImage, ok := existingImages[i].(map[string]interface{}) // nolint: lll
-							if !ok {
-								select {
-								case <-done:
-								case errCh <- fmt.Errorf(
-									"path '%s' has malformed existing "+
-										"image '%v'",
-									path, existingImages[i],
-								):
-								}
-
-								return
-							}
-
-							newImage, ok := newImages[i].(map[string]interface{}) // nolint: lll
-							if !ok {
-								select {
-								case <-done:
-								case errCh <- fmt.Errorf(
-									"path '%s' has malformed new image '%v'",
-									path, existingImages[i],
-								):
-								}
-
-								return
-							}
-
-							differentiator, ok := v.differentiators[kind]
-							if !ok {
-								select {
-								case <-done:
-								case errCh <- fmt.Errorf(
-									"kind %s does not have a"+
-										" differentiator defined",
-									kind,
-								):
-								}
-
-								return
-							}
-
-							if err := differentiator.DifferentiateImage(
-								existingImage, newImage,
-							); err != nil {
-								select {
-								case <-done:
-								case errCh <- fmt.Errorf(
-									"on path '%s', %s", path, err,
-								):
-								}
-
-								return
-							}
-						}()
-					}
-				}()
-			}
-		}()
+	var newLockfileBytBuffer bytes.Buffer
+	if err := v.generator.GenerateLockfile(&newLockfileBytBuffer); err != nil {
+		return nil, nil, nil, err
 	}
 
-	go func() {
-		waitGroup.Wait()
-		close(errCh)
-	}()
+	newLockfileByt := newLockfileBytBuffer.Bytes()
 
-	for err := range errCh {
-		return &differentLockfileError{
-			existingLockfile: existingLockfileByt,
-			newLockfile:      newLockfileByt,
-			err:              err,
-		}
+	newLockfile, err := lockfile.Read(bytes.NewReader(newLockfileByt))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	report, err := newReport(
+		existingLockfile, newLockfile, v.differentiators,
+	)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return nil
+	return report, existingLockfileByt, newLockfileByt, nil
 }
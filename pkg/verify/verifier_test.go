@@ -3,9 +3,11 @@ package verify_test
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	cmd_generate "github.com/safe-waters/docker-lock/cmd/generate"
@@ -138,6 +140,60 @@ spec:
 			},
 			ShouldFail: true,
 		},
+		{
+			Name: "Helmchart Diff",
+			Contents: [][]byte{
+				[]byte(`
+image:
+  repository: redis
+  tag: latest
+`,
+				),
+				[]byte(`
+{
+	"helmcharts": {
+		"values.yaml": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "redis",
+				"key": "image"
+			}
+		]
+	}
+}
+`,
+				),
+			},
+			ShouldFail: true,
+		},
+		{
+			Name: "Helmchart",
+			Contents: [][]byte{
+				[]byte(`
+image:
+  repository: redis
+  tag: latest
+`,
+				),
+				// nolint: lll
+				[]byte(`
+{
+	"helmcharts": {
+		"values.yaml": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "09c33840ec47815dc0351f1eca3befe741d7105b3e95bc8fdb9a7e4985b9e1e5",
+				"key": "image"
+			}
+		]
+	}
+}
+`,
+				),
+			},
+		},
 		{
 			Name: "Normal",
 			Contents: [][]byte{
@@ -258,6 +314,17 @@ services:
 				kubernetesfileImagesWithTempDir[kubernetesfilePath] = images
 			}
 
+			helmchartImagesWithTempDir := map[string][]interface{}{}
+
+			for helmchartPath, images := range lockfile[kind.Helmchart] {
+				uniquePathsToWrite[helmchartPath] = struct{}{}
+
+				helmchartPath = filepath.ToSlash(
+					filepath.Join(tempDir, helmchartPath),
+				)
+				helmchartImagesWithTempDir[helmchartPath] = images
+			}
+
 			var pathsToWrite []string
 			for path := range uniquePathsToWrite {
 				pathsToWrite = append(pathsToWrite, path)
@@ -278,6 +345,10 @@ services:
 				lockfileWithTempDir[kind.Kubernetesfile] = kubernetesfileImagesWithTempDir // nolint: lll
 			}
 
+			if len(helmchartImagesWithTempDir) != 0 {
+				lockfileWithTempDir[kind.Helmchart] = helmchartImagesWithTempDir
+			}
+
 			lockfileWithTempDirByt, err := json.Marshal(lockfileWithTempDir)
 			if err != nil {
 				t.Fatal(err)
@@ -317,8 +388,11 @@ services:
 			kubernetesfilePaths := make(
 				[]string, len(existingLockfile[kind.Kubernetesfile]),
 			)
+			helmchartPaths := make(
+				[]string, len(existingLockfile[kind.Helmchart]),
+			)
 
-			var i, j, k int
+			var i, j, k, l int
 
 			for p := range existingLockfile[kind.Dockerfile] {
 				dockerfilePaths[i] = p
@@ -335,13 +409,39 @@ services:
 				k++
 			}
 
+			for p := range existingLockfile[kind.Helmchart] {
+				helmchartPaths[l] = p
+				l++
+			}
+
 			generatorFlags, err := cmd_generate.NewFlags(
-				".", "",
-				flags.IgnoreMissingDigests, flags.UpdateExistingDigests,
-				dockerfilePaths, composefilePaths,
-				kubernetesfilePaths, nil, nil, nil, false, false, false,
-				len(dockerfilePaths) == 0, len(composefilePaths) == 0,
-				len(kubernetesfilePaths) == 0,
+				&cmd_generate.FlagsWithSharedValues{
+					BaseDir:               ".",
+					IgnoreMissingDigests:  flags.IgnoreMissingDigests,
+					UpdateExistingDigests: flags.UpdateExistingDigests,
+					RegistryFlags: cmd_generate.RegistryFlags{
+						NoCache: true,
+					},
+				},
+				&cmd_generate.FlagsWithSharedNames{
+					ManualPaths:  dockerfilePaths,
+					ExcludePaths: len(dockerfilePaths) == 0,
+				},
+				&cmd_generate.FlagsWithSharedNames{
+					ManualPaths:  composefilePaths,
+					ExcludePaths: len(composefilePaths) == 0,
+				},
+				&cmd_generate.FlagsWithSharedNames{
+					ManualPaths:  kubernetesfilePaths,
+					ExcludePaths: len(kubernetesfilePaths) == 0,
+				},
+				&cmd_generate.FlagsWithSharedNames{
+					ManualPaths:  helmchartPaths,
+					ExcludePaths: len(helmchartPaths) == 0,
+				},
+				&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+				&cmd_generate.FlagsWithSharedNames{ExcludePaths: true},
+				false, nil, nil,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -357,12 +457,21 @@ services:
 				t.Fatal(err)
 			}
 
+			checker, err := cmd_generate.DefaultImagePolicyChecker(
+				generatorFlags,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
 			digestRequester := testutils.NewMockDigestRequester(t, nil)
 
 			imageDigestUpdater, err := update.NewImageDigestUpdater(
 				digestRequester,
 				generatorFlags.FlagsWithSharedValues.IgnoreMissingDigests,
 				generatorFlags.FlagsWithSharedValues.UpdateExistingDigests,
+				generatorFlags.FlagsWithSharedValues.PlatformDigests,
+				generatorFlags.FlagsWithSharedValues.MaxConcurrency,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -379,7 +488,7 @@ services:
 			}
 
 			generator, err := generate.NewGenerator(
-				collector, parser, updater, sorter,
+				collector, parser, checker, updater, sorter,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -397,9 +506,18 @@ services:
 				flags.ExcludeTags,
 			)
 
+			helmchartDifferentiator := diff.NewHelmchartDifferentiator(
+				flags.ExcludeTags,
+			)
+
+			kustomizationDifferentiator := diff.NewKustomizationDifferentiator(
+				flags.ExcludeTags,
+			)
+
 			verifier, err := verify.NewVerifier(
 				generator, dockerfileDifferentiator, composefileDifferentiator,
-				kubernetesfileDifferentiator,
+				kubernetesfileDifferentiator, helmchartDifferentiator,
+				kustomizationDifferentiator,
 			)
 			if err != nil {
 				t.Fatal(err)
@@ -421,3 +539,98 @@ services:
 		})
 	}
 }
+
+func TestReportLockfile(t *testing.T) {
+	t.Parallel()
+
+	existingLockfile := []byte(`{
+	"schemaVersion": 1,
+	"dockerfiles": {
+		"Dockerfile": [
+			{"name": "busybox", "tag": "latest", "digest": "busybox"},
+			{"name": "golang", "tag": "latest", "digest": "golang"}
+		]
+	},
+	"kubernetesfiles": {
+		"pod.yaml": [
+			{
+				"name": "redis",
+				"tag": "latest",
+				"digest": "redis",
+				"container": "redis"
+			}
+		]
+	}
+}`)
+
+	newLockfile := []byte(`{
+	"schemaVersion": 1,
+	"dockerfiles": {
+		"Dockerfile": [
+			{"name": "busybox", "tag": "latest", "digest": "updated"}
+		]
+	},
+	"kubernetesfiles": {
+		"pod.yaml": [
+			{
+				"name": "redis",
+				"tag": "6",
+				"digest": "redis",
+				"container": "redis"
+			}
+		]
+	}
+}`)
+
+	verifier, err := verify.NewVerifier(
+		&fixedGenerator{lockfile: newLockfile},
+		diff.NewDockerfileDifferentiator(false),
+		diff.NewKubernetesfileDifferentiator(false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := verifier.ReportLockfile(bytes.NewReader(existingLockfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTypes := []diff.DifferenceType{
+		diff.DigestChanged, diff.Removed, diff.TagChanged,
+	}
+
+	gotTypes := make([]diff.DifferenceType, len(report.Differences))
+	for i, difference := range report.Differences {
+		gotTypes[i] = difference.Type
+	}
+
+	if !reflect.DeepEqual(expectedTypes, gotTypes) {
+		t.Fatalf("expected %v, got %v", expectedTypes, gotTypes)
+	}
+
+	expectedPaths := map[kind.Kind][]string{
+		kind.Dockerfile:     {"Dockerfile"},
+		kind.Kubernetesfile: {"pod.yaml"},
+	}
+
+	if !reflect.DeepEqual(expectedPaths, report.Paths) {
+		t.Fatalf("expected %v, got %v", expectedPaths, report.Paths)
+	}
+
+	if err := verifier.VerifyLockfile(
+		bytes.NewReader(existingLockfile),
+	); err == nil {
+		t.Fatal("expected error but did not get one")
+	}
+}
+
+type fixedGenerator struct {
+	lockfile []byte
+}
+
+func (f *fixedGenerator) GenerateLockfile(lockfileWriter io.Writer) error {
+	_, err := lockfileWriter.Write(f.lockfile)
+
+	return err
+}